@@ -2,9 +2,13 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
-	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/ooneko/claude-config/internal/aiprovider"
 	"github.com/ooneko/claude-config/internal/claude"
@@ -12,41 +16,92 @@ import (
 )
 
 func createAIProviderCmd() *cobra.Command {
+	var jsonOutput, reachability bool
+
 	cmd := &cobra.Command{
 		Use:   "ai",
 		Short: "AI提供商配置管理",
-		Long:  `管理AI提供商配置，支持DeepSeek、Kimi、GLM、Doubao等多个提供商。`,
-		Run: func(_ *cobra.Command, _ []string) {
-			showAIProviderStatus()
+		Long: `管理AI提供商配置，支持DeepSeek、Kimi、GLM、Doubao、Anthropic等多个提供商。
+
+使用 --reachability 向当前提供商的接口发送一次带API密钥的轻量请求（不消耗 token），
+检查接口能否访问、密钥是否被接受。`,
+		Example: `  claude-config ai
+  claude-config ai --json
+  claude-config ai --reachability`,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if jsonOutput {
+				return printAIProviderStatusJSON(os.Stdout, reachability)
+			}
+			showAIProviderStatus(os.Stdout, reachability)
+			return nil
 		},
 	}
 
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "以JSON格式输出状态")
+	cmd.Flags().BoolVar(&reachability, "reachability", false, "检查当前提供商的接口能否访问、密钥是否有效")
+
 	cmd.AddCommand(
 		createAIProviderResetCmd(),
+		createAIProviderRotateCmd(),
+		createAIProviderEncryptCmd(),
 		createAIProviderOffCmd(),
 		createAIProviderOnCmd(),
+		createAIProviderSwitchCmd(),
 		createAIProviderListCmd(),
+		createAIProviderModelsCmd(),
+		createAIProviderExportCmd(),
+		createAIProviderImportCmd(),
+		createAIProviderPingCmd(),
 	)
 
 	return cmd
 }
 
 func createAIProviderResetCmd() *cobra.Command {
-	return &cobra.Command{
+	var all, yes bool
+
+	cmd := &cobra.Command{
 		Use:   "reset <provider>",
 		Short: "重置AI提供商",
-		Long:  `重置指定的AI提供商（删除API密钥和配置）。支持的提供商：deepseek, kimi, glm, doubao`,
-		Args:  cobra.ExactArgs(1),
+		Long: `重置指定的AI提供商（删除所有配置名的API密钥、接入点和配置）。支持的提供商：deepseek, kimi, glm, doubao, anthropic
+
+使用 --all 重置所有提供商（删除所有API密钥并清理当前配置），执行前需要确认一次。`,
+		Example: `  claude-config ai reset deepseek
+  claude-config ai reset --all
+  claude-config ai reset --all --yes`,
+		Args: func(_ *cobra.Command, args []string) error {
+			if all {
+				return cobra.NoArgs(nil, args)
+			}
+			return cobra.ExactArgs(1)(nil, args)
+		},
 		Run: func(_ *cobra.Command, args []string) {
+			ctx := context.Background()
+
+			if all {
+				if !yes {
+					ok, err := confirm("⚠️  将删除所有AI提供商的API密钥并清理配置，是否继续?")
+					if err != nil {
+						fmt.Printf("❌ 读取确认失败: %v\n", err)
+						return
+					}
+					if !ok {
+						fmt.Println("已取消")
+						return
+					}
+				}
+				resetAllProviders(ctx)
+				return
+			}
+
 			provider := claude.NormalizeProviderName(args[0])
 
 			if provider == claude.ProviderNone {
 				fmt.Printf("❌ 不支持的提供商: %s\n", args[0])
-				fmt.Println("支持的提供商: deepseek, kimi, glm, doubao")
+				fmt.Println("支持的提供商: deepseek, kimi, glm, doubao, anthropic")
 				return
 			}
 
-			ctx := context.Background()
 			err := aiProviderMgr.Reset(ctx, provider)
 			if err != nil {
 				fmt.Printf("❌ 重置AI提供商失败: %v\n", err)
@@ -56,6 +111,100 @@ func createAIProviderResetCmd() *cobra.Command {
 			fmt.Printf("✅ 成功重置 %s\n", provider)
 		},
 	}
+
+	cmd.Flags().BoolVar(&all, "all", false, "重置所有AI提供商")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "跳过确认提示")
+
+	return cmd
+}
+
+// resetAllProviders resets every supported provider and reports each result
+func resetAllProviders(ctx context.Context) {
+	failed := 0
+	for _, provider := range aiProviderMgr.ListSupportedProviders() {
+		if err := aiProviderMgr.Reset(ctx, provider); err != nil {
+			fmt.Printf("❌ 重置 %s 失败: %v\n", provider, err)
+			failed++
+			continue
+		}
+		fmt.Printf("✅ 已重置 %s\n", provider)
+	}
+
+	if failed > 0 {
+		fmt.Printf("⚠️  %d 个提供商重置失败\n", failed)
+	}
+}
+
+func createAIProviderRotateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rotate <provider>",
+		Short: "更换AI提供商的API密钥",
+		Long: `替换指定提供商已保存的API密钥，例如在密钥泄露后。支持的提供商：deepseek, kimi, glm, doubao, anthropic
+
+如果该提供商正在使用，会同时更新 settings.json 中的 ANTHROPIC_AUTH_TOKEN；否则只替换密钥文件。`,
+		Example: `  claude-config ai rotate deepseek`,
+		Args:    cobra.ExactArgs(1),
+		Run: func(_ *cobra.Command, args []string) {
+			ctx := context.Background()
+			provider := claude.NormalizeProviderName(args[0])
+
+			if provider == claude.ProviderNone {
+				fmt.Printf("❌ 不支持的提供商: %s\n", args[0])
+				fmt.Println("支持的提供商: deepseek, kimi, glm, doubao, anthropic")
+				return
+			}
+
+			fmt.Printf("请输入 %s 的新API密钥: ", provider)
+			var apiKey string
+			if _, err := fmt.Scanln(&apiKey); err != nil {
+				fmt.Printf("❌ 读取API密钥失败: %v\n", err)
+				return
+			}
+
+			if err := aiProviderMgr.RotateKey(ctx, provider, apiKey); err != nil {
+				fmt.Printf("❌ 更换API密钥失败: %v\n", err)
+				return
+			}
+
+			fmt.Printf("✅ 已更换 %s 的API密钥\n", provider)
+			if active, err := aiProviderMgr.GetActiveProvider(ctx); err == nil && active == provider {
+				fmt.Println("💡 当前正在使用该提供商，新密钥已立即生效")
+			}
+		},
+	}
+}
+
+func createAIProviderEncryptCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "encrypt",
+		Short: "使用口令加密已保存的API密钥",
+		Long: `使用口令（AES-GCM）加密所有已保存的API密钥文件，之后保存的密钥也会加密。
+
+口令从环境变量 CLAUDE_CONFIG_PASSPHRASE 读取，未设置时提示输入。加密后读取密钥
+（ai on、start 等）需要设置同一口令的 CLAUDE_CONFIG_PASSPHRASE；未加密的密钥仍可正常读取。`,
+		Example: `  CLAUDE_CONFIG_PASSPHRASE=... claude-config ai encrypt`,
+		Args:    cobra.NoArgs,
+		Run: func(_ *cobra.Command, _ []string) {
+			passphrase := os.Getenv(aiprovider.PassphraseEnv)
+			if passphrase == "" {
+				fmt.Print("请输入加密口令: ")
+				if _, err := fmt.Scanln(&passphrase); err != nil {
+					fmt.Printf("❌ 读取口令失败: %v\n", err)
+					return
+				}
+			}
+
+			if err := aiProviderMgr.EnableEncryption(context.Background(), passphrase); err != nil {
+				fmt.Printf("❌ 加密API密钥失败: %v\n", err)
+				return
+			}
+
+			fmt.Println("✅ 已加密所有API密钥")
+			if os.Getenv(aiprovider.PassphraseEnv) == "" {
+				fmt.Printf("💡 之后请设置环境变量 %s 以读取加密的密钥\n", aiprovider.PassphraseEnv)
+			}
+		},
+	}
 }
 
 func createAIProviderOffCmd() *cobra.Command {
@@ -77,14 +226,44 @@ func createAIProviderOffCmd() *cobra.Command {
 }
 
 func createAIProviderOnCmd() *cobra.Command {
-	return &cobra.Command{
+	var profile string
+	var endpoint string
+	var baseURL string
+	var keyStore string
+
+	cmd := &cobra.Command{
 		Use:   "on [provider]",
 		Short: "启用AI提供商",
-		Long:  `启用指定的AI提供商，如果未指定则恢复最后一次关闭前配置的AI提供商。支持的提供商：deepseek, kimi, glm, doubao`,
-		Args:  cobra.MaximumNArgs(1),
+		Long: `启用指定的AI提供商，如果未指定则恢复最后一次关闭前配置的AI提供商。支持的提供商：deepseek, kimi, glm, doubao, anthropic
+
+使用 --profile 为同一提供商保存多个API密钥（例如工作和个人），未指定时使用默认密钥。
+使用 --endpoint 选择提供商的接入点（目前仅 doubao 支持: coding, general），选择会被保存。
+使用 --base-url 覆盖提供商的接入地址（例如测试环境），该设置会一直保留，直到 ai reset 该提供商。
+使用 --keystore keychain 将API密钥保存到系统钥匙串（macOS Keychain / Linux Secret Service），
+密钥文件中只保留引用；--keystore file 将其移回文件。未指定时密钥保留在原来的位置。
+Windows 暂不支持钥匙串。提供商启用期间，settings.json 的 ANTHROPIC_AUTH_TOKEN 中仍是明文密钥，
+需要避免时请用 start 启动会话，它只通过环境变量传递密钥。`,
+		Example: `  claude-config ai on deepseek
+  claude-config ai on deepseek --profile work
+  claude-config ai on doubao --endpoint general
+  claude-config ai on deepseek --base-url https://staging.example.com/anthropic
+  claude-config ai on deepseek --keystore keychain`,
+		Args: cobra.MaximumNArgs(1),
 		Run: func(_ *cobra.Command, args []string) {
 			ctx := context.Background()
 
+			if err := aiprovider.ValidateProfileName(profile); err != nil {
+				fmt.Printf("❌ %v\n", err)
+				return
+			}
+
+			if keyStore != "" {
+				if err := aiProviderMgr.SetKeyStore(keyStore); err != nil {
+					fmt.Printf("❌ %v\n", err)
+					return
+				}
+			}
+
 			if len(args) == 0 {
 				// 恢复之前的配置
 				err := aiProviderMgr.On(ctx)
@@ -101,23 +280,41 @@ func createAIProviderOnCmd() *cobra.Command {
 
 			if provider == claude.ProviderNone {
 				fmt.Printf("❌ 不支持的提供商: %s\n", args[0])
-				fmt.Println("支持的提供商: deepseek, kimi, glm, doubao")
+				fmt.Println("支持的提供商: deepseek, kimi, glm, doubao, anthropic")
 				return
 			}
 
+			if endpoint != "" {
+				if err := aiProviderMgr.SetEndpoint(ctx, provider, endpoint); err != nil {
+					fmt.Printf("❌ 设置接入点失败: %v\n", err)
+					return
+				}
+			}
+
+			if baseURL != "" {
+				if err := aiProviderMgr.SetBaseURL(ctx, provider, baseURL); err != nil {
+					fmt.Printf("❌ 设置接入地址失败: %v\n", err)
+					return
+				}
+			}
+
 			// 检查是否有保存的API密钥
-			hasKey, err := aiProviderMgr.HasAPIKey(ctx, provider)
+			hasKey, err := hasProfileAPIKey(provider, profile)
 			if err != nil {
 				fmt.Printf("❌ 检查API密钥失败: %v\n", err)
 				return
 			}
 
 			if !hasKey {
+				profileFlag := ""
+				if profile != "" {
+					profileFlag = " --profile " + profile
+				}
 				fmt.Printf("⚠️  提供商 %s 的API密钥未配置\n", provider)
 				fmt.Printf("请使用以下命令配置API密钥:\n")
-				fmt.Printf("  echo 'your-api-key' | claude-config ai on %s\n", provider)
+				fmt.Printf("  echo 'your-api-key' | claude-config ai on %s%s\n", provider, profileFlag)
 				fmt.Printf("或者:\n")
-				fmt.Printf("  claude-config ai on %s\n", provider)
+				fmt.Printf("  claude-config ai on %s%s\n", provider, profileFlag)
 				fmt.Printf("然后输入您的API密钥\n")
 
 				// 尝试从标准输入读取API密钥
@@ -134,7 +331,7 @@ func createAIProviderOnCmd() *cobra.Command {
 				}
 
 				// 启用提供商
-				err = aiProviderMgr.Enable(ctx, provider, apiKey)
+				err = aiProviderMgr.EnableProfile(ctx, provider, profile, apiKey)
 				if err != nil {
 					fmt.Printf("❌ 启用AI提供商失败: %v\n", err)
 					return
@@ -146,13 +343,13 @@ func createAIProviderOnCmd() *cobra.Command {
 
 			// 有API密钥，直接启用
 			// 首先获取保存的API密钥
-			apiKey, err := getAPIKeyForProvider(provider)
+			apiKey, err := getAPIKeyForProvider(provider, profile)
 			if err != nil {
 				fmt.Printf("❌ 加载API密钥失败: %v\n", err)
 				return
 			}
 
-			err = aiProviderMgr.Enable(ctx, provider, apiKey)
+			err = aiProviderMgr.EnableProfile(ctx, provider, profile, apiKey)
 			if err != nil {
 				fmt.Printf("❌ 启用AI提供商失败: %v\n", err)
 				return
@@ -161,6 +358,316 @@ func createAIProviderOnCmd() *cobra.Command {
 			fmt.Printf("✅ 成功启用 %s\n", provider)
 		},
 	}
+
+	cmd.Flags().StringVar(&profile, "profile", "", "API密钥配置名 (可选，默认使用默认密钥)")
+	cmd.Flags().StringVar(&endpoint, "endpoint", "", "提供商接入点 (可选，如 doubao: coding, general)")
+	cmd.Flags().StringVar(&baseURL, "base-url", "", "覆盖提供商的接入地址 (可选，保留到 ai reset)")
+	cmd.Flags().StringVar(&keyStore, "keystore", "", "API密钥的保存位置: file 或 keychain (可选)")
+
+	return cmd
+}
+
+// hasProfileAPIKey 检查指定提供商的某个配置名是否已保存API密钥
+func hasProfileAPIKey(provider aiprovider.ProviderType, profile string) (bool, error) {
+	if profile == "" {
+		profile = aiprovider.DefaultProfile
+	}
+
+	profiles, err := aiProviderMgr.ListProfiles(provider)
+	if err != nil {
+		return false, err
+	}
+	for _, p := range profiles {
+		if p == profile {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func createAIProviderSwitchCmd() *cobra.Command {
+	var profile string
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "switch <provider>",
+		Short: "切换到已配置API密钥的AI提供商",
+		Long: `切换当前启用的AI提供商。切换前显示当前和目标提供商及模型，并需要确认一次。
+
+目标提供商必须已保存API密钥（使用 ai on <provider> 配置），使用 --yes 跳过确认。
+标准输入不是终端时（如脚本中）必须使用 --yes。`,
+		Example: `  claude-config ai switch kimi
+  claude-config ai switch deepseek --profile work
+  claude-config ai switch deepseek --yes`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			provider := claude.NormalizeProviderName(args[0])
+			if provider == claude.ProviderNone {
+				return fmt.Errorf("不支持的提供商: %s (支持: deepseek, kimi, glm, doubao, anthropic)", args[0])
+			}
+
+			confirmFn := confirm
+			if yes {
+				confirmFn = nil
+			} else if !stdinIsTerminal() {
+				return fmt.Errorf("标准输入不是终端，无法确认切换，请使用 --yes 跳过确认")
+			}
+			return switchAIProvider(context.Background(), os.Stdout, provider, profile, confirmFn)
+		},
+	}
+
+	cmd.Flags().StringVar(&profile, "profile", "", "API密钥配置名 (可选，默认使用默认密钥)")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "跳过确认提示")
+
+	return cmd
+}
+
+// switchAIProvider enables provider with its stored key after showing what
+// changes. confirmFn asks for confirmation; nil skips the prompt.
+func switchAIProvider(ctx context.Context, w io.Writer, provider claude.ProviderType, profile string, confirmFn func(string) (bool, error)) error {
+	if err := aiprovider.ValidateProfileName(profile); err != nil {
+		return err
+	}
+
+	hasKey, err := hasProfileAPIKey(provider, profile)
+	if err != nil {
+		return fmt.Errorf("检查API密钥失败: %w", err)
+	}
+	if !hasKey {
+		return fmt.Errorf("提供商 %s 的API密钥未配置，请先使用 claude-config ai on %s", provider, provider)
+	}
+
+	status, err := aiProviderMgr.Status(ctx)
+	if err != nil {
+		return fmt.Errorf("获取AI提供商状态失败: %w", err)
+	}
+
+	printSwitchSummary(w, status, provider)
+
+	if confirmFn != nil {
+		ok, err := confirmFn("确认切换?")
+		if err != nil {
+			return fmt.Errorf("读取确认失败: %w", err)
+		}
+		if !ok {
+			fmt.Fprintln(w, "已取消")
+			return nil
+		}
+	}
+
+	apiKey, err := getAPIKeyForProvider(provider, profile)
+	if err != nil {
+		return fmt.Errorf("加载API密钥失败: %w", err)
+	}
+	if err := aiProviderMgr.EnableProfile(ctx, provider, profile, apiKey); err != nil {
+		return fmt.Errorf("切换AI提供商失败: %w", err)
+	}
+
+	fmt.Fprintf(w, "✅ 已切换到 %s\n", provider)
+	return nil
+}
+
+// printSwitchSummary 输出切换前后的提供商和模型
+func printSwitchSummary(w io.Writer, status *claude.ProviderStatus, target claude.ProviderType) {
+	current := "未启用"
+	if status.ActiveProvider != claude.ProviderNone {
+		current = string(status.ActiveProvider)
+		if status.Config != nil && status.Config.Model != "" {
+			current += fmt.Sprintf(" (模型: %s)", status.Config.Model)
+		}
+	}
+
+	next := string(target)
+	if prov, err := getProvider(target); err == nil {
+		next += fmt.Sprintf(" (模型: %s)", prov.GetDefaultConfig("").Model)
+	}
+
+	fmt.Fprintln(w, "🔀 即将切换AI提供商:")
+	fmt.Fprintf(w, "   当前: %s\n", current)
+	fmt.Fprintf(w, "   切换为: %s\n", next)
+}
+
+func createAIProviderExportCmd() *cobra.Command {
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "导出所有已保存的AI提供商密钥",
+		Long: `将所有已保存的AI提供商API密钥（含各配置名）及默认提供商导出为JSON，
+用于在新机器上通过 ai import 恢复。
+
+⚠️  导出内容包含明文API密钥，请妥善保管，使用后及时删除。
+写入文件时权限为 0600。`,
+		Example: `  claude-config ai export > api-keys.json
+  claude-config ai export -o api-keys.json`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			data, err := aiProviderMgr.ExportProviders(context.Background())
+			if err != nil {
+				return fmt.Errorf("导出失败: %w", err)
+			}
+			data = append(data, '\n')
+
+			fmt.Fprintln(os.Stderr, "⚠️  导出内容包含明文API密钥，请妥善保管，使用后及时删除")
+
+			if output != "" {
+				if err := writeSecretFile(output, data); err != nil {
+					return fmt.Errorf("写入导出文件失败: %w", err)
+				}
+				fmt.Fprintf(os.Stderr, "✅ 已导出到 %s\n", output)
+				return nil
+			}
+
+			restrictStdoutFile()
+			_, err = os.Stdout.Write(data)
+			return err
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "", "写入指定文件 (权限 0600)")
+
+	return cmd
+}
+
+// writeSecretFile 以 0600 权限写入 path。已存在的文件先收紧权限再清空，
+// 密钥不会写入权限更宽的文件
+func writeSecretFile(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// OpenFile 不会修改已存在文件的权限
+	if err := file.Chmod(0600); err != nil {
+		return fmt.Errorf("设置文件权限失败: %w", err)
+	}
+	if err := file.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// restrictStdoutFile 当标准输出被重定向到普通文件时将其权限收紧为 0600
+func restrictStdoutFile() {
+	info, err := os.Stdout.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		return
+	}
+	if err := os.Stdout.Chmod(0600); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  无法将输出文件权限设置为 0600: %v\n", err)
+	}
+}
+
+func createAIProviderImportCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "import <file>",
+		Short:   "从 ai export 导出的文件恢复AI提供商密钥",
+		Long:    `从 ai export 导出的JSON文件恢复API密钥和默认提供商。已存在的同名密钥会被覆盖，当前启用的提供商不变。`,
+		Example: `  claude-config ai import api-keys.json`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			data, err := os.ReadFile(args[0])
+			if err != nil {
+				return fmt.Errorf("读取导入文件失败: %w", err)
+			}
+
+			if err := aiProviderMgr.ImportProviders(context.Background(), data); err != nil {
+				return fmt.Errorf("导入失败: %w", err)
+			}
+
+			fmt.Println("✅ 已导入AI提供商配置")
+			fmt.Println("使用 claude-config ai on <provider> 启用提供商")
+			fmt.Printf("⚠️  %s 包含明文API密钥，确认无误后请删除该文件\n", args[0])
+			return nil
+		},
+	}
+}
+
+func createAIProviderPingCmd() *cobra.Command {
+	var timeout time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "ping",
+		Short: "检测当前AI提供商接入点的网络连通性",
+		Long: `连接当前启用的AI提供商的 base URL（经由已配置的代理），检查 TCP 连通性和 TLS 证书。
+不发送API密钥，用于区分网络/DNS/代理问题和密钥问题。`,
+		Example: `  claude-config ai ping
+  claude-config ai ping --timeout 5s`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx := cmd.Context()
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			provider, err := aiProviderMgr.GetActiveProvider(ctx)
+			if err != nil {
+				return fmt.Errorf("获取活跃提供商失败: %w", err)
+			}
+			if provider == claude.ProviderNone {
+				return fmt.Errorf("没有启用的AI提供商，请先使用 claude-config ai on <provider>")
+			}
+
+			config, err := aiProviderMgr.GetProviderConfig(ctx, provider)
+			if err != nil {
+				return fmt.Errorf("获取提供商配置失败: %w", err)
+			}
+			if config == nil {
+				return fmt.Errorf("提供商 %s 未配置 base URL", provider)
+			}
+
+			proxyConfig, err := proxyMgr.GetConfig(ctx)
+			if err != nil {
+				return fmt.Errorf("获取代理配置失败: %w", err)
+			}
+
+			fmt.Printf("🤖 提供商: %s\n", provider)
+			result := aiprovider.NewPinger().Ping(ctx, config.BaseURL, proxyConfig)
+			if !printPingResult(os.Stdout, result) {
+				return fmt.Errorf("接入点检测失败")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().DurationVar(&timeout, "timeout", 10*time.Second, "连接超时时间")
+
+	return cmd
+}
+
+// printPingResult 输出连通性检测结果，全部通过时返回 true
+func printPingResult(w io.Writer, result *aiprovider.PingResult) bool {
+	target := result.Address
+	if target == "" {
+		target = result.BaseURL
+	}
+	if result.Proxy != "" {
+		fmt.Fprintf(w, "🌐 目标: %s (经由代理 %s)\n", target, result.Proxy)
+	} else {
+		fmt.Fprintf(w, "🌐 目标: %s\n", target)
+	}
+
+	if !result.Reachable {
+		fmt.Fprintf(w, "❌ 无法连接: %v\n", result.Err)
+		return false
+	}
+	fmt.Fprintf(w, "✅ 连接成功 (%s)\n", result.Latency.Round(time.Millisecond))
+
+	if !result.TLSChecked {
+		return true
+	}
+	if !result.TLSValid {
+		fmt.Fprintf(w, "❌ TLS 握手失败: %v\n", result.TLSError)
+		return false
+	}
+	fmt.Fprintln(w, "✅ TLS 证书有效")
+	return true
 }
 
 func createAIProviderListCmd() *cobra.Command {
@@ -174,63 +681,129 @@ func createAIProviderListCmd() *cobra.Command {
 	}
 }
 
-func showAIProviderStatus() {
+func createAIProviderModelsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "models <provider>",
+		Short:   "列出AI提供商支持的模型",
+		Long:    `列出指定AI提供商支持的模型名称，可用于 start --model。`,
+		Example: `  claude-config ai models kimi`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			provider := claude.NormalizeProviderName(args[0])
+			if provider == claude.ProviderNone {
+				return fmt.Errorf("不支持的提供商: %s", args[0])
+			}
+
+			return printSupportedModels(provider)
+		},
+	}
+}
+
+func showAIProviderStatus(w io.Writer, reachability bool) {
 	ctx := context.Background()
 
-	fmt.Println("🤖 AI提供商状态")
-	fmt.Println("================")
+	fmt.Fprintln(w, "🤖 AI提供商状态")
+	fmt.Fprintln(w, "================")
 
-	// 获取当前活跃的提供商
-	activeProvider, err := aiProviderMgr.GetActiveProvider(ctx)
+	status, err := aiProviderMgr.Status(ctx)
 	if err != nil {
-		fmt.Printf("❌ 获取活跃提供商失败: %v\n", err)
+		fmt.Fprintf(w, "❌ 获取AI提供商状态失败: %v\n", err)
 		return
 	}
 
-	if activeProvider == aiprovider.ProviderNone {
-		fmt.Println("📍 当前状态: 未启用任何AI提供商")
-	} else {
-		fmt.Printf("📍 当前活跃提供商: %s\n", activeProvider)
-
-		// 获取配置信息
-		config, err := aiProviderMgr.GetProviderConfig(ctx, activeProvider)
-		if err != nil {
-			fmt.Printf("❌ 获取配置失败: %v\n", err)
+	if reachability {
+		if err := addReachability(ctx, status); err != nil {
+			fmt.Fprintf(w, "❌ 检查接口连通性失败: %v\n", err)
 			return
 		}
-
-		if config != nil {
-			fmt.Printf("   📡 基础URL: %s\n", config.BaseURL)
-			fmt.Printf("   🧠 模型: %s\n", config.Model)
-			fmt.Printf("   ⚡ 快速模型: %s\n", config.SmallFastModel)
-		}
 	}
 
-	fmt.Println()
+	renderAIProviderStatus(w, status)
 }
 
-// getAPIKeyForProvider 获取指定提供商的API密钥
-func getAPIKeyForProvider(provider aiprovider.ProviderType) (string, error) {
-	// 通过manager的内部方法获取API密钥，但manager的loadAPIKey是私有的
-	// 我们需要通过文件系统直接读取
-	claudeDir := getClaudeDir()
-	apiKeyPath := filepath.Join(claudeDir, fmt.Sprintf(".%s_api_key", provider))
+// addReachability checks the active provider's API and records the result in status
+func addReachability(ctx context.Context, status *claude.ProviderStatus) error {
+	if status.ActiveProvider == aiprovider.ProviderNone {
+		return nil
+	}
 
-	data, err := os.ReadFile(apiKeyPath)
+	result, err := aiProviderMgr.CheckReachability(ctx, status.ActiveProvider)
 	if err != nil {
-		return "", fmt.Errorf("failed to read API key file: %w", err)
+		return err
+	}
+	status.Reachability = result
+	return nil
+}
+
+// renderReachability 将接口连通性检查结果渲染为一行文本
+func renderReachability(w io.Writer, result *claude.ReachabilityResult) {
+	switch result.Status {
+	case claude.ReachabilityReachable:
+		fmt.Fprintf(w, "   ✅ 接口可访问 (HTTP %d, %s)\n", result.StatusCode, result.Latency.Round(time.Millisecond))
+	case claude.ReachabilityUnauthorized:
+		fmt.Fprintf(w, "   ❌ API密钥被拒绝 (HTTP %d)，请使用 claude-config ai rotate %s 更换密钥\n", result.StatusCode, result.Provider)
+	case claude.ReachabilityTimeout:
+		fmt.Fprintf(w, "   ⏱️  连接 %s 超时，请检查网络和代理设置\n", result.BaseURL)
+	case claude.ReachabilityNoKey:
+		fmt.Fprintf(w, "   ⚠️  未保存 %s 的API密钥，未进行检查\n", result.Provider)
+	default:
+		fmt.Fprintf(w, "   ❌ 无法连接 %s: %s\n", result.BaseURL, result.Error)
+	}
+}
+
+// renderAIProviderStatus 将提供商状态渲染为可读文本
+func renderAIProviderStatus(w io.Writer, status *claude.ProviderStatus) {
+	if status.ActiveProvider == aiprovider.ProviderNone {
+		fmt.Fprintln(w, "📍 当前状态: 未启用任何AI提供商")
+	} else {
+		fmt.Fprintf(w, "📍 当前活跃提供商: %s\n", status.ActiveProvider)
+
+		if status.Config != nil {
+			fmt.Fprintf(w, "   📡 基础URL: %s\n", status.Config.BaseURL)
+			fmt.Fprintf(w, "   🧠 模型: %s\n", status.Config.Model)
+			fmt.Fprintf(w, "   ⚡ 快速模型: %s\n", status.Config.SmallFastModel)
+		}
+		if status.Reachability != nil {
+			renderReachability(w, status.Reachability)
+		}
+	}
+
+	var withKeys []string
+	for provider, hasKey := range status.Keys {
+		if hasKey {
+			withKeys = append(withKeys, string(provider))
+		}
+	}
+	if len(withKeys) > 0 {
+		sort.Strings(withKeys)
+		fmt.Fprintf(w, "🔑 已保存API密钥: %s\n", strings.Join(withKeys, ", "))
 	}
 
-	return string(data), nil
+	fmt.Fprintln(w)
 }
 
-// getClaudeDir 获取Claude配置目录路径
-func getClaudeDir() string {
-	homeDir, err := os.UserHomeDir()
+// printAIProviderStatusJSON 以 JSON 格式输出提供商状态，便于脚本使用
+func printAIProviderStatusJSON(w io.Writer, reachability bool) error {
+	ctx := context.Background()
+	status, err := aiProviderMgr.Status(ctx)
 	if err != nil {
-		return ""
+		return fmt.Errorf("获取AI提供商状态失败: %w", err)
 	}
-	return filepath.Join(homeDir, ".claude")
+
+	if reachability {
+		if err := addReachability(ctx, status); err != nil {
+			return fmt.Errorf("检查接口连通性失败: %w", err)
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(status)
+}
+
+// getAPIKeyForProvider 获取指定提供商的API密钥
+func getAPIKeyForProvider(provider aiprovider.ProviderType, profile string) (string, error) {
+	return aiProviderMgr.LoadAPIKey(provider, profile)
 }
 
 func showAIProviderList() {
@@ -248,10 +821,10 @@ func showAIProviderList() {
 			status = "🟢"
 		}
 
-		hasKey, _ := aiProviderMgr.HasAPIKey(ctx, provider)
+		profiles, _ := aiProviderMgr.ListProfiles(provider)
 		keyStatus := ""
-		if hasKey {
-			keyStatus = " (已保存API密钥)"
+		if len(profiles) > 0 {
+			keyStatus = fmt.Sprintf(" (已保存API密钥: %s)", strings.Join(profiles, ", "))
 		}
 
 		fmt.Printf("%s %s%s\n", status, provider, keyStatus)
@@ -263,8 +836,11 @@ func showAIProviderList() {
 	fmt.Println("⚪ 可用提供商")
 	fmt.Println()
 	fmt.Println("使用方法:")
-	fmt.Println("  claude-config ai on [provider]")
+	fmt.Println("  claude-config ai on [provider] [--profile name]")
 	fmt.Println("  claude-config ai reset <provider>")
 	fmt.Println("  claude-config ai off")
 	fmt.Println("  claude-config ai list")
+	fmt.Println("  claude-config ai models <provider>")
+	fmt.Println("  claude-config ai export -o api-keys.json")
+	fmt.Println("  claude-config ai import api-keys.json")
 }
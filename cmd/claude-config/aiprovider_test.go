@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ooneko/claude-config/internal/aiprovider"
+	"github.com/ooneko/claude-config/internal/claude"
+)
+
+func TestResetAllProviders(t *testing.T) {
+	dir := useTempManagers(t)
+	ctx := context.Background()
+
+	require.NoError(t, aiProviderMgr.Enable(ctx, claude.ProviderKimi, "sk-kimi"))
+	require.NoError(t, aiProviderMgr.Enable(ctx, claude.ProviderDeepSeek, "sk-deepseek"))
+	require.NoError(t, aiProviderMgr.EnableProfile(ctx, claude.ProviderKimi, "work", "sk-kimi-work"))
+	require.NoError(t, aiProviderMgr.SetEndpoint(ctx, claude.ProviderDoubao, aiprovider.DoubaoEndpointGeneral))
+
+	resetAllProviders(ctx)
+
+	for _, provider := range aiProviderMgr.ListSupportedProviders() {
+		hasKey, err := aiProviderMgr.HasAPIKey(ctx, provider)
+		require.NoError(t, err)
+		assert.False(t, hasKey, "API key for %s should be removed", provider)
+	}
+	assert.NoFileExists(t, filepath.Join(dir, ".kimi.work_api_key"))
+	assert.NoFileExists(t, filepath.Join(dir, ".doubao_endpoint"))
+
+	active, err := aiProviderMgr.GetActiveProvider(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, claude.ProviderNone, active)
+
+	data, err := os.ReadFile(filepath.Join(dir, "settings.json"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "ANTHROPIC_AUTH_TOKEN")
+}
+
+func TestPrintAIProviderStatusJSON(t *testing.T) {
+	useTempManagers(t)
+	ctx := context.Background()
+
+	require.NoError(t, aiProviderMgr.Enable(ctx, claude.ProviderKimi, "sk-kimi-secret-key"))
+
+	var buf bytes.Buffer
+	require.NoError(t, printAIProviderStatusJSON(&buf, false))
+	assert.NotContains(t, buf.String(), "sk-kimi-secret-key")
+
+	var status claude.ProviderStatus
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &status))
+	assert.Equal(t, claude.ProviderKimi, status.ActiveProvider)
+	require.NotNil(t, status.Config)
+	assert.Equal(t, "https://api.kimi.com/coding/", status.Config.BaseURL)
+	assert.True(t, status.Keys[claude.ProviderKimi])
+	assert.False(t, status.Keys[claude.ProviderDeepSeek])
+
+	var text bytes.Buffer
+	renderAIProviderStatus(&text, &status)
+	assert.Contains(t, text.String(), "当前活跃提供商: kimi")
+	assert.Contains(t, text.String(), "已保存API密钥: kimi")
+}
+
+func TestPrintPingResult(t *testing.T) {
+	tests := []struct {
+		name   string
+		result *aiprovider.PingResult
+		ok     bool
+		want   string
+	}{
+		{
+			name:   "reachable with valid TLS",
+			result: &aiprovider.PingResult{Address: "api.deepseek.com:443", Reachable: true, TLSChecked: true, TLSValid: true},
+			ok:     true,
+			want:   "TLS 证书有效",
+		},
+		{
+			name:   "unreachable",
+			result: &aiprovider.PingResult{Address: "api.deepseek.com:443", Err: errors.New("dial tcp: no such host")},
+			ok:     false,
+			want:   "无法连接: dial tcp: no such host",
+		},
+		{
+			name:   "invalid TLS through proxy",
+			result: &aiprovider.PingResult{Address: "api.deepseek.com:443", Proxy: "http://127.0.0.1:7890", Reachable: true, TLSChecked: true, TLSError: errors.New("x509: unknown authority")},
+			ok:     false,
+			want:   "经由代理 http://127.0.0.1:7890",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			assert.Equal(t, tt.ok, printPingResult(&buf, tt.result))
+			assert.Contains(t, buf.String(), tt.want)
+		})
+	}
+}
+
+func TestRenderReachability(t *testing.T) {
+	tests := []struct {
+		name   string
+		result *claude.ReachabilityResult
+		want   string
+	}{
+		{
+			name:   "reachable",
+			result: &claude.ReachabilityResult{Provider: claude.ProviderDeepSeek, Status: claude.ReachabilityReachable, StatusCode: 200, Latency: 120 * time.Millisecond},
+			want:   "接口可访问 (HTTP 200, 120ms)",
+		},
+		{
+			name:   "unauthorized",
+			result: &claude.ReachabilityResult{Provider: claude.ProviderDeepSeek, Status: claude.ReachabilityUnauthorized, StatusCode: 401},
+			want:   "claude-config ai rotate deepseek",
+		},
+		{
+			name:   "timeout",
+			result: &claude.ReachabilityResult{BaseURL: "https://api.deepseek.com/anthropic", Status: claude.ReachabilityTimeout},
+			want:   "连接 https://api.deepseek.com/anthropic 超时",
+		},
+		{
+			name:   "no key",
+			result: &claude.ReachabilityResult{Provider: claude.ProviderKimi, Status: claude.ReachabilityNoKey},
+			want:   "未保存 kimi 的API密钥",
+		},
+		{
+			name:   "unreachable",
+			result: &claude.ReachabilityResult{BaseURL: "https://api.deepseek.com/anthropic", Status: claude.ReachabilityUnreachable, Error: "no such host"},
+			want:   "无法连接 https://api.deepseek.com/anthropic: no such host",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			renderReachability(&buf, tt.result)
+			assert.Contains(t, buf.String(), tt.want)
+		})
+	}
+}
+
+func TestSwitchAIProvider(t *testing.T) {
+	useTempManagers(t)
+	ctx := context.Background()
+
+	require.NoError(t, aiProviderMgr.Enable(ctx, claude.ProviderKimi, "sk-kimi"))
+	require.NoError(t, aiProviderMgr.Enable(ctx, claude.ProviderDeepSeek, "sk-deepseek"))
+
+	// Declining the prompt leaves the active provider unchanged
+	var out bytes.Buffer
+	var prompted bool
+	decline := func(string) (bool, error) {
+		prompted = true
+		return false, nil
+	}
+	require.NoError(t, switchAIProvider(ctx, &out, claude.ProviderKimi, "", decline))
+	assert.True(t, prompted)
+	assert.Contains(t, out.String(), "当前: deepseek (模型: deepseek-chat)")
+	assert.Contains(t, out.String(), "切换为: kimi (模型: kimi-for-coding)")
+	assert.Contains(t, out.String(), "已取消")
+
+	active, err := aiProviderMgr.GetActiveProvider(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, claude.ProviderDeepSeek, active)
+
+	// A nil confirm (--yes) switches without prompting
+	out.Reset()
+	require.NoError(t, switchAIProvider(ctx, &out, claude.ProviderKimi, "", nil))
+	assert.Contains(t, out.String(), "已切换到 kimi")
+
+	active, err = aiProviderMgr.GetActiveProvider(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, claude.ProviderKimi, active)
+
+	// Providers without a stored key are rejected before any prompt
+	err = switchAIProvider(ctx, &out, claude.ProviderGLM, "", func(string) (bool, error) {
+		t.Fatal("should not prompt without an API key")
+		return false, nil
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "API密钥未配置")
+
+	// Without a terminal the command requires --yes instead of prompting
+	original := stdinIsTerminal
+	stdinIsTerminal = func() bool { return false }
+	defer func() { stdinIsTerminal = original }()
+
+	cmd := createAIProviderSwitchCmd()
+	cmd.SetArgs([]string{"deepseek"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	err = cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--yes")
+
+	active, err = aiProviderMgr.GetActiveProvider(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, claude.ProviderKimi, active)
+}
+
+func TestWriteSecretFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api-keys.json")
+
+	// An existing world-readable file is restricted before the secrets are written
+	require.NoError(t, os.WriteFile(path, []byte("old content that is longer"), 0644))
+	require.NoError(t, writeSecretFile(path, []byte("secret")))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(data))
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+}
@@ -3,25 +3,150 @@ package main
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
+
+	"github.com/ooneko/claude-config/internal/claude"
 )
 
 // createBackupCmd creates the backup command
 func createBackupCmd() *cobra.Command {
-	return &cobra.Command{
+	var destDir string
+	var includeSecrets bool
+	var allProfiles bool
+	var profilesRoot string
+
+	backupCmd := &cobra.Command{
 		Use:   "backup",
 		Short: "备份配置",
+		Long: `将配置目录打包为 tar.gz 备份文件，默认保存到用户主目录，可使用 --dir 指定目录
+
+默认不包含 API 密钥文件 (.*_api_key) 和 .last_active_provider，
+如需一并备份请使用 --include-secrets。
+
+使用 --all-profiles 将 profiles 根目录（默认 ~/.claude-profiles）下每个包含
+settings.json 的配置目录打包到同一个备份文件中，每个 profile 位于各自的子路径。`,
+		Example: `  claude-config backup
+  claude-config backup --dir /Volumes/external/backups
+  claude-config backup --include-secrets
+  claude-config backup --all-profiles
+  claude-config backup --all-profiles --profiles-root ~/claude-accounts`,
 		RunE: func(_ *cobra.Command, _ []string) error {
 			ctx := context.Background()
-			backupInfo, err := configMgr.Backup(ctx)
+
+			if destDir == "" {
+				homeDir, err := os.UserHomeDir()
+				if err != nil {
+					return fmt.Errorf("获取用户主目录失败: %w", err)
+				}
+				destDir = homeDir
+			}
+
+			opts := &claude.BackupOptions{IncludeSecrets: includeSecrets}
+			var backupInfo *claude.BackupInfo
+			var err error
+			if allProfiles {
+				root, rootErr := resolveProfilesRoot(profilesRoot)
+				if rootErr != nil {
+					return rootErr
+				}
+				backupInfo, err = configMgr.BackupProfiles(ctx, root, destDir, opts)
+			} else {
+				backupInfo, err = configMgr.BackupTo(ctx, destDir, opts)
+			}
 			if err != nil {
 				return err
 			}
 			fmt.Printf("✅ 配置已备份到：%s\n", backupInfo.FilePath)
+			if len(backupInfo.Profiles) > 0 {
+				fmt.Printf("   Profiles：%s\n", strings.Join(backupInfo.Profiles, ", "))
+			}
 			fmt.Printf("   大小：%s\n", formatBytes(backupInfo.Size))
 			fmt.Printf("   时间：%s\n", backupInfo.Timestamp.Format("2006-01-02 15:04:05"))
+			if len(backupInfo.ExcludedFiles) > 0 {
+				fmt.Printf("🔒 已排除敏感文件：%s\n", strings.Join(backupInfo.ExcludedFiles, ", "))
+				fmt.Println("   如需包含请使用 --include-secrets")
+			} else if includeSecrets {
+				fmt.Println("⚠️  备份包含 API 密钥，请妥善保管备份文件")
+			}
 			return nil
 		},
 	}
+
+	backupCmd.Flags().StringVar(&destDir, "dir", "", "备份文件保存目录 (默认: 用户主目录)")
+	backupCmd.Flags().BoolVar(&includeSecrets, "include-secrets", false, "在备份中包含 API 密钥文件")
+	backupCmd.Flags().BoolVar(&allProfiles, "all-profiles", false, "备份 profiles 根目录下的所有配置目录")
+	backupCmd.Flags().StringVar(&profilesRoot, "profiles-root", "", "profiles 根目录 (默认: ~/.claude-profiles)")
+
+	return backupCmd
+}
+
+// createRestoreCmd creates the restore command
+func createRestoreCmd() *cobra.Command {
+	var force bool
+	var allProfiles bool
+	var profilesRoot string
+
+	restoreCmd := &cobra.Command{
+		Use:   "restore <backup-file>",
+		Short: "从备份恢复配置",
+		Long: `从 backup 命令生成的 tar.gz 备份文件恢复配置到 ~/.claude
+
+如果配置目录已存在，需要使用 --force 覆盖。
+
+使用 --all-profiles 恢复 backup --all-profiles 生成的备份，每个 profile 恢复到
+profiles 根目录（默认 ~/.claude-profiles）下的同名目录。`,
+		Example: `  claude-config restore ~/claude-config-backup-20250101_120000.tar.gz
+  claude-config restore ~/claude-config-backup-20250101_120000.tar.gz --force
+  claude-config restore --all-profiles ~/claude-config-profiles-backup-20250101_120000.tar.gz`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			ctx := context.Background()
+			if allProfiles {
+				root, err := resolveProfilesRoot(profilesRoot)
+				if err != nil {
+					return err
+				}
+				profiles, err := configMgr.RestoreProfiles(ctx, args[0], root, force)
+				if err != nil {
+					return fmt.Errorf("恢复配置失败: %w", err)
+				}
+				fmt.Printf("✅ 已从 %s 恢复 %d 个 profile 到：%s\n", args[0], len(profiles), root)
+				for _, profile := range profiles {
+					fmt.Printf("   • %s\n", filepath.Join(root, profile))
+				}
+				return nil
+			}
+
+			if err := configMgr.Restore(ctx, args[0], force); err != nil {
+				return fmt.Errorf("恢复配置失败: %w", err)
+			}
+			fmt.Printf("✅ 配置已从 %s 恢复到：%s\n", args[0], claudeDir)
+			return nil
+		},
+	}
+
+	restoreCmd.Flags().BoolVar(&force, "force", false, "覆盖已存在的配置目录")
+	restoreCmd.Flags().BoolVar(&allProfiles, "all-profiles", false, "恢复 backup --all-profiles 生成的多 profile 备份")
+	restoreCmd.Flags().StringVar(&profilesRoot, "profiles-root", "", "profiles 根目录 (默认: ~/.claude-profiles)")
+
+	return restoreCmd
+}
+
+// defaultProfilesDirName is the profiles root under the home directory
+const defaultProfilesDirName = ".claude-profiles"
+
+// resolveProfilesRoot returns the --profiles-root directory, by default ~/.claude-profiles
+func resolveProfilesRoot(flagDir string) (string, error) {
+	if dir := strings.TrimSpace(flagDir); dir != "" {
+		return filepath.Abs(dir)
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("获取用户主目录失败: %w", err)
+	}
+	return filepath.Join(homeDir, defaultProfilesDirName), nil
 }
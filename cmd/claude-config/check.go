@@ -3,8 +3,11 @@ package main
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
+
+	"github.com/ooneko/claude-config/internal/check"
 )
 
 // createCheckCmd creates the check command
@@ -17,8 +20,13 @@ func createCheckCmd() *cobra.Command {
 启用时会添加以下hooks到settings.json:
   - smart-lint.sh (智能代码检查)
   - smart-test.sh (智能测试)
+  - protect-files.sh (编辑前拦截受保护文件，如 .env、*.pem)
+
+禁用时只移除上述hooks，用户自己配置的其他hooks保持不变。
 
-这些hooks会在代码编辑后自动运行，确保代码质量。`,
+如需自定义，可创建 ~/.claude/.check_config.json，格式与 settings.json 的 hooks 相同
+（仅支持 PreToolUse 和 PostToolUse），启用时将使用其中定义的 matcher、命令和超时:
+  {"PostToolUse": [{"matcher": "Write|Edit", "hooks": [{"command": "~/bin/lint.sh", "timeout": 60}]}]}`,
 		Example: `  claude-config check on   # 启用代码检查hooks
   claude-config check off  # 禁用代码检查hooks`,
 		Args: cobra.ExactArgs(1),
@@ -28,24 +36,108 @@ func createCheckCmd() *cobra.Command {
 		},
 	}
 
+	checkCmd.AddCommand(createCheckLangCmd())
+
 	return checkCmd
 }
 
+// createCheckLangCmd creates the check lang command
+func createCheckLangCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "lang <language> <on|off|reset>",
+		Short: "按语言开关代码检查",
+		Long: fmt.Sprintf(`按语言开关 smart-lint.sh 的代码检查
+
+通过在settings.json的env中设置 CLAUDE_HOOKS_<LANG>_ENABLED 实现，
+reset 会删除该设置，恢复脚本默认值（启用）。
+
+支持的语言: %s`, strings.Join(check.SupportedLanguages, ", ")),
+		Example: `  claude-config check lang go off    # 禁用Go代码检查
+  claude-config check lang go on     # 启用Go代码检查
+  claude-config check lang go reset  # 恢复默认设置`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return handleCheckLangCommand(args[0], args[1])
+		},
+	}
+}
+
+// handleCheckLangCommand handles the check lang command
+func handleCheckLangCommand(lang, action string) error {
+	ctx := context.Background()
+
+	switch action {
+	case "on", "enable":
+		if err := checkMgr.SetLanguageEnabled(ctx, lang, true); err != nil {
+			return fmt.Errorf("启用语言检查失败: %w", err)
+		}
+		fmt.Printf("✅ %s 代码检查已启用\n", lang)
+
+	case "off", "disable":
+		if err := checkMgr.SetLanguageEnabled(ctx, lang, false); err != nil {
+			return fmt.Errorf("禁用语言检查失败: %w", err)
+		}
+		fmt.Printf("❌ %s 代码检查已禁用\n", lang)
+
+	case "reset":
+		if err := checkMgr.ResetLanguage(ctx, lang); err != nil {
+			return fmt.Errorf("重置语言检查失败: %w", err)
+		}
+		fmt.Printf("✅ %s 代码检查已恢复默认设置\n", lang)
+
+	default:
+		return fmt.Errorf("无效操作: %s\n\n支持的操作: on, off, reset\n使用方法: claude-config check lang <language> <on|off|reset>", action)
+	}
+
+	return nil
+}
+
 // handleCheckCommand handles the check command
 func handleCheckCommand(action string) error {
 	ctx := context.Background()
 
 	switch action {
 	case "on", "enable":
-		err := checkMgr.EnableCheck(ctx)
+		enabled, err := checkMgr.IsEnabled(ctx)
+		if err != nil {
+			return fmt.Errorf("获取检查功能状态失败: %w", err)
+		}
+		if enabled {
+			// Hooks enabled by an older version may lack newer events such as the PreToolUse guard
+			complete, err := checkMgr.IsComplete(ctx)
+			if err != nil {
+				return fmt.Errorf("获取检查功能状态失败: %w", err)
+			}
+			if complete {
+				fmt.Println("✅ 代码检查功能已经是启用状态")
+				return nil
+			}
+		}
+
+		err = checkMgr.EnableCheck(ctx)
 		if err != nil {
 			return fmt.Errorf("启用代码检查功能失败: %w", err)
 		}
 		fmt.Println("✅ 代码检查功能已启用")
-		fmt.Println("   - smart-lint.sh (智能代码检查)")
+
+		checkConfig, err := checkMgr.LoadCheckConfig()
+		if err != nil {
+			return fmt.Errorf("读取检查配置失败: %w", err)
+		}
+		if checkConfig.HasHooks() {
+			fmt.Println("   使用 ~/.claude/.check_config.json 中定义的hooks")
+			return nil
+		}
+
+		if checkConfig != nil && checkConfig.ScopeStaged {
+			fmt.Println("   - smart-lint.sh --staged (仅检查 git 暂存的文件)")
+		} else {
+			fmt.Println("   - smart-lint.sh (智能代码检查)")
+		}
 		fmt.Println("   - smart-test.sh (智能测试)")
+		fmt.Println("   - protect-files.sh (拦截受保护文件的编辑)")
 		fmt.Println()
-		fmt.Println("这些hooks将在代码编辑后自动运行，确保代码质量。")
+		fmt.Println("这些hooks将在代码编辑前后自动运行，确保代码质量。")
 
 	case "off", "disable":
 		err := checkMgr.DisableCheck(ctx)
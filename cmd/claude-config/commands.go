@@ -2,15 +2,24 @@ package main
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/spf13/cobra"
 )
 
 func createRootCmd() *cobra.Command {
+	var claudeDirFlag string
+	var quiet, verbose bool
+
 	rootCmd := &cobra.Command{
 		Use:   "claude-config",
 		Short: "Claude 配置管理工具",
 		Long:  `Claude Configuration Tool 是一个统一配置管理工具，整合了配置管理和文件复制功能。`,
+		PersistentPreRun: func(_ *cobra.Command, _ []string) {
+			// 解析参数后再创建管理器，使 --claude-dir 对所有子命令生效
+			logger = newLogger(os.Stdout, quiet, verbose)
+			initManagers(selectClaudeDir(claudeDirFlag, os.Stderr))
+		},
 		Run: func(cmd *cobra.Command, _ []string) {
 			// 没有子命令时显示帮助信息
 			fmt.Println("欢迎使用 Claude 配置管理工具！")
@@ -19,6 +28,10 @@ func createRootCmd() *cobra.Command {
 		},
 	}
 
+	rootCmd.PersistentFlags().StringVar(&claudeDirFlag, "claude-dir", "", "配置目录 (默认使用 CLAUDE_CONFIG_DIR 或 ~/.claude)")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "不输出安装、合并等过程中的提示信息")
+	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "输出更详细的过程信息")
+
 	initCommands(rootCmd)
 	return rootCmd
 }
@@ -27,12 +40,20 @@ func initCommands(rootCmd *cobra.Command) {
 	// 添加所有子命令
 	rootCmd.AddCommand(
 		createStatusCmd(),
+		createConfigCmd(),
 		createProxyCmd(),
 		createCheckCmd(),
+		createHooksCmd(),
 		createAIProviderCmd(),
 		createNotifyCmd(),
 		createInstallCmd(),
 		createBackupCmd(),
+		createRestoreCmd(),
 		createStartCmd(),
+		createSyncCmd(),
+		createSelfTestCmd(),
+		createDoctorCmd(),
+		createFixPermissionsCmd(),
+		createMigrateCmd(),
 	)
 }
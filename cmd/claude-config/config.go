@@ -0,0 +1,402 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ooneko/claude-config/internal/config"
+	"github.com/ooneko/claude-config/internal/fsutil"
+	"github.com/ooneko/claude-config/internal/install"
+)
+
+// createConfigCmd creates the config command and subcommands
+func createConfigCmd() *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config <command>",
+		Short: "配置文件管理",
+		Long:  "查看和维护 settings.json 等配置文件",
+		Run: func(cmd *cobra.Command, _ []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	configCmd.AddCommand(createConfigDiffCmd())
+	configCmd.AddCommand(createConfigRepairPermsCmd())
+	configCmd.AddCommand(createConfigPinCmd())
+	configCmd.AddCommand(createConfigUnpinCmd())
+	configCmd.AddCommand(createConfigKeysCmd())
+	configCmd.AddCommand(createConfigMigrateCmd())
+	configCmd.AddCommand(createConfigEditCmd())
+
+	return configCmd
+}
+
+// createConfigDiffCmd creates the config diff command
+func createConfigDiffCmd() *cobra.Command {
+	var only string
+
+	diffCmd := &cobra.Command{
+		Use:   "diff",
+		Short: "比较已安装的settings.json与内置模板",
+		Long: `按键比较已安装的 settings.json 与内置模板的差异
+
+  + 仅存在于内置模板中
+  - 仅存在于已安装配置中
+  ~ 两边都存在但值不同
+
+使用 --only 只比较 env 或 hooks 部分。`,
+		Example: `  claude-config config diff
+  claude-config config diff --only env
+  claude-config config diff --only hooks`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return showConfigDiff(only)
+		},
+	}
+
+	diffCmd.Flags().StringVar(&only, "only", install.DiffScopeAll, "比较范围: env, hooks, all")
+
+	return diffCmd
+}
+
+// repairPermsLong describes the permission rules shared by config repair-perms and fix-permissions
+const repairPermsLong = `修正 install 管理的文件的权限:
+
+  0600  API 密钥文件 (.*_api_key)
+  0755  安装的脚本文件 (按扩展名或 #! 开头识别)
+  0644  安装的其他文件 (已收紧的权限如 0600 保持不变)
+
+只处理安装清单 (.install_manifest.json) 记录的文件及 hooks/ 等组件目录。
+组件目录中用户自己的脚本只补上执行位，其他文件和 .credentials.json 等
+未由 install 写入的文件不受影响。
+
+适用于从不保留权限的备份恢复、跨机器复制或 git checkout 之后。`
+
+// createConfigRepairPermsCmd creates the config repair-perms command
+func createConfigRepairPermsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "repair-perms",
+		Short: "修复 install 管理的文件的权限",
+		Long:  repairPermsLong,
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return repairPermissions(os.Stdout)
+		},
+	}
+}
+
+// createFixPermissionsCmd creates the top-level fix-permissions command
+func createFixPermissionsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "fix-permissions",
+		Short:   "修复 install 管理的文件的权限",
+		Long:    repairPermsLong + "\n\n与 claude-config config repair-perms 相同。",
+		Example: `  claude-config fix-permissions`,
+		Args:    cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return repairPermissions(os.Stdout)
+		},
+	}
+}
+
+// repairPermissions fixes file modes under the claude directory and lists each change
+func repairPermissions(w io.Writer) error {
+	changes, err := newInstallManager().RepairPermissions()
+	if err != nil {
+		return fmt.Errorf("修复权限失败: %w", err)
+	}
+
+	if len(changes) == 0 {
+		fmt.Fprintln(w, "✅ 所有文件权限均正确")
+		return nil
+	}
+
+	for _, change := range changes {
+		fmt.Fprintf(w, "🔧 %s: %#o → %#o\n", change.Path, change.OldMode, change.NewMode)
+	}
+	fmt.Fprintf(w, "\n✅ 已修复 %d 个文件的权限\n", len(changes))
+	return nil
+}
+
+// createConfigPinCmd creates the config pin command
+func createConfigPinCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "pin [KEY]",
+		Short: "固定环境变量，防止合并时被覆盖",
+		Long: `固定 settings.json 中的环境变量。install 等合并操作会像对待代理配置一样
+保留已固定变量的现有值，不会被内置模板覆盖。
+
+不带参数时列出所有已固定的环境变量。固定列表保存在 ~/.claude/.pinned_env。`,
+		Example: `  claude-config config pin ANTHROPIC_BASE_URL
+  claude-config config pin`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return listPinnedEnv()
+			}
+
+			if err := config.PinEnv(claudeDir, args[0]); err != nil {
+				return fmt.Errorf("固定环境变量失败: %w", err)
+			}
+			fmt.Printf("📌 已固定 %s\n", args[0])
+			return nil
+		},
+	}
+}
+
+// createConfigUnpinCmd creates the config unpin command
+func createConfigUnpinCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "unpin <KEY>",
+		Short:   "取消固定环境变量",
+		Example: `  claude-config config unpin ANTHROPIC_BASE_URL`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			removed, err := config.UnpinEnv(claudeDir, args[0])
+			if err != nil {
+				return fmt.Errorf("取消固定失败: %w", err)
+			}
+			if !removed {
+				fmt.Printf("ℹ️  %s 未被固定\n", args[0])
+				return nil
+			}
+			fmt.Printf("✅ 已取消固定 %s\n", args[0])
+			return nil
+		},
+	}
+}
+
+// envKeyOwnerLabels describes each env key owner for config keys
+var envKeyOwnerLabels = map[string]string{
+	config.EnvKeyPinned:   "托管 - 已固定，合并时保留现有值",
+	config.EnvKeyProxy:    "托管 - 代理 (proxy on/off)，合并时保留现有值",
+	config.EnvKeyProvider: "托管 - AI提供商 (ai on/off)",
+	config.EnvKeyNotify:   "托管 - 通知 (notify on)",
+	config.EnvKeyUser:     "用户设置",
+}
+
+// createConfigKeysCmd creates the config keys command
+func createConfigKeysCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "keys",
+		Short: "列出环境变量由工具托管还是用户设置",
+		Long: `列出 settings.json 中的每个环境变量，并标明它由 claude-config 托管还是由用户设置。
+
+托管的变量包括 ANTHROPIC_*、代理变量 (http_proxy、https_proxy 等)、NTFY_TOPIC
+以及通过 config pin 固定的变量，其他变量均视为用户设置。`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return showEnvKeys(context.Background(), os.Stdout)
+		},
+	}
+}
+
+// showEnvKeys prints every settings.json env key with its owner
+func showEnvKeys(ctx context.Context, w io.Writer) error {
+	settings, err := configMgr.Load(ctx)
+	if err != nil {
+		return fmt.Errorf("读取配置失败: %w", err)
+	}
+
+	pinned, err := config.LoadPinnedEnv(claudeDir)
+	if err != nil {
+		return fmt.Errorf("读取固定环境变量失败: %w", err)
+	}
+
+	if len(settings.Env) == 0 {
+		fmt.Fprintln(w, "settings.json 中没有环境变量")
+		return nil
+	}
+
+	infos := config.ClassifyEnv(settings.Env, pinned)
+	width := 0
+	for _, info := range infos {
+		if len(info.Key) > width {
+			width = len(info.Key)
+		}
+	}
+
+	managed := 0
+	fmt.Fprintln(w, "settings.json 环境变量:")
+	for _, info := range infos {
+		icon := "👤"
+		if info.Managed() {
+			icon = "🔒"
+			managed++
+		}
+		fmt.Fprintf(w, "  %s %-*s  %s\n", icon, width, info.Key, envKeyOwnerLabels[info.Owner])
+	}
+
+	fmt.Fprintf(w, "\n📊 共 %d 个，托管 %d 个，用户设置 %d 个\n", len(infos), managed, len(infos)-managed)
+	return nil
+}
+
+// createConfigMigrateCmd creates config migrate, an alias of migrate
+func createConfigMigrateCmd() *cobra.Command {
+	cmd := createMigrateCmd()
+	cmd.Short = "将settings.json升级到最新的结构版本 (同 claude-config migrate)"
+	return cmd
+}
+
+// createConfigEditCmd creates the config edit command
+func createConfigEditCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "edit",
+		Short: "在编辑器中修改settings.json",
+		Long: `使用 $EDITOR (未设置时使用 vi) 编辑 settings.json 的临时副本。
+
+编辑器退出后先检查 JSON 能否解析，通过后才替换 settings.json；
+解析失败时保留原文件，报告出错的行号和列号，修改内容保留在临时文件中。`,
+		Example: `  claude-config config edit
+  EDITOR="code --wait" claude-config config edit`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return editSettings(os.Stdout)
+		},
+	}
+}
+
+// runEditor opens path in the editor and waits for it to exit, replaceable in tests
+var runEditor = func(editor []string, path string) error {
+	cmd := exec.Command(editor[0], append(editor[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// editorCommand returns $EDITOR split into the program and its arguments, or vi
+func editorCommand() []string {
+	if editor := strings.Fields(os.Getenv("EDITOR")); len(editor) > 0 {
+		return editor
+	}
+	return []string{"vi"}
+}
+
+// editSettings edits a temporary copy of settings.json and replaces the real
+// file only when the edited copy parses
+func editSettings(w io.Writer) error {
+	settingsPath := filepath.Join(claudeDir, "settings.json")
+
+	original, err := os.ReadFile(settingsPath)
+	mode := os.FileMode(0644)
+	switch {
+	case os.IsNotExist(err):
+		original = []byte("{}\n")
+	case err != nil:
+		return fmt.Errorf("读取配置失败: %w", err)
+	default:
+		if info, err := os.Stat(settingsPath); err == nil {
+			mode = info.Mode().Perm()
+		}
+	}
+
+	// 临时文件权限为 0600，settings.json 中可能包含 API 密钥
+	tempFile, err := os.CreateTemp("", "claude-settings-*.json")
+	if err != nil {
+		return fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	tempPath := tempFile.Name()
+	_, err = tempFile.Write(original)
+	if closeErr := tempFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("写入临时文件失败: %w", err)
+	}
+
+	if err := runEditor(editorCommand(), tempPath); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("编辑器异常退出: %w", err)
+	}
+
+	edited, err := os.ReadFile(tempPath)
+	if err != nil {
+		return fmt.Errorf("读取临时文件失败: %w", err)
+	}
+
+	if err := config.ValidateSettings(edited); err != nil {
+		// 保留临时文件，避免丢失修改
+		fmt.Fprintf(w, "📝 修改已保存在 %s\n", tempPath)
+		return fmt.Errorf("settings.json 格式错误，未做修改: %w", err)
+	}
+	os.Remove(tempPath)
+
+	if string(edited) == string(original) {
+		fmt.Fprintln(w, "ℹ️  settings.json 未修改")
+		return nil
+	}
+
+	if err := os.MkdirAll(claudeDir, 0755); err != nil {
+		return fmt.Errorf("创建配置目录失败: %w", err)
+	}
+	if err := fsutil.AtomicWriteFile(settingsPath, edited, mode); err != nil {
+		return fmt.Errorf("保存配置失败: %w", err)
+	}
+
+	fmt.Fprintln(w, "✅ settings.json 已更新")
+	return nil
+}
+
+// listPinnedEnv prints the pinned env keys
+func listPinnedEnv() error {
+	keys, err := config.LoadPinnedEnv(claudeDir)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		fmt.Println("没有固定的环境变量")
+		return nil
+	}
+
+	fmt.Println("📌 已固定的环境变量:")
+	for _, key := range keys {
+		fmt.Printf("  %s\n", key)
+	}
+	return nil
+}
+
+// showConfigDiff prints the differences between installed and embedded settings
+func showConfigDiff(scope string) error {
+	entries, err := newInstallManager().DiffSettings(scope)
+	if err != nil {
+		return fmt.Errorf("比较配置失败: %w", err)
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("✅ settings.json 与内置模板一致")
+		return nil
+	}
+
+	for _, entry := range entries {
+		switch entry.Kind {
+		case install.DiffAdded:
+			fmt.Printf("+ %s: %s\n", entry.Path, formatDiffValue(entry.Embedded))
+		case install.DiffRemoved:
+			fmt.Printf("- %s: %s\n", entry.Path, formatDiffValue(entry.Installed))
+		case install.DiffChanged:
+			fmt.Printf("~ %s: %s → %s\n", entry.Path, formatDiffValue(entry.Installed), formatDiffValue(entry.Embedded))
+		}
+	}
+
+	fmt.Printf("\n📊 共 %d 处差异\n", len(entries))
+	return nil
+}
+
+// formatDiffValue renders a diff value as compact JSON
+func formatDiffValue(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// useEditor replaces the editor with edit for the rest of the test
+func useEditor(t *testing.T, edit func(path string) error) {
+	t.Helper()
+
+	origRunEditor := runEditor
+	runEditor = func(_ []string, path string) error { return edit(path) }
+	t.Cleanup(func() { runEditor = origRunEditor })
+}
+
+func TestEditSettings(t *testing.T) {
+	dir := useTempManagers(t)
+	settingsPath := filepath.Join(dir, "settings.json")
+	require.NoError(t, os.WriteFile(settingsPath, []byte(`{"model": "opus"}`), 0600))
+
+	edited := "{\n  \"model\": \"sonnet\"\n}\n"
+	useEditor(t, func(path string) error {
+		return os.WriteFile(path, []byte(edited), 0600)
+	})
+
+	var out bytes.Buffer
+	require.NoError(t, editSettings(&out))
+	assert.Contains(t, out.String(), "已更新")
+
+	data, err := os.ReadFile(settingsPath)
+	require.NoError(t, err)
+	assert.Equal(t, edited, string(data))
+	info, err := os.Stat(settingsPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestEditSettings_InvalidJSONKeepsOriginal(t *testing.T) {
+	dir := useTempManagers(t)
+	settingsPath := filepath.Join(dir, "settings.json")
+	original := `{"model": "opus"}`
+	require.NoError(t, os.WriteFile(settingsPath, []byte(original), 0644))
+
+	var tempPath string
+	useEditor(t, func(path string) error {
+		tempPath = path
+		return os.WriteFile(path, []byte("{\n  \"model\": \"sonnet\",\n}\n"), 0600)
+	})
+
+	var out bytes.Buffer
+	err := editSettings(&out)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "line 3, column 1")
+	assert.Contains(t, out.String(), tempPath)
+	defer os.Remove(tempPath)
+
+	data, err := os.ReadFile(settingsPath)
+	require.NoError(t, err)
+	assert.Equal(t, original, string(data))
+	assert.FileExists(t, tempPath)
+}
+
+func TestEditSettings_Unchanged(t *testing.T) {
+	useTempManagers(t)
+	useEditor(t, func(string) error { return nil })
+
+	var out bytes.Buffer
+	require.NoError(t, editSettings(&out))
+	assert.Contains(t, out.String(), "未修改")
+	assert.NoFileExists(t, filepath.Join(claudeDir, "settings.json"))
+}
+
+func TestEditorCommand(t *testing.T) {
+	t.Setenv("EDITOR", "")
+	assert.Equal(t, []string{"vi"}, editorCommand())
+
+	t.Setenv("EDITOR", "code --wait")
+	assert.Equal(t, []string{"code", "--wait"}, editorCommand())
+}
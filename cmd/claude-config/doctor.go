@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ooneko/claude-config/internal/doctor"
+)
+
+// createDoctorCmd creates the doctor command
+func createDoctorCmd() *cobra.Command {
+	var timeout time.Duration
+	var reportPath string
+
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "检查配置中的常见问题",
+		Long: `检查 ~/.claude 中的配置并给出修复建议：
+  - settings.json 能否解析
+  - settings.json 引用的 hook 脚本是否存在且可执行
+  - API 密钥文件的权限是否为 0600
+  - 当前 AI 提供商的接入点能否连通（经由已配置的代理）
+
+只读取配置，不做任何修改。存在错误时以非零状态退出。
+
+使用 --report 将检查结果和当前环境摘要写入文件（.json 后缀输出 JSON，否则为文本），
+API 密钥和代理密码会被替换为 [REDACTED]，可直接附在 GitHub issue 中。`,
+		Example: `  claude-config doctor
+  claude-config doctor --timeout 5s
+  claude-config doctor --report doctor.txt
+  claude-config doctor --report doctor.json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx := cmd.Context()
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			diagnostics, err := doctor.NewManager(claudeDir).Doctor(ctx)
+			if err != nil {
+				return fmt.Errorf("检查配置失败: %w", err)
+			}
+
+			failed := printDiagnostics(os.Stdout, diagnostics)
+
+			if reportPath != "" {
+				if err := writeDoctorReport(context.Background(), reportPath, diagnostics); err != nil {
+					return err
+				}
+				fmt.Printf("📝 诊断报告已写入：%s\n", reportPath)
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("发现 %d 个问题", failed)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().DurationVar(&timeout, "timeout", 10*time.Second, "连通性检查的超时时间")
+	cmd.Flags().StringVar(&reportPath, "report", "", "将诊断报告写入文件 (已隐去密钥)")
+
+	return cmd
+}
+
+// printDiagnostics prints each diagnostic with its suggested fix and returns the number of errors
+func printDiagnostics(w io.Writer, diagnostics []doctor.Diagnostic) int {
+	passed, failed := 0, 0
+	for _, diagnostic := range diagnostics {
+		switch diagnostic.Severity {
+		case doctor.SeverityOK:
+			passed++
+			fmt.Fprintf(w, "✅ %s: %s\n", diagnostic.Check, diagnostic.Message)
+		case doctor.SeverityWarning:
+			fmt.Fprintf(w, "⚠️  %s: %s\n", diagnostic.Check, diagnostic.Message)
+		default:
+			failed++
+			fmt.Fprintf(w, "❌ %s: %s\n", diagnostic.Check, diagnostic.Message)
+		}
+		if diagnostic.Fix != "" {
+			fmt.Fprintf(w, "   💡 %s\n", diagnostic.Fix)
+		}
+	}
+
+	fmt.Fprintf(w, "\n📊 %d/%d 项通过\n", passed, len(diagnostics))
+	return failed
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ooneko/claude-config/internal/doctor"
+)
+
+func TestPrintDiagnostics(t *testing.T) {
+	var buf bytes.Buffer
+	failed := printDiagnostics(&buf, []doctor.Diagnostic{
+		{Check: "settings.json", Severity: doctor.SeverityOK, Message: "格式正确"},
+		{Check: "hook脚本", Severity: doctor.SeverityError, Message: "smart-lint.sh 不存在", Fix: "claude-config install --hooks --force"},
+		{Check: "AI提供商连通性", Severity: doctor.SeverityWarning, Message: "跳过"},
+	})
+
+	assert.Equal(t, 1, failed)
+	output := buf.String()
+	assert.Contains(t, output, "✅ settings.json: 格式正确")
+	assert.Contains(t, output, "❌ hook脚本: smart-lint.sh 不存在")
+	assert.Contains(t, output, "💡 claude-config install --hooks --force")
+	assert.Contains(t, output, "1/3 项通过")
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ooneko/claude-config/internal/claude"
+	"github.com/ooneko/claude-config/internal/hooks"
+)
+
+// createHooksCmd creates the hooks command and subcommands
+func createHooksCmd() *cobra.Command {
+	hooksCmd := &cobra.Command{
+		Use:   "hooks <command>",
+		Short: "Hook规则管理",
+		Long:  "查看和编辑 settings.json 中的 hooks 规则",
+		Run: func(cmd *cobra.Command, _ []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	hooksCmd.AddCommand(createHooksListCmd())
+	hooksCmd.AddCommand(createHooksAddCmd())
+	hooksCmd.AddCommand(createHooksRemoveCmd())
+	hooksCmd.AddCommand(createHooksMatcherCmd())
+
+	return hooksCmd
+}
+
+// createHooksListCmd creates the hooks list command
+func createHooksListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "列出已配置的hooks",
+		Long:  "按事件列出 settings.json 中配置的所有 hook 规则，包括 matcher、命令和超时时间",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			settings, err := configMgr.Load(context.Background())
+			if err != nil {
+				return fmt.Errorf("读取配置失败: %w", err)
+			}
+
+			printHooks(os.Stdout, settings.Hooks)
+			return nil
+		},
+	}
+}
+
+// printHooks prints every hook command as a table sorted by event, in rule order within an event
+func printHooks(w io.Writer, hooksConfig *claude.HooksConfig) {
+	events := hooksConfig.Events()
+	if len(events) == 0 {
+		fmt.Fprintln(w, "未配置任何hooks")
+		return
+	}
+
+	names := make([]string, 0, len(events))
+	for name := range events {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "事件\tMATCHER\t命令\t超时")
+	for _, name := range names {
+		for _, rule := range events[name] {
+			matcher := rule.Matcher
+			if matcher == "" {
+				matcher = "*"
+			}
+			for _, hook := range rule.Hooks {
+				timeout := "-"
+				if hook.Timeout > 0 {
+					timeout = fmt.Sprintf("%ds", hook.Timeout)
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", name, matcher, hook.Command, timeout)
+			}
+		}
+	}
+	_ = tw.Flush()
+}
+
+// createHooksAddCmd creates the hooks add command
+func createHooksAddCmd() *cobra.Command {
+	var event, matcher, command string
+	var timeout int
+
+	addCmd := &cobra.Command{
+		Use:   "add",
+		Short: "添加hook命令",
+		Long: `向指定事件中 matcher 相同的规则添加一条 hook 命令，没有该规则时新建规则。
+
+matcher 中工具名的顺序不影响匹配；规则中已有相同命令时不做任何修改。`,
+		Example: `  claude-config hooks add --event PostToolUse --matcher "Write|Edit" --command "~/foo.sh" --timeout 60
+  claude-config hooks add --event Stop --command "~/notify.sh"`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			ctx := context.Background()
+			settings, err := configMgr.Load(ctx)
+			if err != nil {
+				return fmt.Errorf("读取配置失败: %w", err)
+			}
+			if settings.Hooks == nil {
+				settings.Hooks = &claude.HooksConfig{}
+			}
+
+			hook := &claude.HookItem{Type: "command", Command: command, Timeout: timeout}
+			added, err := hooks.AddHook(settings.Hooks, event, matcher, hook)
+			if err != nil {
+				return fmt.Errorf("添加hook失败: %w", err)
+			}
+			if !added {
+				fmt.Printf("ℹ️  %s 规则 %q 中已有命令 %s，未做修改\n", event, matcher, command)
+				return nil
+			}
+
+			if err := configMgr.Save(ctx, settings); err != nil {
+				return fmt.Errorf("保存配置失败: %w", err)
+			}
+			fmt.Printf("✅ 已添加 %s hook：%s\n", event, command)
+			return nil
+		},
+	}
+
+	addCmd.Flags().StringVar(&event, "event", "", "hook事件: "+strings.Join(claude.HookEventNames(), ", "))
+	addCmd.Flags().StringVar(&matcher, "matcher", "", "规则的matcher，如 \"Write|Edit\" (默认为空，匹配所有)")
+	addCmd.Flags().StringVar(&command, "command", "", "要执行的命令")
+	addCmd.Flags().IntVar(&timeout, "timeout", 0, "超时时间（秒），0 表示不设置")
+	_ = addCmd.MarkFlagRequired("event")
+	_ = addCmd.MarkFlagRequired("command")
+
+	return addCmd
+}
+
+// createHooksRemoveCmd creates the hooks remove command
+func createHooksRemoveCmd() *cobra.Command {
+	var event, command string
+
+	removeCmd := &cobra.Command{
+		Use:     "remove",
+		Short:   "移除hook命令",
+		Long:    `从指定事件的所有规则中移除一条 hook 命令，移除后没有命令的规则会被一并删除。`,
+		Example: `  claude-config hooks remove --event PostToolUse --command "~/foo.sh"`,
+		Args:    cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			ctx := context.Background()
+			settings, err := configMgr.Load(ctx)
+			if err != nil {
+				return fmt.Errorf("读取配置失败: %w", err)
+			}
+
+			removed, err := hooks.RemoveHook(settings.Hooks, event, command)
+			if err != nil {
+				return fmt.Errorf("移除hook失败: %w", err)
+			}
+			if removed == 0 {
+				fmt.Printf("ℹ️  %s 中没有命令 %s，未做修改\n", event, command)
+				return nil
+			}
+
+			if len(settings.Hooks.Events()) == 0 {
+				settings.Hooks = nil
+			}
+			if err := configMgr.Save(ctx, settings); err != nil {
+				return fmt.Errorf("保存配置失败: %w", err)
+			}
+			fmt.Printf("✅ 已从 %s 移除 %d 条 hook：%s\n", event, removed, command)
+			return nil
+		},
+	}
+
+	removeCmd.Flags().StringVar(&event, "event", "", "hook事件: "+strings.Join(claude.HookEventNames(), ", "))
+	removeCmd.Flags().StringVar(&command, "command", "", "要移除的命令")
+	_ = removeCmd.MarkFlagRequired("event")
+	_ = removeCmd.MarkFlagRequired("command")
+
+	return removeCmd
+}
+
+// createHooksMatcherCmd creates the hooks matcher command
+func createHooksMatcherCmd() *cobra.Command {
+	var event, matcher, removeToken string
+
+	matcherCmd := &cobra.Command{
+		Use:   "matcher",
+		Short: "编辑hook规则的matcher",
+		Long: `从指定规则的 matcher 中移除一个工具名，规则中的 hooks 保持不变。
+
+规则通过 --event 和 --matcher 定位，matcher 中工具名的顺序不影响匹配。
+如果 matcher 中不包含该工具名，则不做任何修改。`,
+		Example: `  claude-config hooks matcher --event PostToolUse --matcher "Write|Edit|MultiEdit" --remove-token MultiEdit`,
+		Args:    cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			newMatcher, changed, err := hooks.NewManager(claudeDir).RemoveMatcherToken(context.Background(), event, matcher, removeToken)
+			if err != nil {
+				return fmt.Errorf("修改matcher失败: %w", err)
+			}
+
+			if !changed {
+				fmt.Printf("ℹ️  matcher %q 中不包含 %s，未做修改\n", newMatcher, removeToken)
+				return nil
+			}
+
+			fmt.Printf("✅ %s matcher 已更新：%s → %s\n", event, matcher, newMatcher)
+			return nil
+		},
+	}
+
+	matcherCmd.Flags().StringVar(&event, "event", hooks.EventPostToolUse, "hook事件: "+strings.Join(claude.HookEventNames(), ", "))
+	matcherCmd.Flags().StringVar(&matcher, "matcher", "", "要编辑的规则的matcher")
+	matcherCmd.Flags().StringVar(&removeToken, "remove-token", "", "要从matcher中移除的工具名")
+	_ = matcherCmd.MarkFlagRequired("matcher")
+	_ = matcherCmd.MarkFlagRequired("remove-token")
+
+	return matcherCmd
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ooneko/claude-config/internal/claude"
+)
+
+func TestPrintHooks(t *testing.T) {
+	var buf bytes.Buffer
+	printHooks(&buf, nil)
+	assert.Equal(t, "未配置任何hooks\n", buf.String())
+
+	buf.Reset()
+	printHooks(&buf, &claude.HooksConfig{
+		Stop: []*claude.HookRule{
+			{Matcher: "", Hooks: []*claude.HookItem{{Type: "command", Command: "~/.claude/hooks/ntfy-notifier.sh stop"}}},
+		},
+		PostToolUse: []*claude.HookRule{
+			{Matcher: "Write|Edit", Hooks: []*claude.HookItem{
+				{Type: "command", Command: "~/.claude/hooks/smart-lint.sh"},
+				{Type: "command", Command: "~/.claude/hooks/smart-test.sh", Timeout: 60},
+			}},
+		},
+	})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 4)
+	assert.Equal(t, []string{"事件", "MATCHER", "命令", "超时"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"PostToolUse", "Write|Edit", "~/.claude/hooks/smart-lint.sh", "-"}, strings.Fields(lines[1]))
+	assert.Equal(t, []string{"PostToolUse", "Write|Edit", "~/.claude/hooks/smart-test.sh", "60s"}, strings.Fields(lines[2]))
+	assert.Equal(t, []string{"Stop", "*", "~/.claude/hooks/ntfy-notifier.sh", "stop", "-"}, strings.Fields(lines[3]))
+}
+
+func TestHooksAddRemove(t *testing.T) {
+	useTempManagers(t)
+
+	run := func(cmd *cobra.Command, args ...string) {
+		t.Helper()
+		cmd.SetArgs(args)
+		cmd.SetOut(&bytes.Buffer{})
+		require.NoError(t, cmd.Execute())
+	}
+	load := func() *claude.Settings {
+		t.Helper()
+		settings, err := configMgr.Load(context.Background())
+		require.NoError(t, err)
+		return settings
+	}
+
+	run(createHooksAddCmd(), "--event", "PostToolUse", "--matcher", "Write|Edit", "--command", "~/foo.sh", "--timeout", "60")
+	run(createHooksAddCmd(), "--event", "PostToolUse", "--matcher", "Edit|Write", "--command", "~/foo.sh")
+
+	settings := load()
+	require.NotNil(t, settings.Hooks)
+	require.Len(t, settings.Hooks.PostToolUse, 1)
+	require.Len(t, settings.Hooks.PostToolUse[0].Hooks, 1)
+	assert.Equal(t, &claude.HookItem{Type: "command", Command: "~/foo.sh", Timeout: 60}, settings.Hooks.PostToolUse[0].Hooks[0])
+
+	run(createHooksRemoveCmd(), "--event", "PostToolUse", "--command", "~/foo.sh")
+	assert.Nil(t, load().Hooks)
+
+	cmd := createHooksAddCmd()
+	cmd.SetArgs([]string{"--event", "Unknown", "--command", "~/foo.sh"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	assert.ErrorContains(t, cmd.Execute(), "unsupported hook event")
+}
@@ -2,17 +2,57 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 
 	"github.com/ooneko/claude-config/internal/install"
+	"github.com/ooneko/claude-config/internal/logging"
 )
 
+// printableTemplates lists the embedded files that install --print can emit
+var printableTemplates = []string{"settings.json", "CLAUDE.md.template"}
+
+// printTemplate writes an embedded template to w without installing anything
+func printTemplate(w io.Writer, name string) error {
+	supported := false
+	for _, template := range printableTemplates {
+		if template == name {
+			supported = true
+			break
+		}
+	}
+	if !supported {
+		return fmt.Errorf("不支持输出的模板: %s (可选: %s)", name, strings.Join(printableTemplates, ", "))
+	}
+
+	data, err := install.NewResourceManager().ReadFile(name)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+	return err
+}
+
 // runInstall executes the install command
 func runInstall(cmd *cobra.Command) error {
 	ctx := context.Background()
 
+	if printFlag, _ := cmd.Flags().GetString("print"); printFlag != "" {
+		return printTemplate(os.Stdout, printFlag)
+	}
+
+	if fileFlag, _ := cmd.Flags().GetString("file"); fileFlag != "" {
+		forceFlag, _ := cmd.Flags().GetBool("force")
+		return installSingleFile(ctx, fileFlag, forceFlag)
+	}
+
 	// 解析命令行参数
 	options := install.Options{}
 
@@ -26,6 +66,9 @@ func runInstall(cmd *cobra.Command) error {
 	statuslineFlag, _ := cmd.Flags().GetBool("statusline")
 	forceFlag, _ := cmd.Flags().GetBool("force")
 	deleteFlag, _ := cmd.Flags().GetBool("delete")
+	dryRunFlag, _ := cmd.Flags().GetBool("dry-run")
+	strategyFlag, _ := cmd.Flags().GetString("merge-strategy")
+	parallelFlag, _ := cmd.Flags().GetInt("parallel")
 
 	// 如果没有指定任何选项，默认安装所有
 	if !allFlag && !agentsFlag && !commandsFlag && !hooksFlag &&
@@ -42,9 +85,12 @@ func runInstall(cmd *cobra.Command) error {
 		options.Statusline = statuslineFlag
 	}
 
-	// 设置 Force 和 Delete 选项
+	// 设置 Force、Delete、DryRun、并发数和合并策略选项
 	options.Force = forceFlag
 	options.Delete = deleteFlag
+	options.DryRun = dryRunFlag
+	options.Parallel = parallelFlag
+	options.MergeStrategy = install.MergeStrategy(strategyFlag)
 
 	// 验证选项
 	if err := options.Validate(); err != nil {
@@ -52,40 +98,259 @@ func runInstall(cmd *cobra.Command) error {
 	}
 
 	// 创建安装管理器并执行安装
-	installMgr := install.NewManager(claudeDir)
+	installMgr := newInstallManager()
+
+	if checkFlag, _ := cmd.Flags().GetBool("check"); checkFlag {
+		return showOutdatedFiles(os.Stdout, installMgr, options.GetSelectedComponents())
+	}
+
+	if diffFlag, _ := cmd.Flags().GetBool("diff"); diffFlag {
+		if !settingsFlag {
+			return fmt.Errorf("--diff 需要与 --settings 一起使用")
+		}
+		return showSettingsDiff(os.Stdout, installMgr, options.MergeStrategy)
+	}
 
-	fmt.Println("🚀 开始安装Claude配置文件...")
-	if err := installMgr.Install(ctx, options); err != nil {
+	if jsonFlag, _ := cmd.Flags().GetBool("json"); jsonFlag {
+		// 合并settings.json的提示信息会混入JSON输出
+		installMgr.SetLogger(logging.Nop())
+		result, err := installMgr.Install(ctx, options)
+		if err != nil {
+			return fmt.Errorf("安装失败: %w", err)
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+
+	if options.DryRun {
+		fmt.Println("🔍 Dry-run 模式: 预览安装将进行的更改，不会写入任何文件")
+	} else {
+		fmt.Println("🚀 开始安装Claude配置文件...")
+	}
+	result, err := installMgr.Install(ctx, options)
+	printInstallResult(os.Stdout, result)
+	if err != nil {
+		var installErr *install.InstallError
+		if errors.As(err, &installErr) {
+			fmt.Fprintf(os.Stderr, "❌ 组件 %s 安装失败\n", installErr.Component)
+			fmt.Fprintf(os.Stderr, "   嵌入路径：%s\n", installErr.EmbedPath)
+			fmt.Fprintf(os.Stderr, "   原因：%v\n", installErr.Err)
+		}
 		return fmt.Errorf("安装失败: %w", err)
 	}
 
+	if options.DryRun {
+		fmt.Println("\n💡 提示: 去掉 --dry-run 参数实际执行安装")
+		return nil
+	}
+
 	fmt.Println("✅ 安装完成！")
 	fmt.Printf("配置目录：%s\n", claudeDir)
 
 	return nil
 }
 
+// printInstallResult prints what install did, or would do in dry-run mode, for each component
+func printInstallResult(w io.Writer, result *install.InstallResult) {
+	for _, component := range result.Components {
+		printComponentResult(w, component, result.DryRun)
+	}
+}
+
+// printComponentResult prints the result of installing one component
+func printComponentResult(w io.Writer, result *install.ComponentResult, dryRun bool) {
+	will := ""
+	if dryRun {
+		will = "将"
+	}
+
+	switch {
+	case result.Skipped && result.Component == "statusline.js":
+		fmt.Fprintf(w, "⚠️  文件 statusline.js 已存在，%s跳过安装（使用 --force 强制覆盖）\n", will)
+	case result.Skipped:
+		fmt.Fprintf(w, "⚠️  目录 %s 已存在，%s跳过安装（使用 --force 强制覆盖）\n", result.Component, will)
+	case dryRun && result.Component == "settings.json":
+		if len(result.Merged) == 0 {
+			fmt.Fprintln(w, "settings.json配置无变化，跳过")
+			break
+		}
+		fmt.Fprintln(w, "🔄 settings.json 将合并以下配置项:")
+		for _, entry := range result.Merged {
+			switch entry.Kind {
+			case install.DiffAdded:
+				fmt.Fprintf(w, "   + %s\n", entry.Path)
+			case install.DiffRemoved:
+				fmt.Fprintf(w, "   - %s\n", entry.Path)
+			case install.DiffChanged:
+				fmt.Fprintf(w, "   ~ %s\n", entry.Path)
+			}
+		}
+	case dryRun:
+		for _, file := range result.Created {
+			fmt.Fprintf(w, "➕ 新建: %s\n", file)
+		}
+		for _, file := range result.Updated {
+			fmt.Fprintf(w, "📝 覆盖: %s\n", file)
+		}
+	case len(result.Unchanged) > 0 && isDirectoryComponent(result.Component):
+		fmt.Fprintf(w, "📁 %s: 写入 %d 个文件，跳过 %d 个未变化的文件\n", result.Component, result.Written(), len(result.Unchanged))
+	}
+
+	if len(result.Orphaned) > 0 {
+		fmt.Fprintf(w, "\n🔍 Dry-run 模式: 以下文件将被删除 (使用 --force 实际执行删除):\n\n")
+		for _, file := range result.Orphaned {
+			fmt.Fprintf(w, "🗑️  %s\n", file)
+		}
+		fmt.Fprintf(w, "\n📊 总计: %d 个文件将被删除\n", len(result.Orphaned))
+		fmt.Fprintln(w, "\n💡 提示: 使用 --force 参数实际执行删除")
+	}
+	if len(result.Deleted) > 0 {
+		fmt.Fprintf(w, "\n⚠️  警告: 即将删除以下文件\n\n")
+		for _, file := range result.Deleted {
+			fmt.Fprintf(w, "🗑️  已删除: %s\n", file)
+		}
+		fmt.Fprintf(w, "\n✅ 成功删除 %d 个孤立文件\n", len(result.Deleted))
+	}
+}
+
+// isDirectoryComponent reports whether an install component is a directory of files
+func isDirectoryComponent(component string) bool {
+	switch component {
+	case "agents", "commands", "hooks", "output-styles":
+		return true
+	default:
+		return false
+	}
+}
+
+// showOutdatedFiles lists installed files that differ from the embedded version
+func showOutdatedFiles(w io.Writer, installMgr *install.Manager, components []string) error {
+	var outdated []string
+	for _, component := range components {
+		files, err := installMgr.Outdated(component)
+		if err != nil {
+			return fmt.Errorf("检查组件%s失败: %w", component, err)
+		}
+		outdated = append(outdated, files...)
+	}
+
+	if len(outdated) == 0 {
+		fmt.Fprintln(w, "✅ 已安装的文件均与内置版本一致")
+		return nil
+	}
+
+	fmt.Fprintln(w, "🔍 以下已安装的文件与内置版本不同:")
+	for _, file := range outdated {
+		fmt.Fprintf(w, "   %s\n", file)
+	}
+	fmt.Fprintf(w, "\n📊 共 %d 个文件，使用 --force 重新安装以更新\n", len(outdated))
+	return nil
+}
+
+// showSettingsDiff prints how installing settings.json would change the installed file
+func showSettingsDiff(w io.Writer, installMgr *install.Manager, strategy install.MergeStrategy) error {
+	added, changed, preserved, err := installMgr.PreviewSettingsMerge(strategy)
+	if err != nil {
+		return fmt.Errorf("预览settings.json合并失败: %w", err)
+	}
+
+	if len(added) == 0 && len(changed) == 0 && len(preserved) == 0 {
+		fmt.Fprintln(w, "✅ settings.json 合并后无变化")
+		return nil
+	}
+
+	fmt.Fprintln(w, "📋 settings.json 合并预览:")
+	for _, group := range []struct {
+		title string
+		mark  string
+		keys  []string
+	}{
+		{"新增的配置项", "+", added},
+		{"将改变的配置项", "~", changed},
+		{"保留您的配置 (代理或固定的环境变量)", "=", preserved},
+	} {
+		if len(group.keys) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s:\n", group.title)
+		for _, key := range group.keys {
+			fmt.Fprintf(w, "   %s %s\n", group.mark, key)
+		}
+	}
+
+	fmt.Fprintln(w, "\n💡 提示: 去掉 --diff 参数实际执行安装")
+	return nil
+}
+
+// newInstallManager creates an install manager for claudeDir that reports progress through logger
+func newInstallManager() *install.Manager {
+	manager := install.NewManager(claudeDir)
+	manager.SetLogger(logger)
+	return manager
+}
+
+// installSingleFile installs one embedded file from a directory component
+func installSingleFile(ctx context.Context, relPath string, force bool) error {
+	if err := newInstallManager().InstallFile(ctx, relPath, force); err != nil {
+		return fmt.Errorf("安装文件失败: %w", err)
+	}
+	return nil
+}
+
 // createInstallCmd creates the install command
 func createInstallCmd() *cobra.Command {
 	installCmd := &cobra.Command{
 		Use:   "install",
 		Short: "安装配置文件",
-		Long:  `安装Claude Code配置文件到 ~/.claude 目录`,
+		Long: `安装Claude Code配置文件到 ~/.claude 目录
+
+--all（或不指定任何组件）不包含 hooks；check on 和 notify on 会安装它们引用的 hook 脚本，
+需要完整的 hooks 目录时加 --hooks。
+
+使用 --print 将内置模板输出到标准输出而不安装，便于审阅或手动配置。
+使用 --file 只安装目录组件中的单个文件，例如 agents/code-reviewer.md。
+使用 --check 列出已安装但与内置版本不同的文件，不做任何修改。
+使用 --merge-strategy 指定 settings.json 中环境变量冲突的处理方式:
+  source  内置模板完全优先，代理配置和固定的环境变量也会被覆盖
+  target  已有的环境变量保持不变，只添加新的环境变量
+  fail    存在将被覆盖的环境变量时报错并列出冲突项，适合在CI中使用
+不指定时保留代理配置和固定的环境变量，其余以内置模板为准。
+使用 --settings --diff 按配置项预览 settings.json 的合并结果（新增、改变和保留的配置项）。
+使用 --dry-run 预览将新建、覆盖的文件和 settings.json 将合并的配置项，不写入任何文件。
+使用 --json 以JSON格式输出安装结果，便于脚本处理。
+使用 --parallel N 同时提取最多 N 个组件，settings.json 仍在其他组件完成后单独合并。`,
+		Example: `  claude-config install
+  claude-config install --settings --force
+  claude-config install --dry-run
+  claude-config install --file agents/code-reviewer.md --force
+  claude-config install --check --agents
+  claude-config install --settings --diff
+  claude-config install --settings --merge-strategy fail
+  claude-config install --print settings.json`,
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			return runInstall(cmd)
 		},
 	}
 
 	// Install command flags
-	installCmd.Flags().Bool("all", false, "安装所有配置文件")
+	installCmd.Flags().Bool("all", false, "安装除hooks外的所有配置文件")
 	installCmd.Flags().Bool("agents", false, "仅安装agents")
 	installCmd.Flags().Bool("commands", false, "仅安装commands")
-	installCmd.Flags().Bool("hooks", false, "仅安装hooks")
+	installCmd.Flags().Bool("hooks", false, "安装hooks，可与 --all 同时使用")
 	installCmd.Flags().Bool("output-styles", false, "仅安装output-styles")
 	installCmd.Flags().Bool("settings", false, "仅安装settings.json")
 	installCmd.Flags().Bool("claude", false, "仅安装CLAUDE.md")
 	installCmd.Flags().Bool("statusline", false, "仅安装statusline.js")
 	installCmd.Flags().Bool("force", false, "强制覆盖已存在的文件")
+	installCmd.Flags().Bool("diff", false, "与 --settings 一起使用，预览 settings.json 将新增、改变和保留的配置项")
+	installCmd.Flags().Bool("check", false, "列出与内置版本不同的已安装文件，不做修改")
+	installCmd.Flags().String("file", "", "只安装目录组件中的单个文件 (如 agents/code-reviewer.md)")
+	installCmd.Flags().String("print", "", "将内置模板输出到标准输出而不安装 (settings.json 或 CLAUDE.md.template)")
+	installCmd.Flags().Bool("dry-run", false, "只预览将进行的更改，不写入任何文件")
+	installCmd.Flags().Int("parallel", 0, "同时提取的组件数，适合网络文件系统 (默认依次安装)")
+	installCmd.Flags().Bool("json", false, "以JSON格式输出每个组件新建、覆盖、合并和删除的文件")
+	installCmd.Flags().String("merge-strategy", "", "settings.json 环境变量冲突的处理方式 (source, target, fail)")
 	installCmd.Flags().Bool("delete", false, "删除目标目录中不在源资源中的文件 (默认dry-run模式,与--force配合实际删除)")
 
 	return installCmd
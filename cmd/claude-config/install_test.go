@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ooneko/claude-config/internal/install"
+)
+
+func TestPrintTemplate(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, printTemplate(&out, "settings.json"))
+	require.NotEmpty(t, out.Bytes())
+	assert.True(t, json.Valid(out.Bytes()), "settings.json template should be valid JSON")
+
+	out.Reset()
+	require.NoError(t, printTemplate(&out, "CLAUDE.md.template"))
+	assert.NotEmpty(t, out.String())
+
+	out.Reset()
+	err := printTemplate(&out, "hooks")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "不支持输出的模板")
+	assert.Empty(t, out.String())
+}
+
+func TestShowOutdatedFiles(t *testing.T) {
+	dir := useTempManagers(t)
+	installMgr := install.NewManager(dir)
+	_, err := installMgr.Install(context.Background(), install.Options{Agents: true})
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, showOutdatedFiles(&out, installMgr, []string{"agents"}))
+	assert.Contains(t, out.String(), "均与内置版本一致")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "agents", "code-reviewer.md"), []byte("old"), 0644))
+	out.Reset()
+	require.NoError(t, showOutdatedFiles(&out, installMgr, []string{"agents"}))
+	assert.Contains(t, out.String(), filepath.Join("agents", "code-reviewer.md"))
+	assert.Contains(t, out.String(), "共 1 个文件")
+}
+
+func TestShowSettingsDiff(t *testing.T) {
+	dir := useTempManagers(t)
+	installMgr := install.NewManager(dir)
+
+	var out bytes.Buffer
+	require.NoError(t, showSettingsDiff(&out, installMgr, install.MergeDefault))
+	assert.Contains(t, out.String(), "新增的配置项")
+	assert.NoFileExists(t, filepath.Join(dir, "settings.json"))
+
+	_, err := installMgr.Install(context.Background(), install.Options{Settings: true})
+	require.NoError(t, err)
+	out.Reset()
+	require.NoError(t, showSettingsDiff(&out, installMgr, install.MergeDefault))
+	assert.Contains(t, out.String(), "合并后无变化")
+}
+
+func TestPrintInstallResult(t *testing.T) {
+	var out bytes.Buffer
+	printInstallResult(&out, &install.InstallResult{
+		DryRun: true,
+		Components: []*install.ComponentResult{
+			{Component: "agents", Skipped: true},
+			{Component: "commands", Created: []string{"commands/new.md"}, Updated: []string{"commands/old.md"}, Orphaned: []string{"commands/gone.md"}},
+			{Component: "settings.json", Merged: []install.DiffEntry{{Path: "env.FOO", Kind: install.DiffAdded}}},
+		},
+	})
+	report := out.String()
+	assert.Contains(t, report, "目录 agents 已存在，将跳过安装")
+	assert.Contains(t, report, "➕ 新建: commands/new.md")
+	assert.Contains(t, report, "📝 覆盖: commands/old.md")
+	assert.Contains(t, report, "🗑️  commands/gone.md")
+	assert.Contains(t, report, "   + env.FOO")
+
+	out.Reset()
+	printInstallResult(&out, &install.InstallResult{
+		Components: []*install.ComponentResult{
+			{Component: "commands", Updated: []string{"commands/old.md"}, Unchanged: []string{"commands/a.md", "commands/b.md"}, Deleted: []string{"commands/gone.md"}},
+			{Component: "statusline.js", Skipped: true},
+		},
+	})
+	report = out.String()
+	assert.Contains(t, report, "📁 commands: 写入 1 个文件，跳过 2 个未变化的文件")
+	assert.Contains(t, report, "🗑️  已删除: commands/gone.md")
+	assert.Contains(t, report, "文件 statusline.js 已存在，跳过安装")
+	assert.NotContains(t, report, "新建")
+}
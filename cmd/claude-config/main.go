@@ -2,19 +2,25 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/ooneko/claude-config/internal/aiprovider"
 	"github.com/ooneko/claude-config/internal/check"
 	"github.com/ooneko/claude-config/internal/claude"
 	"github.com/ooneko/claude-config/internal/config"
+	"github.com/ooneko/claude-config/internal/logging"
 	"github.com/ooneko/claude-config/internal/proxy"
 )
 
 var (
 	claudeDir string
 
+	// logger receives the managers' progress messages, silenced by --quiet
+	logger = logging.Default()
+
 	// Managers
 	configMgr     claude.ConfigManager
 	proxyMgr      claude.ProxyManager
@@ -22,20 +28,74 @@ var (
 	aiProviderMgr claude.AIProviderManager
 )
 
-func init() {
-	// Get default claude directory
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		claudeDir = ".claude"
-	} else {
-		claudeDir = filepath.Join(homeDir, ".claude")
-	}
+// initManagers binds claudeDir and the managers to dir. It runs after flag
+// parsing so --claude-dir and CLAUDE_CONFIG_DIR apply to every command.
+func initManagers(dir string) {
+	claudeDir = dir
 
-	// Initialize managers
 	configMgr = config.NewManager(claudeDir)
 	proxyMgr = proxy.NewManager(claudeDir)
 	checkMgr = check.NewManager(claudeDir)
+	checkMgr.SetLogger(logger)
 	aiProviderMgr = aiprovider.NewManager(claudeDir)
+
+	// Custom provider names resolve on the command line once registered.
+	// An invalid providers.json registers none; say why.
+	if err := aiprovider.RegisterCustomProviders(claudeDir); err != nil {
+		logger.Warn("⚠️  自定义提供商未加载: %v", err)
+	}
+}
+
+// newLogger returns the progress logger for the --quiet and --verbose flags.
+// --quiet wins when both are given.
+func newLogger(w io.Writer, quiet, verbose bool) logging.Logger {
+	if quiet {
+		return logging.Nop()
+	}
+	return logging.New(w, verbose)
+}
+
+// selectClaudeDir returns the directory given by --claude-dir, then
+// $CLAUDE_CONFIG_DIR, and otherwise ~/.claude. Overrides are made absolute
+// so hook commands written into settings.json don't depend on the working directory.
+func selectClaudeDir(flagDir string, w io.Writer) string {
+	dir := strings.TrimSpace(flagDir)
+	if dir == "" {
+		dir = strings.TrimSpace(os.Getenv("CLAUDE_CONFIG_DIR"))
+	}
+	if dir == "" {
+		return resolveClaudeDir(w)
+	}
+
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	return dir
+}
+
+// resolveClaudeDir returns ~/.claude. When the home directory can't be
+// determined it tries $HOME and %USERPROFILE% before falling back to a
+// directory relative to the working directory, warning on w either way.
+func resolveClaudeDir(w io.Writer) string {
+	homeDir, err := os.UserHomeDir()
+	if err == nil {
+		return filepath.Join(homeDir, ".claude")
+	}
+
+	for _, envVar := range []string{"HOME", "USERPROFILE"} {
+		if value := os.Getenv(envVar); value != "" {
+			dir := filepath.Join(value, ".claude")
+			fmt.Fprintf(w, "⚠️  无法获取用户主目录 (%v)，使用 $%s: %s\n", err, envVar, dir)
+			return dir
+		}
+	}
+
+	dir := ".claude"
+	if abs, absErr := filepath.Abs(dir); absErr == nil {
+		dir = abs
+	}
+	fmt.Fprintf(w, "⚠️  无法获取用户主目录 (%v)，且 HOME/USERPROFILE 均未设置，使用当前目录下的 %s\n", err, dir)
+	return dir
 }
 
 func main() {
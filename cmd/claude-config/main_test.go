@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ooneko/claude-config/internal/aiprovider"
+)
+
+func TestMain(m *testing.M) {
+	// Commands in tests run without the root command's PersistentPreRun
+	initManagers(resolveClaudeDir(io.Discard))
+	// Keys exported in the developer's shell would shadow the ones tests store
+	for _, provider := range aiProviderMgr.ListSupportedProviders() {
+		os.Unsetenv(aiprovider.APIKeyEnvVar(provider))
+	}
+	os.Exit(m.Run())
+}
+
+func TestResolveClaudeDir(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "plan9" {
+		t.Skip("os.UserHomeDir does not read $HOME on this platform")
+	}
+
+	t.Run("home directory available", func(t *testing.T) {
+		homeDir := t.TempDir()
+		t.Setenv("HOME", homeDir)
+
+		var stderr bytes.Buffer
+		assert.Equal(t, filepath.Join(homeDir, ".claude"), resolveClaudeDir(&stderr))
+		assert.Empty(t, stderr.String())
+	})
+
+	t.Run("HOME unset falls back to USERPROFILE", func(t *testing.T) {
+		profileDir := t.TempDir()
+		t.Setenv("HOME", "")
+		t.Setenv("USERPROFILE", profileDir)
+
+		var stderr bytes.Buffer
+		dir := resolveClaudeDir(&stderr)
+		assert.Equal(t, filepath.Join(profileDir, ".claude"), dir)
+		assert.Contains(t, stderr.String(), "$USERPROFILE")
+		assert.Contains(t, stderr.String(), dir)
+	})
+
+	t.Run("nothing set falls back to working directory", func(t *testing.T) {
+		t.Setenv("HOME", "")
+		t.Setenv("USERPROFILE", "")
+
+		expected, err := filepath.Abs(".claude")
+		assert.NoError(t, err)
+
+		var stderr bytes.Buffer
+		assert.Equal(t, expected, resolveClaudeDir(&stderr))
+		assert.Contains(t, stderr.String(), expected)
+	})
+}
+
+func TestSelectClaudeDir(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+	t.Setenv("CLAUDE_CONFIG_DIR", "")
+
+	var stderr bytes.Buffer
+	if runtime.GOOS != "windows" && runtime.GOOS != "plan9" {
+		assert.Equal(t, filepath.Join(homeDir, ".claude"), selectClaudeDir("", &stderr))
+	}
+
+	envDir := filepath.Join(t.TempDir(), "env")
+	t.Setenv("CLAUDE_CONFIG_DIR", envDir)
+	assert.Equal(t, envDir, selectClaudeDir("", &stderr))
+
+	// --claude-dir takes precedence and relative paths are made absolute
+	flagDir := filepath.Join(t.TempDir(), "flag")
+	assert.Equal(t, flagDir, selectClaudeDir(flagDir, &stderr))
+
+	expected, err := filepath.Abs("relative")
+	require.NoError(t, err)
+	assert.Equal(t, expected, selectClaudeDir("relative", &stderr))
+	assert.Empty(t, stderr.String())
+}
+
+func TestNewLogger(t *testing.T) {
+	var buf bytes.Buffer
+	newLogger(&buf, true, true).Warn("⚠️  quiet")
+	assert.Empty(t, buf.String(), "--quiet 优先于 --verbose")
+
+	newLogger(&buf, false, false).Debug("hidden")
+	newLogger(&buf, false, false).Info("shown")
+	newLogger(&buf, false, true).Debug("verbose")
+	assert.Equal(t, "shown\nverbose\n", buf.String())
+}
+
+func TestRootCmd_ClaudeDirFlag(t *testing.T) {
+	useTempManagers(t)
+	dir := filepath.Join(t.TempDir(), "custom")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+
+	rootCmd := createRootCmd()
+	rootCmd.SetArgs([]string{"--claude-dir", dir, "hooks", "add", "--event", "Stop", "--command", "echo done"})
+	rootCmd.SetOut(io.Discard)
+	require.NoError(t, rootCmd.Execute())
+
+	assert.Equal(t, dir, claudeDir)
+	assert.FileExists(t, filepath.Join(dir, "settings.json"))
+
+	settings, err := configMgr.Load(context.Background())
+	require.NoError(t, err)
+	require.Len(t, settings.Hooks.Stop, 1)
+	assert.Equal(t, "echo done", settings.Hooks.Stop[0].Hooks[0].Command)
+}
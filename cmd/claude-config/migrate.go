@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ooneko/claude-config/internal/migrate"
+)
+
+// schemaMigrationLabels describes each schema migration by version
+var schemaMigrationLabels = map[int]string{
+	1: "规范 hooks 事件名大小写 (postToolUse → PostToolUse)",
+	2: "移除配置文件的 UTF-8 BOM，清理单值配置文件中的空白和 CRLF 换行",
+	3: "收紧 API 密钥文件权限",
+	4: "接管旧版 DeepSeek 配置",
+}
+
+// createMigrateCmd creates the migrate command
+func createMigrateCmd() *cobra.Command {
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "将settings.json升级到最新的结构版本",
+		Long: `按 settings.json 中的 schemaVersion 依次执行尚未执行的结构迁移，
+执行前会将原文件备份为 settings.json.schema_v<版本>_backup:
+
+  v1 将 hooks 中的 postToolUse、session_start 等事件名改为标准写法
+  v2 移除配置文件开头的 UTF-8 BOM，清理单值配置文件 (API 密钥、端点等) 中的空白和 CRLF 换行
+  v3 将 API 密钥文件权限收紧为 0600
+  v4 为旧版 DeepSeek 配置记录当前提供商并保存 API 密钥
+
+没有 schemaVersion 的配置视为版本 0。每个迁移只执行一次，
+全部成功后才以统一格式写入 settings.json 并更新 schemaVersion。
+config migrate 是该命令的别名。`,
+		Example: `  claude-config migrate
+  claude-config migrate --dry-run`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return runSchemaMigrations(context.Background(), os.Stdout, migrate.NewManager(claudeDir), dryRun)
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "只列出待执行的迁移，不修改配置")
+
+	return cmd
+}
+
+// runSchemaMigrations applies, or with dryRun lists, the pending schema migrations
+func runSchemaMigrations(ctx context.Context, w io.Writer, manager *migrate.Manager, dryRun bool) error {
+	if dryRun {
+		version, pending, err := manager.Pending(ctx)
+		if err != nil {
+			return fmt.Errorf("检查配置版本失败: %w", err)
+		}
+		if len(pending) == 0 {
+			fmt.Fprintf(w, "✅ settings.json 已是最新版本 (v%d)\n", version)
+			return nil
+		}
+
+		fmt.Fprintf(w, "📋 当前版本 v%d，待执行 %d 个迁移:\n", version, len(pending))
+		for _, migration := range pending {
+			fmt.Fprintf(w, "   v%d: %s\n", migration.Version, schemaMigrationLabel(migration))
+		}
+		return nil
+	}
+
+	result, err := manager.Run(ctx)
+	if err != nil {
+		return fmt.Errorf("迁移配置失败: %w", err)
+	}
+	if len(result.Applied) == 0 {
+		fmt.Fprintf(w, "✅ settings.json 已是最新版本 (v%d)\n", result.ToVersion)
+		return nil
+	}
+
+	fmt.Fprintf(w, "💾 已备份原配置: %s\n", result.BackupPath)
+	for _, applied := range result.Applied {
+		fmt.Fprintf(w, "🔧 v%d: %s\n", applied.Version, schemaMigrationLabel(applied.Migration))
+		for _, change := range applied.Changes {
+			fmt.Fprintf(w, "   %s\n", change)
+		}
+	}
+	fmt.Fprintf(w, "\n✅ settings.json 已从 v%d 升级到 v%d\n", result.FromVersion, result.ToVersion)
+	return nil
+}
+
+// schemaMigrationLabel returns the description shown for a migration
+func schemaMigrationLabel(migration migrate.Migration) string {
+	if label, ok := schemaMigrationLabels[migration.Version]; ok {
+		return label
+	}
+	return migration.Description
+}
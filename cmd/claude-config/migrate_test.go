@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ooneko/claude-config/internal/migrate"
+)
+
+func TestRunSchemaMigrations(t *testing.T) {
+	dir := t.TempDir()
+	settingsPath := filepath.Join(dir, "settings.json")
+	original := `{"hooks": {"postToolUse": []}}`
+	require.NoError(t, os.WriteFile(settingsPath, []byte(original), 0644))
+	manager := migrate.NewManager(dir)
+	ctx := context.Background()
+
+	var out bytes.Buffer
+	require.NoError(t, runSchemaMigrations(ctx, &out, manager, true))
+	assert.Contains(t, out.String(), fmt.Sprintf("待执行 %d 个迁移", migrate.LatestVersion()))
+	assert.Contains(t, out.String(), "v1: 规范 hooks 事件名大小写")
+	assert.Contains(t, out.String(), "v3: 收紧 API 密钥文件权限")
+	data, err := os.ReadFile(settingsPath)
+	require.NoError(t, err)
+	assert.Equal(t, original, string(data))
+
+	out.Reset()
+	require.NoError(t, runSchemaMigrations(ctx, &out, manager, false))
+	assert.Contains(t, out.String(), "settings.json.schema_v0_backup")
+	assert.Contains(t, out.String(), "hooks.postToolUse → hooks.PostToolUse")
+	assert.Contains(t, out.String(), fmt.Sprintf("从 v0 升级到 v%d", migrate.LatestVersion()))
+
+	out.Reset()
+	require.NoError(t, runSchemaMigrations(ctx, &out, manager, false))
+	assert.Contains(t, out.String(), fmt.Sprintf("已是最新版本 (v%d)", migrate.LatestVersion()))
+}
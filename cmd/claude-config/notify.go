@@ -3,10 +3,16 @@ package main
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/ooneko/claude-config/internal/claude"
+	"github.com/ooneko/claude-config/internal/hooks"
 	"github.com/spf13/cobra"
 )
 
@@ -15,7 +21,7 @@ func createNotifyCmd() *cobra.Command {
 	notifyCmd := &cobra.Command{
 		Use:   "notify",
 		Short: "通知配置管理",
-		Long:  `管理通知配置，支持NTFY和macOS原生通知功能。在macOS系统上会自动配置原生通知。`,
+		Long:  `管理通知配置，支持NTFY和系统原生通知功能，可使用 notify test 发送测试通知。在macOS、Linux和Windows系统上会自动配置原生通知。`,
 		Run: func(cmd *cobra.Command, _ []string) {
 			fmt.Println("使用 'claude-config notify on' 启用通知或 'claude-config notify off' 禁用通知")
 			_ = cmd.Help()
@@ -25,20 +31,59 @@ func createNotifyCmd() *cobra.Command {
 	// 添加子命令
 	notifyCmd.AddCommand(createNotifyOnCmd())
 	notifyCmd.AddCommand(createNotifyOffCmd())
+	notifyCmd.AddCommand(createNotifyTestCmd())
 
 	return notifyCmd
 }
 
 // createNotifyOnCmd creates the notify on command
 func createNotifyOnCmd() *cobra.Command {
-	return &cobra.Command{
+	var noNative bool
+	opts := ntfyOptions{}
+
+	onCmd := &cobra.Command{
 		Use:   "on",
 		Short: "启用NTFY通知",
-		Long:  `启用NTFY通知功能，如果未配置NTFY_TOPIC则提示用户输入，并添加通知hooks`,
+		Long: `启用NTFY通知功能并添加通知hooks。使用 --topic 指定NTFY_TOPIC，未指定且尚未配置时
+在终端中提示输入；标准输入不是终端时（如CI和脚本中）必须使用 --topic。
+
+在macOS上会同时配置原生通知；在Linux上会配置桌面通知，需要 PATH 中有 notify-send
+（Debian/Ubuntu 的 libnotify-bin，Fedora/Arch 的 libnotify）；在Windows上会通过 PowerShell 显示 toast 通知。
+使用 --no-native 只启用NTFY。
+
+默认在 Claude Code 完成响应 (Stop) 时通知，使用 --on-subagent-stop 和 --on-pre-compact
+可同时在子代理完成和压缩上下文前通知。`,
+		Example: `  claude-config notify on
+  claude-config notify on --no-native
+  claude-config notify on --topic my-topic --server https://ntfy.example.com
+  claude-config notify on --on-subagent-stop --on-pre-compact`,
 		RunE: func(_ *cobra.Command, _ []string) error {
-			return enableNTFY()
+			opts.native = nativeNotifications(runtime.GOOS, noNative)
+			return enableNTFY(opts)
 		},
 	}
+
+	onCmd.Flags().StringVar(&opts.topic, "topic", "", "NTFY Topic，保存为NTFY_TOPIC (不指定时使用已保存的值或提示输入)")
+	onCmd.Flags().StringVar(&opts.server, "server", "", "自建NTFY服务器地址，保存为NTFY_SERVER (默认 "+defaultNTFYServer+")")
+	onCmd.Flags().BoolVar(&noNative, "no-native", false, "不配置系统原生通知 (macOS/Linux/Windows)，只启用NTFY")
+	onCmd.Flags().BoolVar(&opts.subagentStop, "on-subagent-stop", false, "子代理完成时也发送通知 (SubagentStop 事件)")
+	onCmd.Flags().BoolVar(&opts.preCompact, "on-pre-compact", false, "压缩上下文前也发送通知 (PreCompact 事件)")
+
+	return onCmd
+}
+
+// nativeNotifications returns the platform whose native notifications notify on
+// should configure, or an empty string when none should be configured
+func nativeNotifications(goos string, noNative bool) string {
+	if noNative {
+		return ""
+	}
+	switch goos {
+	case "darwin", "linux", "windows":
+		return goos
+	default:
+		return ""
+	}
 }
 
 // createNotifyOffCmd creates the notify off command
@@ -46,15 +91,117 @@ func createNotifyOffCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "off",
 		Short: "禁用NTFY通知",
-		Long:  `禁用NTFY通知功能，保留NTFY_TOPIC但移除通知hooks`,
+		Long:  `禁用NTFY通知功能，保留NTFY_TOPIC和NTFY_SERVER但移除所有事件的NTFY通知hooks`,
 		RunE: func(_ *cobra.Command, _ []string) error {
 			return disableNTFY()
 		},
 	}
 }
 
+// createNotifyTestCmd creates the notify test command
+func createNotifyTestCmd() *cobra.Command {
+	var timeout time.Duration
+
+	testCmd := &cobra.Command{
+		Use:   "test",
+		Short: "发送测试通知",
+		Long: `读取settings.json中的NTFY_TOPIC和NTFY_SERVER，向NTFY服务器发送一条测试通知并显示HTTP状态，
+用于在长时间运行Claude Code之前确认通知能够送达。`,
+		Example: `  claude-config notify test
+  claude-config notify test --timeout 30s`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx := cmd.Context()
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			return sendTestNotification(ctx, &http.Client{Timeout: timeout})
+		},
+	}
+
+	testCmd.Flags().DurationVar(&timeout, "timeout", 10*time.Second, "请求超时时间")
+
+	return testCmd
+}
+
+// testNotificationMessage 测试通知的内容
+const testNotificationMessage = "claude-config test notification"
+
+// sendTestNotification 向配置的NTFY服务器发送测试通知，非2xx状态视为失败
+func sendTestNotification(ctx context.Context, client *http.Client) error {
+	settings, err := configMgr.Load(ctx)
+	if err != nil {
+		return fmt.Errorf("读取配置失败: %w", err)
+	}
+
+	ntfyTopic := settings.Env["NTFY_TOPIC"]
+	if ntfyTopic == "" {
+		return fmt.Errorf("未配置NTFY_TOPIC，请先运行 claude-config notify on --topic <topic>")
+	}
+	ntfyServer := settings.Env["NTFY_SERVER"]
+	if ntfyServer == "" {
+		ntfyServer = defaultNTFYServer
+	}
+
+	target := strings.TrimRight(ntfyServer, "/") + "/" + url.PathEscape(ntfyTopic)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, strings.NewReader(testNotificationMessage))
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Title", "Claude Code")
+
+	fmt.Printf("📤 发送测试通知到 %s\n", target)
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("发送测试通知失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	fmt.Printf("HTTP状态: %s\n", resp.Status)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("NTFY服务器返回 %s，请检查NTFY_TOPIC和NTFY_SERVER", resp.Status)
+	}
+
+	fmt.Println("✅ 测试通知已发送，请在订阅该Topic的设备上确认")
+	return nil
+}
+
+// defaultNTFYServer 未配置NTFY_SERVER时ntfy-notifier.sh使用的公共服务器
+const defaultNTFYServer = "https://ntfy.sh"
+
+// stdinIsTerminal 报告标准输入是否为终端，测试中可替换
+var stdinIsTerminal = func() bool {
+	return isTerminal(os.Stdin)
+}
+
+// ntfy-notifier.sh 各事件对应的hook命令模板，${CLAUDE_DIR} 由 notifyCommand 展开
+const (
+	ntfyStopCommand         = "${CLAUDE_DIR}/hooks/ntfy-notifier.sh stop"
+	ntfySubagentStopCommand = "${CLAUDE_DIR}/hooks/ntfy-notifier.sh subagent_stop"
+	ntfyPreCompactCommand   = "${CLAUDE_DIR}/hooks/ntfy-notifier.sh pre_compact"
+)
+
+// notifyCommand 将hook命令模板中的 ${CLAUDE_DIR} 展开为当前配置目录
+func notifyCommand(template string) string {
+	return hooks.ExpandCommand(template, claudeDir)
+}
+
+// legacyNotifyCommand 返回旧版本写入的、以 ~/.claude 引用配置目录的命令
+func legacyNotifyCommand(template string) string {
+	return strings.ReplaceAll(template, hooks.ClaudeDirToken, "~/.claude")
+}
+
+// ntfyOptions notify on 的选项
+type ntfyOptions struct {
+	native       string // darwin、linux 或 windows 时同时配置该系统的原生通知
+	topic        string // 非空时保存为NTFY_TOPIC
+	server       string // 非空时保存为NTFY_SERVER
+	subagentStop bool   // 子代理完成时通知
+	preCompact   bool   // 压缩上下文前通知
+}
+
 // enableNTFY 启用NTFY通知功能
-func enableNTFY() error {
+func enableNTFY(opts ntfyOptions) error {
 	ctx := context.Background()
 
 	// 读取当前配置
@@ -68,9 +215,16 @@ func enableNTFY() error {
 		settings.Env = make(map[string]string)
 	}
 
-	// 检查是否已有NTFY_TOPIC配置，如果没有则提示用户输入
+	// 命令行指定的topic优先，否则使用已有配置，都没有时在终端中提示用户输入
 	ntfyTopic := settings.Env["NTFY_TOPIC"]
-	if ntfyTopic == "" {
+	if topic := strings.TrimSpace(opts.topic); topic != "" {
+		ntfyTopic = topic
+		settings.Env["NTFY_TOPIC"] = ntfyTopic
+	} else if ntfyTopic == "" {
+		if !stdinIsTerminal() {
+			return fmt.Errorf("未配置NTFY Topic且标准输入不是终端，请使用 --topic 指定")
+		}
+
 		fmt.Print("请输入NTFY Topic: ")
 		_, _ = fmt.Scanln(&ntfyTopic)
 
@@ -81,6 +235,19 @@ func enableNTFY() error {
 
 		// 更新配置
 		settings.Env["NTFY_TOPIC"] = ntfyTopic
+
+		// 首次配置时一并询问服务器地址
+		if opts.server == "" && settings.Env["NTFY_SERVER"] == "" {
+			fmt.Printf("请输入NTFY服务器地址 (留空使用 %s): ", defaultNTFYServer)
+			_, _ = fmt.Scanln(&opts.server)
+		}
+	}
+
+	if server := strings.TrimSpace(opts.server); server != "" {
+		if err := validateNTFYServer(server); err != nil {
+			return err
+		}
+		settings.Env["NTFY_SERVER"] = strings.TrimRight(server, "/")
 	}
 
 	// 确保hooks配置存在
@@ -88,64 +255,156 @@ func enableNTFY() error {
 		settings.Hooks = &claude.HooksConfig{}
 	}
 
-	// 检查Stop hooks中是否已存在ntfy-notifier.sh
-	ntfyCommand := "~/.claude/hooks/ntfy-notifier.sh stop"
-	ntfyExists := false
+	// 添加Stop通知，以及可选的SubagentStop和PreCompact通知
+	settings.Hooks.Stop = addHookCommand(settings.Hooks.Stop, notifyCommand(ntfyStopCommand))
+	if opts.subagentStop {
+		settings.Hooks.SubagentStop = addHookCommand(settings.Hooks.SubagentStop, notifyCommand(ntfySubagentStopCommand))
+	}
+	if opts.preCompact {
+		settings.Hooks.PreCompact = addHookCommand(settings.Hooks.PreCompact, notifyCommand(ntfyPreCompactCommand))
+	}
 
-	for _, rule := range settings.Hooks.Stop {
-		if rule.Matcher == "" {
-			for _, hook := range rule.Hooks {
-				if hook.Command == ntfyCommand {
-					ntfyExists = true
-					break
-				}
-			}
+	// 在 macOS、Linux 和 Windows 上自动配置原生通知
+	switch opts.native {
+	case "darwin":
+		configureMacOSNotifications(settings)
+	case "linux":
+		configureLinuxNotifications(settings)
+	case "windows":
+		configureWindowsNotifications(settings)
+	}
+
+	// 保存配置
+	if err := configMgr.Save(ctx, settings); err != nil {
+		return fmt.Errorf("保存配置失败: %w", err)
+	}
+
+	fmt.Printf("✅ 通知已启用！Topic: %s\n", ntfyTopic)
+	// install --all 不包含 hook 脚本，补齐通知 hook 引用的内置脚本
+	if _, err := newInstallManager().InstallHookScripts(ctx, settings); err != nil {
+		logger.Warn("⚠️  安装hook脚本失败: %v", err)
+	}
+	if warnings := hooks.ValidateCommands(settings, claudeDir); len(warnings) > 0 {
+		logger.Warn("⚠️  以下hook脚本缺失或不可执行:")
+		for _, warning := range warnings {
+			logger.Warn("   - %s", warning)
+		}
+		logger.Warn("   请运行 claude-config install --hooks 安装hook脚本")
+	}
+	if ntfyServer := settings.Env["NTFY_SERVER"]; ntfyServer != "" {
+		fmt.Printf("🌐 NTFY服务器: %s\n", ntfyServer)
+	}
+	if opts.subagentStop {
+		fmt.Println("🤖 子代理完成通知已启用")
+	}
+	if opts.preCompact {
+		fmt.Println("🗜️  压缩上下文通知已启用")
+	}
+	switch opts.native {
+	case "darwin":
+		fmt.Println("🍎 macOS原生通知已自动配置")
+	case "linux":
+		fmt.Println("🐧 Linux桌面通知已自动配置")
+		if _, err := exec.LookPath("notify-send"); err != nil {
+			fmt.Println("⚠️  未找到 notify-send，请安装 libnotify-bin (Debian/Ubuntu) 或 libnotify (Fedora/Arch)")
 		}
-		if ntfyExists {
+	case "windows":
+		fmt.Println("🪟 Windows toast通知已自动配置")
+	}
+	return nil
+}
+
+// addHookCommand 将命令添加到空matcher的规则中，命令已存在时不重复添加
+func addHookCommand(rules []*claude.HookRule, command string) []*claude.HookRule {
+	// 查找空matcher的rule，如果不存在则创建
+	var targetRule *claude.HookRule
+	for _, rule := range rules {
+		if rule.Matcher == "" {
+			targetRule = rule
 			break
 		}
 	}
 
-	// 如果ntfy hook不存在，添加它
-	if !ntfyExists {
-		// 查找空matcher的rule，如果不存在则创建
-		var targetRule *claude.HookRule
-		for _, rule := range settings.Hooks.Stop {
-			if rule.Matcher == "" {
-				targetRule = rule
-				break
-			}
+	if targetRule == nil {
+		targetRule = &claude.HookRule{
+			Matcher: "",
+			Hooks:   []*claude.HookItem{},
 		}
+		rules = append(rules, targetRule)
+	}
 
-		if targetRule == nil {
-			targetRule = &claude.HookRule{
-				Matcher: "",
-				Hooks:   []*claude.HookItem{},
+	for _, hook := range targetRule.Hooks {
+		if hook.Command == command {
+			return rules
+		}
+	}
+
+	targetRule.Hooks = append(targetRule.Hooks, &claude.HookItem{
+		Type:    "command",
+		Command: command,
+	})
+	return rules
+}
+
+// removeHookCommand 从空matcher的规则中移除命令，规则为空时一并移除，返回是否有命令被移除
+func removeHookCommand(rules []*claude.HookRule, command string) ([]*claude.HookRule, bool) {
+	removed := false
+
+	for i, rule := range rules {
+		if rule.Matcher != "" {
+			continue
+		}
+
+		// 在该rule的hooks中查找并移除命令
+		newHooks := []*claude.HookItem{}
+		for _, hook := range rule.Hooks {
+			if hook.Command != command {
+				newHooks = append(newHooks, hook)
+			} else {
+				removed = true
 			}
-			settings.Hooks.Stop = append(settings.Hooks.Stop, targetRule)
 		}
 
-		// 添加ntfy hook
-		ntfyHook := &claude.HookItem{
-			Type:    "command",
-			Command: ntfyCommand,
+		// 如果该rule没有hooks了，移除整个rule
+		if len(newHooks) == 0 {
+			rules = append(rules[:i], rules[i+1:]...)
+		} else {
+			rule.Hooks = newHooks
 		}
-		targetRule.Hooks = append(targetRule.Hooks, ntfyHook)
+		break
 	}
 
-	// 在 macOS 上自动配置原生通知
-	if runtime.GOOS == "darwin" {
-		configureMacOSNotifications(settings)
+	if len(rules) == 0 {
+		rules = nil
 	}
+	return rules, removed
+}
 
-	// 保存配置
-	if err := configMgr.Save(ctx, settings); err != nil {
-		return fmt.Errorf("保存配置失败: %w", err)
+// removeNotifyCommand 移除由命令模板展开的命令，以及旧版本写入的 ~/.claude 形式
+func removeNotifyCommand(rules []*claude.HookRule, template string) ([]*claude.HookRule, bool) {
+	rules, removed := removeHookCommand(rules, notifyCommand(template))
+	if legacy := legacyNotifyCommand(template); legacy != notifyCommand(template) {
+		var removedLegacy bool
+		rules, removedLegacy = removeHookCommand(rules, legacy)
+		removed = removed || removedLegacy
 	}
+	return rules, removed
+}
 
-	fmt.Printf("✅ 通知已启用！Topic: %s\n", ntfyTopic)
-	if runtime.GOOS == "darwin" {
-		fmt.Println("🍎 macOS原生通知已自动配置")
+// validateNTFYServer 检查NTFY服务器地址是带主机名的 http(s) URL
+func validateNTFYServer(server string) error {
+	parsed, err := url.Parse(server)
+	if err != nil {
+		return fmt.Errorf("无效的NTFY服务器地址 %q: %w", server, err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("无效的NTFY服务器地址 %q: 必须以 http:// 或 https:// 开头", server)
+	}
+	if parsed.Hostname() == "" {
+		return fmt.Errorf("无效的NTFY服务器地址 %q: 缺少主机名", server)
+	}
+	if parsed.RawQuery != "" || parsed.Fragment != "" {
+		return fmt.Errorf("无效的NTFY服务器地址 %q: 不能包含查询参数", server)
 	}
 	return nil
 }
@@ -161,36 +420,17 @@ func disableNTFY() error {
 	}
 
 	// 检查hooks配置是否存在
-	if settings.Hooks == nil || settings.Hooks.Stop == nil {
+	if settings.Hooks == nil {
 		fmt.Println("✅ NTFY通知已经是禁用状态")
 		return nil
 	}
 
-	// 查找并移除ntfy-notifier.sh hook
-	ntfyCommand := "~/.claude/hooks/ntfy-notifier.sh stop"
-	removed := false
-
-	for i, rule := range settings.Hooks.Stop {
-		if rule.Matcher == "" {
-			// 在该rule的hooks中查找并移除ntfy hook
-			newHooks := []*claude.HookItem{}
-			for _, hook := range rule.Hooks {
-				if hook.Command != ntfyCommand {
-					newHooks = append(newHooks, hook)
-				} else {
-					removed = true
-				}
-			}
-
-			// 如果该rule没有hooks了，移除整个rule
-			if len(newHooks) == 0 {
-				settings.Hooks.Stop = append(settings.Hooks.Stop[:i], settings.Hooks.Stop[i+1:]...)
-			} else {
-				rule.Hooks = newHooks
-			}
-			break
-		}
-	}
+	// 查找并移除所有事件中的ntfy-notifier.sh hook
+	var removedStop, removedSubagentStop, removedPreCompact bool
+	settings.Hooks.Stop, removedStop = removeNotifyCommand(settings.Hooks.Stop, ntfyStopCommand)
+	settings.Hooks.SubagentStop, removedSubagentStop = removeNotifyCommand(settings.Hooks.SubagentStop, ntfySubagentStopCommand)
+	settings.Hooks.PreCompact, removedPreCompact = removeNotifyCommand(settings.Hooks.PreCompact, ntfyPreCompactCommand)
+	removed := removedStop || removedSubagentStop || removedPreCompact
 
 	if !removed {
 		fmt.Println("✅ NTFY通知已经是禁用状态")
@@ -202,7 +442,7 @@ func disableNTFY() error {
 		return fmt.Errorf("保存配置失败: %w", err)
 	}
 
-	fmt.Println("✅ NTFY通知已禁用（保留NTFY_TOPIC配置）")
+	fmt.Println("✅ NTFY通知已禁用（保留NTFY_TOPIC和NTFY_SERVER配置）")
 	return nil
 }
 
@@ -220,7 +460,7 @@ func configureMacOSNotifications(settings *claude.Settings) {
 			Hooks: []*claude.HookItem{
 				{
 					Type:    "command",
-					Command: "~/.claude/hooks/ntfy-notifier.sh notification permission_prompt",
+					Command: notifyCommand("${CLAUDE_DIR}/hooks/ntfy-notifier.sh notification permission_prompt"),
 				},
 			},
 		},
@@ -229,3 +469,67 @@ func configureMacOSNotifications(settings *claude.Settings) {
 	// 将通知规则添加到 hooks.Notification 中
 	settings.Hooks.Notification = notificationRules
 }
+
+// linuxNotifyCommand 通过 notify-send 显示桌面通知的 hook 命令模板
+const linuxNotifyCommand = "${CLAUDE_DIR}/hooks/desktop-notifier.sh"
+
+// configureLinuxNotifications 配置Linux桌面通知，已存在时不重复添加
+func configureLinuxNotifications(settings *claude.Settings) {
+	// 确保 hooks 配置存在
+	if settings.Hooks == nil {
+		settings.Hooks = &claude.HooksConfig{}
+	}
+
+	command := notifyCommand(linuxNotifyCommand)
+	for _, rule := range settings.Hooks.Notification {
+		for _, hook := range rule.Hooks {
+			if hook.Command == command {
+				return
+			}
+		}
+	}
+
+	settings.Hooks.Notification = append(settings.Hooks.Notification, &claude.HookRule{
+		Matcher: "permission_prompt",
+		Hooks: []*claude.HookItem{
+			{
+				Type:    "command",
+				Command: command,
+			},
+		},
+	})
+}
+
+// windowsNotifyCommand 通过 PowerShell 显示 toast 通知的 hook 命令模板
+const windowsNotifyCommand = "powershell.exe -NoProfile -ExecutionPolicy Bypass -File ${CLAUDE_DIR}/hooks/toast-notifier.ps1"
+
+// configureWindowsNotifications 配置Windows toast通知，permission_prompt 规则中已有该命令时不重复添加
+func configureWindowsNotifications(settings *claude.Settings) {
+	// 确保 hooks 配置存在
+	if settings.Hooks == nil {
+		settings.Hooks = &claude.HooksConfig{}
+	}
+
+	toastHook := &claude.HookItem{
+		Type:    "command",
+		Command: notifyCommand(windowsNotifyCommand),
+	}
+
+	for _, rule := range settings.Hooks.Notification {
+		if rule.Matcher != "permission_prompt" {
+			continue
+		}
+		for _, hook := range rule.Hooks {
+			if hook.Command == toastHook.Command {
+				return
+			}
+		}
+		rule.Hooks = append(rule.Hooks, toastHook)
+		return
+	}
+
+	settings.Hooks.Notification = append(settings.Hooks.Notification, &claude.HookRule{
+		Matcher: "permission_prompt",
+		Hooks:   []*claude.HookItem{toastHook},
+	})
+}
@@ -1,6 +1,11 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -58,6 +63,250 @@ func TestConfigureMacOSNotifications_Idempotent(t *testing.T) {
 	assert.Equal(t, len(firstNotificationConfig), len(secondNotificationConfig))
 }
 
+// TestConfigureLinuxNotifications tests that the Linux desktop notification rule is added once
+func TestConfigureLinuxNotifications(t *testing.T) {
+	settings := &claude.Settings{
+		Hooks: &claude.HooksConfig{
+			Notification: []*claude.HookRule{
+				{Matcher: "idle_prompt", Hooks: []*claude.HookItem{{Type: "command", Command: "my-notifier"}}},
+			},
+		},
+	}
+
+	configureLinuxNotifications(settings)
+	configureLinuxNotifications(settings)
+
+	// Existing rules are kept and the notify-send rule is not duplicated
+	require.Len(t, settings.Hooks.Notification, 2)
+	assert.Equal(t, "my-notifier", settings.Hooks.Notification[0].Hooks[0].Command)
+
+	permissionRule := findHookRuleByMatcher(settings.Hooks.Notification, "permission_prompt")
+	require.NotNil(t, permissionRule)
+	require.Len(t, permissionRule.Hooks, 1)
+	assert.Equal(t, "~/.claude/hooks/desktop-notifier.sh", permissionRule.Hooks[0].Command)
+}
+
+// TestConfigureWindowsNotifications tests that the toast hook joins an existing permission_prompt rule once
+func TestConfigureWindowsNotifications(t *testing.T) {
+	settings := &claude.Settings{}
+
+	configureWindowsNotifications(settings)
+	configureWindowsNotifications(settings)
+
+	require.Len(t, settings.Hooks.Notification, 1)
+	permissionRule := findHookRuleByMatcher(settings.Hooks.Notification, "permission_prompt")
+	require.NotNil(t, permissionRule)
+	require.Len(t, permissionRule.Hooks, 1)
+	assert.Contains(t, permissionRule.Hooks[0].Command, "powershell.exe")
+	assert.Contains(t, permissionRule.Hooks[0].Command, "toast-notifier.ps1")
+
+	// An existing permission_prompt rule gets the toast hook instead of a second rule
+	settings = &claude.Settings{
+		Hooks: &claude.HooksConfig{
+			Notification: []*claude.HookRule{
+				{Matcher: "permission_prompt", Hooks: []*claude.HookItem{{Type: "command", Command: "my-notifier"}}},
+			},
+		},
+	}
+	configureWindowsNotifications(settings)
+	require.Len(t, settings.Hooks.Notification, 1)
+	require.Len(t, settings.Hooks.Notification[0].Hooks, 2)
+	assert.Equal(t, "my-notifier", settings.Hooks.Notification[0].Hooks[0].Command)
+}
+
+// TestEnableNTFY_Linux tests that notify on twice on Linux configures the desktop hook once
+func TestEnableNTFY_Linux(t *testing.T) {
+	dir := useTempManagers(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "settings.json"), []byte(`{"env": {"NTFY_TOPIC": "my-topic"}}`), 0644))
+
+	require.NoError(t, enableNTFY(ntfyOptions{native: "linux"}))
+	require.NoError(t, enableNTFY(ntfyOptions{native: "linux"}))
+
+	settings, err := configMgr.Load(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, settings.Hooks)
+	require.Len(t, settings.Hooks.Notification, 1)
+	assert.Equal(t, filepath.ToSlash(dir)+"/hooks/desktop-notifier.sh", settings.Hooks.Notification[0].Hooks[0].Command)
+	require.Len(t, findHookRuleByMatcher(settings.Hooks.Stop, "").Hooks, 1)
+}
+
+// TestEnableNTFY_Server tests that a custom server is validated, saved and kept by notify off
+func TestEnableNTFY_Server(t *testing.T) {
+	dir := useTempManagers(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "settings.json"), []byte(`{"env": {"NTFY_TOPIC": "my-topic"}}`), 0644))
+
+	for _, server := range []string{"ntfy.example.com", "ftp://ntfy.example.com", "https://", "https://ntfy.example.com/?a=1"} {
+		err := enableNTFY(ntfyOptions{server: server})
+		require.Error(t, err, server)
+		assert.Contains(t, err.Error(), "无效的NTFY服务器地址")
+	}
+	settings, err := configMgr.Load(context.Background())
+	require.NoError(t, err)
+	assert.NotContains(t, settings.Env, "NTFY_SERVER")
+
+	require.NoError(t, enableNTFY(ntfyOptions{server: "https://ntfy.example.com/"}))
+	settings, err = configMgr.Load(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "https://ntfy.example.com", settings.Env["NTFY_SERVER"])
+
+	// 不指定时保留已保存的服务器
+	require.NoError(t, enableNTFY(ntfyOptions{}))
+	require.NoError(t, disableNTFY())
+	settings, err = configMgr.Load(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "https://ntfy.example.com", settings.Env["NTFY_SERVER"])
+	assert.Equal(t, "my-topic", settings.Env["NTFY_TOPIC"])
+}
+
+// TestEnableNTFY_Topic tests --topic and that notify on never prompts without a terminal
+func TestEnableNTFY_Topic(t *testing.T) {
+	useTempManagers(t)
+
+	original := stdinIsTerminal
+	stdinIsTerminal = func() bool { return false }
+	defer func() { stdinIsTerminal = original }()
+
+	err := enableNTFY(ntfyOptions{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--topic")
+
+	cmd := createNotifyOnCmd()
+	cmd.SetArgs([]string{"--no-native", "--topic", "ci-topic", "--server", "https://ntfy.example.com"})
+	cmd.SetOut(&bytes.Buffer{})
+	require.NoError(t, cmd.Execute())
+
+	settings, err := configMgr.Load(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "ci-topic", settings.Env["NTFY_TOPIC"])
+	assert.Equal(t, "https://ntfy.example.com", settings.Env["NTFY_SERVER"])
+
+	// 已保存topic后无需再指定，指定时覆盖
+	require.NoError(t, enableNTFY(ntfyOptions{}))
+	require.NoError(t, enableNTFY(ntfyOptions{topic: "new-topic"}))
+	settings, err = configMgr.Load(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "new-topic", settings.Env["NTFY_TOPIC"])
+}
+
+// TestSendTestNotification tests that the test message is posted to the configured server and topic
+func TestSendTestNotification(t *testing.T) {
+	dir := useTempManagers(t)
+
+	var gotPath, gotBody string
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		body, _ := io.ReadAll(r.Body)
+		gotPath, gotBody = r.URL.Path, string(body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	err := sendTestNotification(context.Background(), server.Client())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "未配置NTFY_TOPIC")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "settings.json"),
+		[]byte(`{"env": {"NTFY_TOPIC": "my-topic", "NTFY_SERVER": "`+server.URL+`/"}}`), 0644))
+
+	require.NoError(t, sendTestNotification(context.Background(), server.Client()))
+	assert.Equal(t, "/my-topic", gotPath)
+	assert.Equal(t, "claude-config test notification", gotBody)
+
+	status = http.StatusForbidden
+	err = sendTestNotification(context.Background(), server.Client())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "403")
+}
+
+// TestEnableNTFY_ExtraEvents tests the optional SubagentStop and PreCompact notifications
+func TestEnableNTFY_ExtraEvents(t *testing.T) {
+	dir := useTempManagers(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "settings.json"), []byte(`{"env": {"NTFY_TOPIC": "my-topic"}}`), 0644))
+
+	require.NoError(t, enableNTFY(ntfyOptions{}))
+	settings, err := configMgr.Load(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, settings.Hooks.SubagentStop)
+	assert.Empty(t, settings.Hooks.PreCompact)
+
+	opts := ntfyOptions{subagentStop: true, preCompact: true}
+	require.NoError(t, enableNTFY(opts))
+	require.NoError(t, enableNTFY(opts))
+
+	settings, err = configMgr.Load(context.Background())
+	require.NoError(t, err)
+	require.Len(t, settings.Hooks.SubagentStop, 1)
+	require.Len(t, settings.Hooks.SubagentStop[0].Hooks, 1)
+	assert.Equal(t, filepath.ToSlash(dir)+"/hooks/ntfy-notifier.sh subagent_stop", settings.Hooks.SubagentStop[0].Hooks[0].Command)
+	require.Len(t, settings.Hooks.PreCompact, 1)
+	require.Len(t, settings.Hooks.PreCompact[0].Hooks, 1)
+	assert.Equal(t, filepath.ToSlash(dir)+"/hooks/ntfy-notifier.sh pre_compact", settings.Hooks.PreCompact[0].Hooks[0].Command)
+	require.Len(t, settings.Hooks.Stop[0].Hooks, 1)
+
+	// notify off 移除所有事件的通知
+	require.NoError(t, disableNTFY())
+	settings, err = configMgr.Load(context.Background())
+	require.NoError(t, err)
+	if settings.Hooks != nil {
+		assert.Empty(t, settings.Hooks.Stop)
+		assert.Empty(t, settings.Hooks.SubagentStop)
+		assert.Empty(t, settings.Hooks.PreCompact)
+	}
+}
+
+// TestDisableNTFY_LegacyCommand tests that notify off removes hooks written with ~/.claude
+// by older versions, even when the config directory is elsewhere
+func TestDisableNTFY_LegacyCommand(t *testing.T) {
+	dir := useTempManagers(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "settings.json"), []byte(`{
+		"env": {"NTFY_TOPIC": "my-topic"},
+		"hooks": {"Stop": [{"matcher": "", "hooks": [
+			{"type": "command", "command": "~/.claude/hooks/ntfy-notifier.sh stop"},
+			{"type": "command", "command": "my-stop-hook"}
+		]}]}
+	}`), 0644))
+
+	require.NoError(t, disableNTFY())
+
+	settings, err := configMgr.Load(context.Background())
+	require.NoError(t, err)
+	require.Len(t, settings.Hooks.Stop, 1)
+	require.Len(t, settings.Hooks.Stop[0].Hooks, 1)
+	assert.Equal(t, "my-stop-hook", settings.Hooks.Stop[0].Hooks[0].Command)
+}
+
+// TestEnableNTFY_NoNative tests that --no-native on macOS only adds the NTFY Stop hook
+func TestEnableNTFY_NoNative(t *testing.T) {
+	dir := useTempManagers(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "settings.json"), []byte(`{"env": {"NTFY_TOPIC": "my-topic"}}`), 0644))
+
+	assert.Equal(t, "darwin", nativeNotifications("darwin", false))
+	assert.Empty(t, nativeNotifications("darwin", true))
+	assert.Equal(t, "linux", nativeNotifications("linux", false))
+	assert.Empty(t, nativeNotifications("linux", true))
+	assert.Equal(t, "windows", nativeNotifications("windows", false))
+	assert.Empty(t, nativeNotifications("freebsd", false))
+
+	require.NoError(t, enableNTFY(ntfyOptions{native: nativeNotifications("darwin", true)}))
+
+	settings, err := configMgr.Load(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, settings.Hooks)
+	assert.Empty(t, settings.Hooks.Notification)
+
+	stopRule := findHookRuleByMatcher(settings.Hooks.Stop, "")
+	require.NotNil(t, stopRule)
+	require.Len(t, stopRule.Hooks, 1)
+	assert.Equal(t, filepath.ToSlash(dir)+"/hooks/ntfy-notifier.sh stop", stopRule.Hooks[0].Command)
+
+	// Without --no-native macOS also gets the Notification hooks
+	require.NoError(t, enableNTFY(ntfyOptions{native: nativeNotifications("darwin", false)}))
+	settings, err = configMgr.Load(context.Background())
+	require.NoError(t, err)
+	assert.NotEmpty(t, settings.Hooks.Notification)
+}
+
 // TestRuntimeGOSSection tests that we're using runtime.GOOS correctly
 func TestRuntimeGOSSection(t *testing.T) {
 	// This test verifies that runtime.GOOS detection works as expected
@@ -3,7 +3,9 @@ package main
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
@@ -12,8 +14,9 @@ import (
 	"github.com/ooneko/claude-config/internal/claude"
 )
 
-// enableProxy enables proxy with saved or user-input configuration
-func enableProxy() error {
+// enableProxy enables proxy with saved or user-input configuration.
+// With check set, the proxy must accept connections before it is enabled.
+func enableProxy(check bool) error {
 	ctx := context.Background()
 
 	// Try to load saved proxy configuration first
@@ -26,15 +29,31 @@ func enableProxy() error {
 		}
 	}
 
+	if check {
+		fmt.Println("🔍 正在检查代理连通性...")
+		if err := proxyMgr.TestProxy(ctx, proxyConfig); err != nil {
+			return fmt.Errorf("代理连通性检查失败: %w", err)
+		}
+	}
+
 	err = proxyMgr.Enable(ctx, proxyConfig)
 	if err != nil {
 		return fmt.Errorf("启用代理失败: %w", err)
 	}
 
-	fmt.Printf("✅ 代理已启用：%s\n", proxyConfig.HTTPProxy)
+	fmt.Printf("✅ 代理已启用：%s\n", proxyDisplayAddress(proxyConfig))
 	return nil
 }
 
+// proxyDisplayAddress returns the address shown in status output,
+// falling back to the SOCKS proxy when no HTTP proxy is set
+func proxyDisplayAddress(config *claude.ProxyConfig) string {
+	if config.HTTPProxy != "" {
+		return config.HTTPProxy
+	}
+	return config.AllProxy
+}
+
 // promptForProxyConfig prompts user for proxy configuration
 func promptForProxyConfig() (*claude.ProxyConfig, error) {
 	reader := bufio.NewReader(os.Stdin)
@@ -59,9 +78,23 @@ func promptForProxyConfig() (*claude.ProxyConfig, error) {
 		httpsProxy = httpProxy
 	}
 
+	fmt.Print("请输入SOCKS代理地址 (可选，如 socks5://127.0.0.1:1080): ")
+	allProxy, err := reader.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("读取SOCKS代理地址失败: %w", err)
+	}
+
+	fmt.Print("请输入不使用代理的地址 (可选，逗号分隔，如 localhost,.corp.example.com): ")
+	noProxy, err := reader.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("读取不使用代理的地址失败: %w", err)
+	}
+
 	return &claude.ProxyConfig{
 		HTTPProxy:  httpProxy,
 		HTTPSProxy: httpsProxy,
+		NoProxy:    strings.TrimSpace(noProxy),
+		AllProxy:   strings.TrimSpace(allProxy),
 	}, nil
 }
 
@@ -76,13 +109,20 @@ func createProxyCmd() *cobra.Command {
 		},
 	}
 
+	var checkProxy bool
 	proxyOnCmd := &cobra.Command{
 		Use:   "on",
 		Short: "启用代理",
+		Long: `启用代理
+
+使用 --check 在启用前检查代理是否可以连接，离线配置时不要使用。`,
+		Example: `  claude-config proxy on
+  claude-config proxy on --check`,
 		RunE: func(_ *cobra.Command, _ []string) error {
-			return enableProxy()
+			return enableProxy(checkProxy)
 		},
 	}
+	proxyOnCmd.Flags().BoolVar(&checkProxy, "check", false, "启用前检查代理是否可以连接")
 
 	proxyOffCmd := &cobra.Command{
 		Use:   "off",
@@ -132,7 +172,13 @@ func createProxyCmd() *cobra.Command {
 				if err != nil {
 					return fmt.Errorf("获取代理配置失败: %w", err)
 				}
-				fmt.Printf("🌐 代理状态: ✅ 已启用 (%s)\n", config.HTTPProxy)
+				fmt.Printf("🌐 代理状态: ✅ 已启用 (%s)\n", proxyDisplayAddress(config))
+				if config.AllProxy != "" {
+					fmt.Printf("   SOCKS代理: %s\n", config.AllProxy)
+				}
+				if config.NoProxy != "" {
+					fmt.Printf("   不使用代理: %s\n", config.NoProxy)
+				}
 			} else {
 				fmt.Println("🌐 代理状态: ❌ 已禁用")
 			}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ooneko/claude-config/internal/aiprovider"
+	"github.com/ooneko/claude-config/internal/claude"
+	"github.com/ooneko/claude-config/internal/doctor"
+)
+
+// redactedText replaces secrets in reports
+const redactedText = "[REDACTED]"
+
+var (
+	// tokenPattern matches sk- style API keys
+	tokenPattern = regexp.MustCompile(`sk-[A-Za-z0-9_\-]{4,}`)
+	// urlCredentialsPattern matches the user:password part of URLs
+	urlCredentialsPattern = regexp.MustCompile(`://[^/\s:@"]+:[^/\s@"]+@`)
+)
+
+// redactSecrets masks known secret values, sk- style tokens and URL credentials
+func redactSecrets(text string, secrets []string) string {
+	// Replace longer secrets first so a secret containing another is fully masked
+	sorted := append([]string(nil), secrets...)
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+	for _, secret := range sorted {
+		if len(secret) >= 4 {
+			text = strings.ReplaceAll(text, secret, redactedText)
+		}
+	}
+
+	text = tokenPattern.ReplaceAllString(text, redactedText)
+	return urlCredentialsPattern.ReplaceAllString(text, "://"+redactedText+"@")
+}
+
+// knownSecrets returns the API keys stored in claudeDir and the active auth token
+func knownSecrets(ctx context.Context, claudeDir string) []string {
+	var secrets []string
+
+	paths, _ := filepath.Glob(filepath.Join(claudeDir, ".*_api_key"))
+	for _, path := range paths {
+		if data, err := os.ReadFile(path); err == nil {
+			if secret, err := aiprovider.DecodeAPIKey(data, os.Getenv(aiprovider.PassphraseEnv)); err == nil && secret != "" {
+				secrets = append(secrets, secret)
+			}
+		}
+	}
+
+	if settings, err := configMgr.Load(ctx); err == nil && settings.Env != nil {
+		if token := settings.Env["ANTHROPIC_AUTH_TOKEN"]; token != "" {
+			secrets = append(secrets, token)
+		}
+	}
+
+	return secrets
+}
+
+// reportField is a single line of the environment summary
+type reportField struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// reportResult is a selftest step in the report
+type reportResult struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Error  string `json:"error,omitempty"`
+}
+
+// diagnosticReport is the content written by selftest --report and doctor --report
+type diagnosticReport struct {
+	GeneratedAt string              `json:"generated_at"`
+	Environment []reportField       `json:"environment"`
+	Results     []reportResult      `json:"results,omitempty"`
+	Diagnostics []doctor.Diagnostic `json:"diagnostics,omitempty"`
+	Passed      int                 `json:"passed"`
+	Total       int                 `json:"total"`
+}
+
+// buildDiagnosticReport collects the environment summary and selftest results
+func buildDiagnosticReport(ctx context.Context, results []selfTestResult, now time.Time) *diagnosticReport {
+	report := &diagnosticReport{
+		GeneratedAt: now.Format(time.RFC3339),
+		Environment: reportEnvironment(ctx),
+		Total:       len(results),
+	}
+
+	for _, result := range results {
+		entry := reportResult{Name: result.Name, Passed: result.Err == nil}
+		if result.Err != nil {
+			entry.Error = result.Err.Error()
+		} else {
+			report.Passed++
+		}
+		report.Results = append(report.Results, entry)
+	}
+
+	return report
+}
+
+// buildDoctorReport collects the environment summary and doctor diagnostics
+func buildDoctorReport(ctx context.Context, diagnostics []doctor.Diagnostic, now time.Time) *diagnosticReport {
+	report := &diagnosticReport{
+		GeneratedAt: now.Format(time.RFC3339),
+		Environment: reportEnvironment(ctx),
+		Diagnostics: diagnostics,
+		Total:       len(diagnostics),
+	}
+
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Severity == doctor.SeverityOK {
+			report.Passed++
+		}
+	}
+
+	return report
+}
+
+// reportEnvironment summarizes the system and the current configuration
+func reportEnvironment(ctx context.Context) []reportField {
+	environment := []reportField{
+		{"系统", runtime.GOOS + "/" + runtime.GOARCH},
+		{"Go版本", runtime.Version()},
+		{"配置目录", claudeDir},
+	}
+
+	provider := "未启用"
+	if active, err := aiProviderMgr.GetActiveProvider(ctx); err != nil {
+		provider = "获取失败: " + err.Error()
+	} else if active != claude.ProviderNone {
+		provider = string(active)
+		if config, err := aiProviderMgr.GetProviderConfig(ctx, active); err == nil && config != nil {
+			provider += fmt.Sprintf(" (%s, 模型: %s)", config.BaseURL, config.Model)
+		}
+	}
+	environment = append(environment, reportField{"AI提供商", provider})
+
+	proxyStatus := "未启用"
+	if enabled, err := proxyMgr.IsEnabled(ctx); err != nil {
+		proxyStatus = "获取失败: " + err.Error()
+	} else if enabled {
+		if config, err := proxyMgr.GetConfig(ctx); err == nil {
+			proxyStatus = proxyDisplayAddress(config)
+		}
+	}
+	environment = append(environment, reportField{"代理", proxyStatus})
+
+	checkStatus := "未启用"
+	if enabled, err := isCheckEnabled(ctx); err != nil {
+		checkStatus = "获取失败: " + err.Error()
+	} else if enabled {
+		checkStatus = "已启用"
+	}
+	environment = append(environment, reportField{"代码检查", checkStatus})
+
+	return environment
+}
+
+// formatDiagnosticReport renders the report as JSON for .json paths and as text otherwise
+func formatDiagnosticReport(report *diagnosticReport, path string) ([]byte, error) {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("生成报告失败: %w", err)
+		}
+		return append(data, '\n'), nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintln(&b, "claude-config 诊断报告")
+	fmt.Fprintf(&b, "生成时间: %s\n\n", report.GeneratedAt)
+	for _, field := range report.Environment {
+		fmt.Fprintf(&b, "%s: %s\n", field.Name, field.Value)
+	}
+	fmt.Fprintln(&b)
+	if report.Diagnostics != nil {
+		printDiagnostics(&b, report.Diagnostics)
+		return []byte(b.String()), nil
+	}
+	for _, result := range report.Results {
+		if result.Passed {
+			fmt.Fprintf(&b, "✅ %s\n", result.Name)
+		} else {
+			fmt.Fprintf(&b, "❌ %s: %s\n", result.Name, result.Error)
+		}
+	}
+	fmt.Fprintf(&b, "\n📊 %d/%d 项通过\n", report.Passed, report.Total)
+	return []byte(b.String()), nil
+}
+
+// writeDiagnosticReport writes the redacted selftest report to path
+func writeDiagnosticReport(ctx context.Context, path string, results []selfTestResult) error {
+	return writeReport(ctx, path, buildDiagnosticReport(ctx, results, time.Now()))
+}
+
+// writeDoctorReport writes the redacted doctor report to path
+func writeDoctorReport(ctx context.Context, path string, diagnostics []doctor.Diagnostic) error {
+	return writeReport(ctx, path, buildDoctorReport(ctx, diagnostics, time.Now()))
+}
+
+// writeReport formats report for path, masks secrets and writes it
+func writeReport(ctx context.Context, path string, report *diagnosticReport) error {
+	data, err := formatDiagnosticReport(report, path)
+	if err != nil {
+		return err
+	}
+
+	redacted := redactSecrets(string(data), knownSecrets(ctx, claudeDir))
+	if err := os.WriteFile(path, []byte(redacted), 0644); err != nil {
+		return fmt.Errorf("写入报告失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ooneko/claude-config/internal/aiprovider"
+	"github.com/ooneko/claude-config/internal/check"
+	"github.com/ooneko/claude-config/internal/claude"
+	"github.com/ooneko/claude-config/internal/config"
+	"github.com/ooneko/claude-config/internal/proxy"
+)
+
+// selfTestStep is a single round-trip check run by selftest
+type selfTestStep struct {
+	name string
+	run  func(ctx context.Context, claudeDir string) error
+}
+
+// selfTestResult records the outcome of one step
+type selfTestResult struct {
+	Name string
+	Err  error
+}
+
+// createSelfTestCmd creates the selftest command
+func createSelfTestCmd() *cobra.Command {
+	var reportPath string
+
+	cmd := &cobra.Command{
+		Use:   "selftest",
+		Short: "在临时目录中自检各项功能",
+		Long: `在临时目录中依次执行 AI 提供商启用/关闭、代理开关、代码检查开关、
+备份/恢复等操作并验证结果，用于确认工具在当前环境中可以正常工作。
+
+不会修改 ~/.claude，结束后自动清理临时目录。提交 bug 报告时请附上输出。
+
+使用 --report 将自检结果和当前环境摘要写入文件（.json 后缀输出 JSON，否则为文本），
+API 密钥和代理密码会被替换为 [REDACTED]，可直接附在 GitHub issue 中。`,
+		Example: `  claude-config selftest
+  claude-config selftest --report selftest.txt
+  claude-config selftest --report selftest.json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			results, err := runSelfTest(cmd.Context())
+			if err != nil {
+				return err
+			}
+			passed := printSelfTestResults(os.Stdout, results)
+
+			if reportPath != "" {
+				if err := writeDiagnosticReport(context.Background(), reportPath, results); err != nil {
+					return err
+				}
+				fmt.Printf("📝 诊断报告已写入：%s\n", reportPath)
+			}
+
+			if !passed {
+				return fmt.Errorf("自检失败")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&reportPath, "report", "", "将诊断报告写入文件 (已隐去密钥)")
+
+	return cmd
+}
+
+// selfTestSteps returns the checks run by selftest, in order
+func selfTestSteps() []selfTestStep {
+	return []selfTestStep{
+		{name: "AI提供商启用/关闭", run: selfTestAIProvider},
+		{name: "代理开启/关闭", run: selfTestProxy},
+		{name: "代码检查开启/关闭", run: selfTestCheck},
+		{name: "备份/恢复", run: selfTestBackupRestore},
+	}
+}
+
+// runSelfTest runs every step against a fresh temporary claude directory
+func runSelfTest(ctx context.Context) ([]selfTestResult, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	tempDir, err := os.MkdirTemp("", "claude-config-selftest-")
+	if err != nil {
+		return nil, fmt.Errorf("创建临时目录失败: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	var results []selfTestResult
+	for i, step := range selfTestSteps() {
+		claudeDir := filepath.Join(tempDir, fmt.Sprintf("step-%d", i), ".claude")
+		if err := os.MkdirAll(claudeDir, 0755); err != nil {
+			return nil, fmt.Errorf("创建临时目录失败: %w", err)
+		}
+		results = append(results, selfTestResult{Name: step.name, Err: step.run(ctx, claudeDir)})
+	}
+
+	return results, nil
+}
+
+// printSelfTestResults reports each step and returns whether all passed
+func printSelfTestResults(w io.Writer, results []selfTestResult) bool {
+	passed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			fmt.Fprintf(w, "❌ %s: %v\n", result.Name, result.Err)
+			continue
+		}
+		passed++
+		fmt.Fprintf(w, "✅ %s\n", result.Name)
+	}
+
+	fmt.Fprintf(w, "\n📊 %d/%d 项通过\n", passed, len(results))
+	return passed == len(results)
+}
+
+func selfTestAIProvider(ctx context.Context, claudeDir string) error {
+	mgr := aiprovider.NewManager(claudeDir)
+
+	if err := mgr.Enable(ctx, claude.ProviderDeepSeek, "sk-selftest"); err != nil {
+		return fmt.Errorf("启用失败: %w", err)
+	}
+	active, err := mgr.GetActiveProvider(ctx)
+	if err != nil {
+		return err
+	}
+	if active != claude.ProviderDeepSeek {
+		return fmt.Errorf("启用后活跃提供商为 %q，期望 %q", active, claude.ProviderDeepSeek)
+	}
+
+	if err := mgr.Off(ctx); err != nil {
+		return fmt.Errorf("关闭失败: %w", err)
+	}
+	active, err = mgr.GetActiveProvider(ctx)
+	if err != nil {
+		return err
+	}
+	if active != claude.ProviderNone {
+		return fmt.Errorf("关闭后仍有活跃提供商 %q", active)
+	}
+
+	if err := mgr.On(ctx); err != nil {
+		return fmt.Errorf("恢复失败: %w", err)
+	}
+	active, err = mgr.GetActiveProvider(ctx)
+	if err != nil {
+		return err
+	}
+	if active != claude.ProviderDeepSeek {
+		return fmt.Errorf("恢复后活跃提供商为 %q，期望 %q", active, claude.ProviderDeepSeek)
+	}
+	return nil
+}
+
+func selfTestProxy(ctx context.Context, claudeDir string) error {
+	mgr := proxy.NewManager(claudeDir)
+	proxyConfig := &claude.ProxyConfig{
+		HTTPProxy:  "http://127.0.0.1:7890",
+		HTTPSProxy: "http://127.0.0.1:7890",
+	}
+
+	if err := mgr.Enable(ctx, proxyConfig); err != nil {
+		return fmt.Errorf("开启失败: %w", err)
+	}
+	if enabled, err := mgr.IsEnabled(ctx); err != nil || !enabled {
+		return fmt.Errorf("开启后代理未生效 (err: %v)", err)
+	}
+
+	if err := mgr.Disable(ctx); err != nil {
+		return fmt.Errorf("关闭失败: %w", err)
+	}
+	if enabled, err := mgr.IsEnabled(ctx); err != nil || enabled {
+		return fmt.Errorf("关闭后代理仍生效 (err: %v)", err)
+	}
+	return nil
+}
+
+func selfTestCheck(ctx context.Context, claudeDir string) error {
+	mgr := check.NewManager(claudeDir)
+	cfgMgr := config.NewManager(claudeDir)
+
+	if err := mgr.EnableCheck(ctx); err != nil {
+		return fmt.Errorf("开启失败: %w", err)
+	}
+	status, err := cfgMgr.GetStatus(ctx)
+	if err != nil {
+		return err
+	}
+	if !status.HooksEnabled {
+		return fmt.Errorf("开启后代码检查未生效")
+	}
+
+	if err := mgr.DisableCheck(ctx); err != nil {
+		return fmt.Errorf("关闭失败: %w", err)
+	}
+	status, err = cfgMgr.GetStatus(ctx)
+	if err != nil {
+		return err
+	}
+	if status.HooksEnabled {
+		return fmt.Errorf("关闭后代码检查仍生效")
+	}
+	return nil
+}
+
+func selfTestBackupRestore(ctx context.Context, claudeDir string) error {
+	original := []byte(`{"includeCoAuthoredBy": true, "env": {"SELFTEST": "1"}}`)
+	if err := os.WriteFile(filepath.Join(claudeDir, "settings.json"), original, 0644); err != nil {
+		return err
+	}
+
+	backupDir := filepath.Join(filepath.Dir(claudeDir), "backups")
+	backupInfo, err := config.NewManager(claudeDir).BackupTo(ctx, backupDir, nil)
+	if err != nil {
+		return fmt.Errorf("备份失败: %w", err)
+	}
+
+	restoreDir := filepath.Join(filepath.Dir(claudeDir), "restored")
+	if err := config.NewManager(restoreDir).Restore(ctx, backupInfo.FilePath, false); err != nil {
+		return fmt.Errorf("恢复失败: %w", err)
+	}
+
+	restored, err := os.ReadFile(filepath.Join(restoreDir, "settings.json"))
+	if err != nil {
+		return fmt.Errorf("读取恢复的配置失败: %w", err)
+	}
+	if string(restored) != string(original) {
+		return fmt.Errorf("恢复的 settings.json 与原文件不一致")
+	}
+	return nil
+}
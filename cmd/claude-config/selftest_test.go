@@ -0,0 +1,37 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunSelfTest(t *testing.T) {
+	results, err := runSelfTest(context.Background())
+	require.NoError(t, err)
+	require.Len(t, results, len(selfTestSteps()))
+
+	for _, result := range results {
+		assert.NoError(t, result.Err, result.Name)
+	}
+
+	var buf bytes.Buffer
+	assert.True(t, printSelfTestResults(&buf, results))
+	assert.Contains(t, buf.String(), "4/4")
+}
+
+func TestPrintSelfTestResults_Failure(t *testing.T) {
+	results := []selfTestResult{
+		{Name: "ok step"},
+		{Name: "bad step", Err: errors.New("boom")},
+	}
+
+	var buf bytes.Buffer
+	assert.False(t, printSelfTestResults(&buf, results))
+	assert.Contains(t, buf.String(), "❌ bad step: boom")
+	assert.Contains(t, buf.String(), "1/2")
+}
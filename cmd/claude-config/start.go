@@ -2,19 +2,22 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
-	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/ooneko/claude-config/internal/aiprovider"
 	"github.com/ooneko/claude-config/internal/claude"
 	"github.com/ooneko/claude-config/internal/provider"
+	"github.com/ooneko/claude-config/internal/proxy"
 	"github.com/spf13/cobra"
 )
 
-// anthropicEnvVars 需要清理的 ANTHROPIC 相关环境变量
+// anthropicEnvVars 启动原生 Claude Code 时不传给子进程的 ANTHROPIC 相关环境变量
 var anthropicEnvVars = []string{
 	"ANTHROPIC_AUTH_TOKEN",
 	"ANTHROPIC_BASE_URL",
@@ -24,8 +27,16 @@ var anthropicEnvVars = []string{
 }
 
 type startOptions struct {
-	apiKey string
-	model  string
+	apiKey          string
+	profile         string
+	endpoint        string
+	model           string
+	proxy           string
+	listModels      bool
+	defaultProvider string
+	claudeBin       string
+	printEnv        bool
+	last            bool
 }
 
 func createStartCmd() *cobra.Command {
@@ -36,21 +47,41 @@ func createStartCmd() *cobra.Command {
 		Short: "启动 Claude Code，可指定 AI provider",
 		Long: `启动 Claude Code，可选择指定 AI provider 通过环境变量设置配置。
 
-无参数时启动原生 Claude Code（清理现有配置）。
+无参数时启动原生 Claude Code（清理现有配置）；如果通过 --default-provider
+设置了默认 provider，则启动该 provider。
+也可以显式指定 native/claude 启动原生 Claude Code，便于脚本中始终传递 provider 变量。
+
+provider 选择优先级: 命令行指定的 provider > 默认 provider > 原生 Claude Code。
+使用 --default-provider native 清除默认 provider。
+使用 --last 启动 ai off 之前使用的 provider（与 ai on 恢复的相同），包括其配置名和模型。
 支持以下 provider:
 - deepseek: DeepSeek API
 - kimi: Kimi API
 - GLM: 智谱 GLM API
 - doubao: 豆包 API
+- anthropic: Anthropic API（使用 ai on anthropic 保存的密钥）
+
+Claude Code 可执行文件:
+默认从 PATH 中查找 claude，可使用 --claude-bin 或环境变量 CLAUDE_BIN 指定其他名称或路径，
+--claude-bin 优先。
 
 透传参数:
 使用 -- 可以将后续参数直接传递给 Claude Code
 
 示例:
   claude-config start              # 启动原生 Claude Code
+  claude-config start native       # 显式指定原生 Claude Code，忽略默认 provider
+  claude-config start --default-provider kimi    # 设置默认 provider 并启动
   claude-config start deepseek
-  claude-config start kimi --model kimi-plus
+  claude-config start kimi --model kimi-k2-turbo-preview
+  claude-config start kimi --list-models
   claude-config start GLM --api-key sk-xxxxxxxx
+  claude-config start deepseek --profile work
+  claude-config start doubao --endpoint general
+  claude-config start deepseek --proxy http://127.0.0.1:7890
+  claude-config start --claude-bin ~/.local/bin/claude
+  claude-config start kimi --print-env
+  claude-config start --last       # 使用 ai off 之前的 provider 启动
   claude-config start deepseek -- --dangerously-skip-permissions
   claude-config start -- --verbose --debug`,
 		Args: func(cmd *cobra.Command, args []string) error {
@@ -78,24 +109,25 @@ func createStartCmd() *cobra.Command {
 	}
 
 	cmd.Flags().StringVar(&opts.apiKey, "api-key", "", "API 密钥 (可选，优先使用存储的密钥)")
+	cmd.Flags().StringVar(&opts.profile, "profile", "", "使用指定配置名的 API 密钥 (可选，见 ai on --profile)")
+	cmd.Flags().StringVar(&opts.endpoint, "endpoint", "", "本次启动使用的接入点 (可选，默认使用 ai on --endpoint 保存的选择)")
 	cmd.Flags().StringVar(&opts.model, "model", "", "指定模型 (可选，使用 provider 默认模型)")
+	cmd.Flags().StringVar(&opts.proxy, "proxy", "", "本次启动使用的临时代理，同时设置 http_proxy、https_proxy 和 all_proxy (可选，不写入 settings.json)")
+	cmd.Flags().BoolVar(&opts.listModels, "list-models", false, "列出 provider 支持的模型后退出")
+	cmd.Flags().StringVar(&opts.defaultProvider, "default-provider", "", "设置无参数启动时使用的默认 provider (native 表示清除)")
+	cmd.Flags().BoolVar(&opts.last, "last", false, "使用 ai off 之前启用的 provider 启动 (与 ai on 恢复的相同)")
+	cmd.Flags().BoolVar(&opts.printEnv, "print-env", false, "打印将传给 Claude Code 的环境变量后退出，不启动也不修改配置")
+	cmd.Flags().StringVar(&opts.claudeBin, "claude-bin", "", "Claude Code 可执行文件的名称或路径 (默认使用 CLAUDE_BIN 或 PATH 中的 claude)")
 
 	return cmd
 }
 
 func runStart(cmd *cobra.Command, args []string, opts *startOptions) error {
-	// 获取 home 目录
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return fmt.Errorf("failed to get home directory: %w", err)
-	}
-
-	claudeDir := filepath.Join(homeDir, ".claude")
-
 	// 使用 Cobra 的 ArgsLenAtDash 来分离参数
 	argsLenAtDash := cmd.ArgsLenAtDash()
 	var providerArg string
 	var passthroughArgs []string
+	var err error
 
 	if argsLenAtDash == -1 {
 		// 没有 --
@@ -111,16 +143,98 @@ func runStart(cmd *cobra.Command, args []string, opts *startOptions) error {
 		passthroughArgs = args[argsLenAtDash:]
 	}
 
+	// 持久化默认 provider；--print-env 不修改配置，只在本次预览中使用它
+	if opts.defaultProvider != "" && !opts.printEnv {
+		if err := saveDefaultProvider(claudeDir, opts.defaultProvider); err != nil {
+			return err
+		}
+	}
+
+	// --last 使用 ai off 之前启用的 provider
+	if opts.last {
+		if providerArg != "" {
+			return fmt.Errorf("--last 不能与 provider 参数同时使用")
+		}
+		providerArg, err = lastProviderArg(claudeDir, opts)
+		if err != nil {
+			return err
+		}
+	}
+
+	// 未指定 provider 时使用默认 provider
+	if providerArg == "" && opts.printEnv && opts.defaultProvider != "" {
+		providerArg = opts.defaultProvider
+	} else if providerArg == "" {
+		providerArg, err = defaultProviderArg(claudeDir)
+		if err != nil {
+			return err
+		}
+	}
+
 	// 无 provider：启动原生 Claude Code
 	if providerArg == "" {
-		return startNativeClaude(claudeDir, passthroughArgs)
+		if opts.printEnv {
+			printNativeEnv(os.Stdout)
+			return nil
+		}
+		return startNativeClaude(claudeDir, opts.claudeBin, passthroughArgs)
 	}
 
 	// 有 provider：启动指定 provider
 	return startWithProvider(claudeDir, providerArg, opts, passthroughArgs)
 }
 
+// lastProviderArg 返回 ai on 将恢复的 provider，并在未通过命令行指定时沿用其配置名和模型
+func lastProviderArg(claudeDir string, opts *startOptions) (string, error) {
+	lastState, err := aiprovider.NewManager(claudeDir).GetLastActiveProvider(context.Background())
+	if err != nil {
+		return "", err
+	}
+
+	if opts.profile == "" {
+		opts.profile = lastState.Profile
+	}
+	if prov, err := getProvider(lastState.Provider); opts.model == "" && err == nil && aiprovider.IsSupportedModel(prov, lastState.Model) {
+		opts.model = lastState.Model
+	}
+
+	return string(lastState.Provider), nil
+}
+
+// saveDefaultProvider 保存默认 provider，原生别名表示清除
+func saveDefaultProvider(claudeDir, arg string) error {
+	providerType, err := parseProviderFromArg(arg)
+	if err != nil {
+		return err
+	}
+
+	if err := aiprovider.NewManager(claudeDir).SetDefaultProvider(context.Background(), providerType); err != nil {
+		return fmt.Errorf("failed to save default provider: %w", err)
+	}
+
+	if providerType == claude.ProviderNone {
+		fmt.Println("✅ 已清除默认 provider，无参数启动将使用原生 Claude Code")
+	} else {
+		fmt.Printf("✅ 默认 provider 已设置为 %s\n", providerType)
+	}
+	return nil
+}
+
+// defaultProviderArg 返回默认 provider 名称，未设置时返回空字符串
+func defaultProviderArg(claudeDir string) (string, error) {
+	providerType, err := aiprovider.NewManager(claudeDir).GetDefaultProvider(context.Background())
+	if err != nil {
+		return "", fmt.Errorf("failed to load default provider: %w", err)
+	}
+	return string(providerType), nil
+}
+
+// parseProviderFromArg 解析 provider 参数，原生别名返回 ProviderNone
 func parseProviderFromArg(arg string) (claude.ProviderType, error) {
+	if claude.IsNativeProviderAlias(arg) {
+		return claude.ProviderNone, nil
+	}
+
 	providerType := claude.NormalizeProviderName(arg)
 
 	if providerType == claude.ProviderNone {
@@ -130,59 +244,106 @@ func parseProviderFromArg(arg string) (claude.ProviderType, error) {
 	return providerType, nil
 }
 
-func loadStoredAPIKey(claudeDir string, providerType claude.ProviderType) (string, error) {
-	apiKeyPath := filepath.Join(claudeDir, "."+string(providerType)+"_api_key")
+func loadStoredAPIKey(claudeDir string, providerType claude.ProviderType, profile string) (string, error) {
+	apiKey, err := aiprovider.NewManager(claudeDir).LoadAPIKey(providerType, profile)
+	if errors.Is(err, os.ErrNotExist) {
+		if profile != "" && profile != aiprovider.DefaultProfile {
+			return "", fmt.Errorf("API key not found for provider %s profile %s, please provide --api-key or configure first", providerType, profile)
+		}
+		return "", fmt.Errorf("API key not found for provider %s, please provide --api-key or configure first", providerType)
+	}
+	return apiKey, err
+}
 
-	data, err := os.ReadFile(apiKeyPath)
+// printSupportedModels 打印 provider 支持的模型，第一个为默认模型
+func printSupportedModels(providerType claude.ProviderType) error {
+	prov, err := getProvider(providerType)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return "", fmt.Errorf("API key not found for provider %s, please provide --api-key or configure first", providerType)
+		return err
+	}
+	defaultModel := prov.GetDefaultConfig("").Model
+
+	fmt.Printf("🧠 %s 支持的模型:\n", providerType)
+	for _, model := range prov.SupportedModels() {
+		if model == defaultModel {
+			fmt.Printf("  - %s (默认)\n", model)
+		} else {
+			fmt.Printf("  - %s\n", model)
 		}
-		return "", fmt.Errorf("failed to read API key file: %w", err)
 	}
-
-	return strings.TrimSpace(string(data)), nil
+	return nil
 }
 
-func getProvider(providerType claude.ProviderType) aiprovider.Provider {
+// getProvider 返回 provider 的实现。自定义 provider 从 providers.json 读取，
+// 文件无效或已不再声明该 provider 时返回错误
+func getProvider(providerType claude.ProviderType) (aiprovider.Provider, error) {
 	switch providerType {
 	case claude.ProviderDeepSeek:
-		return &aiprovider.DeepSeekProvider{}
+		return &aiprovider.DeepSeekProvider{}, nil
 	case claude.ProviderKimi:
-		return &aiprovider.KimiProvider{}
+		return &aiprovider.KimiProvider{}, nil
 	case claude.ProviderGLM:
-		return &aiprovider.GLMProvider{}
+		return &aiprovider.GLMProvider{}, nil
 	case claude.ProviderDoubao:
-		return &aiprovider.DoubaoProvider{}
+		return &aiprovider.DoubaoProvider{}, nil
+	case claude.ProviderAnthropic:
+		return &aiprovider.AnthropicProvider{}, nil
 	default:
-		return nil
+		customProviders, err := aiprovider.LoadCustomProviders(claudeDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, custom := range customProviders {
+			if custom.GetType() == providerType {
+				return custom, nil
+			}
+		}
+		return nil, fmt.Errorf("unsupported provider: %s", providerType)
 	}
 }
 
-func startClaudeCode(envVars map[string]string, passthroughArgs []string) error {
-	// 设置环境变量
+// resolveClaudeBin 返回要启动的 Claude Code 可执行文件
+// 优先级: --claude-bin > CLAUDE_BIN 环境变量 > PATH 中的 claude
+func resolveClaudeBin(claudeBin string) (string, error) {
+	name := claudeBin
+	if name == "" {
+		name = os.Getenv("CLAUDE_BIN")
+	}
+	if name == "" {
+		name = "claude"
+	}
+
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "", fmt.Errorf("claude executable %q not found, use --claude-bin or CLAUDE_BIN to set its path: %w", name, err)
+	}
+	return path, nil
+}
+
+// startClaudeCode 启动 Claude Code 子进程。envVars 和 unsetVars 只作用于子进程的环境，
+// 不修改当前进程的环境变量
+func startClaudeCode(claudeBin string, envVars map[string]string, unsetVars, passthroughArgs []string) error {
+	childVars := make(map[string]string, len(envVars)+1)
 	for key, value := range envVars {
-		os.Setenv(key, value)
+		childVars[key] = value
 	}
 
 	// 设置透传参数到环境变量（用于测试验证）
 	if len(passthroughArgs) > 0 {
-		os.Setenv("CLAUDE_PASSTHROUGH_ARGS", strings.Join(passthroughArgs, " "))
+		childVars["CLAUDE_PASSTHROUGH_ARGS"] = strings.Join(passthroughArgs, " ")
 	}
 
 	// 检查是否存在 CLAUDE_MOCK 环境变量（用于测试）
-	if mockCmd := os.Getenv("CLAUDE_MOCK"); mockCmd != "" {
-		args := passthroughArgs
-		cmd := exec.Command(mockCmd, args...)
-		cmd.Stdin = os.Stdin
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-		return cmd.Run()
-	}
-
-	// 启动 Claude Code (假设 claude 命令在 PATH 中)
-	args := passthroughArgs
-	cmd := exec.Command("claude", args...)
+	bin := os.Getenv("CLAUDE_MOCK")
+	if bin == "" {
+		var err error
+		if bin, err = resolveClaudeBin(claudeBin); err != nil {
+			return err
+		}
+	}
+
+	cmd := exec.Command(bin, passthroughArgs...)
+	cmd.Env = childEnv(os.Environ(), childVars, unsetVars)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -190,29 +351,53 @@ func startClaudeCode(envVars map[string]string, passthroughArgs []string) error
 	return cmd.Run()
 }
 
+// childEnv 在 environ 的基础上移除 unsetVars 并用 envVars 覆盖同名变量，返回子进程的环境
+func childEnv(environ []string, envVars map[string]string, unsetVars []string) []string {
+	drop := make(map[string]bool, len(envVars)+len(unsetVars))
+	for _, key := range unsetVars {
+		drop[key] = true
+	}
+	for key := range envVars {
+		drop[key] = true
+	}
+
+	env := make([]string, 0, len(environ)+len(envVars))
+	for _, entry := range environ {
+		key, _, _ := strings.Cut(entry, "=")
+		if !drop[key] {
+			env = append(env, entry)
+		}
+	}
+
+	keys := make([]string, 0, len(envVars))
+	for key := range envVars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		env = append(env, key+"="+envVars[key])
+	}
+
+	return env
+}
+
 // startNativeClaude 启动原生 Claude Code（清理配置）
-func startNativeClaude(claudeDir string, passthroughArgs []string) error {
+func startNativeClaude(claudeDir, claudeBin string, passthroughArgs []string) error {
 	if err := cleanAnthropicConfig(claudeDir); err != nil {
 		fmt.Printf("Warning: failed to clean existing config: %v\n", err)
 	}
 
-	// 启动原生 Claude Code（无环境变量）
-	return startClaudeCode(map[string]string{}, passthroughArgs)
+	// 启动原生 Claude Code，子进程不继承 ANTHROPIC 相关环境变量
+	return startClaudeCode(claudeBin, map[string]string{}, anthropicEnvVars, passthroughArgs)
 }
 
-// cleanAnthropicConfig 清理 settings.json 和环境变量中的 ANTHROPIC 配置
+// cleanAnthropicConfig 清理 settings.json 中的 ANTHROPIC 配置
 func cleanAnthropicConfig(claudeDir string) error {
-	// 清理 settings.json 中的配置
 	manager := aiprovider.NewManager(claudeDir)
 	if err := manager.Off(context.Background()); err != nil {
 		return fmt.Errorf("failed to clean settings.json: %w", err)
 	}
 
-	// 清理环境变量
-	for _, envVar := range anthropicEnvVars {
-		os.Unsetenv(envVar)
-	}
-
 	return nil
 }
 
@@ -223,8 +408,21 @@ func startWithProvider(claudeDir string, providerArg string, opts *startOptions,
 		return err
 	}
 
+	// 原生别名：与无参数启动一致
+	if providerType == claude.ProviderNone {
+		if opts.printEnv {
+			printNativeEnv(os.Stdout)
+			return nil
+		}
+		return startNativeClaude(claudeDir, opts.claudeBin, passthroughArgs)
+	}
+
+	if opts.listModels {
+		return printSupportedModels(providerType)
+	}
+
 	// 获取 API 密钥
-	apiKey, err := getAPIKey(claudeDir, providerType, opts.apiKey)
+	apiKey, err := getAPIKey(claudeDir, providerType, opts.profile, opts.apiKey)
 	if err != nil {
 		return err
 	}
@@ -235,27 +433,166 @@ func startWithProvider(claudeDir string, providerArg string, opts *startOptions,
 		return err
 	}
 
+	// 与 ai on 一致使用 --base-url 覆盖或选择的接入点
+	baseURL, err := providerBaseURL(claudeDir, providerType, opts.endpoint)
+	if err != nil {
+		return err
+	}
+	if baseURL != "" {
+		envVars["ANTHROPIC_BASE_URL"] = baseURL
+	}
+
+	// 临时代理只作用于本次启动的子进程
+	if opts.proxy != "" {
+		if warning := proxyConflictWarning(claudeDir, opts.proxy); warning != "" {
+			fmt.Println(warning)
+		}
+		envVars["http_proxy"] = opts.proxy
+		envVars["https_proxy"] = opts.proxy
+		envVars["all_proxy"] = opts.proxy
+	}
+
+	if opts.printEnv {
+		printProviderEnv(os.Stdout, envVars)
+		return nil
+	}
+
 	// 启动 Claude Code
-	return startClaudeCode(envVars, passthroughArgs)
+	return startClaudeCode(opts.claudeBin, envVars, nil, passthroughArgs)
+}
+
+// secretEnvVars 打印环境变量时需要隐去的密钥
+var secretEnvVars = map[string]bool{
+	"ANTHROPIC_AUTH_TOKEN": true,
+	"ANTHROPIC_API_KEY":    true,
+}
+
+// printProviderEnv 按名称排序打印 provider 的环境变量，密钥只保留最后 4 位
+func printProviderEnv(w io.Writer, envVars map[string]string) {
+	keys := make([]string, 0, len(envVars))
+	for key := range envVars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := envVars[key]
+		if secretEnvVars[key] {
+			value = maskSecret(value)
+		}
+		fmt.Fprintf(w, "%s=%s\n", key, value)
+	}
+}
+
+// printNativeEnv 说明原生启动不设置 provider 环境变量
+func printNativeEnv(w io.Writer) {
+	fmt.Fprintf(w, "# 原生 Claude Code: 不设置 provider 环境变量，将移除 %s\n", strings.Join(anthropicEnvVars, ", "))
+}
+
+// maskSecret 只保留密钥的最后 4 位
+func maskSecret(secret string) string {
+	if len(secret) <= 4 {
+		return strings.Repeat("*", len(secret))
+	}
+	return strings.Repeat("*", len(secret)-4) + secret[len(secret)-4:]
+}
+
+// providerBaseURL 返回启动时使用的 base URL：命令行指定的接入点优先，其次与 ai on 一致，
+// 依次使用 ai on --base-url 保存的覆盖地址和已保存的接入点；都没有时返回空字符串，使用默认地址
+func providerBaseURL(claudeDir string, providerType claude.ProviderType, endpoint string) (string, error) {
+	if endpoint == "" {
+		override, err := aiprovider.NewManager(claudeDir).GetBaseURL(context.Background(), providerType)
+		if err != nil {
+			return "", err
+		}
+		if override != "" {
+			return override, nil
+		}
+	}
+	return selectedEndpointURL(claudeDir, providerType, endpoint)
+}
+
+// selectedEndpointURL 返回 provider 接入点的 base URL，优先使用命令行指定的接入点，
+// 其次使用已保存的选择；provider 不支持多个接入点时返回空字符串
+func selectedEndpointURL(claudeDir string, providerType claude.ProviderType, endpoint string) (string, error) {
+	prov, err := getProvider(providerType)
+	if err != nil {
+		return "", err
+	}
+	endpointProvider, ok := prov.(aiprovider.EndpointProvider)
+	if !ok {
+		if endpoint != "" {
+			return "", fmt.Errorf("provider %s has no selectable endpoints", providerType)
+		}
+		return "", nil
+	}
+
+	if endpoint == "" {
+		saved, err := aiprovider.NewManager(claudeDir).GetEndpoint(context.Background(), providerType)
+		if err != nil {
+			return "", err
+		}
+		endpoint = saved
+	}
+
+	baseURL, ok := endpointProvider.Endpoints()[endpoint]
+	if !ok {
+		return "", fmt.Errorf("unknown endpoint %q for provider %s", endpoint, providerType)
+	}
+	return baseURL, nil
 }
 
-// getAPIKey 获取 API 密钥，优先使用命令行参数，其次使用存储的密钥
-func getAPIKey(claudeDir string, providerType claude.ProviderType, cmdAPIKey string) (string, error) {
+// proxyConflictWarning 检查临时代理与 settings.json 中的代理是否冲突，无冲突时返回空字符串
+func proxyConflictWarning(claudeDir, ephemeralProxy string) string {
+	persisted, err := proxy.NewManager(claudeDir).GetConfig(context.Background())
+	if err != nil || persisted == nil {
+		return ""
+	}
+
+	// 只列出已设置的代理，仅设置 all_proxy (如 SOCKS) 时也能提示
+	var conflicting []string
+	for _, entry := range []struct{ label, value string }{
+		{"http", persisted.HTTPProxy},
+		{"https", persisted.HTTPSProxy},
+		{"all", persisted.AllProxy},
+	} {
+		if entry.value != "" && entry.value != ephemeralProxy {
+			conflicting = append(conflicting, fmt.Sprintf("%s: %s", entry.label, entry.value))
+		}
+	}
+	if len(conflicting) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("⚠️  --proxy %s 与 settings.json 中的代理 (%s) 不一致，本次启动以 --proxy 为准",
+		ephemeralProxy, strings.Join(conflicting, ", "))
+}
+
+// getAPIKey 获取 API 密钥，优先使用命令行参数，其次是提供商的环境变量（如 DEEPSEEK_API_KEY，
+// 仅默认配置名），最后是指定配置名下存储的密钥
+func getAPIKey(claudeDir string, providerType claude.ProviderType, profile, cmdAPIKey string) (string, error) {
 	if cmdAPIKey != "" {
 		return cmdAPIKey, nil
 	}
 
-	return loadStoredAPIKey(claudeDir, providerType)
+	return loadStoredAPIKey(claudeDir, providerType, profile)
 }
 
 // buildProviderEnvVars 构建 provider 的环境变量配置
 func buildProviderEnvVars(providerType claude.ProviderType, apiKey, model string) (map[string]string, error) {
 	// 获取 provider 配置
-	prov := getProvider(providerType)
+	prov, err := getProvider(providerType)
+	if err != nil {
+		return nil, err
+	}
 	providerConfig := prov.GetDefaultConfig(apiKey)
 
 	// 应用命令行参数覆盖
 	if model != "" {
+		if !aiprovider.IsSupportedModel(prov, model) {
+			return nil, fmt.Errorf("unsupported model %q for provider %s, valid models: %s",
+				model, providerType, strings.Join(prov.SupportedModels(), ", "))
+		}
 		providerConfig.Model = model
 		providerConfig.SmallFastModel = model
 	}
@@ -3,14 +3,30 @@ package main
 import (
 	"bytes"
 	"context"
+	"io"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/ooneko/claude-config/internal/aiprovider"
+	"github.com/ooneko/claude-config/internal/claude"
 )
 
+// useClaudeDir points the claudeDir global at dir for the rest of the test
+func useClaudeDir(t *testing.T, dir string) {
+	t.Helper()
+
+	origDir := claudeDir
+	claudeDir = dir
+	t.Cleanup(func() { claudeDir = origDir })
+}
+
 func TestCreateStartCmd(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -30,6 +46,7 @@ func TestCreateStartCmd(t *testing.T) {
 				tempDir := t.TempDir()
 				originalHome := os.Getenv("HOME")
 				os.Setenv("HOME", tempDir)
+				useClaudeDir(t, filepath.Join(tempDir, ".claude"))
 
 				claudeDir := tempDir + "/.claude"
 				err := os.MkdirAll(claudeDir, 0755)
@@ -46,12 +63,13 @@ func TestCreateStartCmd(t *testing.T) {
 		},
 		{
 			name:    "valid Kimi provider with custom model",
-			args:    []string{"start", "kimi", "--model", "kimi-plus"},
+			args:    []string{"start", "kimi", "--model", "kimi-k2-turbo-preview"},
 			wantErr: false,
 			setup: func() func() {
 				tempDir := t.TempDir()
 				originalHome := os.Getenv("HOME")
 				os.Setenv("HOME", tempDir)
+				useClaudeDir(t, filepath.Join(tempDir, ".claude"))
 
 				claudeDir := tempDir + "/.claude"
 				err := os.MkdirAll(claudeDir, 0755)
@@ -74,6 +92,7 @@ func TestCreateStartCmd(t *testing.T) {
 				tempDir := t.TempDir()
 				originalHome := os.Getenv("HOME")
 				os.Setenv("HOME", tempDir)
+				useClaudeDir(t, filepath.Join(tempDir, ".claude"))
 
 				claudeDir := tempDir + "/.claude"
 				err := os.MkdirAll(claudeDir, 0755)
@@ -92,6 +111,7 @@ func TestCreateStartCmd(t *testing.T) {
 				tempDir := t.TempDir()
 				originalHome := os.Getenv("HOME")
 				os.Setenv("HOME", tempDir)
+				useClaudeDir(t, filepath.Join(tempDir, ".claude"))
 
 				claudeDir := tempDir + "/.claude"
 				err := os.MkdirAll(claudeDir, 0755)
@@ -128,6 +148,7 @@ func TestCreateStartCmd(t *testing.T) {
 				tempDir := t.TempDir()
 				originalHome := os.Getenv("HOME")
 				os.Setenv("HOME", tempDir)
+				useClaudeDir(t, filepath.Join(tempDir, ".claude"))
 
 				claudeDir := tempDir + "/.claude"
 				err := os.MkdirAll(claudeDir, 0755)
@@ -262,6 +283,7 @@ func TestStartNativeClaude(t *testing.T) {
 			tempDir := t.TempDir()
 			originalHome := os.Getenv("HOME")
 			os.Setenv("HOME", tempDir)
+			useClaudeDir(t, filepath.Join(tempDir, ".claude"))
 			defer func() {
 				os.Setenv("HOME", originalHome)
 			}()
@@ -405,6 +427,7 @@ func TestPassthroughArgs(t *testing.T) {
 				tempDir := t.TempDir()
 				originalHome := os.Getenv("HOME")
 				os.Setenv("HOME", tempDir)
+				useClaudeDir(t, filepath.Join(tempDir, ".claude"))
 
 				claudeDir := tempDir + "/.claude"
 				err := os.MkdirAll(claudeDir, 0755)
@@ -428,6 +451,7 @@ func TestPassthroughArgs(t *testing.T) {
 				tempDir := t.TempDir()
 				originalHome := os.Getenv("HOME")
 				os.Setenv("HOME", tempDir)
+				useClaudeDir(t, filepath.Join(tempDir, ".claude"))
 
 				claudeDir := tempDir + "/.claude"
 				err := os.MkdirAll(claudeDir, 0755)
@@ -451,6 +475,7 @@ func TestPassthroughArgs(t *testing.T) {
 				tempDir := t.TempDir()
 				originalHome := os.Getenv("HOME")
 				os.Setenv("HOME", tempDir)
+				useClaudeDir(t, filepath.Join(tempDir, ".claude"))
 
 				claudeDir := tempDir + "/.claude"
 				err := os.MkdirAll(claudeDir, 0755)
@@ -474,6 +499,7 @@ func TestPassthroughArgs(t *testing.T) {
 				tempDir := t.TempDir()
 				originalHome := os.Getenv("HOME")
 				os.Setenv("HOME", tempDir)
+				useClaudeDir(t, filepath.Join(tempDir, ".claude"))
 
 				claudeDir := tempDir + "/.claude"
 				err := os.MkdirAll(claudeDir, 0755)
@@ -493,6 +519,7 @@ func TestPassthroughArgs(t *testing.T) {
 				tempDir := t.TempDir()
 				originalHome := os.Getenv("HOME")
 				os.Setenv("HOME", tempDir)
+				useClaudeDir(t, filepath.Join(tempDir, ".claude"))
 
 				claudeDir := tempDir + "/.claude"
 				err := os.MkdirAll(claudeDir, 0755)
@@ -518,8 +545,7 @@ func TestPassthroughArgs(t *testing.T) {
 			}
 
 			// 设置 mock 命令来验证透传的参数
-			os.Setenv("CLAUDE_MOCK", "echo")
-			defer os.Unsetenv("CLAUDE_MOCK")
+			childEnv := mockClaude(t)
 
 			cmd := createStartCmd()
 			cmd.SetArgs(tt.args)
@@ -542,8 +568,8 @@ func TestPassthroughArgs(t *testing.T) {
 			} else {
 				assert.NoError(t, err)
 				// 验证透传的参数被正确处理
-				// 这里我们检查环境变量 CLAUDE_PASSTHROUGH_ARGS 是否包含期望的参数
-				passthroughArgs := os.Getenv("CLAUDE_PASSTHROUGH_ARGS")
+				// 这里我们检查子进程的环境变量 CLAUDE_PASSTHROUGH_ARGS 是否包含期望的参数
+				passthroughArgs := childEnv()["CLAUDE_PASSTHROUGH_ARGS"]
 				if len(tt.wantArgs) > 0 {
 					for _, arg := range tt.wantArgs {
 						assert.Contains(t, passthroughArgs, arg)
@@ -553,3 +579,611 @@ func TestPassthroughArgs(t *testing.T) {
 		})
 	}
 }
+
+// TestStartNativeAlias 测试 native/claude 别名与无参数启动行为一致
+func TestStartNativeAlias(t *testing.T) {
+	for _, alias := range []string{"native", "claude", "NATIVE"} {
+		t.Run(alias, func(t *testing.T) {
+			tempDir := t.TempDir()
+			originalHome := os.Getenv("HOME")
+			os.Setenv("HOME", tempDir)
+			useClaudeDir(t, filepath.Join(tempDir, ".claude"))
+			defer func() {
+				os.Setenv("HOME", originalHome)
+			}()
+
+			claudeDir := tempDir + "/.claude"
+			err := os.MkdirAll(claudeDir, 0755)
+			require.NoError(t, err)
+
+			settingsContent := `{
+  "env": {
+    "ANTHROPIC_AUTH_TOKEN": "old-token",
+    "ANTHROPIC_BASE_URL": "old-url",
+    "OTHER_VAR": "other-value"
+  }
+}`
+			err = os.WriteFile(claudeDir+"/settings.json", []byte(settingsContent), 0644)
+			require.NoError(t, err)
+
+			os.Setenv("CLAUDE_MOCK", "echo")
+			defer os.Unsetenv("CLAUDE_MOCK")
+
+			cmd := createStartCmd()
+			cmd.SetArgs([]string{alias})
+
+			var buf bytes.Buffer
+			cmd.SetOut(&buf)
+			cmd.SetErr(&buf)
+
+			err = cmd.Execute()
+			require.NoError(t, err)
+
+			content, err := os.ReadFile(claudeDir + "/settings.json")
+			require.NoError(t, err)
+			assert.NotContains(t, string(content), "ANTHROPIC_AUTH_TOKEN")
+			assert.NotContains(t, string(content), "ANTHROPIC_BASE_URL")
+			assert.Contains(t, string(content), "OTHER_VAR")
+		})
+	}
+}
+
+func TestParseProviderFromArg(t *testing.T) {
+	tests := []struct {
+		name    string
+		arg     string
+		want    string
+		wantErr bool
+	}{
+		{name: "native alias", arg: "native", want: ""},
+		{name: "anthropic provider", arg: "Anthropic", want: "anthropic"},
+		{name: "claude alias", arg: "claude", want: ""},
+		{name: "regular provider", arg: "deepseek", want: "deepseek"},
+		{name: "unknown provider", arg: "unknown", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseProviderFromArg(tt.arg)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestProxyConflictWarning(t *testing.T) {
+	tests := []struct {
+		name        string
+		settings    string
+		proxy       string
+		wantWarning string
+	}{
+		{name: "no settings file", proxy: "http://127.0.0.1:7890"},
+		{name: "no persisted proxy", settings: `{"env": {"OTHER_VAR": "x"}}`, proxy: "http://127.0.0.1:7890"},
+		{
+			name:     "same proxy",
+			settings: `{"env": {"http_proxy": "http://127.0.0.1:7890", "https_proxy": "http://127.0.0.1:7890"}}`,
+			proxy:    "http://127.0.0.1:7890",
+		},
+		{
+			name:        "different proxy",
+			settings:    `{"env": {"http_proxy": "http://10.0.0.1:8080", "https_proxy": "http://10.0.0.1:8080"}}`,
+			proxy:       "http://127.0.0.1:7890",
+			wantWarning: "http: http://10.0.0.1:8080, https: http://10.0.0.1:8080",
+		},
+		{
+			name:        "all_proxy only",
+			settings:    `{"env": {"all_proxy": "socks5://10.0.0.1:1080"}}`,
+			proxy:       "http://127.0.0.1:7890",
+			wantWarning: "(all: socks5://10.0.0.1:1080)",
+		},
+		{
+			name:     "same all_proxy",
+			settings: `{"env": {"all_proxy": "socks5://127.0.0.1:1080"}}`,
+			proxy:    "socks5://127.0.0.1:1080",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claudeDir := t.TempDir()
+			if tt.settings != "" {
+				require.NoError(t, os.WriteFile(claudeDir+"/settings.json", []byte(tt.settings), 0644))
+			}
+
+			warning := proxyConflictWarning(claudeDir, tt.proxy)
+			if tt.wantWarning != "" {
+				assert.Contains(t, warning, tt.proxy)
+				assert.Contains(t, warning, tt.wantWarning)
+			} else {
+				assert.Empty(t, warning)
+			}
+		})
+	}
+}
+
+func TestGetProvider_MatchesManagerRegistry(t *testing.T) {
+	mgr := aiprovider.NewManager(t.TempDir())
+	ctx := context.Background()
+
+	for _, providerType := range mgr.ListSupportedProviders() {
+		t.Run(string(providerType), func(t *testing.T) {
+			prov, err := getProvider(providerType)
+			require.NoError(t, err, "start cannot launch a provider the manager supports")
+			require.NotNil(t, prov)
+
+			apiKey := "test-key"
+			if providerType == claude.ProviderAnthropic {
+				apiKey = "sk-ant-test-key"
+			}
+			require.NoError(t, mgr.Enable(ctx, providerType, apiKey))
+			active, err := mgr.GetActiveProvider(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, providerType, active)
+		})
+	}
+
+	assert.Contains(t, mgr.ListSupportedProviders(), claude.ProviderDoubao)
+}
+
+// TestGetProvider_Unknown 测试 providers.json 中已不存在的 provider 返回错误而不是 nil
+func TestGetProvider_Unknown(t *testing.T) {
+	useClaudeDir(t, t.TempDir())
+
+	_, err := getProvider("removed-host")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "removed-host")
+
+	_, err = buildProviderEnvVars("removed-host", "sk-test", "")
+	require.Error(t, err)
+}
+
+func TestBuildProviderEnvVars_ModelValidation(t *testing.T) {
+	envVars, err := buildProviderEnvVars(claude.ProviderDeepSeek, "sk-test", "deepseek-reasoner")
+	require.NoError(t, err)
+	assert.Equal(t, "deepseek-reasoner", envVars["ANTHROPIC_DEFAULT_SONNET_MODEL"])
+
+	_, err = buildProviderEnvVars(claude.ProviderDeepSeek, "sk-test", "gpt-4")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "gpt-4")
+	assert.Contains(t, err.Error(), "deepseek-chat")
+	assert.Contains(t, err.Error(), "deepseek-reasoner")
+}
+
+// TestStartGLMModel 测试 GLM 的 --model 会写入所有默认模型环境变量
+func TestStartGLMModel(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("HOME", tempDir)
+	claudeDir := filepath.Join(tempDir, ".claude")
+	useClaudeDir(t, claudeDir)
+	childEnv := mockClaude(t)
+
+	cmd := createStartCmd()
+	cmd.SetArgs([]string{"glm", "--api-key", "sk-glm789", "--model", "glm-4.6"})
+	cmd.SetOut(&bytes.Buffer{})
+	require.NoError(t, cmd.Execute())
+
+	env := childEnv()
+	for _, key := range []string{"ANTHROPIC_DEFAULT_HAIKU_MODEL", "ANTHROPIC_DEFAULT_SONNET_MODEL", "ANTHROPIC_DEFAULT_OPUS_MODEL"} {
+		assert.Equal(t, "glm-4.6", env[key], key)
+	}
+}
+
+func TestStartDefaultProvider(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("HOME", tempDir)
+	useClaudeDir(t, filepath.Join(tempDir, ".claude"))
+	childEnv := mockClaude(t)
+
+	claudeDir := tempDir + "/.claude"
+	require.NoError(t, os.MkdirAll(claudeDir, 0755))
+	require.NoError(t, os.WriteFile(claudeDir+"/.deepseek_api_key", []byte("sk-test123"), 0600))
+
+	run := func(args ...string) {
+		cmd := createStartCmd()
+		cmd.SetArgs(args)
+		cmd.SetOut(&bytes.Buffer{})
+		require.NoError(t, cmd.Execute())
+	}
+
+	// 设置默认 provider
+	run("--default-provider", "deepseek")
+	assert.Equal(t, "https://api.deepseek.com/anthropic", childEnv()["ANTHROPIC_BASE_URL"])
+
+	// 无参数启动使用默认 provider
+	run()
+	assert.Equal(t, "https://api.deepseek.com/anthropic", childEnv()["ANTHROPIC_BASE_URL"])
+
+	// 显式 native 覆盖默认 provider
+	run("native")
+	assert.NotContains(t, childEnv(), "ANTHROPIC_BASE_URL")
+
+	// native 作为默认值表示清除
+	run("--default-provider", "native")
+	providerArg, err := defaultProviderArg(claudeDir)
+	require.NoError(t, err)
+	assert.Empty(t, providerArg)
+}
+
+// TestStartAnthropic 测试 start anthropic 使用 anthropic provider 启动，而不是清理配置的原生启动
+func TestStartAnthropic(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("HOME", tempDir)
+	claudeDir := filepath.Join(tempDir, ".claude")
+	useClaudeDir(t, claudeDir)
+	childEnv := mockClaude(t)
+
+	ctx := context.Background()
+	mgr := aiprovider.NewManager(claudeDir)
+	require.NoError(t, mgr.Enable(ctx, claude.ProviderAnthropic, "sk-ant-test"))
+
+	cmd := createStartCmd()
+	cmd.SetArgs([]string{"anthropic"})
+	cmd.SetOut(&bytes.Buffer{})
+	require.NoError(t, cmd.Execute())
+
+	env := childEnv()
+	assert.Equal(t, "sk-ant-test", env["ANTHROPIC_AUTH_TOKEN"])
+	assert.Equal(t, "https://api.anthropic.com", env["ANTHROPIC_BASE_URL"])
+
+	// ai on anthropic 保存的配置保持不变
+	active, err := mgr.GetActiveProvider(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, claude.ProviderAnthropic, active)
+}
+
+func TestStartBaseURLOverride(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("HOME", tempDir)
+	claudeDir := filepath.Join(tempDir, ".claude")
+	useClaudeDir(t, claudeDir)
+	childEnv := mockClaude(t)
+
+	ctx := context.Background()
+	mgr := aiprovider.NewManager(claudeDir)
+	require.NoError(t, mgr.Enable(ctx, claude.ProviderDoubao, "doubao-key"))
+	require.NoError(t, mgr.SetBaseURL(ctx, claude.ProviderDoubao, "https://staging.example.com/anthropic"))
+
+	run := func(args ...string) map[string]string {
+		cmd := createStartCmd()
+		cmd.SetArgs(args)
+		cmd.SetOut(&bytes.Buffer{})
+		require.NoError(t, cmd.Execute())
+		return childEnv()
+	}
+
+	// ai on --base-url 保存的覆盖地址与 ai on 一样生效
+	env := run("doubao")
+	assert.Equal(t, "https://staging.example.com/anthropic", env["ANTHROPIC_BASE_URL"])
+
+	// 命令行指定的接入点优先
+	env = run("doubao", "--endpoint", "general")
+	assert.Equal(t, (&aiprovider.DoubaoProvider{}).Endpoints()[aiprovider.DoubaoEndpointGeneral], env["ANTHROPIC_BASE_URL"])
+}
+
+func TestStartLast(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("HOME", tempDir)
+	useClaudeDir(t, filepath.Join(tempDir, ".claude"))
+	childEnv := mockClaude(t)
+
+	claudeDir := tempDir + "/.claude"
+	require.NoError(t, os.MkdirAll(claudeDir, 0755))
+
+	run := func(args ...string) error {
+		cmd := createStartCmd()
+		cmd.SetArgs(args)
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&bytes.Buffer{})
+		return cmd.Execute()
+	}
+
+	// 没有保存过 provider
+	err := run("--last")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "没有找到之前的AI提供商配置")
+
+	ctx := context.Background()
+	mgr := aiprovider.NewManager(claudeDir)
+	require.NoError(t, mgr.Enable(ctx, claude.ProviderKimi, "sk-last123"))
+	require.NoError(t, mgr.Off(ctx))
+
+	require.NoError(t, run("--last"))
+	env := childEnv()
+	assert.Equal(t, "sk-last123", env["ANTHROPIC_AUTH_TOKEN"])
+	assert.Equal(t, "https://api.kimi.com/coding/", env["ANTHROPIC_BASE_URL"])
+
+	// 只用于启动，不会重新启用 settings.json 中的配置
+	active, err := mgr.GetActiveProvider(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, claude.ProviderNone, active)
+
+	err = run("--last", "glm")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--last")
+
+	// 密钥丢失
+	require.NoError(t, os.Remove(filepath.Join(claudeDir, ".kimi_api_key")))
+	err = run("--last")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "API密钥已丢失")
+}
+
+// TestStartKeepsParentEnv 测试 start 只设置子进程的环境变量，不修改当前进程
+func TestStartKeepsParentEnv(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("HOME", tempDir)
+	useClaudeDir(t, filepath.Join(tempDir, ".claude"))
+	t.Setenv("ANTHROPIC_DEFAULT_OPUS_MODEL", "parent-model")
+	childEnv := mockClaude(t)
+
+	claudeDir := tempDir + "/.claude"
+	require.NoError(t, os.MkdirAll(claudeDir, 0755))
+	require.NoError(t, os.WriteFile(claudeDir+"/.kimi_api_key", []byte("sk-test123"), 0600))
+
+	parentEnv := os.Environ()
+
+	cmd := createStartCmd()
+	cmd.SetArgs([]string{"kimi", "--proxy", "http://127.0.0.1:7890", "--", "--verbose"})
+	cmd.SetOut(&bytes.Buffer{})
+	require.NoError(t, cmd.Execute())
+
+	assert.Equal(t, parentEnv, os.Environ(), "start 不应修改当前进程的环境变量")
+
+	env := childEnv()
+	assert.Equal(t, "sk-test123", env["ANTHROPIC_AUTH_TOKEN"])
+	assert.Equal(t, "http://127.0.0.1:7890", env["https_proxy"])
+	assert.Equal(t, "--verbose", env["CLAUDE_PASSTHROUGH_ARGS"])
+	assert.Equal(t, tempDir, env["HOME"], "子进程应继承当前进程的环境变量")
+
+	// 原生启动时子进程不继承 ANTHROPIC 相关变量，当前进程保持不变
+	cmd = createStartCmd()
+	cmd.SetArgs([]string{"native"})
+	cmd.SetOut(&bytes.Buffer{})
+	require.NoError(t, cmd.Execute())
+
+	assert.NotContains(t, childEnv(), "ANTHROPIC_DEFAULT_OPUS_MODEL")
+	assert.Equal(t, "parent-model", os.Getenv("ANTHROPIC_DEFAULT_OPUS_MODEL"))
+}
+
+// TestStartPrintEnv 测试 --print-env 只打印环境变量，不启动 Claude Code
+func TestStartPrintEnv(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("HOME", tempDir)
+	useClaudeDir(t, filepath.Join(tempDir, ".claude"))
+	t.Setenv("CLAUDE_MOCK", "/nonexistent/claude")
+
+	claudeDir := tempDir + "/.claude"
+	require.NoError(t, os.MkdirAll(claudeDir, 0755))
+	require.NoError(t, os.WriteFile(claudeDir+"/.kimi_api_key", []byte("sk-test123456"), 0600))
+
+	output := captureStdout(t, func() {
+		cmd := createStartCmd()
+		cmd.SetArgs([]string{"kimi", "--proxy", "http://127.0.0.1:7890", "--print-env"})
+		require.NoError(t, cmd.Execute())
+	})
+	assert.Contains(t, output, "ANTHROPIC_AUTH_TOKEN=*********3456\n")
+	assert.NotContains(t, output, "sk-test123456")
+	assert.Contains(t, output, "ANTHROPIC_BASE_URL=https://api.kimi.com/coding/\n")
+	assert.Contains(t, output, "https_proxy=http://127.0.0.1:7890\n")
+	assert.Contains(t, output, "all_proxy=http://127.0.0.1:7890\n")
+	assert.Less(t, strings.Index(output, "ANTHROPIC_AUTH_TOKEN"), strings.Index(output, "ANTHROPIC_BASE_URL"))
+
+	output = captureStdout(t, func() {
+		cmd := createStartCmd()
+		cmd.SetArgs([]string{"native", "--print-env"})
+		require.NoError(t, cmd.Execute())
+	})
+	assert.Contains(t, output, "不设置 provider 环境变量")
+
+	// --default-provider 只用于本次预览，不保存
+	output = captureStdout(t, func() {
+		cmd := createStartCmd()
+		cmd.SetArgs([]string{"--default-provider", "kimi", "--print-env"})
+		require.NoError(t, cmd.Execute())
+	})
+	assert.Contains(t, output, "ANTHROPIC_BASE_URL=https://api.kimi.com/coding/\n")
+	assert.NotContains(t, output, "默认 provider 已设置")
+	providerArg, err := defaultProviderArg(claudeDir)
+	require.NoError(t, err)
+	assert.Empty(t, providerArg)
+}
+
+func TestMaskSecret(t *testing.T) {
+	assert.Equal(t, "*******5678", maskSecret("sk-12345678"))
+	assert.Equal(t, "***", maskSecret("abc"))
+	assert.Equal(t, "", maskSecret(""))
+}
+
+func TestChildEnv(t *testing.T) {
+	environ := []string{"PATH=/usr/bin", "ANTHROPIC_BASE_URL=old", "ANTHROPIC_AUTH_TOKEN=old-token", "OTHER=1"}
+
+	env := childEnv(environ, map[string]string{"ANTHROPIC_BASE_URL": "new", "NEW": "2"}, []string{"ANTHROPIC_AUTH_TOKEN"})
+	assert.Equal(t, []string{"PATH=/usr/bin", "OTHER=1", "ANTHROPIC_BASE_URL=new", "NEW=2"}, env)
+	assert.Len(t, environ, 4, "不应修改传入的环境")
+}
+
+// captureStdout 返回 fn 执行期间写入标准输出的内容
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+
+	reader, writer, err := os.Pipe()
+	require.NoError(t, err)
+
+	stdout := os.Stdout
+	os.Stdout = writer
+	defer func() { os.Stdout = stdout }()
+
+	done := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(reader)
+		done <- string(data)
+	}()
+
+	fn()
+	require.NoError(t, writer.Close())
+	return <-done
+}
+
+// mockClaude 将 CLAUDE_MOCK 设置为记录环境变量的脚本，返回读取最近一次启动时子进程环境的函数
+func mockClaude(t *testing.T) func() map[string]string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("mock claude script requires a POSIX shell")
+	}
+
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, "env.out")
+	script := filepath.Join(dir, "claude-mock")
+	require.NoError(t, os.WriteFile(script, []byte("#!/bin/sh\nenv > '"+envFile+"'\n"), 0755))
+	t.Setenv("CLAUDE_MOCK", script)
+
+	return func() map[string]string {
+		data, err := os.ReadFile(envFile)
+		require.NoError(t, err)
+
+		env := make(map[string]string)
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			if key, value, ok := strings.Cut(line, "="); ok {
+				env[key] = value
+			}
+		}
+		return env
+	}
+}
+
+func TestGetAPIKey_Profile(t *testing.T) {
+	claudeDir := t.TempDir()
+	require.NoError(t, os.WriteFile(claudeDir+"/.deepseek_api_key", []byte("sk-personal\n"), 0600))
+	require.NoError(t, os.WriteFile(claudeDir+"/.deepseek.work_api_key", []byte("sk-work\n"), 0600))
+
+	apiKey, err := getAPIKey(claudeDir, claude.ProviderDeepSeek, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-personal", apiKey)
+
+	apiKey, err = getAPIKey(claudeDir, claude.ProviderDeepSeek, "work", "")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-work", apiKey)
+
+	// 命令行密钥优先于配置名
+	apiKey, err = getAPIKey(claudeDir, claude.ProviderDeepSeek, "work", "sk-flag")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-flag", apiKey)
+
+	_, err = getAPIKey(claudeDir, claude.ProviderDeepSeek, "missing", "")
+	assert.ErrorContains(t, err, "profile missing")
+}
+
+func TestGetAPIKey_Env(t *testing.T) {
+	claudeDir := t.TempDir()
+	t.Setenv("DEEPSEEK_API_KEY", "sk-env")
+
+	// 没有密钥文件时使用环境变量
+	apiKey, err := getAPIKey(claudeDir, claude.ProviderDeepSeek, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-env", apiKey)
+
+	// 环境变量优先于密钥文件，命令行密钥优先于环境变量
+	require.NoError(t, os.WriteFile(claudeDir+"/.deepseek_api_key", []byte("sk-file"), 0600))
+	apiKey, err = getAPIKey(claudeDir, claude.ProviderDeepSeek, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-env", apiKey)
+
+	apiKey, err = getAPIKey(claudeDir, claude.ProviderDeepSeek, "", "sk-flag")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-flag", apiKey)
+
+	// 配置名不读取环境变量
+	_, err = getAPIKey(claudeDir, claude.ProviderDeepSeek, "work", "")
+	assert.ErrorContains(t, err, "profile work")
+}
+
+func TestSelectedEndpointURL(t *testing.T) {
+	claudeDir := t.TempDir()
+	endpoints := (&aiprovider.DoubaoProvider{}).Endpoints()
+
+	baseURL, err := selectedEndpointURL(claudeDir, claude.ProviderDoubao, "")
+	require.NoError(t, err)
+	assert.Equal(t, endpoints[aiprovider.DoubaoEndpointCoding], baseURL)
+
+	mgr := aiprovider.NewManager(claudeDir)
+	require.NoError(t, mgr.SetEndpoint(context.Background(), claude.ProviderDoubao, aiprovider.DoubaoEndpointGeneral))
+
+	baseURL, err = selectedEndpointURL(claudeDir, claude.ProviderDoubao, "")
+	require.NoError(t, err)
+	assert.Equal(t, endpoints[aiprovider.DoubaoEndpointGeneral], baseURL)
+
+	// 命令行指定的接入点优先于已保存的选择
+	baseURL, err = selectedEndpointURL(claudeDir, claude.ProviderDoubao, aiprovider.DoubaoEndpointCoding)
+	require.NoError(t, err)
+	assert.Equal(t, endpoints[aiprovider.DoubaoEndpointCoding], baseURL)
+
+	baseURL, err = selectedEndpointURL(claudeDir, claude.ProviderDeepSeek, "")
+	require.NoError(t, err)
+	assert.Empty(t, baseURL)
+
+	_, err = selectedEndpointURL(claudeDir, claude.ProviderDeepSeek, "general")
+	assert.Error(t, err)
+}
+
+func TestGetAPIKey_CRLF(t *testing.T) {
+	claudeDir := t.TempDir()
+	// Notepad 保存的文件：带 BOM 和 CRLF 换行
+	require.NoError(t, os.WriteFile(claudeDir+"/.deepseek_api_key", []byte("\ufeffsk-windows\r\n"), 0600))
+
+	apiKey, err := getAPIKey(claudeDir, claude.ProviderDeepSeek, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-windows", apiKey)
+}
+
+func TestResolveClaudeBin(t *testing.T) {
+	binDir := t.TempDir()
+	custom := filepath.Join(binDir, "claude-custom")
+	require.NoError(t, os.WriteFile(custom, []byte("#!/bin/sh\n"), 0755))
+	fromEnv := filepath.Join(binDir, "claude-env")
+	require.NoError(t, os.WriteFile(fromEnv, []byte("#!/bin/sh\n"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "claude"), []byte("#!/bin/sh\n"), 0755))
+
+	t.Setenv("PATH", binDir)
+	t.Setenv("CLAUDE_BIN", "")
+
+	// 默认从 PATH 中查找 claude
+	bin, err := resolveClaudeBin("")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(binDir, "claude"), bin)
+
+	// CLAUDE_BIN 覆盖默认值，可以是 PATH 中的名称
+	t.Setenv("CLAUDE_BIN", "claude-env")
+	bin, err = resolveClaudeBin("")
+	require.NoError(t, err)
+	assert.Equal(t, fromEnv, bin)
+
+	// --claude-bin 优先于 CLAUDE_BIN
+	bin, err = resolveClaudeBin(custom)
+	require.NoError(t, err)
+	assert.Equal(t, custom, bin)
+
+	_, err = resolveClaudeBin(filepath.Join(binDir, "missing"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--claude-bin")
+}
+
+func TestStartClaudeBinNotFound(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("HOME", tempDir)
+	useClaudeDir(t, filepath.Join(tempDir, ".claude"))
+	t.Setenv("CLAUDE_MOCK", "")
+
+	cmd := createStartCmd()
+	cmd.SetArgs([]string{"--claude-bin", "/nonexistent/claude"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "/nonexistent/claude")
+}
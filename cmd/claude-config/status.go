@@ -2,59 +2,163 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 )
 
+// clearScreenSeq 清屏并将光标移动到左上角
+const clearScreenSeq = "\033[H\033[2J"
+
 // createStatusCmd creates the status command
 func createStatusCmd() *cobra.Command {
+	var watch, jsonOutput bool
+	var interval time.Duration
+
 	statusCmd := &cobra.Command{
 		Use:   "status",
 		Short: "显示当前配置状态",
-		Long:  `显示代理、检查功能和通知的当前状态`,
+		Long: `显示代理、检查功能和通知的当前状态
+
+使用 --watch 按固定间隔刷新状态（非终端环境下只输出一次）。
+使用 --json 以JSON格式输出配置状态，便于脚本和监控面板读取。`,
+		Example: `  claude-config status
+  claude-config status --json
+  claude-config status --watch
+  claude-config status --watch --interval 5s`,
 		RunE: func(_ *cobra.Command, _ []string) error {
-			return showStatus()
+			if jsonOutput {
+				if watch {
+					return fmt.Errorf("--json 不能与 --watch 一起使用")
+				}
+				return printStatusJSON(os.Stdout)
+			}
+			if watch {
+				return watchStatus(interval)
+			}
+			return showStatus(os.Stdout)
 		},
 	}
 
+	statusCmd.Flags().BoolVarP(&watch, "watch", "w", false, "持续刷新显示状态")
+	statusCmd.Flags().BoolVar(&jsonOutput, "json", false, "以JSON格式输出配置状态")
+	statusCmd.Flags().DurationVar(&interval, "interval", 2*time.Second, "--watch 模式下的刷新间隔")
+
 	return statusCmd
 }
 
+// printStatusJSON writes the configuration status as JSON
+func printStatusJSON(w io.Writer) error {
+	status, err := configMgr.GetStatus(context.Background())
+	if err != nil {
+		return fmt.Errorf("获取配置状态失败: %w", err)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(status)
+}
+
+// watchStatus redraws the status summary until interrupted
+func watchStatus(interval time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf("刷新间隔必须大于0: %s", interval)
+	}
+
+	// 非终端环境下不清屏刷新，只输出一次
+	if !isTerminal(os.Stdout) {
+		return showStatus(os.Stdout)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := renderStatusFrame(os.Stdout, time.Now(), interval); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			fmt.Println()
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// renderStatusFrame renders a single --watch iteration
+func renderStatusFrame(w io.Writer, now time.Time, interval time.Duration) error {
+	fmt.Fprint(w, clearScreenSeq)
+	fmt.Fprintf(w, "刷新时间: %s (每 %s 刷新，Ctrl+C 退出)\n\n", now.Format("2006-01-02 15:04:05"), interval)
+	return showStatus(w)
+}
+
 // showStatus displays the current status of all services
-func showStatus() error {
+func showStatus(w io.Writer) error {
 	ctx := context.Background()
 
-	fmt.Println("Claude 配置状态:")
-	fmt.Println("================")
-	fmt.Println()
+	fmt.Fprintln(w, "Claude 配置状态:")
+	fmt.Fprintln(w, "================")
+	fmt.Fprintln(w)
+
+	// Show files merged in through "includes"
+	if err := showIncludesStatus(ctx, w); err != nil {
+		fmt.Fprintf(w, "❌ 引用配置加载失败: %v\n", err)
+		fmt.Fprintln(w)
+	}
 
 	// Check proxy status
-	if err := showProxyStatus(ctx); err != nil {
-		fmt.Printf("❌ 代理状态检查失败: %v\n", err)
+	if err := showProxyStatus(ctx, w); err != nil {
+		fmt.Fprintf(w, "❌ 代理状态检查失败: %v\n", err)
 	}
-	fmt.Println()
+	fmt.Fprintln(w)
 
 	// Check hooks/check status
-	if err := showCheckStatus(ctx); err != nil {
-		fmt.Printf("❌ 检查功能状态检查失败: %v\n", err)
+	if err := showCheckStatus(ctx, w); err != nil {
+		fmt.Fprintf(w, "❌ 检查功能状态检查失败: %v\n", err)
 	}
-	fmt.Println()
+	fmt.Fprintln(w)
 
 	// Check notify status
-	if err := showNotifyStatus(ctx); err != nil {
-		fmt.Printf("❌ 通知状态检查失败: %v\n", err)
+	if err := showNotifyStatus(ctx, w); err != nil {
+		fmt.Fprintf(w, "❌ 通知状态检查失败: %v\n", err)
 	}
-	fmt.Println()
+	fmt.Fprintln(w)
 
 	// Check AI provider status
-	showAIProviderStatus()
+	showAIProviderStatus(w, false)
+
+	return nil
+}
+
+// showIncludesStatus lists the files merged into settings.json through "includes"
+func showIncludesStatus(ctx context.Context, w io.Writer) error {
+	status, err := configMgr.GetStatus(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(status.IncludedFiles) > 0 {
+		fmt.Fprintf(w, "📄 引用的配置文件: %s\n", strings.Join(status.IncludedFiles, ", "))
+		fmt.Fprintln(w)
+	}
 
 	return nil
 }
 
 // showProxyStatus shows the current proxy status
-func showProxyStatus(ctx context.Context) error {
+func showProxyStatus(ctx context.Context, w io.Writer) error {
 	isEnabled, err := proxyMgr.IsEnabled(ctx)
 	if err != nil {
 		return fmt.Errorf("获取代理状态失败: %w", err)
@@ -65,32 +169,32 @@ func showProxyStatus(ctx context.Context) error {
 		if err != nil {
 			return fmt.Errorf("获取代理配置失败: %w", err)
 		}
-		fmt.Printf("🌐 代理状态: ✅ 已启用 (%s)\n", config.HTTPProxy)
+		fmt.Fprintf(w, "🌐 代理状态: ✅ 已启用 (%s)\n", proxyDisplayAddress(config))
 	} else {
-		fmt.Println("🌐 代理状态: ❌ 已禁用")
+		fmt.Fprintln(w, "🌐 代理状态: ❌ 已禁用")
 	}
 
 	return nil
 }
 
 // showCheckStatus shows the current check/hooks status
-func showCheckStatus(ctx context.Context) error {
+func showCheckStatus(ctx context.Context, w io.Writer) error {
 	isEnabled, err := isCheckEnabled(ctx)
 	if err != nil {
 		return fmt.Errorf("获取检查功能状态失败: %w", err)
 	}
 
 	if isEnabled {
-		fmt.Println("🔍 检查功能: ✅ 已启用 ")
+		fmt.Fprintln(w, "🔍 检查功能: ✅ 已启用 ")
 	} else {
-		fmt.Println("🔍 检查功能: ❌ 已禁用")
+		fmt.Fprintln(w, "🔍 检查功能: ❌ 已禁用")
 	}
 
 	return nil
 }
 
 // showNotifyStatus shows the current notify status
-func showNotifyStatus(ctx context.Context) error {
+func showNotifyStatus(ctx context.Context, w io.Writer) error {
 	isEnabled, ntfyTopic, err := isNotifyEnabled(ctx)
 	if err != nil {
 		return fmt.Errorf("获取通知状态失败: %w", err)
@@ -98,59 +202,30 @@ func showNotifyStatus(ctx context.Context) error {
 
 	if isEnabled {
 		if ntfyTopic != "" {
-			fmt.Printf("📱 通知状态: ✅ 已启用 (Topic: %s)\n", ntfyTopic)
+			fmt.Fprintf(w, "📱 通知状态: ✅ 已启用 (Topic: %s)\n", ntfyTopic)
 		} else {
-			fmt.Println("📱 通知状态: ⚠️  hooks已启用但未配置NTFY_TOPIC")
+			fmt.Fprintln(w, "📱 通知状态: ⚠️  hooks已启用但未配置NTFY_TOPIC")
 		}
 	} else {
-		fmt.Println("📱 通知状态: ❌ 已禁用")
+		fmt.Fprintln(w, "📱 通知状态: ❌ 已禁用")
 	}
 
 	return nil
 }
 
-// isCheckEnabled checks if the check functionality is enabled
+// isCheckEnabled checks if the check functionality is enabled in the effective settings
 func isCheckEnabled(ctx context.Context) (bool, error) {
-	settings, err := configMgr.Load(ctx)
+	settings, err := configMgr.LoadEffective(ctx)
 	if err != nil {
 		return false, fmt.Errorf("读取配置失败: %w", err)
 	}
 
-	// Check if PostToolUse hooks exist and contain smart-lint.sh or smart-test.sh
-	if settings.Hooks == nil || settings.Hooks.PostToolUse == nil {
-		return false, nil
-	}
-
-	smartLintCommand := "~/.claude/hooks/smart-lint.sh"
-	smartTestCommand := "~/.claude/hooks/smart-test.sh"
-
-	for _, rule := range settings.Hooks.PostToolUse {
-		if rule.Matcher == "Write|Edit|MultiEdit" {
-			hasSmartLint := false
-			hasSmartTest := false
-
-			for _, hook := range rule.Hooks {
-				if hook.Command == smartLintCommand {
-					hasSmartLint = true
-				}
-				if hook.Command == smartTestCommand {
-					hasSmartTest = true
-				}
-			}
-
-			// Consider enabled if we have at least one of the smart hooks
-			if hasSmartLint || hasSmartTest {
-				return true, nil
-			}
-		}
-	}
-
-	return false, nil
+	return checkMgr.HasCheckHooks(settings.Hooks)
 }
 
 // isNotifyEnabled checks if the notify functionality is enabled
 func isNotifyEnabled(ctx context.Context) (enabled bool, ntfyTopic string, err error) {
-	settings, err := configMgr.Load(ctx)
+	settings, err := configMgr.LoadEffective(ctx)
 	if err != nil {
 		return false, "", fmt.Errorf("读取配置失败: %w", err)
 	}
@@ -165,12 +240,17 @@ func isNotifyEnabled(ctx context.Context) (enabled bool, ntfyTopic string, err e
 		return false, ntfyTopic, nil
 	}
 
-	ntfyCommand := "~/.claude/hooks/ntfy-notifier.sh"
+	// Match the script itself so both the expanded and the legacy ~/.claude form count
+	ntfyScript := "${CLAUDE_DIR}/hooks/ntfy-notifier.sh"
+	scripts := map[string]bool{
+		notifyCommand(ntfyScript):       true,
+		legacyNotifyCommand(ntfyScript): true,
+	}
 
 	for _, rule := range settings.Hooks.Stop {
 		if rule.Matcher == "" { // Empty matcher for stop hooks
 			for _, hook := range rule.Hooks {
-				if hook.Command == ntfyCommand {
+				if fields := strings.Fields(hook.Command); len(fields) > 0 && scripts[fields[0]] {
 					return true, ntfyTopic, nil
 				}
 			}
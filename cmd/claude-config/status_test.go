@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ooneko/claude-config/internal/aiprovider"
+	"github.com/ooneko/claude-config/internal/check"
+	"github.com/ooneko/claude-config/internal/config"
+	"github.com/ooneko/claude-config/internal/proxy"
+)
+
+// useTempManagers points the global managers at a temporary claude directory
+func useTempManagers(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	origDir, origConfig, origProxy, origCheck, origAI := claudeDir, configMgr, proxyMgr, checkMgr, aiProviderMgr
+
+	claudeDir = dir
+	configMgr = config.NewManager(dir)
+	proxyMgr = proxy.NewManager(dir)
+	checkMgr = check.NewManager(dir)
+	aiProviderMgr = aiprovider.NewManager(dir)
+
+	t.Cleanup(func() {
+		claudeDir, configMgr, proxyMgr, checkMgr, aiProviderMgr = origDir, origConfig, origProxy, origCheck, origAI
+	})
+
+	return dir
+}
+
+func TestRenderStatusFrame(t *testing.T) {
+	useTempManagers(t)
+
+	var buf bytes.Buffer
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	err := renderStatusFrame(&buf, now, 2*time.Second)
+	require.NoError(t, err)
+
+	output := buf.String()
+	assert.True(t, strings.HasPrefix(output, clearScreenSeq))
+	assert.Contains(t, output, "2025-01-02 03:04:05")
+	assert.Contains(t, output, "2s")
+	assert.Contains(t, output, "Claude 配置状态:")
+	assert.Contains(t, output, "🌐 代理状态: ❌ 已禁用")
+	assert.Contains(t, output, "🤖 AI提供商状态")
+}
+
+func TestShowStatus(t *testing.T) {
+	dir := useTempManagers(t)
+	settings := `{"env": {"http_proxy": "http://127.0.0.1:7890", "https_proxy": "http://127.0.0.1:7890", "NTFY_TOPIC": "my-topic"}}`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "settings.json"), []byte(settings), 0644))
+
+	var buf bytes.Buffer
+	require.NoError(t, showStatus(&buf))
+
+	output := buf.String()
+	assert.True(t, strings.HasPrefix(output, "Claude 配置状态:\n"))
+	assert.Contains(t, output, "🌐 代理状态: ✅ 已启用 (http://127.0.0.1:7890)")
+	assert.Contains(t, output, "🔍 检查功能: ❌ 已禁用")
+	assert.Contains(t, output, "📱 通知状态: ❌ 已禁用")
+	assert.Contains(t, output, "📍 当前状态: 未启用任何AI提供商")
+}
+
+func TestWatchStatus_InvalidInterval(t *testing.T) {
+	err := watchStatus(0)
+	assert.Error(t, err)
+}
+
+func TestPrintStatusJSON(t *testing.T) {
+	dir := useTempManagers(t)
+
+	var buf bytes.Buffer
+	require.NoError(t, printStatusJSON(&buf))
+
+	var status map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &status))
+	assert.Equal(t, false, status["config_exists"])
+	assert.NotContains(t, status, "proxy_config", "未启用代理时不输出proxy_config")
+
+	settings := `{"env": {"http_proxy": "http://127.0.0.1:7890", "https_proxy": "http://127.0.0.1:7890"}}`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "settings.json"), []byte(settings), 0644))
+
+	buf.Reset()
+	require.NoError(t, printStatusJSON(&buf))
+	status = nil
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &status))
+	assert.Equal(t, true, status["config_exists"])
+	assert.Equal(t, true, status["proxy_enabled"])
+	assert.Equal(t, "http://127.0.0.1:7890", status["proxy_config"].(map[string]interface{})["http_proxy"])
+	assert.Contains(t, status, "hooks_enabled")
+	assert.Contains(t, status, "active_provider")
+	assert.Contains(t, status, "deepseek_enabled")
+}
+
+func TestIsNotifyEnabled(t *testing.T) {
+	dir := useTempManagers(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "settings.json"), []byte(`{"env": {"NTFY_TOPIC": "my-topic"}}`), 0644))
+
+	enabled, topic, err := isNotifyEnabled(context.Background())
+	require.NoError(t, err)
+	assert.False(t, enabled)
+	assert.Equal(t, "my-topic", topic)
+
+	require.NoError(t, enableNTFY(ntfyOptions{}))
+	enabled, _, err = isNotifyEnabled(context.Background())
+	require.NoError(t, err)
+	assert.True(t, enabled)
+
+	// Hooks written by older versions reference ~/.claude
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "settings.json"), []byte(`{"hooks": {"Stop": [{"matcher": "", "hooks": [
+		{"type": "command", "command": "~/.claude/hooks/ntfy-notifier.sh stop"}
+	]}]}}`), 0644))
+	enabled, _, err = isNotifyEnabled(context.Background())
+	require.NoError(t, err)
+	assert.True(t, enabled)
+}
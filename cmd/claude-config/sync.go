@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ooneko/claude-config/internal/reposync"
+)
+
+// createSyncCmd creates the sync command
+func createSyncCmd() *cobra.Command {
+	var repoURL string
+
+	syncCmd := &cobra.Command{
+		Use:   "sync",
+		Short: "从团队模板仓库同步配置",
+		Long: `从 git 模板仓库同步 agents、commands、settings 等配置
+
+首次运行时克隆仓库到缓存目录，之后运行时拉取更新，然后安装到 ~/.claude。
+仓库可以在根目录或 claude-config/ 子目录下存放资源。
+认证使用本机现有的 git 配置；合并 settings.json 时保留本地代理设置并忽略模板中的密钥。`,
+		Example: `  claude-config sync --repo git@github.com:my-org/claude-template.git`,
+		Args:    cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return runSync(repoURL)
+		},
+	}
+
+	syncCmd.Flags().StringVar(&repoURL, "repo", "", "模板仓库地址 (git URL)")
+	_ = syncCmd.MarkFlagRequired("repo")
+
+	return syncCmd
+}
+
+// runSync syncs the template repository into the claude directory
+func runSync(repoURL string) error {
+	ctx := context.Background()
+
+	cacheDir, err := reposync.DefaultCacheDir()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("🔄 正在同步模板仓库 %s...\n", repoURL)
+	result, err := reposync.NewManager(claudeDir, cacheDir).Sync(ctx, repoURL)
+	if err != nil {
+		return fmt.Errorf("同步失败: %w", err)
+	}
+
+	if result.Cloned {
+		fmt.Printf("📥 已克隆到：%s\n", result.CacheDir)
+	} else {
+		fmt.Printf("📥 已更新缓存：%s\n", result.CacheDir)
+	}
+	fmt.Println("✅ 同步完成！")
+	fmt.Printf("配置目录：%s\n", claudeDir)
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package aiprovider
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/ooneko/claude-config/internal/claude"
+)
+
+// CustomProvidersFile is the file in the claude directory declaring extra providers
+const CustomProvidersFile = "providers.json"
+
+// customProvidersConfig is the layout of providers.json
+type customProvidersConfig struct {
+	Providers []*CustomProvider `json:"providers"`
+}
+
+// CustomProvider is a self-hosted or third-party provider declared in
+// providers.json. It must speak the Anthropic API at BaseURL.
+type CustomProvider struct {
+	Name           string `json:"name"`
+	BaseURL        string `json:"base_url"`
+	Model          string `json:"model"`
+	SmallFastModel string `json:"small_fast_model,omitempty"`
+}
+
+// LoadCustomProviders reads and validates providers.json in claudeDir. A
+// missing file declares no providers. Any invalid entry fails the whole file
+// so a typo doesn't silently drop a provider.
+func LoadCustomProviders(claudeDir string) ([]*CustomProvider, error) {
+	path := filepath.Join(claudeDir, CustomProvidersFile)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var config customProvidersConfig
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse %s: unexpected data after the top-level object", path)
+	}
+
+	seen := make(map[string]bool, len(config.Providers))
+	for i, provider := range config.Providers {
+		if provider == nil {
+			return nil, fmt.Errorf("invalid %s: provider #%d is null", path, i+1)
+		}
+		if err := provider.validate(); err != nil {
+			return nil, fmt.Errorf("invalid %s: provider #%d: %w", path, i+1, err)
+		}
+		if seen[provider.Name] {
+			return nil, fmt.Errorf("invalid %s: provider %q is declared twice", path, provider.Name)
+		}
+		seen[provider.Name] = true
+	}
+
+	return config.Providers, nil
+}
+
+// validate checks a providers.json entry
+func (p *CustomProvider) validate() error {
+	if p.Name == "" {
+		return errors.New("name is required")
+	}
+	for _, r := range p.Name {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' && r != '_' {
+			return fmt.Errorf("invalid name %q: only lowercase letters, digits, '-' and '_' are allowed", p.Name)
+		}
+	}
+	if claude.IsBuiltinProviderName(p.Name) {
+		return fmt.Errorf("name %q is taken by a built-in provider", p.Name)
+	}
+	if claude.IsNativeProviderAlias(p.Name) {
+		return fmt.Errorf("name %q is reserved for native Claude Code in start", p.Name)
+	}
+
+	if p.BaseURL == "" {
+		return fmt.Errorf("%s: base_url is required", p.Name)
+	}
+	if err := ValidateBaseURL(p.BaseURL); err != nil {
+		return fmt.Errorf("%s: %w", p.Name, err)
+	}
+	if p.Model == "" {
+		return fmt.Errorf("%s: model is required", p.Name)
+	}
+	return nil
+}
+
+// GetType returns the provider type, which is the declared name
+func (p *CustomProvider) GetType() ProviderType {
+	return ProviderType(p.Name)
+}
+
+// GetDefaultConfig returns the configuration declared in providers.json
+func (p *CustomProvider) GetDefaultConfig(apiKey string) *ProviderConfig {
+	return &ProviderConfig{
+		Type:           p.GetType(),
+		AuthToken:      apiKey,
+		BaseURL:        p.BaseURL,
+		Model:          p.Model,
+		SmallFastModel: p.SmallFastModel,
+	}
+}
+
+// ValidateConfig validates the custom provider configuration
+func (p *CustomProvider) ValidateConfig(config *ProviderConfig) error {
+	if config.AuthToken == "" {
+		return fmt.Errorf("auth token is required for %s", p.Name)
+	}
+	if config.BaseURL == "" {
+		return fmt.Errorf("base URL is required for %s", p.Name)
+	}
+	return nil
+}
+
+// SupportedModels returns the declared model and small-fast model
+func (p *CustomProvider) SupportedModels() []string {
+	if p.SmallFastModel == "" || p.SmallFastModel == p.Model {
+		return []string{p.Model}
+	}
+	return []string{p.Model, p.SmallFastModel}
+}
@@ -0,0 +1,138 @@
+package aiprovider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ooneko/claude-config/internal/claude"
+)
+
+func writeCustomProviders(t *testing.T, claudeDir, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(claudeDir, CustomProvidersFile), []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+}
+
+func TestLoadCustomProviders(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    int
+		wantErr string
+	}{
+		{
+			name:    "valid",
+			content: `{"providers":[{"name":"myhost","base_url":"https://llm.example.com/anthropic","model":"my-model","small_fast_model":"my-small"},{"name":"lab-2","base_url":"http://10.0.0.2:8080","model":"m"}]}`,
+			want:    2,
+		},
+		{name: "empty list", content: `{"providers":[]}`},
+		{name: "malformed json", content: `{"providers":[`, wantErr: "failed to parse"},
+		{name: "unknown field", content: `{"providers":[{"name":"myhost","url":"https://x.example.com","model":"m"}]}`, wantErr: "unknown field"},
+		{name: "trailing data", content: `{"providers":[]} {}`, wantErr: "unexpected data"},
+		{name: "null entry", content: `{"providers":[null]}`, wantErr: "provider #1 is null"},
+		{name: "missing name", content: `{"providers":[{"base_url":"https://x.example.com","model":"m"}]}`, wantErr: "name is required"},
+		{name: "invalid name", content: `{"providers":[{"name":"My Host","base_url":"https://x.example.com","model":"m"}]}`, wantErr: "invalid name"},
+		{name: "built-in name", content: `{"providers":[{"name":"zhipu","base_url":"https://x.example.com","model":"m"}]}`, wantErr: "built-in provider"},
+		{name: "native alias", content: `{"providers":[{"name":"native","base_url":"https://x.example.com","model":"m"}]}`, wantErr: "reserved"},
+		{name: "claude alias", content: `{"providers":[{"name":"claude","base_url":"https://x.example.com","model":"m"}]}`, wantErr: "reserved"},
+		{name: "missing base_url", content: `{"providers":[{"name":"myhost","model":"m"}]}`, wantErr: "base_url is required"},
+		{name: "invalid base_url", content: `{"providers":[{"name":"myhost","base_url":"ftp://x.example.com","model":"m"}]}`, wantErr: "myhost"},
+		{name: "missing model", content: `{"providers":[{"name":"myhost","base_url":"https://x.example.com"}]}`, wantErr: "model is required"},
+		{
+			name:    "duplicate",
+			content: `{"providers":[{"name":"myhost","base_url":"https://x.example.com","model":"m"},{"name":"myhost","base_url":"https://y.example.com","model":"m"}]}`,
+			wantErr: "declared twice",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			writeCustomProviders(t, tmpDir, tt.content)
+
+			got, err := LoadCustomProviders(tmpDir)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadCustomProviders() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadCustomProviders() error = %v", err)
+			}
+			if len(got) != tt.want {
+				t.Errorf("LoadCustomProviders() returned %d providers, want %d", len(got), tt.want)
+			}
+		})
+	}
+
+	// No file declares no providers
+	if got, err := LoadCustomProviders(t.TempDir()); err != nil || got != nil {
+		t.Errorf("LoadCustomProviders() without a file = %v, %v, want nil, nil", got, err)
+	}
+}
+
+func TestManager_CustomProvider(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeCustomProviders(t, tmpDir, `{"providers":[{"name":"myhost","base_url":"https://llm.example.com/anthropic","model":"my-model","small_fast_model":"my-small"}]}`)
+
+	mgr := NewManager(tmpDir).(*Manager)
+	ctx := context.Background()
+
+	// NewManager knows the provider; only RegisterCustomProviders touches the
+	// process-wide name registry
+	myhost := ProviderType("myhost")
+	if _, exists := mgr.providers[myhost]; !exists {
+		t.Fatal("NewManager() should add myhost")
+	}
+
+	// RegisterCustomProviders makes the name resolve like a built-in one
+	if err := RegisterCustomProviders(tmpDir); err != nil {
+		t.Fatalf("RegisterCustomProviders() error = %v", err)
+	}
+	if got := claude.NormalizeProviderName("MyHost"); got != myhost {
+		t.Errorf("NormalizeProviderName(MyHost) = %q, want myhost", got)
+	}
+
+	if err := mgr.Enable(ctx, myhost, "sk-myhost"); err != nil {
+		t.Fatalf("Enable() error = %v", err)
+	}
+
+	settings, err := mgr.loadSettings()
+	if err != nil {
+		t.Fatalf("loadSettings() error = %v", err)
+	}
+	want := map[string]string{
+		"ANTHROPIC_AUTH_TOKEN":           "sk-myhost",
+		"ANTHROPIC_BASE_URL":             "https://llm.example.com/anthropic",
+		"ANTHROPIC_DEFAULT_SONNET_MODEL": "my-model",
+		"ANTHROPIC_DEFAULT_OPUS_MODEL":   "my-model",
+		"ANTHROPIC_DEFAULT_HAIKU_MODEL":  "my-small",
+	}
+	for key, value := range want {
+		if settings.Env[key] != value {
+			t.Errorf("Env[%s] = %q, want %q", key, settings.Env[key], value)
+		}
+	}
+
+	active, err := mgr.GetActiveProvider(ctx)
+	if err != nil {
+		t.Fatalf("GetActiveProvider() error = %v", err)
+	}
+	if active != myhost {
+		t.Errorf("GetActiveProvider() = %q, want myhost", active)
+	}
+
+	// An invalid file adds and registers no custom providers
+	writeCustomProviders(t, tmpDir, `{"providers":[{"name":"myhost"}]}`)
+	if _, exists := NewManager(tmpDir).(*Manager).providers[myhost]; exists {
+		t.Error("NewManager() with an invalid file should not add myhost")
+	}
+	if err := RegisterCustomProviders(tmpDir); err == nil {
+		t.Error("RegisterCustomProviders() with an invalid file should fail")
+	}
+}
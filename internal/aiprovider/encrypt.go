@@ -0,0 +1,164 @@
+package aiprovider
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// PassphraseEnv names the environment variable holding the passphrase for encrypted API key files
+const PassphraseEnv = "CLAUDE_CONFIG_PASSPHRASE"
+
+// encryptedKeyPrefix marks an encrypted API key file. The rest of the file is
+// base64 of salt || nonce || AES-GCM ciphertext.
+const encryptedKeyPrefix = "claude-config:enc:v1:"
+
+const (
+	saltSize = 16
+	keySize  = 32
+
+	// scrypt cost parameters recommended for interactive logins
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+// ErrPassphraseRequired is returned when an encrypted API key is read without a passphrase
+var ErrPassphraseRequired = errors.New("API key file is encrypted, set " + PassphraseEnv)
+
+// EnableEncryption makes the manager encrypt API key files with passphrase and
+// encrypts every plaintext key file already stored. Keys that are already
+// encrypted must decrypt with the same passphrase; nothing is rewritten otherwise.
+func (m *Manager) EnableEncryption(_ context.Context, passphrase string) error {
+	if passphrase == "" {
+		return errors.New("passphrase cannot be empty")
+	}
+
+	type plainKey struct {
+		provider ProviderType
+		profile  string
+		apiKey   string
+	}
+
+	// Read everything first so a wrong passphrase leaves all files untouched
+	var plainKeys []plainKey
+	for _, provider := range m.ListSupportedProviders() {
+		profiles, err := m.ListProfiles(provider)
+		if err != nil {
+			return err
+		}
+		for _, profile := range profiles {
+			data, err := m.files.read(provider, profile)
+			if errors.Is(err, os.ErrNotExist) {
+				// The key comes from the environment
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			// Keys in the OS keychain are protected by the keychain itself
+			if isKeychainReference(data) {
+				continue
+			}
+			apiKey, err := DecodeAPIKey(data, passphrase)
+			if err != nil {
+				return fmt.Errorf("%s: %w", APIKeyFileName(provider, profile), err)
+			}
+			if !IsEncryptedAPIKey(data) {
+				plainKeys = append(plainKeys, plainKey{provider, profile, apiKey})
+			}
+		}
+	}
+
+	m.files.passphrase = passphrase
+	for _, key := range plainKeys {
+		if err := m.saveProfileAPIKey(key.provider, key.profile, key.apiKey); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// IsEncryptedAPIKey reports whether the contents of an API key file are encrypted
+func IsEncryptedAPIKey(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(encryptedKeyPrefix))
+}
+
+// DecodeAPIKey returns the API key stored in a key file. Plaintext files are
+// returned as is for backward compatibility; encrypted files need the passphrase.
+func DecodeAPIKey(data []byte, passphrase string) (string, error) {
+	if !IsEncryptedAPIKey(data) {
+		return CleanFileValue(data), nil
+	}
+	if passphrase == "" {
+		return "", ErrPassphraseRequired
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(CleanFileValue(bytes.TrimPrefix(data, []byte(encryptedKeyPrefix))))
+	if err != nil {
+		return "", fmt.Errorf("malformed encrypted API key: %w", err)
+	}
+	if len(raw) < saltSize {
+		return "", errors.New("malformed encrypted API key: too short")
+	}
+
+	gcm, err := newKeyCipher(passphrase, raw[:saltSize])
+	if err != nil {
+		return "", err
+	}
+	raw = raw[saltSize:]
+	if len(raw) < gcm.NonceSize() {
+		return "", errors.New("malformed encrypted API key: too short")
+	}
+
+	plaintext, err := gcm.Open(nil, raw[:gcm.NonceSize()], raw[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", errors.New("failed to decrypt API key: wrong passphrase or corrupted file")
+	}
+	return string(plaintext), nil
+}
+
+// encryptAPIKey encrypts apiKey with a key derived from passphrase and a fresh salt
+func encryptAPIKey(apiKey, passphrase string) ([]byte, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	gcm, err := newKeyCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	raw := append(salt, nonce...)
+	raw = gcm.Seal(raw, nonce, []byte(apiKey), nil)
+	return []byte(encryptedKeyPrefix + base64.StdEncoding.EncodeToString(raw)), nil
+}
+
+// newKeyCipher derives the AES-256 key for passphrase and salt
+func newKeyCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive encryption key: %w", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,152 @@
+package aiprovider
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDecodeAPIKey(t *testing.T) {
+	encrypted, err := encryptAPIKey("sk-secret", "hunter2")
+	if err != nil {
+		t.Fatalf("encryptAPIKey() error = %v", err)
+	}
+	if !IsEncryptedAPIKey(encrypted) {
+		t.Fatalf("encrypted key should start with %q, got %q", encryptedKeyPrefix, encrypted)
+	}
+	if strings.Contains(string(encrypted), "sk-secret") {
+		t.Error("encrypted key should not contain the plaintext")
+	}
+
+	tests := []struct {
+		name       string
+		data       []byte
+		passphrase string
+		want       string
+		wantErr    bool
+	}{
+		{"plaintext", []byte("sk-plain\r\n"), "", "sk-plain", false},
+		{"plaintext with passphrase", []byte("sk-plain"), "hunter2", "sk-plain", false},
+		{"encrypted", encrypted, "hunter2", "sk-secret", false},
+		{"encrypted with trailing newline", append(encrypted, '\n'), "hunter2", "sk-secret", false},
+		{"wrong passphrase", encrypted, "wrong", "", true},
+		{"no passphrase", encrypted, "", "", true},
+		{"truncated", []byte(encryptedKeyPrefix + "AAAA"), "hunter2", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeAPIKey(tt.data, tt.passphrase)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecodeAPIKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("DecodeAPIKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestManager_EnableEncryption(t *testing.T) {
+	t.Setenv(PassphraseEnv, "")
+	tmpDir := t.TempDir()
+	mgr := NewManager(tmpDir).(*Manager)
+	ctx := context.Background()
+
+	if err := mgr.Enable(ctx, ProviderDeepSeek, "sk-deepseek"); err != nil {
+		t.Fatalf("Setup enable failed: %v", err)
+	}
+	if err := mgr.EnableProfile(ctx, ProviderKimi, "work", "sk-kimi-work"); err != nil {
+		t.Fatalf("Setup enable failed: %v", err)
+	}
+
+	if err := mgr.EnableEncryption(ctx, ""); err == nil {
+		t.Error("EnableEncryption() with an empty passphrase should fail")
+	}
+	if err := mgr.EnableEncryption(ctx, "hunter2"); err != nil {
+		t.Fatalf("EnableEncryption() error = %v", err)
+	}
+
+	// Existing keys are encrypted in place and still readable
+	for _, name := range []string{".deepseek_api_key", ".kimi.work_api_key"} {
+		path := filepath.Join(tmpDir, name)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("ReadFile() error = %v", err)
+		}
+		if !IsEncryptedAPIKey(data) {
+			t.Errorf("%s should be encrypted, got %q", name, data)
+		}
+		if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+			t.Errorf("%s mode = %#o, want 0600", name, info.Mode().Perm())
+		}
+	}
+	if apiKey, err := mgr.loadProfileAPIKey(ProviderKimi, "work"); err != nil || apiKey != "sk-kimi-work" {
+		t.Errorf("loadProfileAPIKey() = %q, %v, want sk-kimi-work", apiKey, err)
+	}
+
+	// Newly saved keys are encrypted too
+	if err := mgr.Enable(ctx, ProviderDoubao, "sk-doubao"); err != nil {
+		t.Fatalf("Enable() error = %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(tmpDir, ".doubao_api_key"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if !IsEncryptedAPIKey(data) {
+		t.Errorf("new key should be encrypted, got %q", data)
+	}
+
+	// A manager without the passphrase can't read them
+	plain := NewManager(tmpDir).(*Manager)
+	if _, err := plain.loadAPIKey(ProviderDoubao); !errors.Is(err, ErrPassphraseRequired) {
+		t.Errorf("loadAPIKey() without passphrase error = %v, want ErrPassphraseRequired", err)
+	}
+
+	// The passphrase can come from the environment
+	t.Setenv(PassphraseEnv, "hunter2")
+	fromEnv := NewManager(tmpDir).(*Manager)
+	if apiKey, err := fromEnv.loadAPIKey(ProviderDoubao); err != nil || apiKey != "sk-doubao" {
+		t.Errorf("loadAPIKey() = %q, %v, want sk-doubao", apiKey, err)
+	}
+}
+
+func TestManager_EnableEncryption_WrongPassphrase(t *testing.T) {
+	t.Setenv(PassphraseEnv, "")
+	tmpDir := t.TempDir()
+	mgr := NewManager(tmpDir).(*Manager)
+	ctx := context.Background()
+
+	if err := mgr.EnableEncryption(ctx, "first"); err != nil {
+		t.Fatalf("EnableEncryption() error = %v", err)
+	}
+	if err := mgr.Enable(ctx, ProviderDeepSeek, "sk-deepseek"); err != nil {
+		t.Fatalf("Setup enable failed: %v", err)
+	}
+
+	// A plaintext key written by an older version sits next to the encrypted one
+	kimiPath := filepath.Join(tmpDir, ".kimi_api_key")
+	if err := os.WriteFile(kimiPath, []byte("sk-kimi"), 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	other := NewManager(tmpDir).(*Manager)
+	if err := other.EnableEncryption(ctx, "second"); err == nil {
+		t.Fatal("EnableEncryption() with a different passphrase should fail")
+	}
+
+	// Nothing was rewritten
+	data, err := os.ReadFile(kimiPath)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(data) != "sk-kimi" {
+		t.Errorf("plaintext key = %q, want it left untouched", data)
+	}
+	if other.files.passphrase != "" {
+		t.Error("passphrase should not be set after a failed EnableEncryption()")
+	}
+}
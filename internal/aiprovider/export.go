@@ -0,0 +1,111 @@
+package aiprovider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// exportVersion is the format version written by ExportProviders
+const exportVersion = 1
+
+// providerExport is the JSON document produced by ExportProviders
+type providerExport struct {
+	Version         int                 `json:"version"`
+	DefaultProvider ProviderType        `json:"default_provider,omitempty"`
+	Keys            []providerKeyExport `json:"keys"`
+}
+
+// providerKeyExport is a single stored API key
+type providerKeyExport struct {
+	Provider ProviderType `json:"provider"`
+	Profile  string       `json:"profile"`
+	APIKey   string       `json:"api_key"`
+}
+
+// ExportProviders serializes every stored API key and the default provider
+// to JSON. The output contains secrets in plain text.
+func (m *Manager) ExportProviders(ctx context.Context) ([]byte, error) {
+	export := providerExport{
+		Version: exportVersion,
+		Keys:    []providerKeyExport{},
+	}
+
+	defaultProvider, err := m.GetDefaultProvider(ctx)
+	if err != nil {
+		return nil, err
+	}
+	export.DefaultProvider = defaultProvider
+
+	for _, provider := range m.ListSupportedProviders() {
+		profiles, err := m.ListProfiles(provider)
+		if err != nil {
+			return nil, err
+		}
+		for _, profile := range profiles {
+			apiKey, err := m.loadProfileAPIKey(provider, profile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load API key for %s/%s: %w", provider, profile, err)
+			}
+			export.Keys = append(export.Keys, providerKeyExport{
+				Provider: provider,
+				Profile:  profile,
+				APIKey:   apiKey,
+			})
+		}
+	}
+
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal providers: %w", err)
+	}
+
+	return data, nil
+}
+
+// ImportProviders restores API keys and the default provider produced by
+// ExportProviders. Every entry is validated before anything is written, and
+// the active provider in settings.json is left unchanged.
+func (m *Manager) ImportProviders(ctx context.Context, data []byte) error {
+	var export providerExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return fmt.Errorf("failed to parse providers: %w", err)
+	}
+
+	if export.Version != exportVersion {
+		return fmt.Errorf("unsupported export version: %d", export.Version)
+	}
+
+	if export.DefaultProvider != ProviderNone {
+		if _, exists := m.providers[export.DefaultProvider]; !exists {
+			return fmt.Errorf("unsupported default provider: %s", export.DefaultProvider)
+		}
+	}
+
+	for i, entry := range export.Keys {
+		if _, exists := m.providers[entry.Provider]; !exists {
+			return fmt.Errorf("entry %d: unsupported provider: %s", i, entry.Provider)
+		}
+		if err := ValidateProfileName(entry.Profile); err != nil {
+			return fmt.Errorf("entry %d: %w", i, err)
+		}
+		if err := ValidateAPIKey(entry.Provider, entry.APIKey); err != nil {
+			return fmt.Errorf("entry %d: %w", i, err)
+		}
+	}
+
+	for _, entry := range export.Keys {
+		if err := m.saveProfileAPIKey(entry.Provider, entry.Profile, strings.TrimSpace(entry.APIKey)); err != nil {
+			return fmt.Errorf("failed to save API key for %s/%s: %w", entry.Provider, entry.Profile, err)
+		}
+	}
+
+	if export.DefaultProvider != ProviderNone {
+		if err := m.SetDefaultProvider(ctx, export.DefaultProvider); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package aiprovider
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestManager_ExportImportProviders(t *testing.T) {
+	ctx := context.Background()
+	src := NewManager(t.TempDir()).(*Manager)
+
+	if err := src.Enable(ctx, ProviderDeepSeek, "sk-personal"); err != nil {
+		t.Fatalf("Enable() error = %v", err)
+	}
+	if err := src.EnableProfile(ctx, ProviderDeepSeek, "work", "sk-work"); err != nil {
+		t.Fatalf("EnableProfile() error = %v", err)
+	}
+	if err := src.Enable(ctx, ProviderKimi, "kimi-key"); err != nil {
+		t.Fatalf("Enable() error = %v", err)
+	}
+	if err := src.SetDefaultProvider(ctx, ProviderKimi); err != nil {
+		t.Fatalf("SetDefaultProvider() error = %v", err)
+	}
+
+	data, err := src.ExportProviders(ctx)
+	if err != nil {
+		t.Fatalf("ExportProviders() error = %v", err)
+	}
+
+	dstDir := t.TempDir()
+	dst := NewManager(dstDir).(*Manager)
+	if err := dst.ImportProviders(ctx, data); err != nil {
+		t.Fatalf("ImportProviders() error = %v", err)
+	}
+
+	for _, tt := range []struct {
+		provider ProviderType
+		profile  string
+		want     string
+	}{
+		{ProviderDeepSeek, DefaultProfile, "sk-personal"},
+		{ProviderDeepSeek, "work", "sk-work"},
+		{ProviderKimi, DefaultProfile, "kimi-key"},
+	} {
+		got, err := dst.loadProfileAPIKey(tt.provider, tt.profile)
+		if err != nil {
+			t.Fatalf("loadProfileAPIKey(%s, %s) error = %v", tt.provider, tt.profile, err)
+		}
+		if got != tt.want {
+			t.Errorf("loadProfileAPIKey(%s, %s) = %q, want %q", tt.provider, tt.profile, got, tt.want)
+		}
+
+		info, err := os.Stat(dst.getProfileAPIKeyPath(tt.provider, tt.profile))
+		if err != nil {
+			t.Fatalf("Stat() error = %v", err)
+		}
+		if info.Mode().Perm() != 0600 {
+			t.Errorf("key file mode = %o, want 600", info.Mode().Perm())
+		}
+	}
+
+	defaultProvider, err := dst.GetDefaultProvider(ctx)
+	if err != nil {
+		t.Fatalf("GetDefaultProvider() error = %v", err)
+	}
+	if defaultProvider != ProviderKimi {
+		t.Errorf("GetDefaultProvider() = %v, want %v", defaultProvider, ProviderKimi)
+	}
+
+	// Import only stores keys; it doesn't switch the active provider
+	active, err := dst.GetActiveProvider(ctx)
+	if err != nil {
+		t.Fatalf("GetActiveProvider() error = %v", err)
+	}
+	if active != ProviderNone {
+		t.Errorf("GetActiveProvider() after import = %v, want none", active)
+	}
+}
+
+func TestManager_ImportProviders_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{"malformed json", `{`, "failed to parse"},
+		{"unknown version", `{"version": 2, "keys": []}`, "unsupported export version"},
+		{"unknown provider", `{"version": 1, "keys": [{"provider": "openai", "profile": "default", "api_key": "k"}]}`, "unsupported provider"},
+		{"bad profile", `{"version": 1, "keys": [{"provider": "deepseek", "profile": "../x", "api_key": "k"}]}`, "invalid profile name"},
+		{"empty key", `{"version": 1, "keys": [{"provider": "deepseek", "profile": "default", "api_key": ""}]}`, "cannot be empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			mgr := NewManager(tmpDir).(*Manager)
+
+			err := mgr.ImportProviders(context.Background(), []byte(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ImportProviders() error = %v, want containing %q", err, tt.wantErr)
+			}
+
+			entries, _ := os.ReadDir(tmpDir)
+			if len(entries) != 0 {
+				t.Errorf("ImportProviders() wrote files on error: %v", entries)
+			}
+		})
+	}
+}
@@ -0,0 +1,269 @@
+package aiprovider
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/ooneko/claude-config/internal/claude"
+	"github.com/ooneko/claude-config/internal/fsutil"
+)
+
+// Key store names accepted by SetKeyStore
+const (
+	KeyStoreFile     = "file"
+	KeyStoreKeychain = "keychain"
+)
+
+// keychainService is the service name API keys are stored under in the OS keychain
+const keychainService = "claude-config"
+
+// keychainReference replaces the key in a key file when the key lives in the
+// OS keychain, so listing profiles and backups keep working without the secret
+const keychainReference = "claude-config:keychain"
+
+// KeyStore stores API keys by provider and profile.
+// Get returns an error wrapping os.ErrNotExist when no key is stored.
+type KeyStore interface {
+	Get(provider ProviderType, profile string) (string, error)
+	Set(provider ProviderType, profile, apiKey string) error
+	Delete(provider ProviderType, profile string) error
+}
+
+// fileKeyStore keeps each key in a .{provider}[.profile]_api_key file in the
+// claude directory, encrypted when a passphrase is set
+type fileKeyStore struct {
+	claudeDir  string
+	passphrase string
+}
+
+// path returns the key file path for a provider profile
+func (s *fileKeyStore) path(provider ProviderType, profile string) string {
+	return filepath.Join(s.claudeDir, APIKeyFileName(provider, profile))
+}
+
+// read returns the raw contents of a key file
+func (s *fileKeyStore) read(provider ProviderType, profile string) ([]byte, error) {
+	data, err := os.ReadFile(s.path(provider, profile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API key file: %w", err)
+	}
+	return data, nil
+}
+
+// write replaces a key file with restricted permissions in one step
+func (s *fileKeyStore) write(provider ProviderType, profile string, data []byte) error {
+	if err := os.MkdirAll(s.claudeDir, 0755); err != nil {
+		return fmt.Errorf("failed to create claude directory: %w", err)
+	}
+
+	// AtomicWriteFile keeps an existing file's mode, so restrict a loose key
+	// file before the new key goes into it
+	path := s.path(provider, profile)
+	if info, err := os.Stat(path); err == nil && info.Mode().Perm() != 0600 {
+		if err := os.Chmod(path, 0600); err != nil {
+			return fmt.Errorf("failed to restrict API key file: %w", err)
+		}
+	}
+
+	if err := fsutil.AtomicWriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write API key file: %w", err)
+	}
+	return nil
+}
+
+func (s *fileKeyStore) Get(provider ProviderType, profile string) (string, error) {
+	data, err := s.read(provider, profile)
+	if err != nil {
+		return "", err
+	}
+	return DecodeAPIKey(data, s.passphrase)
+}
+
+func (s *fileKeyStore) Set(provider ProviderType, profile, apiKey string) error {
+	data := []byte(apiKey)
+	if s.passphrase != "" {
+		encrypted, err := encryptAPIKey(apiKey, s.passphrase)
+		if err != nil {
+			return err
+		}
+		data = encrypted
+	}
+	return s.write(provider, profile, data)
+}
+
+func (s *fileKeyStore) Delete(provider ProviderType, profile string) error {
+	if err := os.Remove(s.path(provider, profile)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove API key file: %w", err)
+	}
+	return nil
+}
+
+// APIKeyEnvVar returns the environment variable that supplies the provider's
+// default API key, e.g. DEEPSEEK_API_KEY
+func APIKeyEnvVar(provider ProviderType) string {
+	return strings.ToUpper(string(provider)) + "_API_KEY"
+}
+
+// envAPIKey returns the key set in the provider's environment variable.
+// Only the default profile can come from the environment.
+func envAPIKey(provider ProviderType, profile string) string {
+	if profile != DefaultProfile {
+		return ""
+	}
+	return strings.TrimSpace(os.Getenv(APIKeyEnvVar(provider)))
+}
+
+// isKeychainReference reports whether a key file points at the OS keychain
+func isKeychainReference(data []byte) bool {
+	return CleanFileValue(data) == keychainReference
+}
+
+// commandRunner runs an external command with stdin and returns its stdout
+type commandRunner func(stdin, name string, args ...string) ([]byte, error)
+
+// keychainKeyStore keeps keys in the OS keychain through its command line
+// tool: security on macOS and secret-tool (Secret Service) on Linux
+type keychainKeyStore struct {
+	goos string
+	run  commandRunner
+}
+
+func newKeychainKeyStore() *keychainKeyStore {
+	return &keychainKeyStore{goos: runtime.GOOS, run: runCommand}
+}
+
+// keychainAccount returns the account name a provider profile is stored under, e.g. deepseek.work
+func keychainAccount(provider ProviderType, profile string) string {
+	return strings.TrimPrefix(strings.TrimSuffix(APIKeyFileName(provider, profile), claude.APIKeyFileSuffix), ".")
+}
+
+func (s *keychainKeyStore) Get(provider ProviderType, profile string) (string, error) {
+	account := keychainAccount(provider, profile)
+
+	var out []byte
+	var err error
+	switch s.goos {
+	case "darwin":
+		out, err = s.run("", "security", "find-generic-password", "-s", keychainService, "-a", account, "-w")
+		// security exits with 44 when the item doesn't exist
+		if exitCode(err) == 44 {
+			err = os.ErrNotExist
+		}
+	case "linux":
+		out, err = s.run("", "secret-tool", "lookup", "service", keychainService, "account", account)
+		// secret-tool lookup fails without output when nothing matches
+		if exitCode(err) == 1 && len(out) == 0 {
+			err = os.ErrNotExist
+		}
+	default:
+		return "", s.unsupported()
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s from keychain: %w", account, err)
+	}
+
+	apiKey := strings.TrimSpace(string(out))
+	if apiKey == "" {
+		return "", fmt.Errorf("failed to read %s from keychain: %w", account, os.ErrNotExist)
+	}
+	return apiKey, nil
+}
+
+func (s *keychainKeyStore) Set(provider ProviderType, profile, apiKey string) error {
+	account := keychainAccount(provider, profile)
+
+	var err error
+	switch s.goos {
+	case "darwin":
+		// security only takes the password as an argument, so the command is
+		// fed to its interactive mode on stdin to keep the key out of argv
+		// where ps can see it; -U updates an existing item
+		_, err = s.run(securityCommand("add-generic-password", "-U", "-s", keychainService, "-a", account, "-w", apiKey), "security", "-i")
+		// security -i doesn't reflect a failed command in its exit status
+		if err == nil {
+			var stored string
+			if stored, err = s.Get(provider, profile); err == nil && stored != apiKey {
+				err = errors.New("security stored a different key")
+			}
+		}
+	case "linux":
+		_, err = s.run(apiKey, "secret-tool", "store", "--label", keychainService+" "+account,
+			"service", keychainService, "account", account)
+	default:
+		return s.unsupported()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save %s to keychain: %w", account, err)
+	}
+	return nil
+}
+
+func (s *keychainKeyStore) Delete(provider ProviderType, profile string) error {
+	account := keychainAccount(provider, profile)
+
+	var err error
+	switch s.goos {
+	case "darwin":
+		_, err = s.run("", "security", "delete-generic-password", "-s", keychainService, "-a", account)
+		if exitCode(err) == 44 {
+			err = nil
+		}
+	case "linux":
+		_, err = s.run("", "secret-tool", "clear", "service", keychainService, "account", account)
+	default:
+		return s.unsupported()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete %s from keychain: %w", account, err)
+	}
+	return nil
+}
+
+// securityCommand formats a command line for security -i, quoting each
+// argument so the key is read back verbatim
+func securityCommand(args ...string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+	}
+	return strings.Join(quoted, " ") + "\n"
+}
+
+func (s *keychainKeyStore) unsupported() error {
+	return fmt.Errorf("OS keychain is not supported on %s, use the file key store", s.goos)
+}
+
+// runCommand runs name with args, passing stdin when it isn't empty.
+// Errors include the command's stderr and wrap the *exec.ExitError.
+func runCommand(stdin, name string, args ...string) ([]byte, error) {
+	cmd := exec.Command(name, args...)
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return out, fmt.Errorf("%s: %w: %s", name, err, msg)
+		}
+		return out, fmt.Errorf("%s: %w", name, err)
+	}
+	return out, nil
+}
+
+// exitCode returns the exit code wrapped in err, or -1 when err isn't an exit error
+func exitCode(err error) int {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
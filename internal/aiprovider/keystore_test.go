@@ -0,0 +1,310 @@
+package aiprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// memoryKeyStore is a KeyStore standing in for the OS keychain
+type memoryKeyStore map[string]string
+
+func (s memoryKeyStore) Get(provider ProviderType, profile string) (string, error) {
+	apiKey, ok := s[keychainAccount(provider, profile)]
+	if !ok {
+		return "", fmt.Errorf("not in keychain: %w", os.ErrNotExist)
+	}
+	return apiKey, nil
+}
+
+func (s memoryKeyStore) Set(provider ProviderType, profile, apiKey string) error {
+	s[keychainAccount(provider, profile)] = apiKey
+	return nil
+}
+
+func (s memoryKeyStore) Delete(provider ProviderType, profile string) error {
+	delete(s, keychainAccount(provider, profile))
+	return nil
+}
+
+func TestManager_KeychainKeyStore(t *testing.T) {
+	tmpDir := t.TempDir()
+	mgr := NewManager(tmpDir).(*Manager)
+	keychain := memoryKeyStore{}
+	mgr.keychain = keychain
+	ctx := context.Background()
+
+	if err := mgr.SetKeyStore("vault"); err == nil {
+		t.Error("SetKeyStore() with an unknown store should fail")
+	}
+	if err := mgr.Enable(ctx, ProviderDeepSeek, "sk-file"); err != nil {
+		t.Fatalf("Enable() error = %v", err)
+	}
+
+	// Opting in moves the key into the keychain and leaves a reference behind
+	if err := mgr.SetKeyStore(KeyStoreKeychain); err != nil {
+		t.Fatalf("SetKeyStore() error = %v", err)
+	}
+	if err := mgr.EnableProfile(ctx, ProviderDeepSeek, "work", "sk-work"); err != nil {
+		t.Fatalf("EnableProfile() error = %v", err)
+	}
+	if err := mgr.Enable(ctx, ProviderDeepSeek, "sk-keychain"); err != nil {
+		t.Fatalf("Enable() error = %v", err)
+	}
+
+	want := memoryKeyStore{"deepseek": "sk-keychain", "deepseek.work": "sk-work"}
+	if !reflect.DeepEqual(keychain, want) {
+		t.Errorf("keychain = %v, want %v", keychain, want)
+	}
+	data, err := os.ReadFile(filepath.Join(tmpDir, ".deepseek_api_key"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(data) != keychainReference {
+		t.Errorf("key file = %q, want the keychain reference", data)
+	}
+
+	// A fresh manager finds the key through the reference and keeps it there
+	fresh := NewManager(tmpDir).(*Manager)
+	fresh.keychain = keychain
+	if apiKey, err := fresh.LoadAPIKey(ProviderDeepSeek, ""); err != nil || apiKey != "sk-keychain" {
+		t.Errorf("LoadAPIKey() = %q, %v, want sk-keychain", apiKey, err)
+	}
+	if hasKey, _ := fresh.HasAPIKey(ctx, ProviderDeepSeek); !hasKey {
+		t.Error("HasAPIKey() should report a key stored in the keychain")
+	}
+	if err := fresh.RotateKey(ctx, ProviderDeepSeek, "sk-rotated"); err != nil {
+		t.Fatalf("RotateKey() error = %v", err)
+	}
+	if keychain["deepseek"] != "sk-rotated" {
+		t.Errorf("keychain key = %q, want sk-rotated", keychain["deepseek"])
+	}
+
+	// Switching back to files removes the keychain entry
+	if err := fresh.SetKeyStore(KeyStoreFile); err != nil {
+		t.Fatalf("SetKeyStore() error = %v", err)
+	}
+	if err := fresh.EnableProfile(ctx, ProviderDeepSeek, "work", "sk-work"); err != nil {
+		t.Fatalf("EnableProfile() error = %v", err)
+	}
+	if _, ok := keychain["deepseek.work"]; ok {
+		t.Error("work key should be removed from the keychain")
+	}
+	data, err = os.ReadFile(filepath.Join(tmpDir, ".deepseek.work_api_key"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(data) != "sk-work" {
+		t.Errorf("work key file = %q, want sk-work", data)
+	}
+
+	// Reset clears the keychain too, including named profiles
+	if err := fresh.SetKeyStore(KeyStoreKeychain); err != nil {
+		t.Fatalf("SetKeyStore() error = %v", err)
+	}
+	if err := fresh.EnableProfile(ctx, ProviderDeepSeek, "team", "sk-team"); err != nil {
+		t.Fatalf("EnableProfile() error = %v", err)
+	}
+	if err := fresh.Reset(ctx, ProviderDeepSeek); err != nil {
+		t.Fatalf("Reset() error = %v", err)
+	}
+	if len(keychain) != 0 {
+		t.Errorf("keychain after Reset() = %v, want empty", keychain)
+	}
+	if _, err := fresh.LoadAPIKey(ProviderDeepSeek, ""); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("LoadAPIKey() after Reset() error = %v, want os.ErrNotExist", err)
+	}
+	if profiles, err := fresh.ListProfiles(ProviderDeepSeek); err != nil || len(profiles) != 0 {
+		t.Errorf("ListProfiles() after Reset() = %v, %v, want none", profiles, err)
+	}
+}
+
+func TestKeychainKeyStore_Commands(t *testing.T) {
+	// exitError produces a real *exec.ExitError with the given code
+	exitError := func(code int) error {
+		return exec.Command("sh", "-c", fmt.Sprintf("exit %d", code)).Run()
+	}
+
+	tests := []struct {
+		name    string
+		goos    string
+		out     string
+		err     error
+		call    func(s *keychainKeyStore) error
+		want    string
+		wantErr error
+	}{
+		{
+			name: "darwin get",
+			goos: "darwin",
+			out:  "sk-secret\n",
+			call: func(s *keychainKeyStore) error {
+				apiKey, err := s.Get(ProviderDeepSeek, "work")
+				if err == nil && apiKey != "sk-secret" {
+					return fmt.Errorf("Get() = %q", apiKey)
+				}
+				return err
+			},
+			want: "security find-generic-password -s claude-config -a deepseek.work -w",
+		},
+		{
+			name:    "darwin get missing",
+			goos:    "darwin",
+			err:     exitError(44),
+			call:    func(s *keychainKeyStore) error { _, err := s.Get(ProviderDeepSeek, DefaultProfile); return err },
+			want:    "security find-generic-password -s claude-config -a deepseek -w",
+			wantErr: os.ErrNotExist,
+		},
+		{
+			name: "darwin set",
+			goos: "darwin",
+			out:  "sk-kimi\n",
+			call: func(s *keychainKeyStore) error { return s.Set(ProviderKimi, DefaultProfile, "sk-kimi") },
+			want: "stdin=\"add-generic-password\" \"-U\" \"-s\" \"claude-config\" \"-a\" \"kimi\" \"-w\" \"sk-kimi\"\n security -i; " +
+				"security find-generic-password -s claude-config -a kimi -w",
+		},
+		{
+			name: "darwin set not stored",
+			goos: "darwin",
+			call: func(s *keychainKeyStore) error { return s.Set(ProviderKimi, DefaultProfile, "sk-kimi") },
+			want: "stdin=\"add-generic-password\" \"-U\" \"-s\" \"claude-config\" \"-a\" \"kimi\" \"-w\" \"sk-kimi\"\n security -i; " +
+				"security find-generic-password -s claude-config -a kimi -w",
+			wantErr: os.ErrNotExist,
+		},
+		{
+			name: "darwin delete missing",
+			goos: "darwin",
+			err:  exitError(44),
+			call: func(s *keychainKeyStore) error { return s.Delete(ProviderKimi, DefaultProfile) },
+			want: "security delete-generic-password -s claude-config -a kimi",
+		},
+		{
+			name:    "linux get missing",
+			goos:    "linux",
+			err:     exitError(1),
+			call:    func(s *keychainKeyStore) error { _, err := s.Get(ProviderDoubao, DefaultProfile); return err },
+			want:    "secret-tool lookup service claude-config account doubao",
+			wantErr: os.ErrNotExist,
+		},
+		{
+			name: "linux set",
+			goos: "linux",
+			call: func(s *keychainKeyStore) error { return s.Set(ProviderDoubao, DefaultProfile, "sk-doubao") },
+			want: "stdin=sk-doubao secret-tool store --label claude-config doubao service claude-config account doubao",
+		},
+		{
+			name: "unsupported",
+			goos: "windows",
+			call: func(s *keychainKeyStore) error { return s.Set(ProviderDoubao, DefaultProfile, "sk-doubao") },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			store := &keychainKeyStore{
+				goos: tt.goos,
+				run: func(stdin, name string, args ...string) ([]byte, error) {
+					call := strings.Join(append([]string{name}, args...), " ")
+					if stdin != "" {
+						call = "stdin=" + stdin + " " + call
+					}
+					if got != "" {
+						got += "; "
+					}
+					got += call
+					return []byte(tt.out), tt.err
+				},
+			}
+
+			err := tt.call(store)
+			if got != tt.want {
+				t.Errorf("ran %q, want %q", got, tt.want)
+			}
+			switch {
+			case tt.goos == "windows":
+				if err == nil {
+					t.Error("expected an error on an unsupported OS")
+				}
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("error = %v, want %v", err, tt.wantErr)
+				}
+			case err != nil:
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestManager_EnvAPIKey(t *testing.T) {
+	tmpDir := t.TempDir()
+	mgr := NewManager(tmpDir).(*Manager)
+	ctx := context.Background()
+
+	if got := APIKeyEnvVar(ProviderDeepSeek); got != "DEEPSEEK_API_KEY" {
+		t.Errorf("APIKeyEnvVar() = %q, want DEEPSEEK_API_KEY", got)
+	}
+
+	t.Setenv("DEEPSEEK_API_KEY", " sk-env ")
+
+	if hasKey, err := mgr.HasAPIKey(ctx, ProviderDeepSeek); err != nil || !hasKey {
+		t.Errorf("HasAPIKey() = %v, %v, want true from the environment", hasKey, err)
+	}
+	if profiles, _ := mgr.ListProfiles(ProviderDeepSeek); !reflect.DeepEqual(profiles, []string{DefaultProfile}) {
+		t.Errorf("ListProfiles() = %v, want [default]", profiles)
+	}
+
+	// Enabling with the key from the environment doesn't write it to disk
+	apiKey, err := mgr.LoadAPIKey(ProviderDeepSeek, "")
+	if err != nil || apiKey != "sk-env" {
+		t.Fatalf("LoadAPIKey() = %q, %v, want sk-env", apiKey, err)
+	}
+	if err := mgr.Enable(ctx, ProviderDeepSeek, apiKey); err != nil {
+		t.Fatalf("Enable() error = %v", err)
+	}
+	keyPath := filepath.Join(tmpDir, ".deepseek_api_key")
+	if _, err := os.Stat(keyPath); !os.IsNotExist(err) {
+		t.Errorf("key file should not be written, stat error = %v", err)
+	}
+	if err := mgr.RotateKey(ctx, ProviderDeepSeek, "sk-new"); err == nil {
+		t.Error("RotateKey() should fail while the key comes from the environment")
+	}
+
+	// The environment takes precedence over a stored key; profiles still use files
+	if err := os.WriteFile(keyPath, []byte("sk-file"), 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if apiKey, _ := mgr.LoadAPIKey(ProviderDeepSeek, ""); apiKey != "sk-env" {
+		t.Errorf("LoadAPIKey() = %q, want the environment key", apiKey)
+	}
+	if err := mgr.EnableProfile(ctx, ProviderDeepSeek, "work", "sk-work"); err != nil {
+		t.Fatalf("EnableProfile() error = %v", err)
+	}
+	if apiKey, _ := mgr.LoadAPIKey(ProviderDeepSeek, "work"); apiKey != "sk-work" {
+		t.Errorf("LoadAPIKey(work) = %q, want sk-work", apiKey)
+	}
+
+	// Without the variable the file is used again
+	t.Setenv("DEEPSEEK_API_KEY", "")
+	if apiKey, _ := mgr.LoadAPIKey(ProviderDeepSeek, ""); apiKey != "sk-file" {
+		t.Errorf("LoadAPIKey() = %q, want sk-file", apiKey)
+	}
+	if hasKey, _ := mgr.HasAPIKey(ctx, ProviderKimi); hasKey {
+		t.Error("HasAPIKey(kimi) should be false without a file or variable")
+	}
+}
+
+func TestSecurityCommand(t *testing.T) {
+	got := securityCommand("add-generic-password", "-w", `sk-a"b\c`)
+	want := `"add-generic-password" "-w" "sk-a\"b\\c"` + "\n"
+	if got != want {
+		t.Errorf("securityCommand() = %q, want %q", got, want)
+	}
+}
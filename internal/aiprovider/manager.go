@@ -3,17 +3,28 @@ package aiprovider
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/ooneko/claude-config/internal/claude"
+	"github.com/ooneko/claude-config/internal/settingsstore"
 )
 
 // Manager implements the claude.AIProviderManager interface
 type Manager struct {
 	claudeDir string
 	providers map[ProviderType]Provider
+	// files stores API keys in the claude directory, keychain in the OS keychain
+	files    *fileKeyStore
+	keychain KeyStore
+	// keyStore selects the store for keys saved from now on; when empty a key
+	// stays in the store that already holds it
+	keyStore string
 }
 
 // NewManager creates a new AI provider manager
@@ -21,6 +32,8 @@ func NewManager(claudeDir string) claude.AIProviderManager {
 	m := &Manager{
 		claudeDir: claudeDir,
 		providers: make(map[ProviderType]Provider),
+		files:     &fileKeyStore{claudeDir: claudeDir, passphrase: os.Getenv(PassphraseEnv)},
+		keychain:  newKeychainKeyStore(),
 	}
 
 	// Register supported providers
@@ -28,22 +41,60 @@ func NewManager(claudeDir string) claude.AIProviderManager {
 	m.providers[ProviderKimi] = &KimiProvider{}
 	m.providers[ProviderGLM] = &GLMProvider{}
 	m.providers[ProviderDoubao] = &DoubaoProvider{}
+	m.providers[ProviderAnthropic] = &AnthropicProvider{}
+
+	// Add providers from providers.json. An invalid file adds none; the error
+	// is reported by whoever calls LoadCustomProviders directly. Their names
+	// only resolve on the command line after RegisterCustomProviders.
+	customProviders, _ := LoadCustomProviders(claudeDir)
+	for _, provider := range customProviders {
+		m.providers[provider.GetType()] = provider
+	}
 
 	return m
 }
 
+// RegisterCustomProviders registers the names of the providers declared in
+// claudeDir's providers.json with claude.NormalizeProviderName, so they
+// resolve on the command line. The registry is process-wide, so this is
+// called once at startup rather than by every NewManager.
+func RegisterCustomProviders(claudeDir string) error {
+	customProviders, err := LoadCustomProviders(claudeDir)
+	if err != nil {
+		return err
+	}
+	for _, provider := range customProviders {
+		if err := claude.RegisterProviderName(provider.GetType()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Enable enables an AI provider with the given API key
-func (m *Manager) Enable(_ context.Context, provider ProviderType, apiKey string) error {
-	if !provider.IsValid() {
+func (m *Manager) Enable(ctx context.Context, provider ProviderType, apiKey string) error {
+	return m.EnableProfile(ctx, provider, DefaultProfile, apiKey)
+}
+
+// EnableProfile enables an AI provider with the API key stored under the
+// named profile. The default profile uses the legacy .{provider}_api_key file.
+func (m *Manager) EnableProfile(ctx context.Context, provider ProviderType, profile, apiKey string) error {
+	if _, exists := m.providers[provider]; !exists {
 		return fmt.Errorf("unsupported provider: %s", provider)
 	}
 
-	if apiKey == "" {
-		return fmt.Errorf("API key cannot be empty")
+	if err := ValidateProfileName(profile); err != nil {
+		return err
+	}
+
+	// Store the trimmed key so it matches what start reads back
+	apiKey = strings.TrimSpace(apiKey)
+	if err := ValidateAPIKey(provider, apiKey); err != nil {
+		return err
 	}
 
 	// Save API key
-	if err := m.saveAPIKey(provider, apiKey); err != nil {
+	if err := m.saveProfileAPIKey(provider, profile, apiKey); err != nil {
 		return fmt.Errorf("failed to save API key: %w", err)
 	}
 
@@ -55,6 +106,9 @@ func (m *Manager) Enable(_ context.Context, provider ProviderType, apiKey string
 
 	// Get default configuration
 	config := providerImpl.GetDefaultConfig(apiKey)
+	if err := m.applyBaseURL(ctx, provider, config); err != nil {
+		return err
+	}
 
 	// Load current settings
 	settings, err := m.loadSettings()
@@ -79,18 +133,98 @@ func (m *Manager) Enable(_ context.Context, provider ProviderType, apiKey string
 		return fmt.Errorf("failed to save settings: %w", err)
 	}
 
+	// Record the provider explicitly so detection doesn't rely on the base URL
+	if err := os.WriteFile(m.getActiveProviderPath(), []byte(provider), 0644); err != nil {
+		return fmt.Errorf("failed to write active provider file: %w", err)
+	}
+
+	return nil
+}
+
+// RotateKey replaces the provider's default API key, e.g. after it leaked.
+// When the provider is active with that key, ANTHROPIC_AUTH_TOKEN in
+// settings.json is updated as well; if settings.json can't be written the
+// old key is put back so the key file and settings.json stay in step.
+func (m *Manager) RotateKey(ctx context.Context, provider ProviderType, newKey string) error {
+	if _, exists := m.providers[provider]; !exists {
+		return fmt.Errorf("unsupported provider: %s", provider)
+	}
+
+	newKey = strings.TrimSpace(newKey)
+	if err := ValidateAPIKey(provider, newKey); err != nil {
+		return err
+	}
+
+	// A stored key would stay hidden behind the environment variable
+	if envAPIKey(provider, DefaultProfile) != "" {
+		return fmt.Errorf("the API key for %s comes from %s, update the environment variable instead", provider, APIKeyEnvVar(provider))
+	}
+
+	oldKey, err := m.loadAPIKey(provider)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("no API key stored for %s, use ai on %s first", provider, provider)
+	}
+	if err != nil {
+		return err
+	}
+
+	activeProvider, err := m.GetActiveProvider(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get active provider: %w", err)
+	}
+
+	// A provider enabled with another profile keeps using that profile's key
+	var settings *claude.Settings
+	if activeProvider == provider {
+		settings, err = m.loadSettings()
+		if err != nil {
+			return fmt.Errorf("failed to load settings: %w", err)
+		}
+		if token := settings.Env["ANTHROPIC_AUTH_TOKEN"]; token != oldKey && m.profileForAPIKey(provider, token) != "" {
+			settings = nil
+		}
+	}
+
+	if err := m.saveAPIKey(provider, newKey); err != nil {
+		return fmt.Errorf("failed to save API key: %w", err)
+	}
+	if settings == nil {
+		return nil
+	}
+
+	settings.Env["ANTHROPIC_AUTH_TOKEN"] = newKey
+	if err := m.saveSettings(settings); err != nil {
+		if restoreErr := m.saveAPIKey(provider, oldKey); restoreErr != nil {
+			return fmt.Errorf("failed to save settings: %w (restoring the old API key also failed: %v)", err, restoreErr)
+		}
+		return fmt.Errorf("failed to save settings: %w", err)
+	}
+
 	return nil
 }
 
-// Reset removes the API key and disables the provider
-func (m *Manager) Reset(_ context.Context, provider ProviderType) error {
+// Reset removes the API keys of all the provider's profiles, its base URL
+// override and selected endpoint, and disables the provider.
+// Settings are only cleared when the provider is the active one.
+func (m *Manager) Reset(ctx context.Context, provider ProviderType) error {
+	activeProvider, err := m.GetActiveProvider(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get active provider: %w", err)
+	}
+
 	// First disable the provider by clearing environment variables
 	settings, err := m.loadSettings()
 	if err != nil {
 		return fmt.Errorf("failed to load settings: %w", err)
 	}
 
-	if settings.Env != nil {
+	if activeProvider == provider {
+		if err := m.clearActiveProvider(); err != nil {
+			return err
+		}
+	}
+
+	if activeProvider == provider && settings.Env != nil {
 		// Remove AI provider environment variables
 		delete(settings.Env, "ANTHROPIC_AUTH_TOKEN")
 		delete(settings.Env, "ANTHROPIC_BASE_URL")
@@ -109,10 +243,23 @@ func (m *Manager) Reset(_ context.Context, provider ProviderType) error {
 		return fmt.Errorf("failed to save settings: %w", err)
 	}
 
-	// Remove API key file
-	apiKeyPath := m.getAPIKeyPath(provider)
-	if err := os.Remove(apiKeyPath); err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("failed to remove API key file: %w", err)
+	// Remove the API keys of every profile, wherever they are stored
+	profiles, err := m.ListProfiles(provider)
+	if err != nil {
+		return fmt.Errorf("failed to list profiles: %w", err)
+	}
+	for _, profile := range append([]string{DefaultProfile}, profiles...) {
+		if err := m.deleteProfileAPIKey(provider, profile); err != nil {
+			return err
+		}
+	}
+
+	// Remove base URL override and selected endpoint
+	if err := os.Remove(m.getBaseURLPath(provider)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove base URL file: %w", err)
+	}
+	if err := os.Remove(m.getEndpointPath(provider)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove endpoint file: %w", err)
 	}
 
 	return nil
@@ -150,49 +297,88 @@ func (m *Manager) Off(ctx context.Context) error {
 		return fmt.Errorf("failed to save settings: %w", err)
 	}
 
-	return nil
+	return m.clearActiveProvider()
 }
 
-// On restores the previously active AI provider
-func (m *Manager) On(ctx context.Context) error {
-	// Load the last active provider
-	lastProvider, err := m.loadLastActiveProvider()
+// GetLastActiveProvider returns the provider saved by Off that On would restore.
+// It fails when no provider was saved or its API key is gone.
+func (m *Manager) GetLastActiveProvider(_ context.Context) (*claude.LastActiveProvider, error) {
+	lastState, err := m.loadLastActiveProvider()
 	if err != nil {
-		return fmt.Errorf("failed to load last active provider: %w", err)
+		return nil, fmt.Errorf("failed to load last active provider: %w", err)
 	}
 
-	if lastProvider == ProviderNone {
-		return fmt.Errorf("没有找到之前的AI提供商配置")
+	if lastState.Provider == ProviderNone {
+		return nil, fmt.Errorf("没有找到之前的AI提供商配置")
 	}
 
 	// Check if we have API key for this provider
-	hasKey, err := m.HasAPIKey(ctx, lastProvider)
+	hasKey, err := m.hasProfileAPIKey(lastState.Provider, lastState.Profile)
 	if err != nil {
-		return fmt.Errorf("failed to check API key: %w", err)
+		return nil, fmt.Errorf("failed to check API key: %w", err)
 	}
-
 	if !hasKey {
-		return fmt.Errorf("提供商 %s 的API密钥已丢失，请重新启用", lastProvider)
+		return nil, fmt.Errorf("提供商 %s 的API密钥已丢失，请重新启用", lastState.Provider)
+	}
+
+	return lastState, nil
+}
+
+// On restores the previously active AI provider
+func (m *Manager) On(ctx context.Context) error {
+	lastState, err := m.GetLastActiveProvider(ctx)
+	if err != nil {
+		return err
 	}
+	lastProvider := lastState.Provider
 
 	// Load the API key
-	apiKey, err := m.loadAPIKey(lastProvider)
+	apiKey, err := m.loadProfileAPIKey(lastProvider, lastState.Profile)
 	if err != nil {
 		return fmt.Errorf("failed to load API key: %w", err)
 	}
 
 	// Re-enable the provider
-	if err := m.Enable(ctx, lastProvider, apiKey); err != nil {
+	if err := m.EnableProfile(ctx, lastProvider, lastState.Profile, apiKey); err != nil {
 		return fmt.Errorf("failed to restore provider %s: %w", lastProvider, err)
 	}
 
+	// Restore the models that were in use when the provider was turned off
+	if lastState.Model == "" && lastState.SmallFastModel == "" {
+		return nil
+	}
+
+	settings, err := m.loadSettings()
+	if err != nil {
+		return fmt.Errorf("failed to load settings: %w", err)
+	}
+	if lastState.Model != "" {
+		settings.Env["ANTHROPIC_DEFAULT_SONNET_MODEL"] = lastState.Model
+		settings.Env["ANTHROPIC_DEFAULT_OPUS_MODEL"] = lastState.Model
+	}
+	if lastState.SmallFastModel != "" {
+		settings.Env["ANTHROPIC_DEFAULT_HAIKU_MODEL"] = lastState.SmallFastModel
+	}
+	if err := m.saveSettings(settings); err != nil {
+		return fmt.Errorf("failed to save settings: %w", err)
+	}
+
 	return nil
 }
 
-// HasAPIKey returns whether an API key is stored for the provider
+// HasAPIKey returns whether an API key is set in the provider's environment
+// variable or stored for the provider
 func (m *Manager) HasAPIKey(_ context.Context, provider ProviderType) (bool, error) {
-	apiKeyPath := m.getAPIKeyPath(provider)
-	_, err := os.Stat(apiKeyPath)
+	return m.hasProfileAPIKey(provider, DefaultProfile)
+}
+
+// hasProfileAPIKey returns whether a profile's key is set in the environment or stored
+func (m *Manager) hasProfileAPIKey(provider ProviderType, profile string) (bool, error) {
+	if envAPIKey(provider, profile) != "" {
+		return true, nil
+	}
+
+	_, err := os.Stat(m.getProfileAPIKeyPath(provider, profile))
 	if os.IsNotExist(err) {
 		return false, nil
 	}
@@ -261,17 +447,106 @@ func (m *Manager) GetActiveProvider(_ context.Context) (ProviderType, error) {
 		return ProviderNone, nil
 	}
 
-	baseURL := settings.Env["ANTHROPIC_BASE_URL"]
+	return m.ProviderForBaseURL(settings.Env["ANTHROPIC_BASE_URL"]), nil
+}
+
+// ProviderForBaseURL returns the provider serving baseURL: the one recorded by
+// Enable, else the provider whose default base URL, known endpoint or
+// --base-url override equals baseURL. It returns ProviderNone when none matches.
+func (m *Manager) ProviderForBaseURL(baseURL string) ProviderType {
+	if baseURL == "" {
+		return ProviderNone
+	}
+
+	// Prefer the provider recorded by Enable
+	if data, err := os.ReadFile(m.getActiveProviderPath()); err == nil {
+		providerType := ProviderType(CleanFileValue(data))
+		if _, exists := m.providers[providerType]; exists {
+			return providerType
+		}
+	}
+
+	// Legacy configs: determine provider based on base URL
+	if providerType := m.providerForBaseURL(baseURL); providerType != ProviderNone {
+		return providerType
+	}
+	for _, providerType := range m.ListSupportedProviders() {
+		if override, err := m.loadBaseURL(providerType); err == nil && override == baseURL {
+			return providerType
+		}
+	}
+
+	return ProviderNone
+}
+
+// providerForBaseURL returns the provider whose default base URL or one of
+// whose known endpoints equals baseURL, ignoring a trailing slash
+func (m *Manager) providerForBaseURL(baseURL string) ProviderType {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	if baseURL == "" {
+		return ProviderNone
+	}
+
+	for _, providerType := range m.ListSupportedProviders() {
+		providerImpl := m.providers[providerType]
+		urls := []string{providerImpl.GetDefaultConfig("").BaseURL}
+		if endpointProvider, ok := providerImpl.(EndpointProvider); ok {
+			for _, url := range endpointProvider.Endpoints() {
+				urls = append(urls, url)
+			}
+		}
+
+		for _, url := range urls {
+			if strings.TrimSuffix(url, "/") == baseURL {
+				return providerType
+			}
+		}
+	}
+
+	return ProviderNone
+}
+
+// Status returns the active provider, its configuration and which providers
+// have a stored API key. The auth token in the returned config is masked.
+func (m *Manager) Status(ctx context.Context) (*ProviderStatus, error) {
+	activeProvider, err := m.GetActiveProvider(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &ProviderStatus{
+		ActiveProvider: activeProvider,
+		Keys:           make(map[ProviderType]bool, len(m.providers)),
+	}
+
+	if activeProvider != ProviderNone {
+		config, err := m.GetProviderConfig(ctx, activeProvider)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get provider config: %w", err)
+		}
+		if config != nil {
+			config.AuthToken = maskAPIKey(config.AuthToken)
+		}
+		status.Config = config
+	}
 
-	// Determine provider based on base URL
-	for providerType, provider := range m.providers {
-		config := provider.GetDefaultConfig("")
-		if config.BaseURL == baseURL {
-			return providerType, nil
+	for _, provider := range m.ListSupportedProviders() {
+		hasKey, err := m.HasAPIKey(ctx, provider)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check API key for %s: %w", provider, err)
 		}
+		status.Keys[provider] = hasKey
 	}
 
-	return ProviderNone, nil
+	return status, nil
+}
+
+// maskAPIKey keeps only the first and last few characters of a key
+func maskAPIKey(key string) string {
+	if len(key) <= 8 {
+		return strings.Repeat("*", len(key))
+	}
+	return key[:4] + strings.Repeat("*", len(key)-8) + key[len(key)-4:]
 }
 
 // ListSupportedProviders returns all supported provider types
@@ -280,73 +555,386 @@ func (m *Manager) ListSupportedProviders() []ProviderType {
 	for providerType := range m.providers {
 		providers = append(providers, providerType)
 	}
+	sort.Slice(providers, func(i, j int) bool {
+		return strings.ToLower(string(providers[i])) < strings.ToLower(string(providers[j]))
+	})
 	return providers
 }
 
-// getAPIKeyPath returns the API key file path for a provider
-func (m *Manager) getAPIKeyPath(provider ProviderType) string {
-	return filepath.Join(m.claudeDir, fmt.Sprintf(".%s_api_key", provider))
-}
+// SetDefaultProvider persists the provider used by start without arguments.
+// Passing ProviderNone clears the default.
+func (m *Manager) SetDefaultProvider(_ context.Context, provider ProviderType) error {
+	path := m.getDefaultProviderPath()
 
-// saveAPIKey saves API key to a secure file with restricted permissions
-func (m *Manager) saveAPIKey(provider ProviderType, apiKey string) error {
-	apiKeyPath := m.getAPIKeyPath(provider)
+	if provider == ProviderNone {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove default provider file: %w", err)
+		}
+		return nil
+	}
+
+	if _, exists := m.providers[provider]; !exists {
+		return fmt.Errorf("unsupported provider: %s", provider)
+	}
 
-	// Ensure directory exists
 	if err := os.MkdirAll(m.claudeDir, 0755); err != nil {
 		return fmt.Errorf("failed to create claude directory: %w", err)
 	}
 
-	// Write API key with restricted permissions
-	if err := os.WriteFile(apiKeyPath, []byte(apiKey), 0600); err != nil {
-		return fmt.Errorf("failed to write API key file: %w", err)
+	if err := os.WriteFile(path, []byte(provider), 0644); err != nil {
+		return fmt.Errorf("failed to write default provider file: %w", err)
 	}
 
 	return nil
 }
 
-// loadSettings loads settings from settings.json
-func (m *Manager) loadSettings() (*claude.Settings, error) {
-	settingsPath := filepath.Join(m.claudeDir, "settings.json")
+// GetDefaultProvider returns the persisted default provider, or ProviderNone
+func (m *Manager) GetDefaultProvider(_ context.Context) (ProviderType, error) {
+	data, err := os.ReadFile(m.getDefaultProviderPath())
+	if os.IsNotExist(err) {
+		return ProviderNone, nil
+	}
+	if err != nil {
+		return ProviderNone, fmt.Errorf("failed to read default provider file: %w", err)
+	}
+
+	provider := ProviderType(CleanFileValue(data))
+	if _, exists := m.providers[provider]; !exists {
+		return ProviderNone, fmt.Errorf("invalid default provider: %s", provider)
+	}
+
+	return provider, nil
+}
 
-	// If file doesn't exist, return default settings
-	if _, err := os.Stat(settingsPath); os.IsNotExist(err) {
-		return &claude.Settings{
-			IncludeCoAuthoredBy: false,
-		}, nil
+// ListProfiles returns the names of the profiles with a stored API key for
+// the provider, sorted. The legacy key file and a key from the provider's
+// environment variable are reported as DefaultProfile.
+func (m *Manager) ListProfiles(provider ProviderType) ([]string, error) {
+	var profiles []string
+	hasDefault, err := m.HasAPIKey(context.Background(), provider)
+	if err != nil {
+		return nil, err
+	}
+	if hasDefault {
+		profiles = append(profiles, DefaultProfile)
 	}
 
-	data, err := os.ReadFile(settingsPath)
+	entries, err := os.ReadDir(m.claudeDir)
+	if os.IsNotExist(err) {
+		return profiles, nil
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to read settings file: %w", err)
+		return nil, fmt.Errorf("failed to read claude directory: %w", err)
 	}
 
-	var settings claude.Settings
-	if err := json.Unmarshal(data, &settings); err != nil {
-		return nil, fmt.Errorf("failed to parse settings file: %w", err)
+	defaultName := APIKeyFileName(provider, DefaultProfile)
+	prefix := fmt.Sprintf(".%s.", provider)
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			continue
+		}
+		if name == defaultName {
+			continue
+		}
+		if strings.HasPrefix(name, prefix) && strings.HasSuffix(name, claude.APIKeyFileSuffix) {
+			profile := strings.TrimSuffix(strings.TrimPrefix(name, prefix), claude.APIKeyFileSuffix)
+			if ValidateProfileName(profile) == nil {
+				profiles = append(profiles, profile)
+			}
+		}
 	}
 
-	return &settings, nil
+	sort.Strings(profiles)
+	return profiles, nil
 }
 
-// saveSettings saves settings to settings.json
-func (m *Manager) saveSettings(settings *claude.Settings) error {
-	settingsPath := filepath.Join(m.claudeDir, "settings.json")
+// APIKeyFileName returns the key file name for a provider profile.
+// An empty profile is treated as DefaultProfile.
+func APIKeyFileName(provider ProviderType, profile string) string {
+	if profile == "" || profile == DefaultProfile {
+		return fmt.Sprintf(".%s%s", provider, claude.APIKeyFileSuffix)
+	}
+	return fmt.Sprintf(".%s.%s%s", provider, profile, claude.APIKeyFileSuffix)
+}
+
+// ValidateProfileName checks that a profile name is safe to use in a file name.
+// An empty name selects the default profile.
+func ValidateProfileName(profile string) error {
+	for _, r := range profile {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '-' && r != '_' {
+			return fmt.Errorf("invalid profile name %q: only letters, digits, '-' and '_' are allowed", profile)
+		}
+	}
+	return nil
+}
+
+// SetEndpoint selects one of the provider's known base URLs, used by the next
+// Enable. An empty name or the provider's default endpoint clears the selection.
+func (m *Manager) SetEndpoint(_ context.Context, provider ProviderType, endpoint string) error {
+	endpointProvider, ok := m.providers[provider].(EndpointProvider)
+	if !ok {
+		return fmt.Errorf("provider %s has no selectable endpoints", provider)
+	}
+
+	path := m.getEndpointPath(provider)
+	if endpoint == "" || endpoint == endpointProvider.DefaultEndpoint() {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove endpoint file: %w", err)
+		}
+		return nil
+	}
+
+	endpoints := endpointProvider.Endpoints()
+	if _, exists := endpoints[endpoint]; !exists {
+		names := make([]string, 0, len(endpoints))
+		for name := range endpoints {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown endpoint %q for provider %s, valid endpoints: %s",
+			endpoint, provider, strings.Join(names, ", "))
+	}
+
+	if err := os.MkdirAll(m.claudeDir, 0755); err != nil {
+		return fmt.Errorf("failed to create claude directory: %w", err)
+	}
+
+	if err := os.WriteFile(path, []byte(endpoint), 0644); err != nil {
+		return fmt.Errorf("failed to write endpoint file: %w", err)
+	}
+
+	return nil
+}
+
+// GetEndpoint returns the selected endpoint name for the provider, or its
+// default endpoint. Providers without selectable endpoints return "".
+func (m *Manager) GetEndpoint(_ context.Context, provider ProviderType) (string, error) {
+	endpointProvider, ok := m.providers[provider].(EndpointProvider)
+	if !ok {
+		return "", nil
+	}
+
+	data, err := os.ReadFile(m.getEndpointPath(provider))
+	if os.IsNotExist(err) {
+		return endpointProvider.DefaultEndpoint(), nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read endpoint file: %w", err)
+	}
+
+	endpoint := CleanFileValue(data)
+	if _, exists := endpointProvider.Endpoints()[endpoint]; !exists {
+		return "", fmt.Errorf("invalid endpoint for %s: %s", provider, endpoint)
+	}
+
+	return endpoint, nil
+}
+
+// endpointURL returns the base URL of the selected endpoint, or "" when the
+// provider has no selectable endpoints
+func (m *Manager) endpointURL(ctx context.Context, provider ProviderType) (string, error) {
+	endpoint, err := m.GetEndpoint(ctx, provider)
+	if err != nil || endpoint == "" {
+		return "", err
+	}
+	return m.providers[provider].(EndpointProvider).Endpoints()[endpoint], nil
+}
+
+// SetBaseURL overrides the provider's base URL for the next Enable, e.g. to
+// test a staging endpoint. The override persists until cleared with an empty
+// URL or the provider is reset.
+func (m *Manager) SetBaseURL(_ context.Context, provider ProviderType, baseURL string) error {
+	if _, exists := m.providers[provider]; !exists {
+		return fmt.Errorf("unsupported provider: %s", provider)
+	}
+
+	path := m.getBaseURLPath(provider)
+	baseURL = strings.TrimSpace(baseURL)
+	if baseURL == "" {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove base URL file: %w", err)
+		}
+		return nil
+	}
+
+	if err := ValidateBaseURL(baseURL); err != nil {
+		return err
+	}
 
-	// Ensure directory exists
 	if err := os.MkdirAll(m.claudeDir, 0755); err != nil {
 		return fmt.Errorf("failed to create claude directory: %w", err)
 	}
 
-	data, err := json.MarshalIndent(settings, "", "  ")
+	if err := os.WriteFile(path, []byte(baseURL), 0644); err != nil {
+		return fmt.Errorf("failed to write base URL file: %w", err)
+	}
+
+	return nil
+}
+
+// applyBaseURL sets config.BaseURL to the provider's base URL override, or the
+// selected endpoint for providers with several base URLs
+func (m *Manager) applyBaseURL(ctx context.Context, provider ProviderType, config *ProviderConfig) error {
+	baseURL, err := m.GetBaseURL(ctx, provider)
 	if err != nil {
-		return fmt.Errorf("failed to marshal settings: %w", err)
+		return err
+	}
+	if baseURL == "" {
+		baseURL, err = m.endpointURL(ctx, provider)
+		if err != nil {
+			return err
+		}
 	}
+	if baseURL != "" {
+		config.BaseURL = baseURL
+	}
+	return nil
+}
 
-	if err := os.WriteFile(settingsPath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write settings file: %w", err)
+// GetBaseURL returns the base URL override for the provider, or "" when none is set
+func (m *Manager) GetBaseURL(_ context.Context, provider ProviderType) (string, error) {
+	baseURL, err := m.loadBaseURL(provider)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read base URL file: %w", err)
 	}
+	return baseURL, nil
+}
 
+// ValidateBaseURL checks that baseURL is an absolute http(s) URL with a host
+func ValidateBaseURL(baseURL string) error {
+	parsed, err := url.Parse(baseURL)
+	if err != nil {
+		return fmt.Errorf("invalid base URL %q: %w", baseURL, err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("invalid base URL %q: scheme must be http or https", baseURL)
+	}
+	if parsed.Hostname() == "" {
+		return fmt.Errorf("invalid base URL %q: missing host", baseURL)
+	}
+	return nil
+}
+
+// loadBaseURL reads the base URL override file for the provider
+func (m *Manager) loadBaseURL(provider ProviderType) (string, error) {
+	data, err := os.ReadFile(m.getBaseURLPath(provider))
+	if err != nil {
+		return "", err
+	}
+	return CleanFileValue(data), nil
+}
+
+// getBaseURLPath returns the path storing the base URL override for a provider
+func (m *Manager) getBaseURLPath(provider ProviderType) string {
+	return filepath.Join(m.claudeDir, fmt.Sprintf(".%s_base_url", provider))
+}
+
+// getEndpointPath returns the path storing the selected endpoint for a provider
+func (m *Manager) getEndpointPath(provider ProviderType) string {
+	return filepath.Join(m.claudeDir, fmt.Sprintf(".%s_endpoint", provider))
+}
+
+// getAPIKeyPath returns the API key file path for a provider
+func (m *Manager) getAPIKeyPath(provider ProviderType) string {
+	return m.getProfileAPIKeyPath(provider, DefaultProfile)
+}
+
+// getProfileAPIKeyPath returns the API key file path for a provider profile
+func (m *Manager) getProfileAPIKeyPath(provider ProviderType, profile string) string {
+	return m.files.path(provider, profile)
+}
+
+// saveAPIKey saves API key to a secure file with restricted permissions
+func (m *Manager) saveAPIKey(provider ProviderType, apiKey string) error {
+	return m.saveProfileAPIKey(provider, DefaultProfile, apiKey)
+}
+
+// saveProfileAPIKey saves a profile's API key in the configured key store.
+// Moving a key out of the keychain removes the keychain entry. A key that
+// came from the provider's environment variable is not written anywhere.
+func (m *Manager) saveProfileAPIKey(provider ProviderType, profile, apiKey string) error {
+	if envKey := envAPIKey(provider, profile); envKey != "" && envKey == apiKey {
+		return nil
+	}
+
+	inKeychain, err := m.inKeychain(provider, profile)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if m.keyStore == KeyStoreKeychain || (m.keyStore == "" && inKeychain) {
+		if err := m.keychain.Set(provider, profile, apiKey); err != nil {
+			return err
+		}
+		return m.files.write(provider, profile, []byte(keychainReference))
+	}
+
+	if err := m.files.Set(provider, profile, apiKey); err != nil {
+		return err
+	}
+	if inKeychain {
+		return m.keychain.Delete(provider, profile)
+	}
+	return nil
+}
+
+// deleteProfileAPIKey removes a profile's API key from whichever store holds it
+func (m *Manager) deleteProfileAPIKey(provider ProviderType, profile string) error {
+	inKeychain, err := m.inKeychain(provider, profile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if inKeychain {
+		if err := m.keychain.Delete(provider, profile); err != nil {
+			return err
+		}
+	}
+	return m.files.Delete(provider, profile)
+}
+
+// inKeychain reports whether a profile's key file points at the OS keychain
+func (m *Manager) inKeychain(provider ProviderType, profile string) (bool, error) {
+	data, err := m.files.read(provider, profile)
+	if err != nil {
+		return false, err
+	}
+	return isKeychainReference(data), nil
+}
+
+// loadSettings loads settings from settings.json
+func (m *Manager) loadSettings() (*claude.Settings, error) {
+	return settingsstore.Load(m.claudeDir)
+}
+
+// saveSettings saves settings to settings.json
+func (m *Manager) saveSettings(settings *claude.Settings) error {
+	return settingsstore.Save(m.claudeDir, settings)
+}
+
+// getDefaultProviderPath returns the path storing the default provider for start
+func (m *Manager) getDefaultProviderPath() string {
+	return filepath.Join(m.claudeDir, ".default_provider")
+}
+
+// getActiveProviderPath returns the path recording the currently enabled provider
+func (m *Manager) getActiveProviderPath() string {
+	return filepath.Join(m.claudeDir, ".active_provider")
+}
+
+// clearActiveProvider removes the active provider record
+func (m *Manager) clearActiveProvider() error {
+	if err := os.Remove(m.getActiveProviderPath()); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove active provider file: %w", err)
+	}
 	return nil
 }
 
@@ -355,7 +943,7 @@ func (m *Manager) getLastActiveProviderPath() string {
 	return filepath.Join(m.claudeDir, ".last_active_provider")
 }
 
-// saveLastActiveProvider saves the currently active provider
+// saveLastActiveProvider saves the currently active provider and its models
 func (m *Manager) saveLastActiveProvider(ctx context.Context) error {
 	activeProvider, err := m.GetActiveProvider(ctx)
 	if err != nil {
@@ -367,6 +955,22 @@ func (m *Manager) saveLastActiveProvider(ctx context.Context) error {
 		return nil
 	}
 
+	state := claude.LastActiveProvider{Provider: activeProvider}
+	config, err := m.GetProviderConfig(ctx, activeProvider)
+	if err != nil {
+		return fmt.Errorf("failed to get provider config: %w", err)
+	}
+	if config != nil {
+		state.Model = config.Model
+		state.SmallFastModel = config.SmallFastModel
+		state.Profile = m.profileForAPIKey(activeProvider, config.AuthToken)
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal last active provider: %w", err)
+	}
+
 	lastProviderPath := m.getLastActiveProviderPath()
 
 	// Ensure directory exists
@@ -375,45 +979,118 @@ func (m *Manager) saveLastActiveProvider(ctx context.Context) error {
 	}
 
 	// Write last active provider
-	if err := os.WriteFile(lastProviderPath, []byte(string(activeProvider)), 0644); err != nil {
+	if err := os.WriteFile(lastProviderPath, data, 0644); err != nil {
 		return fmt.Errorf("failed to write last active provider file: %w", err)
 	}
 
 	return nil
 }
 
-// loadLastActiveProvider loads the last active provider
-func (m *Manager) loadLastActiveProvider() (ProviderType, error) {
+// loadLastActiveProvider loads the last active provider and its models.
+// Older versions stored the bare provider name, which is still accepted.
+func (m *Manager) loadLastActiveProvider() (*claude.LastActiveProvider, error) {
 	lastProviderPath := m.getLastActiveProviderPath()
 
-	// If file doesn't exist, return ProviderNone
+	// If file doesn't exist, return no provider
 	if _, err := os.Stat(lastProviderPath); os.IsNotExist(err) {
-		return ProviderNone, nil
+		return &claude.LastActiveProvider{Provider: ProviderNone}, nil
 	}
 
 	data, err := os.ReadFile(lastProviderPath)
 	if err != nil {
-		return ProviderNone, fmt.Errorf("failed to read last active provider file: %w", err)
+		return nil, fmt.Errorf("failed to read last active provider file: %w", err)
+	}
+
+	state := &claude.LastActiveProvider{}
+	trimmed := CleanFileValue(data)
+	if strings.HasPrefix(trimmed, "{") {
+		if err := json.Unmarshal([]byte(trimmed), state); err != nil {
+			return nil, fmt.Errorf("failed to parse last active provider file: %w", err)
+		}
+	} else {
+		// Legacy format: plain provider name
+		state.Provider = ProviderType(trimmed)
 	}
 
-	providerType := ProviderType(string(data))
-	if !providerType.IsValid() {
-		return ProviderNone, fmt.Errorf("invalid provider type: %s", providerType)
+	if _, exists := m.providers[state.Provider]; !exists {
+		return nil, fmt.Errorf("invalid provider type: %s", state.Provider)
 	}
 
-	return providerType, nil
+	return state, nil
+}
+
+// profileForAPIKey returns the non-default profile whose stored key matches
+// apiKey, or an empty string for the default profile
+func (m *Manager) profileForAPIKey(provider ProviderType, apiKey string) string {
+	profiles, err := m.ListProfiles(provider)
+	if err != nil {
+		return ""
+	}
+	for _, profile := range profiles {
+		if profile == DefaultProfile {
+			continue
+		}
+		if key, err := m.loadProfileAPIKey(provider, profile); err == nil && key == apiKey {
+			return profile
+		}
+	}
+	return ""
 }
 
-// loadAPIKey loads API key from file
+// loadAPIKey loads the provider's default API key
 func (m *Manager) loadAPIKey(provider ProviderType) (string, error) {
-	apiKeyPath := m.getAPIKeyPath(provider)
+	return m.loadProfileAPIKey(provider, DefaultProfile)
+}
+
+// loadProfileAPIKey loads a profile's API key. The provider's environment
+// variable takes precedence over the key file for the default profile.
+func (m *Manager) loadProfileAPIKey(provider ProviderType, profile string) (string, error) {
+	if apiKey := envAPIKey(provider, profile); apiKey != "" {
+		return apiKey, nil
+	}
 
-	data, err := os.ReadFile(apiKeyPath)
+	inKeychain, err := m.inKeychain(provider, profile)
 	if err != nil {
-		return "", fmt.Errorf("failed to read API key file: %w", err)
+		return "", err
+	}
+	if inKeychain {
+		return m.keychain.Get(provider, profile)
+	}
+
+	return m.files.Get(provider, profile)
+}
+
+// LoadAPIKey returns the API key stored for a provider profile, reading it
+// from the OS keychain or decrypting it as needed
+func (m *Manager) LoadAPIKey(provider ProviderType, profile string) (string, error) {
+	if err := ValidateProfileName(profile); err != nil {
+		return "", err
+	}
+	if profile == "" {
+		profile = DefaultProfile
+	}
+	return m.loadProfileAPIKey(provider, profile)
+}
+
+// SetKeyStore selects where API keys saved from now on are stored: "file"
+// or "keychain". Keys already stored elsewhere move when they are next saved.
+func (m *Manager) SetKeyStore(name string) error {
+	switch name {
+	case KeyStoreFile, KeyStoreKeychain:
+		m.keyStore = name
+		return nil
+	default:
+		return fmt.Errorf("unknown key store %q, expected %s or %s", name, KeyStoreFile, KeyStoreKeychain)
 	}
+}
 
-	return string(data), nil
+// CleanFileValue normalizes the contents of a single-value file that may have
+// been edited by hand, e.g. in Windows Notepad: it drops a UTF-8 BOM, converts
+// CRLF line endings to LF and trims surrounding whitespace
+func CleanFileValue(data []byte) string {
+	value := strings.TrimPrefix(string(data), "\ufeff")
+	value = strings.ReplaceAll(value, "\r\n", "\n")
+	return strings.TrimSpace(value)
 }
 
 // addDefaultModelEnvVars 添加默认模型环境变量
@@ -430,13 +1107,25 @@ func (m *Manager) addDefaultModelEnvVars(env map[string]string, provider Provide
 		sonnetModel = config.Model
 		opusModel = config.Model
 	case ProviderGLM:
-		haikuModel = "glm-4.7"
-		sonnetModel = "glm-4.7"
-		opusModel = "glm-4.7"
+		haikuModel = config.Model
+		sonnetModel = config.Model
+		opusModel = config.Model
 	case ProviderDoubao:
 		haikuModel = config.Model
 		sonnetModel = config.Model
 		opusModel = config.Model
+	case ProviderAnthropic:
+		haikuModel = config.SmallFastModel
+		sonnetModel = config.Model
+		opusModel = config.Model
+	default:
+		// Providers from providers.json
+		haikuModel = config.SmallFastModel
+		if haikuModel == "" {
+			haikuModel = config.Model
+		}
+		sonnetModel = config.Model
+		opusModel = config.Model
 	}
 
 	env["ANTHROPIC_DEFAULT_HAIKU_MODEL"] = haikuModel
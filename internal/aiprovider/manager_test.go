@@ -4,11 +4,22 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/ooneko/claude-config/internal/claude"
 )
 
+func TestMain(m *testing.M) {
+	// Keys exported in the developer's shell would shadow the ones tests store
+	for _, provider := range NewManager("").ListSupportedProviders() {
+		os.Unsetenv(APIKeyEnvVar(provider))
+	}
+	os.Exit(m.Run())
+}
+
 func TestNewManager(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -18,12 +29,12 @@ func TestNewManager(t *testing.T) {
 		{
 			name:      "create manager with valid directory",
 			claudeDir: "/tmp/test-claude",
-			want:      4, // DeepSeek, Kimi, ZhiPu, Doubao
+			want:      5, // DeepSeek, Kimi, ZhiPu, Doubao, Anthropic
 		},
 		{
 			name:      "create manager with empty directory",
 			claudeDir: "",
-			want:      4,
+			want:      5,
 		},
 	}
 
@@ -51,7 +62,7 @@ func TestNewManager(t *testing.T) {
 			}
 
 			// Check all expected providers are registered
-			expectedProviders := []ProviderType{ProviderDeepSeek, ProviderKimi, ProviderGLM, ProviderDoubao}
+			expectedProviders := []ProviderType{ProviderDeepSeek, ProviderKimi, ProviderGLM, ProviderDoubao, ProviderAnthropic}
 			for _, provider := range expectedProviders {
 				if _, exists := mgr.providers[provider]; !exists {
 					t.Errorf("NewManager() missing provider %v", provider)
@@ -297,14 +308,14 @@ func TestManager_Off(t *testing.T) {
 
 				// Verify last active provider was saved if any provider was enabled
 				if len(tt.setup) > 0 {
-					lastProvider, err := mgr.loadLastActiveProvider()
+					lastState, err := mgr.loadLastActiveProvider()
 					if err != nil {
-						t.Errorf("loadLastActiveProvider() error = %v", err)
+						t.Fatalf("loadLastActiveProvider() error = %v", err)
 					}
 					// Should save the last enabled provider
 					expectedLast := tt.setup[len(tt.setup)-1]
-					if lastProvider != expectedLast {
-						t.Errorf("Last active provider = %v, want %v", lastProvider, expectedLast)
+					if lastState.Provider != expectedLast {
+						t.Errorf("Last active provider = %v, want %v", lastState.Provider, expectedLast)
 					}
 				}
 			}
@@ -564,7 +575,7 @@ func TestManager_ListSupportedProviders(t *testing.T) {
 
 	providers := mgr.ListSupportedProviders()
 
-	expectedProviders := []ProviderType{ProviderDeepSeek, ProviderKimi, ProviderGLM, ProviderDoubao}
+	expectedProviders := []ProviderType{ProviderDeepSeek, ProviderKimi, ProviderGLM, ProviderDoubao, ProviderAnthropic}
 	if len(providers) != len(expectedProviders) {
 		t.Errorf("ListSupportedProviders() returned %d providers, want %d", len(providers), len(expectedProviders))
 	}
@@ -747,6 +758,33 @@ func TestManager_saveAPIKey(t *testing.T) {
 	}
 }
 
+func TestManager_saveAPIKey_RestrictsLooseFile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix permission bits are not supported on Windows")
+	}
+
+	tmpDir := t.TempDir()
+	mgr := NewManager(tmpDir).(*Manager)
+	apiKeyPath := mgr.getAPIKeyPath(ProviderDeepSeek)
+	if err := os.WriteFile(apiKeyPath, []byte("sk-old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(apiKeyPath, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mgr.saveAPIKey(ProviderDeepSeek, "sk-new"); err != nil {
+		t.Fatalf("saveAPIKey() error = %v", err)
+	}
+	info, err := os.Stat(apiKeyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("API key file permissions = %v, want 0600", info.Mode().Perm())
+	}
+}
+
 func TestManager_loadAPIKey(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -794,3 +832,718 @@ func TestManager_loadAPIKey(t *testing.T) {
 		})
 	}
 }
+
+func TestManager_Reset_InactiveProviderKeepsSettings(t *testing.T) {
+	tmpDir := t.TempDir()
+	mgr := NewManager(tmpDir).(*Manager)
+	ctx := context.Background()
+
+	if err := mgr.Enable(ctx, ProviderKimi, "sk-kimi"); err != nil {
+		t.Fatalf("Setup enable failed: %v", err)
+	}
+	if err := mgr.Enable(ctx, ProviderDeepSeek, "sk-deepseek"); err != nil {
+		t.Fatalf("Setup enable failed: %v", err)
+	}
+
+	// Kimi is not active, so only its key should be removed
+	if err := mgr.Reset(ctx, ProviderKimi); err != nil {
+		t.Fatalf("Manager.Reset() error = %v", err)
+	}
+
+	hasKey, _ := mgr.HasAPIKey(ctx, ProviderKimi)
+	if hasKey {
+		t.Error("Kimi API key should be removed after reset")
+	}
+
+	active, err := mgr.GetActiveProvider(ctx)
+	if err != nil {
+		t.Fatalf("Manager.GetActiveProvider() error = %v", err)
+	}
+	if active != ProviderDeepSeek {
+		t.Errorf("Active provider should remain deepseek, got %v", active)
+	}
+}
+
+func TestManager_RotateKey(t *testing.T) {
+	tmpDir := t.TempDir()
+	mgr := NewManager(tmpDir).(*Manager)
+	ctx := context.Background()
+
+	if err := mgr.RotateKey(ctx, ProviderDeepSeek, "sk-new"); err == nil {
+		t.Error("RotateKey() without a stored key should fail")
+	}
+
+	if err := mgr.Enable(ctx, ProviderDeepSeek, "sk-old"); err != nil {
+		t.Fatalf("Setup enable failed: %v", err)
+	}
+	if err := mgr.RotateKey(ctx, ProviderDeepSeek, "  "); err == nil {
+		t.Error("RotateKey() with an empty key should fail")
+	}
+
+	// DeepSeek is active, so settings.json picks up the new key too
+	if err := mgr.RotateKey(ctx, ProviderDeepSeek, " sk-new\n"); err != nil {
+		t.Fatalf("RotateKey() error = %v", err)
+	}
+
+	apiKey, err := mgr.loadAPIKey(ProviderDeepSeek)
+	if err != nil {
+		t.Fatalf("loadAPIKey() error = %v", err)
+	}
+	if apiKey != "sk-new" {
+		t.Errorf("stored key = %q, want %q", apiKey, "sk-new")
+	}
+
+	settings, err := mgr.loadSettings()
+	if err != nil {
+		t.Fatalf("loadSettings() error = %v", err)
+	}
+	if token := settings.Env["ANTHROPIC_AUTH_TOKEN"]; token != "sk-new" {
+		t.Errorf("ANTHROPIC_AUTH_TOKEN = %q, want %q", token, "sk-new")
+	}
+}
+
+func TestManager_RotateKey_InactiveProvider(t *testing.T) {
+	tmpDir := t.TempDir()
+	mgr := NewManager(tmpDir).(*Manager)
+	ctx := context.Background()
+
+	if err := mgr.Enable(ctx, ProviderKimi, "sk-kimi-old"); err != nil {
+		t.Fatalf("Setup enable failed: %v", err)
+	}
+	if err := mgr.Enable(ctx, ProviderDeepSeek, "sk-deepseek"); err != nil {
+		t.Fatalf("Setup enable failed: %v", err)
+	}
+
+	// Kimi is not active, so only its key file should change
+	if err := mgr.RotateKey(ctx, ProviderKimi, "sk-kimi-new"); err != nil {
+		t.Fatalf("RotateKey() error = %v", err)
+	}
+
+	apiKey, err := mgr.loadAPIKey(ProviderKimi)
+	if err != nil {
+		t.Fatalf("loadAPIKey() error = %v", err)
+	}
+	if apiKey != "sk-kimi-new" {
+		t.Errorf("stored key = %q, want %q", apiKey, "sk-kimi-new")
+	}
+
+	settings, err := mgr.loadSettings()
+	if err != nil {
+		t.Fatalf("loadSettings() error = %v", err)
+	}
+	if token := settings.Env["ANTHROPIC_AUTH_TOKEN"]; token != "sk-deepseek" {
+		t.Errorf("ANTHROPIC_AUTH_TOKEN = %q, want %q", token, "sk-deepseek")
+	}
+
+	active, err := mgr.GetActiveProvider(ctx)
+	if err != nil {
+		t.Fatalf("GetActiveProvider() error = %v", err)
+	}
+	if active != ProviderDeepSeek {
+		t.Errorf("Active provider should remain deepseek, got %v", active)
+	}
+}
+
+func TestManager_OffOn_RestoresCustomModel(t *testing.T) {
+	tmpDir := t.TempDir()
+	mgr := NewManager(tmpDir).(*Manager)
+	ctx := context.Background()
+
+	if err := mgr.Enable(ctx, ProviderKimi, "test-key"); err != nil {
+		t.Fatalf("Enable() error = %v", err)
+	}
+
+	// Override the model the way a user would in settings.json
+	settings, err := mgr.loadSettings()
+	if err != nil {
+		t.Fatalf("loadSettings() error = %v", err)
+	}
+	settings.Env["ANTHROPIC_DEFAULT_SONNET_MODEL"] = "custom-model"
+	settings.Env["ANTHROPIC_DEFAULT_OPUS_MODEL"] = "custom-model"
+	settings.Env["ANTHROPIC_DEFAULT_HAIKU_MODEL"] = "custom-fast-model"
+	if err := mgr.saveSettings(settings); err != nil {
+		t.Fatalf("saveSettings() error = %v", err)
+	}
+
+	if err := mgr.Off(ctx); err != nil {
+		t.Fatalf("Off() error = %v", err)
+	}
+	if err := mgr.On(ctx); err != nil {
+		t.Fatalf("On() error = %v", err)
+	}
+
+	settings, err = mgr.loadSettings()
+	if err != nil {
+		t.Fatalf("loadSettings() error = %v", err)
+	}
+	if got := settings.Env["ANTHROPIC_DEFAULT_SONNET_MODEL"]; got != "custom-model" {
+		t.Errorf("sonnet model = %q, want %q", got, "custom-model")
+	}
+	if got := settings.Env["ANTHROPIC_DEFAULT_OPUS_MODEL"]; got != "custom-model" {
+		t.Errorf("opus model = %q, want %q", got, "custom-model")
+	}
+	if got := settings.Env["ANTHROPIC_DEFAULT_HAIKU_MODEL"]; got != "custom-fast-model" {
+		t.Errorf("haiku model = %q, want %q", got, "custom-fast-model")
+	}
+}
+
+func TestManager_GetLastActiveProvider(t *testing.T) {
+	tmpDir := t.TempDir()
+	mgr := NewManager(tmpDir).(*Manager)
+	ctx := context.Background()
+
+	if _, err := mgr.GetLastActiveProvider(ctx); err == nil {
+		t.Fatal("GetLastActiveProvider() expected error without a saved provider")
+	}
+
+	if err := mgr.Enable(ctx, ProviderKimi, "test-key"); err != nil {
+		t.Fatalf("Enable() error = %v", err)
+	}
+	if err := mgr.Off(ctx); err != nil {
+		t.Fatalf("Off() error = %v", err)
+	}
+
+	lastState, err := mgr.GetLastActiveProvider(ctx)
+	if err != nil {
+		t.Fatalf("GetLastActiveProvider() error = %v", err)
+	}
+	if lastState.Provider != ProviderKimi {
+		t.Errorf("provider = %q, want %q", lastState.Provider, ProviderKimi)
+	}
+
+	// Only reading the saved provider must not enable it
+	if active, _ := mgr.GetActiveProvider(ctx); active != ProviderNone {
+		t.Errorf("active provider = %q, want none", active)
+	}
+
+	if err := os.Remove(mgr.getAPIKeyPath(ProviderKimi)); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if _, err := mgr.GetLastActiveProvider(ctx); err == nil || !strings.Contains(err.Error(), "API密钥已丢失") {
+		t.Errorf("GetLastActiveProvider() error = %v, want missing API key", err)
+	}
+}
+
+func TestManager_On_LegacyLastActiveProvider(t *testing.T) {
+	tmpDir := t.TempDir()
+	mgr := NewManager(tmpDir).(*Manager)
+	ctx := context.Background()
+
+	if err := mgr.saveAPIKey(ProviderDeepSeek, "test-key"); err != nil {
+		t.Fatalf("saveAPIKey() error = %v", err)
+	}
+	// Older versions wrote the bare provider name
+	if err := os.WriteFile(mgr.getLastActiveProviderPath(), []byte("deepseek"), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	if err := mgr.On(ctx); err != nil {
+		t.Fatalf("On() error = %v", err)
+	}
+
+	activeProvider, err := mgr.GetActiveProvider(ctx)
+	if err != nil {
+		t.Fatalf("GetActiveProvider() error = %v", err)
+	}
+	if activeProvider != ProviderDeepSeek {
+		t.Errorf("Active provider = %v, want %v", activeProvider, ProviderDeepSeek)
+	}
+}
+
+func TestManager_Enable_AnthropicRoundTrip(t *testing.T) {
+	tmpDir := t.TempDir()
+	mgr := NewManager(tmpDir).(*Manager)
+	ctx := context.Background()
+
+	if err := mgr.Enable(ctx, ProviderAnthropic, "sk-ant-test"); err != nil {
+		t.Fatalf("Enable() error = %v", err)
+	}
+
+	activeProvider, err := mgr.GetActiveProvider(ctx)
+	if err != nil {
+		t.Fatalf("GetActiveProvider() error = %v", err)
+	}
+	if activeProvider != ProviderAnthropic {
+		t.Errorf("Active provider = %v, want %v", activeProvider, ProviderAnthropic)
+	}
+
+	config, err := mgr.GetProviderConfig(ctx, ProviderAnthropic)
+	if err != nil {
+		t.Fatalf("GetProviderConfig() error = %v", err)
+	}
+	if config.AuthToken != "sk-ant-test" {
+		t.Errorf("AuthToken = %q, want %q", config.AuthToken, "sk-ant-test")
+	}
+	if config.BaseURL != "https://api.anthropic.com" {
+		t.Errorf("BaseURL = %q, want %q", config.BaseURL, "https://api.anthropic.com")
+	}
+	if config.Model != "claude-sonnet-4-5" {
+		t.Errorf("Model = %q, want %q", config.Model, "claude-sonnet-4-5")
+	}
+	if config.SmallFastModel != "claude-haiku-4-5" {
+		t.Errorf("SmallFastModel = %q, want %q", config.SmallFastModel, "claude-haiku-4-5")
+	}
+}
+
+// sharedURLProvider mimics a provider whose base URL collides with another one
+type sharedURLProvider struct {
+	providerType ProviderType
+	baseURL      string
+}
+
+func (p *sharedURLProvider) GetType() ProviderType { return p.providerType }
+
+func (p *sharedURLProvider) GetDefaultConfig(apiKey string) *ProviderConfig {
+	return &ProviderConfig{Type: p.providerType, AuthToken: apiKey, BaseURL: p.baseURL, Model: "shared-model"}
+}
+
+func (p *sharedURLProvider) ValidateConfig(_ *ProviderConfig) error { return nil }
+
+func (p *sharedURLProvider) SupportedModels() []string { return []string{"shared-model"} }
+
+func TestManager_GetActiveProvider_SharedBaseURL(t *testing.T) {
+	tmpDir := t.TempDir()
+	mgr := NewManager(tmpDir).(*Manager)
+	ctx := context.Background()
+
+	sharedURL := "https://shared.example.com/anthropic"
+	mgr.providers[ProviderDeepSeek] = &sharedURLProvider{providerType: ProviderDeepSeek, baseURL: sharedURL}
+	mgr.providers[ProviderKimi] = &sharedURLProvider{providerType: ProviderKimi, baseURL: sharedURL}
+
+	for _, provider := range []ProviderType{ProviderKimi, ProviderDeepSeek} {
+		if err := mgr.Enable(ctx, provider, "test-key"); err != nil {
+			t.Fatalf("Enable(%v) error = %v", provider, err)
+		}
+
+		// Repeat to catch map iteration order dependence
+		for i := 0; i < 20; i++ {
+			active, err := mgr.GetActiveProvider(ctx)
+			if err != nil {
+				t.Fatalf("GetActiveProvider() error = %v", err)
+			}
+			if active != provider {
+				t.Fatalf("GetActiveProvider() = %v, want %v", active, provider)
+			}
+		}
+	}
+
+	if err := mgr.Off(ctx); err != nil {
+		t.Fatalf("Off() error = %v", err)
+	}
+	if _, err := os.Stat(mgr.getActiveProviderPath()); !os.IsNotExist(err) {
+		t.Errorf("active provider file should be removed after Off, stat error = %v", err)
+	}
+	active, err := mgr.GetActiveProvider(ctx)
+	if err != nil {
+		t.Fatalf("GetActiveProvider() error = %v", err)
+	}
+	if active != ProviderNone {
+		t.Errorf("GetActiveProvider() after Off = %v, want none", active)
+	}
+}
+
+func TestManager_GetActiveProvider_LegacyConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	mgr := NewManager(tmpDir).(*Manager)
+	ctx := context.Background()
+
+	// Settings written by an older version have no .active_provider file
+	settings := &claude.Settings{Env: map[string]string{
+		"ANTHROPIC_AUTH_TOKEN": "test-key",
+		"ANTHROPIC_BASE_URL":   "https://open.bigmodel.cn/api/anthropic",
+	}}
+	if err := mgr.saveSettings(settings); err != nil {
+		t.Fatalf("saveSettings() error = %v", err)
+	}
+
+	active, err := mgr.GetActiveProvider(ctx)
+	if err != nil {
+		t.Fatalf("GetActiveProvider() error = %v", err)
+	}
+	if active != ProviderGLM {
+		t.Errorf("GetActiveProvider() = %v, want %v", active, ProviderGLM)
+	}
+}
+
+func TestProviderForBaseURL(t *testing.T) {
+	tests := []struct {
+		baseURL string
+		want    ProviderType
+	}{
+		{"https://api.deepseek.com/anthropic", ProviderDeepSeek},
+		{"https://api.kimi.com/coding/", ProviderKimi},
+		{"https://api.kimi.com/coding", ProviderKimi},
+		{"https://open.bigmodel.cn/api/anthropic", ProviderGLM},
+		{doubaoEndpoints[DoubaoEndpointGeneral], ProviderDoubao},
+		{"https://api.anthropic.com", ProviderAnthropic},
+		{"https://proxy.example.com/anthropic", ProviderNone},
+		{"", ProviderNone},
+	}
+
+	mgr := NewManager(t.TempDir())
+	for _, tt := range tests {
+		if got := mgr.ProviderForBaseURL(tt.baseURL); got != tt.want {
+			t.Errorf("ProviderForBaseURL(%q) = %v, want %v", tt.baseURL, got, tt.want)
+		}
+	}
+}
+
+func TestManager_Reset_ClearsActiveProvider(t *testing.T) {
+	tmpDir := t.TempDir()
+	mgr := NewManager(tmpDir).(*Manager)
+	ctx := context.Background()
+
+	if err := mgr.Enable(ctx, ProviderDoubao, "test-key"); err != nil {
+		t.Fatalf("Enable() error = %v", err)
+	}
+	if err := mgr.Reset(ctx, ProviderDoubao); err != nil {
+		t.Fatalf("Reset() error = %v", err)
+	}
+	if _, err := os.Stat(mgr.getActiveProviderPath()); !os.IsNotExist(err) {
+		t.Errorf("active provider file should be removed after Reset, stat error = %v", err)
+	}
+}
+
+func TestManager_DefaultProvider(t *testing.T) {
+	tmpDir := t.TempDir()
+	mgr := NewManager(tmpDir)
+	ctx := context.Background()
+
+	got, err := mgr.GetDefaultProvider(ctx)
+	if err != nil {
+		t.Fatalf("GetDefaultProvider() error = %v", err)
+	}
+	if got != ProviderNone {
+		t.Errorf("GetDefaultProvider() = %v, want none", got)
+	}
+
+	if err := mgr.SetDefaultProvider(ctx, ProviderKimi); err != nil {
+		t.Fatalf("SetDefaultProvider() error = %v", err)
+	}
+	got, err = mgr.GetDefaultProvider(ctx)
+	if err != nil {
+		t.Fatalf("GetDefaultProvider() error = %v", err)
+	}
+	if got != ProviderKimi {
+		t.Errorf("GetDefaultProvider() = %v, want %v", got, ProviderKimi)
+	}
+
+	if err := mgr.SetDefaultProvider(ctx, ProviderType("unknown")); err == nil {
+		t.Error("SetDefaultProvider() should reject unknown providers")
+	}
+
+	if err := mgr.SetDefaultProvider(ctx, ProviderNone); err != nil {
+		t.Fatalf("SetDefaultProvider(none) error = %v", err)
+	}
+	got, err = mgr.GetDefaultProvider(ctx)
+	if err != nil {
+		t.Fatalf("GetDefaultProvider() error = %v", err)
+	}
+	if got != ProviderNone {
+		t.Errorf("GetDefaultProvider() after clear = %v, want none", got)
+	}
+}
+
+func TestManager_Enable_TrimsAPIKey(t *testing.T) {
+	tmpDir := t.TempDir()
+	mgr := NewManager(tmpDir).(*Manager)
+	ctx := context.Background()
+
+	if err := mgr.Enable(ctx, ProviderDeepSeek, "  sk-trimmed\n"); err != nil {
+		t.Fatalf("Enable() error = %v", err)
+	}
+
+	data, err := os.ReadFile(mgr.getAPIKeyPath(ProviderDeepSeek))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(data) != "sk-trimmed" {
+		t.Errorf("stored key = %q, want %q", string(data), "sk-trimmed")
+	}
+
+	config, err := mgr.GetProviderConfig(ctx, ProviderDeepSeek)
+	if err != nil {
+		t.Fatalf("GetProviderConfig() error = %v", err)
+	}
+	if config.AuthToken != "sk-trimmed" {
+		t.Errorf("AuthToken = %q, want %q", config.AuthToken, "sk-trimmed")
+	}
+}
+
+func TestManager_Status(t *testing.T) {
+	tmpDir := t.TempDir()
+	mgr := NewManager(tmpDir)
+	ctx := context.Background()
+
+	status, err := mgr.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if status.ActiveProvider != ProviderNone || status.Config != nil {
+		t.Errorf("Status() on empty dir = %+v, want no active provider", status)
+	}
+
+	if err := mgr.Enable(ctx, ProviderGLM, "glm-secret-key-1234"); err != nil {
+		t.Fatalf("Enable() error = %v", err)
+	}
+
+	status, err = mgr.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if status.ActiveProvider != ProviderGLM {
+		t.Errorf("ActiveProvider = %v, want %v", status.ActiveProvider, ProviderGLM)
+	}
+	if status.Config == nil || status.Config.AuthToken != "glm-***********1234" {
+		t.Errorf("Config = %+v, want masked auth token", status.Config)
+	}
+	if len(status.Keys) != len(mgr.ListSupportedProviders()) {
+		t.Errorf("Keys has %d entries, want %d", len(status.Keys), len(mgr.ListSupportedProviders()))
+	}
+	if !status.Keys[ProviderGLM] || status.Keys[ProviderKimi] {
+		t.Errorf("Keys = %v, want only GLM", status.Keys)
+	}
+}
+
+func TestManager_Profiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	mgr := NewManager(tmpDir).(*Manager)
+	ctx := context.Background()
+
+	if err := mgr.Enable(ctx, ProviderDeepSeek, "sk-personal"); err != nil {
+		t.Fatalf("Enable() error = %v", err)
+	}
+	if err := mgr.EnableProfile(ctx, ProviderDeepSeek, "work", "sk-work"); err != nil {
+		t.Fatalf("EnableProfile() error = %v", err)
+	}
+	if err := mgr.EnableProfile(ctx, ProviderDeepSeek, "../evil", "sk-evil"); err == nil {
+		t.Error("EnableProfile() with invalid profile name should fail")
+	}
+
+	// The work key lives next to the legacy default key
+	data, err := os.ReadFile(filepath.Join(tmpDir, ".deepseek.work_api_key"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(data) != "sk-work" {
+		t.Errorf("work key = %q, want %q", string(data), "sk-work")
+	}
+
+	profiles, err := mgr.ListProfiles(ProviderDeepSeek)
+	if err != nil {
+		t.Fatalf("ListProfiles() error = %v", err)
+	}
+	if !reflect.DeepEqual(profiles, []string{DefaultProfile, "work"}) {
+		t.Errorf("ListProfiles() = %v, want [default work]", profiles)
+	}
+
+	profiles, err = mgr.ListProfiles(ProviderKimi)
+	if err != nil {
+		t.Fatalf("ListProfiles() error = %v", err)
+	}
+	if len(profiles) != 0 {
+		t.Errorf("ListProfiles(kimi) = %v, want none", profiles)
+	}
+
+	// Off/On restores the profile that was active
+	if err := mgr.Off(ctx); err != nil {
+		t.Fatalf("Off() error = %v", err)
+	}
+	if err := mgr.On(ctx); err != nil {
+		t.Fatalf("On() error = %v", err)
+	}
+	config, err := mgr.GetProviderConfig(ctx, ProviderDeepSeek)
+	if err != nil {
+		t.Fatalf("GetProviderConfig() error = %v", err)
+	}
+	if config.AuthToken != "sk-work" {
+		t.Errorf("AuthToken after On() = %q, want %q", config.AuthToken, "sk-work")
+	}
+}
+
+func TestManager_DoubaoEndpoint(t *testing.T) {
+	tmpDir := t.TempDir()
+	mgr := NewManager(tmpDir).(*Manager)
+	ctx := context.Background()
+
+	endpoint, err := mgr.GetEndpoint(ctx, ProviderDoubao)
+	if err != nil {
+		t.Fatalf("GetEndpoint() error = %v", err)
+	}
+	if endpoint != DoubaoEndpointCoding {
+		t.Errorf("GetEndpoint() default = %q, want %q", endpoint, DoubaoEndpointCoding)
+	}
+
+	if err := mgr.SetEndpoint(ctx, ProviderDoubao, "unknown"); err == nil {
+		t.Error("SetEndpoint() with unknown endpoint should fail")
+	}
+	if err := mgr.SetEndpoint(ctx, ProviderDeepSeek, DoubaoEndpointGeneral); err == nil {
+		t.Error("SetEndpoint() for provider without endpoints should fail")
+	}
+
+	if err := mgr.SetEndpoint(ctx, ProviderDoubao, DoubaoEndpointGeneral); err != nil {
+		t.Fatalf("SetEndpoint() error = %v", err)
+	}
+	if err := mgr.Enable(ctx, ProviderDoubao, "doubao-key"); err != nil {
+		t.Fatalf("Enable() error = %v", err)
+	}
+
+	settings, err := mgr.loadSettings()
+	if err != nil {
+		t.Fatalf("loadSettings() error = %v", err)
+	}
+	wantURL := (&DoubaoProvider{}).Endpoints()[DoubaoEndpointGeneral]
+	if settings.Env["ANTHROPIC_BASE_URL"] != wantURL {
+		t.Errorf("ANTHROPIC_BASE_URL = %q, want %q", settings.Env["ANTHROPIC_BASE_URL"], wantURL)
+	}
+
+	// Without the explicit record, the alternate URL is still recognized
+	if err := os.Remove(mgr.getActiveProviderPath()); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	active, err := mgr.GetActiveProvider(ctx)
+	if err != nil {
+		t.Fatalf("GetActiveProvider() error = %v", err)
+	}
+	if active != ProviderDoubao {
+		t.Errorf("GetActiveProvider() = %v, want %v", active, ProviderDoubao)
+	}
+
+	// Selecting the default endpoint clears the stored choice
+	if err := mgr.SetEndpoint(ctx, ProviderDoubao, DoubaoEndpointCoding); err != nil {
+		t.Fatalf("SetEndpoint() error = %v", err)
+	}
+	if _, err := os.Stat(mgr.getEndpointPath(ProviderDoubao)); !os.IsNotExist(err) {
+		t.Errorf("endpoint file should be removed, stat err = %v", err)
+	}
+}
+
+func TestManager_BaseURLOverride(t *testing.T) {
+	tmpDir := t.TempDir()
+	mgr := NewManager(tmpDir).(*Manager)
+	ctx := context.Background()
+	staging := "https://staging.example.com/anthropic"
+
+	for _, invalid := range []string{"staging.example.com", "ftp://staging.example.com", "https://"} {
+		if err := mgr.SetBaseURL(ctx, ProviderDeepSeek, invalid); err == nil {
+			t.Errorf("SetBaseURL(%q) should fail", invalid)
+		}
+	}
+
+	if err := mgr.SetBaseURL(ctx, ProviderDeepSeek, staging); err != nil {
+		t.Fatalf("SetBaseURL() error = %v", err)
+	}
+	if err := mgr.Enable(ctx, ProviderDeepSeek, "sk-deepseek"); err != nil {
+		t.Fatalf("Enable() error = %v", err)
+	}
+
+	settings, err := mgr.loadSettings()
+	if err != nil {
+		t.Fatalf("loadSettings() error = %v", err)
+	}
+	if settings.Env["ANTHROPIC_BASE_URL"] != staging {
+		t.Errorf("ANTHROPIC_BASE_URL = %q, want %q", settings.Env["ANTHROPIC_BASE_URL"], staging)
+	}
+
+	// The override persists for later enables by a new manager
+	reloaded := NewManager(tmpDir).(*Manager)
+	baseURL, err := reloaded.GetBaseURL(ctx, ProviderDeepSeek)
+	if err != nil {
+		t.Fatalf("GetBaseURL() error = %v", err)
+	}
+	if baseURL != staging {
+		t.Errorf("GetBaseURL() = %q, want %q", baseURL, staging)
+	}
+
+	// Without the explicit record, the overridden URL resolves back to the provider
+	if err := os.Remove(mgr.getActiveProviderPath()); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	active, err := reloaded.GetActiveProvider(ctx)
+	if err != nil {
+		t.Fatalf("GetActiveProvider() error = %v", err)
+	}
+	if active != ProviderDeepSeek {
+		t.Errorf("GetActiveProvider() = %v, want %v", active, ProviderDeepSeek)
+	}
+
+	// Reset clears the override
+	if err := reloaded.Reset(ctx, ProviderDeepSeek); err != nil {
+		t.Fatalf("Reset() error = %v", err)
+	}
+	baseURL, err = reloaded.GetBaseURL(ctx, ProviderDeepSeek)
+	if err != nil {
+		t.Fatalf("GetBaseURL() error = %v", err)
+	}
+	if baseURL != "" {
+		t.Errorf("GetBaseURL() after reset = %q, want empty", baseURL)
+	}
+}
+
+func TestCleanFileValue(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"plain", "sk-abc", "sk-abc"},
+		{"LF", "sk-abc\n", "sk-abc"},
+		{"CRLF", "sk-abc\r\n", "sk-abc"},
+		{"BOM and CRLF", "\ufeffsk-abc\r\n\r\n", "sk-abc"},
+		{"surrounding whitespace", "  kimi \r\n", "kimi"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CleanFileValue([]byte(tt.data)); got != tt.want {
+				t.Errorf("CleanFileValue(%q) = %q, want %q", tt.data, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestManager_CRLFFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	mgr := NewManager(tmpDir).(*Manager)
+	ctx := context.Background()
+
+	files := map[string]string{
+		".deepseek_api_key":     "sk-crlf\r\n",
+		".kimi_api_key":         "\ufeffsk-kimi\r\n",
+		".last_active_provider": "deepseek\r\n",
+		".default_provider":     "kimi\r\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0600); err != nil {
+			t.Fatalf("WriteFile(%s) error = %v", name, err)
+		}
+	}
+
+	apiKey, err := mgr.loadAPIKey(ProviderDeepSeek)
+	if err != nil {
+		t.Fatalf("loadAPIKey() error = %v", err)
+	}
+	if apiKey != "sk-crlf" {
+		t.Errorf("loadAPIKey() = %q, want %q", apiKey, "sk-crlf")
+	}
+
+	defaultProvider, err := mgr.GetDefaultProvider(ctx)
+	if err != nil {
+		t.Fatalf("GetDefaultProvider() error = %v", err)
+	}
+	if defaultProvider != ProviderKimi {
+		t.Errorf("GetDefaultProvider() = %q, want %q", defaultProvider, ProviderKimi)
+	}
+
+	// On restores the legacy plain-text last active provider with its clean key
+	if err := mgr.On(ctx); err != nil {
+		t.Fatalf("On() error = %v", err)
+	}
+	settings, err := mgr.loadSettings()
+	if err != nil {
+		t.Fatalf("loadSettings() error = %v", err)
+	}
+	if settings.Env["ANTHROPIC_AUTH_TOKEN"] != "sk-crlf" {
+		t.Errorf("ANTHROPIC_AUTH_TOKEN = %q, want %q", settings.Env["ANTHROPIC_AUTH_TOKEN"], "sk-crlf")
+	}
+}
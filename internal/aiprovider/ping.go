@@ -0,0 +1,296 @@
+package aiprovider
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ooneko/claude-config/internal/claude"
+)
+
+// DialFunc opens a network connection, matching net.Dialer.DialContext
+type DialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// PingResult reports whether a provider endpoint is reachable.
+// It only checks the network path and TLS, never authentication.
+type PingResult struct {
+	BaseURL    string
+	Address    string
+	Proxy      string
+	Reachable  bool
+	TLSChecked bool
+	TLSValid   bool
+	TLSError   error
+	Latency    time.Duration
+	Err        error
+}
+
+// Pinger connects to provider endpoints to diagnose network problems
+type Pinger struct {
+	// Dial opens TCP connections to the endpoint or proxy
+	Dial DialFunc
+	// TLSConfig is cloned for each handshake; nil uses the system roots
+	TLSConfig *tls.Config
+}
+
+// NewPinger creates a pinger that dials with net.Dialer
+func NewPinger() *Pinger {
+	dialer := &net.Dialer{}
+	return &Pinger{Dial: dialer.DialContext}
+}
+
+// Ping connects to the host of baseURL, through the proxy from proxyConfig
+// when one applies, and performs a TLS handshake for https URLs
+func (p *Pinger) Ping(ctx context.Context, baseURL string, proxyConfig *claude.ProxyConfig) *PingResult {
+	result := &PingResult{BaseURL: baseURL}
+
+	target, err := url.Parse(baseURL)
+	if err != nil || target.Hostname() == "" {
+		result.Err = fmt.Errorf("invalid base URL %q", baseURL)
+		return result
+	}
+
+	port := target.Port()
+	if port == "" {
+		port = "443"
+		if target.Scheme == "http" {
+			port = "80"
+		}
+	}
+	result.Address = net.JoinHostPort(target.Hostname(), port)
+	result.Proxy = proxyForTarget(target, proxyConfig)
+
+	start := time.Now()
+	conn, err := p.connect(ctx, result.Address, result.Proxy)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer conn.Close()
+	result.Reachable = true
+	result.Latency = time.Since(start)
+
+	if target.Scheme != "https" {
+		return result
+	}
+
+	result.TLSChecked = true
+	tlsConfig := &tls.Config{}
+	if p.TLSConfig != nil {
+		tlsConfig = p.TLSConfig.Clone()
+	}
+	tlsConfig.ServerName = target.Hostname()
+
+	tlsConn := tls.Client(conn, tlsConfig)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		result.TLSError = err
+		return result
+	}
+	result.TLSValid = true
+
+	return result
+}
+
+// connect dials address directly or through an http(s)/socks5 proxy
+func (p *Pinger) connect(ctx context.Context, address, proxyURL string) (net.Conn, error) {
+	if proxyURL == "" {
+		return p.Dial(ctx, "tcp", address)
+	}
+
+	proxy, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy %q: %w", proxyURL, err)
+	}
+
+	proxyPort := proxy.Port()
+	if proxyPort == "" {
+		proxyPort = map[string]string{"http": "80", "https": "443", "socks5": "1080", "socks5h": "1080"}[proxy.Scheme]
+	}
+	conn, err := p.Dial(ctx, "tcp", net.JoinHostPort(proxy.Hostname(), proxyPort))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to proxy %s: %w", proxy.Host, err)
+	}
+
+	// Proxy handshakes use plain reads and writes, so bound them by the context
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	// An https proxy is itself reached over TLS before the CONNECT request
+	if proxy.Scheme == "https" {
+		if conn, err = p.proxyTLS(ctx, conn, proxy); err != nil {
+			return nil, err
+		}
+	}
+
+	switch proxy.Scheme {
+	case "http", "https":
+		err = httpConnect(conn, proxy, address)
+	case "socks5", "socks5h":
+		err = socks5Connect(conn, proxy, address)
+	default:
+		err = fmt.Errorf("unsupported proxy scheme: %s", proxy.Scheme)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	_ = conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+// proxyTLS performs a TLS handshake with an https proxy, verifying its
+// certificate against the proxy host name. conn is closed on failure.
+func (p *Pinger) proxyTLS(ctx context.Context, conn net.Conn, proxy *url.URL) (net.Conn, error) {
+	tlsConfig := &tls.Config{}
+	if p.TLSConfig != nil {
+		tlsConfig = p.TLSConfig.Clone()
+	}
+	tlsConfig.ServerName = proxy.Hostname()
+
+	tlsConn := tls.Client(conn, tlsConfig)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("TLS handshake with proxy %s failed: %w", proxy.Host, err)
+	}
+	return tlsConn, nil
+}
+
+// httpConnect opens a tunnel to address with an HTTP CONNECT request
+func httpConnect(conn net.Conn, proxy *url.URL, address string) error {
+	request := fmt.Sprintf("CONNECT %s HTTP/1.1\r\nHost: %s\r\n", address, address)
+	if proxy.User != nil {
+		password, _ := proxy.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(proxy.User.Username() + ":" + password))
+		request += "Proxy-Authorization: Basic " + credentials + "\r\n"
+	}
+	request += "\r\n"
+
+	if _, err := io.WriteString(conn, request); err != nil {
+		return fmt.Errorf("proxy CONNECT failed: %w", err)
+	}
+
+	response, err := http.ReadResponse(bufio.NewReader(conn), &http.Request{Method: http.MethodConnect})
+	if err != nil {
+		return fmt.Errorf("proxy CONNECT failed: %w", err)
+	}
+	response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("proxy CONNECT failed: %s", response.Status)
+	}
+	return nil
+}
+
+// socks5Connect opens a tunnel to address with an unauthenticated SOCKS5 CONNECT
+func socks5Connect(conn net.Conn, proxy *url.URL, address string) error {
+	if proxy.User != nil {
+		return fmt.Errorf("SOCKS5 proxy authentication is not supported")
+	}
+
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || len(host) > 255 {
+		return fmt.Errorf("invalid address %q", address)
+	}
+
+	// Greeting: version 5, one method, no authentication
+	if _, err := conn.Write([]byte{0x05, 0x01, 0x00}); err != nil {
+		return fmt.Errorf("SOCKS5 handshake failed: %w", err)
+	}
+	reply := make([]byte, 2)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return fmt.Errorf("SOCKS5 handshake failed: %w", err)
+	}
+	if reply[0] != 0x05 || reply[1] != 0x00 {
+		return fmt.Errorf("SOCKS5 proxy requires unsupported authentication")
+	}
+
+	// CONNECT request with a domain name address
+	request := []byte{0x05, 0x01, 0x00, 0x03, byte(len(host))}
+	request = append(request, host...)
+	request = binary.BigEndian.AppendUint16(request, uint16(port))
+	if _, err := conn.Write(request); err != nil {
+		return fmt.Errorf("SOCKS5 connect failed: %w", err)
+	}
+
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return fmt.Errorf("SOCKS5 connect failed: %w", err)
+	}
+	if header[1] != 0x00 {
+		return fmt.Errorf("SOCKS5 connect failed: reply code %d", header[1])
+	}
+
+	// Skip the bound address and port
+	var skip int
+	switch header[3] {
+	case 0x01:
+		skip = net.IPv4len + 2
+	case 0x04:
+		skip = net.IPv6len + 2
+	case 0x03:
+		length := make([]byte, 1)
+		if _, err := io.ReadFull(conn, length); err != nil {
+			return fmt.Errorf("SOCKS5 connect failed: %w", err)
+		}
+		skip = int(length[0]) + 2
+	default:
+		return fmt.Errorf("SOCKS5 connect failed: unknown address type %d", header[3])
+	}
+	if _, err := io.ReadFull(conn, make([]byte, skip)); err != nil {
+		return fmt.Errorf("SOCKS5 connect failed: %w", err)
+	}
+
+	return nil
+}
+
+// proxyForTarget picks the proxy Claude would use for target, honoring no_proxy
+func proxyForTarget(target *url.URL, proxyConfig *claude.ProxyConfig) string {
+	if proxyConfig == nil || bypassProxy(target.Hostname(), proxyConfig.NoProxy) {
+		return ""
+	}
+
+	candidates := []string{proxyConfig.HTTPSProxy, proxyConfig.AllProxy}
+	if target.Scheme == "http" {
+		candidates = []string{proxyConfig.HTTPProxy, proxyConfig.AllProxy}
+	}
+	for _, candidate := range candidates {
+		if candidate != "" {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// bypassProxy reports whether host matches an entry of a no_proxy list
+func bypassProxy(host, noProxy string) bool {
+	for _, entry := range strings.Split(noProxy, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if entry == "*" {
+			return true
+		}
+		entry = strings.TrimPrefix(entry, ".")
+		if host == entry || strings.HasSuffix(host, "."+entry) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,204 @@
+package aiprovider
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ooneko/claude-config/internal/claude"
+)
+
+// dialTo returns a dialer that ignores the requested address and connects to target
+func dialTo(target string, dialed *[]string) DialFunc {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		*dialed = append(*dialed, address)
+		var d net.Dialer
+		return d.DialContext(ctx, network, target)
+	}
+}
+
+func TestPinger_Reachable(t *testing.T) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+
+	var dialed []string
+	pinger := &Pinger{
+		Dial:      dialTo(server.Listener.Addr().String(), &dialed),
+		TLSConfig: server.Client().Transport.(*http.Transport).TLSClientConfig,
+	}
+
+	// httptest certificates are valid for example.com
+	result := pinger.Ping(context.Background(), "https://example.com/anthropic", nil)
+	if result.Err != nil {
+		t.Fatalf("Ping() error = %v", result.Err)
+	}
+	if !result.Reachable || !result.TLSChecked || !result.TLSValid {
+		t.Errorf("Ping() = %+v, want reachable with valid TLS", result)
+	}
+	if len(dialed) != 1 || dialed[0] != "example.com:443" {
+		t.Errorf("dialed = %v, want [example.com:443]", dialed)
+	}
+}
+
+func TestPinger_InvalidTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+
+	var dialed []string
+	// No custom roots: the self-signed test certificate is untrusted
+	pinger := &Pinger{Dial: dialTo(server.Listener.Addr().String(), &dialed)}
+
+	result := pinger.Ping(context.Background(), "https://example.com", nil)
+	if !result.Reachable {
+		t.Fatalf("Ping() should reach the server, err = %v", result.Err)
+	}
+	if result.TLSValid || result.TLSError == nil {
+		t.Errorf("Ping() = %+v, want TLS failure", result)
+	}
+}
+
+func TestPinger_Unreachable(t *testing.T) {
+	dialErr := errors.New("connection refused")
+	pinger := &Pinger{
+		Dial: func(context.Context, string, string) (net.Conn, error) {
+			return nil, dialErr
+		},
+	}
+
+	result := pinger.Ping(context.Background(), "https://api.example.com", nil)
+	if result.Reachable {
+		t.Error("Ping() should report unreachable")
+	}
+	if !errors.Is(result.Err, dialErr) {
+		t.Errorf("Ping() error = %v, want %v", result.Err, dialErr)
+	}
+	if result.TLSChecked {
+		t.Error("TLS should not be checked when the host is unreachable")
+	}
+}
+
+func TestPinger_RespectsContextTimeout(t *testing.T) {
+	pinger := &Pinger{
+		Dial: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	result := pinger.Ping(ctx, "https://api.example.com", nil)
+	if !errors.Is(result.Err, context.DeadlineExceeded) {
+		t.Errorf("Ping() error = %v, want deadline exceeded", result.Err)
+	}
+}
+
+func TestPinger_HTTPProxy(t *testing.T) {
+	client, proxySide := net.Pipe()
+	connectLine := make(chan string, 1)
+	go func() {
+		defer proxySide.Close()
+		request, err := http.ReadRequest(bufio.NewReader(proxySide))
+		if err != nil {
+			connectLine <- err.Error()
+			return
+		}
+		connectLine <- request.Method + " " + request.Host
+		_, _ = proxySide.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+	}()
+
+	var dialed []string
+	pinger := &Pinger{
+		Dial: func(_ context.Context, _, address string) (net.Conn, error) {
+			dialed = append(dialed, address)
+			return client, nil
+		},
+	}
+
+	proxyConfig := &claude.ProxyConfig{HTTPProxy: "http://127.0.0.1:7890"}
+	result := pinger.Ping(context.Background(), "http://api.example.com", proxyConfig)
+	if !result.Reachable {
+		t.Fatalf("Ping() through proxy error = %v", result.Err)
+	}
+	if result.Proxy != "http://127.0.0.1:7890" {
+		t.Errorf("Proxy = %q, want the configured http proxy", result.Proxy)
+	}
+	if len(dialed) != 1 || dialed[0] != "127.0.0.1:7890" {
+		t.Errorf("dialed = %v, want the proxy address", dialed)
+	}
+	if got := <-connectLine; got != "CONNECT api.example.com:80" {
+		t.Errorf("proxy received %q, want CONNECT api.example.com:80", got)
+	}
+}
+
+func TestPinger_HTTPSProxy(t *testing.T) {
+	connectLine := make(chan string, 1)
+	proxyServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		connectLine <- r.Method + " " + r.Host
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer proxyServer.Close()
+
+	var dialed []string
+	pinger := &Pinger{
+		Dial:      dialTo(proxyServer.Listener.Addr().String(), &dialed),
+		TLSConfig: proxyServer.Client().Transport.(*http.Transport).TLSClientConfig,
+	}
+
+	// httptest certificates are valid for example.com
+	proxyConfig := &claude.ProxyConfig{HTTPProxy: "https://example.com:8443"}
+	result := pinger.Ping(context.Background(), "http://api.example.com", proxyConfig)
+	if !result.Reachable {
+		t.Fatalf("Ping() through https proxy error = %v", result.Err)
+	}
+	if len(dialed) != 1 || dialed[0] != "example.com:8443" {
+		t.Errorf("dialed = %v, want the proxy address", dialed)
+	}
+	if got := <-connectLine; got != "CONNECT api.example.com:80" {
+		t.Errorf("proxy received %q, want CONNECT api.example.com:80", got)
+	}
+
+	// The proxy certificate is verified like any other
+	untrusted := &Pinger{Dial: dialTo(proxyServer.Listener.Addr().String(), &dialed)}
+	result = untrusted.Ping(context.Background(), "http://api.example.com", proxyConfig)
+	if result.Reachable || result.Err == nil || !strings.Contains(result.Err.Error(), "TLS handshake with proxy") {
+		t.Errorf("Ping() through untrusted proxy = %+v, want a proxy TLS error", result)
+	}
+}
+
+func TestProxyForTarget(t *testing.T) {
+	proxyConfig := &claude.ProxyConfig{
+		HTTPSProxy: "http://proxy:8080",
+		AllProxy:   "socks5://127.0.0.1:1080",
+		NoProxy:    "localhost,.corp.example.com",
+	}
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://api.deepseek.com", "http://proxy:8080"},
+		{"http://api.deepseek.com", "socks5://127.0.0.1:1080"},
+		{"https://llm.corp.example.com", ""},
+		{"https://localhost:8443", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			pinger := &Pinger{Dial: func(context.Context, string, string) (net.Conn, error) {
+				return nil, errors.New("not dialed")
+			}}
+			result := pinger.Ping(context.Background(), tt.url, proxyConfig)
+			if result.Proxy != tt.want {
+				t.Errorf("Proxy = %q, want %q", result.Proxy, tt.want)
+			}
+		})
+	}
+}
@@ -126,7 +126,7 @@ func TestAIProviderManager_ListSupportedProviders(t *testing.T) {
 	mgr := NewManager("/tmp/test-claude")
 
 	providers := mgr.ListSupportedProviders()
-	expectedProviders := []ProviderType{ProviderDeepSeek, ProviderKimi, ProviderGLM, ProviderDoubao}
+	expectedProviders := []ProviderType{ProviderDeepSeek, ProviderKimi, ProviderGLM, ProviderDoubao, ProviderAnthropic}
 
 	if len(providers) != len(expectedProviders) {
 		t.Errorf("Expected %d providers, got %d", len(expectedProviders), len(providers))
@@ -193,3 +193,84 @@ func TestDoubaoProvider(t *testing.T) {
 		t.Error("ValidateConfig() should error with missing base URL")
 	}
 }
+
+func TestAnthropicProvider(t *testing.T) {
+	provider := &AnthropicProvider{}
+
+	// Test GetType
+	if provider.GetType() != ProviderAnthropic {
+		t.Errorf("Expected ProviderAnthropic, got %v", provider.GetType())
+	}
+
+	// Test GetDefaultConfig
+	config := provider.GetDefaultConfig("test-api-key")
+	if config.Type != ProviderAnthropic {
+		t.Errorf("Expected type ProviderAnthropic, got %v", config.Type)
+	}
+	if config.AuthToken != "test-api-key" {
+		t.Errorf("Expected auth token 'test-api-key', got '%s'", config.AuthToken)
+	}
+	if config.BaseURL != "https://api.anthropic.com" {
+		t.Errorf("Expected base URL 'https://api.anthropic.com', got '%s'", config.BaseURL)
+	}
+
+	// Test ValidateConfig with valid config
+	err := provider.ValidateConfig(config)
+	if err != nil {
+		t.Errorf("ValidateConfig() should not error with valid config: %v", err)
+	}
+
+	// Test ValidateConfig with missing auth token
+	config.AuthToken = ""
+	err = provider.ValidateConfig(config)
+	if err == nil {
+		t.Error("ValidateConfig() should error with missing auth token")
+	}
+}
+
+func TestProviders_SupportedModelsIncludeDefault(t *testing.T) {
+	mgr := NewManager("/tmp/test-claude").(*Manager)
+
+	for providerType, provider := range mgr.providers {
+		models := provider.SupportedModels()
+		if len(models) == 0 {
+			t.Errorf("%v has no supported models", providerType)
+			continue
+		}
+
+		defaultModel := provider.GetDefaultConfig("").Model
+		if !IsSupportedModel(provider, defaultModel) {
+			t.Errorf("%v default model %q is not in SupportedModels %v", providerType, defaultModel, models)
+		}
+	}
+
+	if IsSupportedModel(&DeepSeekProvider{}, "kimi-for-coding") {
+		t.Error("IsSupportedModel() should reject another provider's model")
+	}
+}
+
+func TestValidateAPIKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider ProviderType
+		key      string
+		wantErr  bool
+	}{
+		{name: "plain key", provider: ProviderDeepSeek, key: "sk-abc123"},
+		{name: "trailing newline is trimmed", provider: ProviderDeepSeek, key: "sk-abc123\n"},
+		{name: "empty", provider: ProviderDeepSeek, key: "", wantErr: true},
+		{name: "whitespace only", provider: ProviderKimi, key: " \n\t", wantErr: true},
+		{name: "embedded whitespace", provider: ProviderKimi, key: "sk-abc 123", wantErr: true},
+		{name: "anthropic prefix", provider: ProviderAnthropic, key: "sk-ant-abc123"},
+		{name: "anthropic wrong prefix", provider: ProviderAnthropic, key: "sk-abc123", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAPIKey(tt.provider, tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateAPIKey(%q) error = %v, wantErr %v", tt.key, err, tt.wantErr)
+			}
+		})
+	}
+}
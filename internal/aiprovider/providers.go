@@ -1,6 +1,15 @@
 package aiprovider
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// apiKeyPrefixes lists the documented key prefix for providers that have a stable one
+var apiKeyPrefixes = map[ProviderType]string{
+	ProviderAnthropic: "sk-ant-",
+}
 
 // DeepSeekProvider implements the Provider interface for DeepSeek
 type DeepSeekProvider struct{}
@@ -32,6 +41,11 @@ func (p *DeepSeekProvider) ValidateConfig(config *ProviderConfig) error {
 	return nil
 }
 
+// SupportedModels returns the models available for DeepSeek
+func (p *DeepSeekProvider) SupportedModels() []string {
+	return []string{"deepseek-chat", "deepseek-reasoner"}
+}
+
 // KimiProvider implements the Provider interface for Kimi
 type KimiProvider struct{}
 
@@ -62,6 +76,11 @@ func (p *KimiProvider) ValidateConfig(config *ProviderConfig) error {
 	return nil
 }
 
+// SupportedModels returns the models available for Kimi
+func (p *KimiProvider) SupportedModels() []string {
+	return []string{"kimi-for-coding", "kimi-k2-turbo-preview", "kimi-k2-0905-preview"}
+}
+
 // GLMProvider implements the Provider interface for GLM
 type GLMProvider struct{}
 
@@ -92,6 +111,11 @@ func (p *GLMProvider) ValidateConfig(config *ProviderConfig) error {
 	return nil
 }
 
+// SupportedModels returns the models available for GLM
+func (p *GLMProvider) SupportedModels() []string {
+	return []string{"glm-4.7", "glm-4.6", "glm-4.5", "glm-4.5-air", "glm-4-plus"}
+}
+
 // DoubaoProvider implements the Provider interface for Doubao
 type DoubaoProvider struct{}
 
@@ -100,12 +124,24 @@ func (p *DoubaoProvider) GetType() ProviderType {
 	return ProviderDoubao
 }
 
+// Doubao Ark endpoint names
+const (
+	DoubaoEndpointCoding  = "coding"
+	DoubaoEndpointGeneral = "general"
+)
+
+// doubaoEndpoints lists the known Anthropic-compatible Ark base URLs
+var doubaoEndpoints = map[string]string{
+	DoubaoEndpointCoding:  "https://ark.cn-beijing.volces.com/api/coding",
+	DoubaoEndpointGeneral: "https://ark.cn-beijing.volces.com/api/compatible",
+}
+
 // GetDefaultConfig returns the default configuration for Doubao
 func (p *DoubaoProvider) GetDefaultConfig(apiKey string) *ProviderConfig {
 	return &ProviderConfig{
 		Type:           ProviderDoubao,
 		AuthToken:      apiKey,
-		BaseURL:        "https://ark.cn-beijing.volces.com/api/coding",
+		BaseURL:        doubaoEndpoints[DoubaoEndpointCoding],
 		Model:          "doubao-seed-code-preview-latest",
 		SmallFastModel: "doubao-seed-code-preview-latest",
 	}
@@ -121,3 +157,87 @@ func (p *DoubaoProvider) ValidateConfig(config *ProviderConfig) error {
 	}
 	return nil
 }
+
+// SupportedModels returns the models available for Doubao
+func (p *DoubaoProvider) SupportedModels() []string {
+	return []string{"doubao-seed-code-preview-latest"}
+}
+
+// Endpoints returns the selectable Doubao base URLs
+func (p *DoubaoProvider) Endpoints() map[string]string {
+	endpoints := make(map[string]string, len(doubaoEndpoints))
+	for name, url := range doubaoEndpoints {
+		endpoints[name] = url
+	}
+	return endpoints
+}
+
+// DefaultEndpoint returns the endpoint used by GetDefaultConfig
+func (p *DoubaoProvider) DefaultEndpoint() string {
+	return DoubaoEndpointCoding
+}
+
+// AnthropicProvider implements the Provider interface for the official Anthropic API
+type AnthropicProvider struct{}
+
+// GetType returns the provider type
+func (p *AnthropicProvider) GetType() ProviderType {
+	return ProviderAnthropic
+}
+
+// GetDefaultConfig returns the default configuration for Anthropic
+func (p *AnthropicProvider) GetDefaultConfig(apiKey string) *ProviderConfig {
+	return &ProviderConfig{
+		Type:           ProviderAnthropic,
+		AuthToken:      apiKey,
+		BaseURL:        "https://api.anthropic.com",
+		Model:          "claude-sonnet-4-5",
+		SmallFastModel: "claude-haiku-4-5",
+	}
+}
+
+// ValidateConfig validates the Anthropic configuration
+func (p *AnthropicProvider) ValidateConfig(config *ProviderConfig) error {
+	if config.AuthToken == "" {
+		return fmt.Errorf("auth token is required for Anthropic")
+	}
+	if config.BaseURL == "" {
+		return fmt.Errorf("base URL is required for Anthropic")
+	}
+	return nil
+}
+
+// SupportedModels returns the models available for Anthropic
+func (p *AnthropicProvider) SupportedModels() []string {
+	return []string{"claude-sonnet-4-5", "claude-opus-4-1", "claude-haiku-4-5"}
+}
+
+// IsSupportedModel reports whether model is one of the provider's supported models
+func IsSupportedModel(p Provider, model string) bool {
+	for _, supported := range p.SupportedModels() {
+		if supported == model {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateAPIKey checks an API key after trimming surrounding whitespace.
+// It rejects empty keys, keys with embedded whitespace and keys missing the
+// provider's expected prefix.
+func ValidateAPIKey(provider ProviderType, key string) error {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return fmt.Errorf("API key cannot be empty")
+	}
+
+	if strings.IndexFunc(key, unicode.IsSpace) >= 0 {
+		return fmt.Errorf("API key must not contain whitespace")
+	}
+
+	if prefix, ok := apiKeyPrefixes[provider]; ok && !strings.HasPrefix(key, prefix) {
+		return fmt.Errorf("API key for %s should start with %q", provider, prefix)
+	}
+
+	return nil
+}
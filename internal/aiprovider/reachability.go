@@ -0,0 +1,137 @@
+package aiprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ooneko/claude-config/internal/claude"
+)
+
+// ReachabilityTimeout bounds a reachability check unless the context expires sooner
+const ReachabilityTimeout = 5 * time.Second
+
+// reachabilityPath is requested under the base URL. Listing models is the
+// cheapest authenticated call of the Anthropic API and costs no tokens.
+const reachabilityPath = "/v1/models"
+
+// CheckReachability sends a lightweight authenticated request to the
+// provider's base URL, through the proxy configured in settings.json, and
+// reports whether it answered and accepted the stored key. Connection
+// problems are reported in the result; the error is only for checks that
+// can't be made, e.g. an unreadable key. The active provider is checked with
+// the token in settings.json, which may belong to a non-default profile; any
+// other provider with its default key. Without a key nothing is sent and the
+// status is ReachabilityNoKey.
+func (m *Manager) CheckReachability(ctx context.Context, provider ProviderType) (*ReachabilityResult, error) {
+	providerImpl, exists := m.providers[provider]
+	if !exists {
+		return nil, fmt.Errorf("unsupported provider: %s", provider)
+	}
+
+	result := &ReachabilityResult{Provider: provider}
+
+	settings, err := m.loadSettings()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load settings: %w", err)
+	}
+
+	apiKey, err := m.reachabilityAPIKey(ctx, provider, settings)
+	if errors.Is(err, os.ErrNotExist) {
+		result.Status = claude.ReachabilityNoKey
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	config := providerImpl.GetDefaultConfig(apiKey)
+	if err := m.applyBaseURL(ctx, provider, config); err != nil {
+		return nil, err
+	}
+	result.BaseURL = config.BaseURL
+
+	ctx, cancel := context.WithTimeout(ctx, ReachabilityTimeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(config.BaseURL, "/")+reachabilityPath, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL %q: %w", config.BaseURL, err)
+	}
+	// Anthropic-compatible APIs read x-api-key, some gateways only a bearer token
+	request.Header.Set("x-api-key", apiKey)
+	request.Header.Set("Authorization", "Bearer "+apiKey)
+	request.Header.Set("anthropic-version", "2023-06-01")
+
+	client := &http.Client{Transport: settingsTransport(settings.Env)}
+
+	start := time.Now()
+	response, err := client.Do(request)
+	result.Latency = time.Since(start)
+	if err != nil {
+		var netErr net.Error
+		if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+			result.Status = claude.ReachabilityTimeout
+		} else {
+			result.Status = claude.ReachabilityUnreachable
+		}
+		result.Error = err.Error()
+		return result, nil
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, 64<<10))
+
+	result.StatusCode = response.StatusCode
+	switch response.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		result.Status = claude.ReachabilityUnauthorized
+	default:
+		// Any other answer, even 404 from gateways without the models endpoint,
+		// means the server is up and didn't reject the key
+		result.Status = claude.ReachabilityReachable
+	}
+
+	return result, nil
+}
+
+// reachabilityAPIKey returns the key a session would send for provider:
+// the token in settings.json when provider is active, otherwise its default key
+func (m *Manager) reachabilityAPIKey(ctx context.Context, provider ProviderType, settings *claude.Settings) (string, error) {
+	activeProvider, err := m.GetActiveProvider(ctx)
+	if err != nil {
+		return "", err
+	}
+	if activeProvider == provider {
+		if token := settings.Env["ANTHROPIC_AUTH_TOKEN"]; token != "" {
+			return token, nil
+		}
+	}
+	return m.loadAPIKey(provider)
+}
+
+// settingsTransport returns an HTTP transport using the proxies from the settings.json env
+func settingsTransport(env map[string]string) http.RoundTripper {
+	proxyConfig := &claude.ProxyConfig{
+		HTTPProxy:  env["http_proxy"],
+		HTTPSProxy: env["https_proxy"],
+		NoProxy:    env["no_proxy"],
+		AllProxy:   env["all_proxy"],
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = func(request *http.Request) (*url.URL, error) {
+		proxy := proxyForTarget(request.URL, proxyConfig)
+		if proxy == "" {
+			return nil, nil
+		}
+		return url.Parse(proxy)
+	}
+	return transport
+}
@@ -0,0 +1,145 @@
+package aiprovider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ooneko/claude-config/internal/claude"
+)
+
+func TestManager_CheckReachability(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/anthropic/v1/models" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("x-api-key") != "sk-good" || r.Header.Get("Authorization") != "Bearer sk-good" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	tmpDir := t.TempDir()
+	mgr := NewManager(tmpDir).(*Manager)
+	ctx := context.Background()
+
+	// Without a key nothing is sent
+	result, err := mgr.CheckReachability(ctx, ProviderDeepSeek)
+	if err != nil {
+		t.Fatalf("CheckReachability() error = %v", err)
+	}
+	if result.Status != claude.ReachabilityNoKey {
+		t.Errorf("Status = %q, want %q", result.Status, claude.ReachabilityNoKey)
+	}
+
+	if err := mgr.SetBaseURL(ctx, ProviderDeepSeek, server.URL+"/anthropic/"); err != nil {
+		t.Fatalf("SetBaseURL() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		apiKey     string
+		wantStatus claude.ReachabilityStatus
+		wantCode   int
+	}{
+		{"accepted key", "sk-good", claude.ReachabilityReachable, http.StatusOK},
+		{"rejected key", "sk-bad", claude.ReachabilityUnauthorized, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := mgr.saveAPIKey(ProviderDeepSeek, tt.apiKey); err != nil {
+				t.Fatalf("saveAPIKey() error = %v", err)
+			}
+
+			result, err := mgr.CheckReachability(ctx, ProviderDeepSeek)
+			if err != nil {
+				t.Fatalf("CheckReachability() error = %v", err)
+			}
+			if result.Status != tt.wantStatus || result.StatusCode != tt.wantCode {
+				t.Errorf("CheckReachability() = %q (HTTP %d), want %q (HTTP %d)", result.Status, result.StatusCode, tt.wantStatus, tt.wantCode)
+			}
+			if result.BaseURL != server.URL+"/anthropic/" {
+				t.Errorf("BaseURL = %q, want the override", result.BaseURL)
+			}
+		})
+	}
+
+	// An active provider enabled with a profile is checked with that profile's key
+	if err := mgr.saveAPIKey(ProviderDeepSeek, "sk-bad"); err != nil {
+		t.Fatalf("saveAPIKey() error = %v", err)
+	}
+	if err := mgr.EnableProfile(ctx, ProviderDeepSeek, "work", "sk-good"); err != nil {
+		t.Fatalf("EnableProfile() error = %v", err)
+	}
+	result, err = mgr.CheckReachability(ctx, ProviderDeepSeek)
+	if err != nil {
+		t.Fatalf("CheckReachability() error = %v", err)
+	}
+	if result.Status != claude.ReachabilityReachable {
+		t.Errorf("CheckReachability() with the work profile active = %q (HTTP %d), want %q", result.Status, result.StatusCode, claude.ReachabilityReachable)
+	}
+
+	if _, err := mgr.CheckReachability(ctx, ProviderType("unknown")); err == nil {
+		t.Error("CheckReachability() with an unknown provider should fail")
+	}
+}
+
+func TestManager_CheckReachability_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	mgr := NewManager(t.TempDir()).(*Manager)
+	if err := mgr.saveAPIKey(ProviderKimi, "sk-kimi"); err != nil {
+		t.Fatalf("saveAPIKey() error = %v", err)
+	}
+	if err := mgr.SetBaseURL(context.Background(), ProviderKimi, server.URL); err != nil {
+		t.Fatalf("SetBaseURL() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	result, err := mgr.CheckReachability(ctx, ProviderKimi)
+	if err != nil {
+		t.Fatalf("CheckReachability() error = %v", err)
+	}
+	if result.Status != claude.ReachabilityTimeout {
+		t.Errorf("Status = %q, want %q (error: %s)", result.Status, claude.ReachabilityTimeout, result.Error)
+	}
+}
+
+func TestManager_CheckReachability_Unreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	baseURL := server.URL
+	server.Close()
+
+	mgr := NewManager(t.TempDir()).(*Manager)
+	ctx := context.Background()
+	if err := mgr.saveAPIKey(ProviderGLM, "sk-glm"); err != nil {
+		t.Fatalf("saveAPIKey() error = %v", err)
+	}
+	if err := mgr.SetBaseURL(ctx, ProviderGLM, baseURL); err != nil {
+		t.Fatalf("SetBaseURL() error = %v", err)
+	}
+
+	result, err := mgr.CheckReachability(ctx, ProviderGLM)
+	if err != nil {
+		t.Fatalf("CheckReachability() error = %v", err)
+	}
+	if result.Status != claude.ReachabilityUnreachable || result.Error == "" {
+		t.Errorf("CheckReachability() = %q (%q), want unreachable with an error", result.Status, result.Error)
+	}
+}
@@ -9,16 +9,22 @@ import (
 // Type aliases for convenience
 type ProviderType = claude.ProviderType
 type ProviderConfig = claude.ProviderConfig
+type ProviderStatus = claude.ProviderStatus
+type ReachabilityResult = claude.ReachabilityResult
 
 // Provider type constants
 const (
-	ProviderNone     = claude.ProviderNone
-	ProviderDeepSeek = claude.ProviderDeepSeek
-	ProviderKimi     = claude.ProviderKimi
-	ProviderGLM      = claude.ProviderGLM
-	ProviderDoubao   = claude.ProviderDoubao
+	ProviderNone      = claude.ProviderNone
+	ProviderDeepSeek  = claude.ProviderDeepSeek
+	ProviderKimi      = claude.ProviderKimi
+	ProviderGLM       = claude.ProviderGLM
+	ProviderDoubao    = claude.ProviderDoubao
+	ProviderAnthropic = claude.ProviderAnthropic
 )
 
+// DefaultProfile is the profile name of the API key stored at .{provider}_api_key
+const DefaultProfile = "default"
+
 // ProviderManager defines the interface for managing AI providers
 type ProviderManager interface {
 	// Enable enables an AI provider with the given API key
@@ -56,4 +62,15 @@ type Provider interface {
 
 	// ValidateConfig validates the provider configuration
 	ValidateConfig(config *ProviderConfig) error
+
+	// SupportedModels returns the model names accepted by this provider
+	SupportedModels() []string
+}
+
+// EndpointProvider is implemented by providers that offer several known base
+// URLs. Endpoints maps endpoint names to base URLs; DefaultEndpoint names the
+// one returned by GetDefaultConfig.
+type EndpointProvider interface {
+	Endpoints() map[string]string
+	DefaultEndpoint() string
 }
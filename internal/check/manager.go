@@ -1,29 +1,101 @@
 package check
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/ooneko/claude-config/internal/claude"
+	"github.com/ooneko/claude-config/internal/hooks"
+	"github.com/ooneko/claude-config/internal/install"
+	"github.com/ooneko/claude-config/internal/logging"
+	"github.com/ooneko/claude-config/internal/settingsstore"
 )
 
+// SupportedLanguages lists the languages smart-lint.sh can toggle via CLAUDE_HOOKS_<LANG>_ENABLED
+var SupportedLanguages = []string{"go", "python", "js", "rust", "nix", "tilt"}
+
 // Manager implements check functionality management
 type Manager struct {
 	claudeDir string
+	logger    logging.Logger
 }
 
 // NewManager creates a new check manager
 func NewManager(claudeDir string) *Manager {
 	return &Manager{
 		claudeDir: claudeDir,
+		logger:    logging.Default(),
 	}
 }
 
-// EnableCheck enables code checking hooks (PostToolUse hooks)
-func (m *Manager) EnableCheck(_ context.Context) error {
+// SetLogger sets where progress messages go
+func (m *Manager) SetLogger(logger logging.Logger) {
+	m.logger = logger
+}
+
+// checkConfigFile lets users tune or replace the default check hooks.
+// Hooks use the settings.json format, limited to PreToolUse and PostToolUse.
+const checkConfigFile = ".check_config.json"
+
+// CheckConfig is the content of .check_config.json
+type CheckConfig struct {
+	// ScopeStaged makes the default smart-lint.sh hook lint only the files
+	// staged in git instead of the edited files
+	ScopeStaged bool `json:"scope_staged,omitempty"`
+
+	PreToolUse  []*claude.HookRule `json:"PreToolUse,omitempty"`
+	PostToolUse []*claude.HookRule `json:"PostToolUse,omitempty"`
+}
+
+// HasHooks reports whether the config replaces the default hooks
+func (c *CheckConfig) HasHooks() bool {
+	return c != nil && (len(c.PreToolUse) > 0 || len(c.PostToolUse) > 0)
+}
+
+// defaultCheckCommands are the hook commands owned by the check feature, as
+// templates expanded against the claude directory by hooks.ExpandCommand.
+// Other rules in the same events belong to the user and are never touched.
+var defaultCheckCommands = []string{
+	"${CLAUDE_DIR}/hooks/smart-lint.sh",
+	"${CLAUDE_DIR}/hooks/smart-test.sh",
+	legacyTestScript,
+	"${CLAUDE_DIR}/hooks/protect-files.sh",
+}
+
+// legacyTestScript was the default test hook of older versions, but no such
+// script ships. It stays check-owned so disable still removes it, and hooks
+// restored from a backup are pointed at smart-test.sh instead.
+const legacyTestScript = "${CLAUDE_DIR}/hooks/smarter-test.sh"
+
+// commandSet identifies check-owned hooks by command
+type commandSet map[string]bool
+
+// owns reports whether command is check-owned. Commands also match when they
+// only differ by arguments, such as "smart-lint.sh --staged".
+func (c commandSet) owns(command string) bool {
+	if c[command] {
+		return true
+	}
+	fields := strings.Fields(command)
+	return len(fields) > 0 && c[fields[0]]
+}
+
+// EnableCheck enables code checking hooks (PostToolUse lint/test hooks and
+// PreToolUse guards), keeping any other hooks already configured.
+// The hooks come from .check_config.json when present, otherwise from the
+// last disabled configuration or the defaults.
+func (m *Manager) EnableCheck(ctx context.Context) error {
+	checkConfig, err := m.LoadCheckConfig()
+	if err != nil {
+		return err
+	}
+	owned := m.ownedCommands(checkConfig)
+
 	settings, err := m.loadSettings()
 	if err != nil {
 		return fmt.Errorf("failed to load settings: %w", err)
@@ -34,27 +106,72 @@ func (m *Manager) EnableCheck(_ context.Context) error {
 		settings.Hooks = &claude.HooksConfig{}
 	}
 
-	// Load from backup or create default configuration
-	var hooksConfig *claude.HooksConfig
-	if backupConfig, err := m.loadHooksBackup(); err == nil {
-		hooksConfig = backupConfig
-	} else {
-		hooksConfig = m.createDefaultHooksConfig()
-	}
+	hooksConfig := m.desiredHooks(checkConfig, owned)
 
-	// Enable PostToolUse hooks
-	settings.Hooks.PostToolUse = hooksConfig.PostToolUse
+	// Replace any previous check rules so enabling twice doesn't duplicate them
+	settings.Hooks.PreToolUse = append(owned.userRules(settings.Hooks.PreToolUse), hooksConfig.PreToolUse...)
+	settings.Hooks.PostToolUse = append(owned.userRules(settings.Hooks.PostToolUse), hooksConfig.PostToolUse...)
 
 	// Save settings
 	if err := m.saveSettings(settings); err != nil {
 		return fmt.Errorf("failed to save settings: %w", err)
 	}
 
+	// Install the built-in scripts the hooks run; install --all leaves them out
+	scriptInstaller := install.NewManager(m.claudeDir)
+	scriptInstaller.SetLogger(m.logger)
+	if _, err := scriptInstaller.InstallHookScripts(ctx, settings); err != nil {
+		m.logger.Warn("⚠️  安装hook脚本失败: %v", err)
+	}
+
+	// The hooks are saved either way; missing scripts only make them fail later
+	if warnings := hooks.ValidateCommands(settings, m.claudeDir); len(warnings) > 0 {
+		m.logger.Warn("⚠️  以下hook脚本缺失或不可执行:")
+		for _, warning := range warnings {
+			m.logger.Warn("   - %s", warning)
+		}
+		m.logger.Warn("   请运行 claude-config install --hooks 安装hook脚本")
+	}
+
 	return nil
 }
 
-// DisableCheck disables code checking hooks (PostToolUse hooks)
+// desiredHooks returns the check hooks to install. It prefers the user's check
+// config, then the backup, then the defaults. An event the backup lacks, such
+// as PreToolUse in backups made before the protect-files.sh guard, falls back
+// to the defaults.
+func (m *Manager) desiredHooks(checkConfig *CheckConfig, owned commandSet) *claude.HooksConfig {
+	switch {
+	case checkConfig.HasHooks():
+		return &claude.HooksConfig{PreToolUse: checkConfig.PreToolUse, PostToolUse: checkConfig.PostToolUse}
+	case checkConfig != nil:
+		return m.createDefaultHooksConfig(checkConfig.ScopeStaged)
+	}
+
+	hooksConfig := m.createDefaultHooksConfig(false)
+	backupConfig, err := m.loadHooksBackup()
+	if err != nil {
+		return hooksConfig
+	}
+	if backupPre := owned.checkRules(backupConfig.PreToolUse); len(backupPre) > 0 {
+		hooksConfig.PreToolUse = backupPre
+	}
+	if backupPost := owned.checkRules(backupConfig.PostToolUse); len(backupPost) > 0 {
+		m.replaceLegacyTestScript(backupPost)
+		hooksConfig.PostToolUse = backupPost
+	}
+	return hooksConfig
+}
+
+// DisableCheck removes the check-owned hooks, leaving user rules in
+// PreToolUse/PostToolUse and other events such as SessionStart intact
 func (m *Manager) DisableCheck(_ context.Context) error {
+	checkConfig, err := m.LoadCheckConfig()
+	if err != nil {
+		return err
+	}
+	ownedCommands := m.ownedCommands(checkConfig)
+
 	settings, err := m.loadSettings()
 	if err != nil {
 		return fmt.Errorf("failed to load settings: %w", err)
@@ -65,17 +182,28 @@ func (m *Manager) DisableCheck(_ context.Context) error {
 		return nil
 	}
 
-	// Save current hooks configuration before modifying
-	if err := m.saveHooksBackup(settings.Hooks); err != nil {
-		return fmt.Errorf("failed to save hooks backup: %w", err)
+	// Save the check rules before removing them so a later enable restores them
+	owned := &claude.HooksConfig{
+		PreToolUse:  ownedCommands.checkRules(settings.Hooks.PreToolUse),
+		PostToolUse: ownedCommands.checkRules(settings.Hooks.PostToolUse),
+	}
+	if len(owned.PreToolUse) > 0 || len(owned.PostToolUse) > 0 {
+		if err := m.saveHooksBackup(owned); err != nil {
+			return fmt.Errorf("failed to save hooks backup: %w", err)
+		}
 	}
 
-	// Remove PostToolUse hooks
-	settings.Hooks.PostToolUse = nil
+	settings.Hooks.PreToolUse = ownedCommands.userRules(settings.Hooks.PreToolUse)
+	settings.Hooks.PostToolUse = ownedCommands.userRules(settings.Hooks.PostToolUse)
 
 	// If all hooks are removed, set hooks to nil
-	if len(settings.Hooks.PostToolUse) == 0 &&
-		len(settings.Hooks.Stop) == 0 {
+	if len(settings.Hooks.PreToolUse) == 0 &&
+		len(settings.Hooks.PostToolUse) == 0 &&
+		len(settings.Hooks.Stop) == 0 &&
+		len(settings.Hooks.Notification) == 0 &&
+		len(settings.Hooks.SessionStart) == 0 &&
+		len(settings.Hooks.SubagentStop) == 0 &&
+		len(settings.Hooks.PreCompact) == 0 {
 		settings.Hooks = nil
 	}
 
@@ -87,21 +215,253 @@ func (m *Manager) DisableCheck(_ context.Context) error {
 	return nil
 }
 
-// createDefaultHooksConfig creates a default hooks configuration
-func (m *Manager) createDefaultHooksConfig() *claude.HooksConfig {
+// IsEnabled reports whether check-owned hooks are present in PreToolUse or PostToolUse
+func (m *Manager) IsEnabled(_ context.Context) (bool, error) {
+	settings, err := m.loadSettings()
+	if err != nil {
+		return false, fmt.Errorf("failed to load settings: %w", err)
+	}
+
+	return m.HasCheckHooks(settings.Hooks)
+}
+
+// HasCheckHooks reports whether hooks contains a check-owned PreToolUse or PostToolUse hook
+func (m *Manager) HasCheckHooks(hooks *claude.HooksConfig) (bool, error) {
+	if hooks == nil {
+		return false, nil
+	}
+
+	checkConfig, err := m.LoadCheckConfig()
+	if err != nil {
+		return false, err
+	}
+
+	owned := m.ownedCommands(checkConfig)
+	return len(owned.checkRules(hooks.PreToolUse)) > 0 || len(owned.checkRules(hooks.PostToolUse)) > 0, nil
+}
+
+// IsComplete reports whether every event EnableCheck would configure already
+// holds a check-owned hook. It is false for checks enabled before an event was
+// added to them, such as the PreToolUse protect-files.sh guard.
+func (m *Manager) IsComplete(_ context.Context) (bool, error) {
+	checkConfig, err := m.LoadCheckConfig()
+	if err != nil {
+		return false, err
+	}
+	owned := m.ownedCommands(checkConfig)
+
+	settings, err := m.loadSettings()
+	if err != nil {
+		return false, fmt.Errorf("failed to load settings: %w", err)
+	}
+	if settings.Hooks == nil {
+		settings.Hooks = &claude.HooksConfig{}
+	}
+
+	desired := m.desiredHooks(checkConfig, owned)
+	if len(desired.PreToolUse) > 0 && len(owned.checkRules(settings.Hooks.PreToolUse)) == 0 {
+		return false, nil
+	}
+	if len(desired.PostToolUse) > 0 && len(owned.checkRules(settings.Hooks.PostToolUse)) == 0 {
+		return false, nil
+	}
+	return true, nil
+}
+
+// LoadCheckConfig loads .check_config.json.
+// It returns nil when the file doesn't exist, meaning the defaults apply.
+func (m *Manager) LoadCheckConfig() (*CheckConfig, error) {
+	data, err := os.ReadFile(filepath.Join(m.claudeDir, checkConfigFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read check config file: %w", err)
+	}
+
+	// Reject other hook events and typos instead of silently ignoring them
+	var checkConfig CheckConfig
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&checkConfig); err != nil {
+		return nil, fmt.Errorf("failed to parse check config file: %w", err)
+	}
+
+	for _, rules := range [][]*claude.HookRule{checkConfig.PreToolUse, checkConfig.PostToolUse} {
+		for _, rule := range rules {
+			if len(rule.Hooks) == 0 {
+				return nil, fmt.Errorf("check config rule %q has no hooks", rule.Matcher)
+			}
+			for _, hook := range rule.Hooks {
+				if strings.TrimSpace(hook.Command) == "" {
+					return nil, fmt.Errorf("check config rule %q has a hook without a command", rule.Matcher)
+				}
+				if hook.Timeout < 0 {
+					return nil, fmt.Errorf("check config hook %s has a negative timeout", hook.Command)
+				}
+				if hook.Type == "" {
+					hook.Type = "command"
+				}
+				hook.Command = hooks.ExpandCommand(hook.Command, m.claudeDir)
+			}
+		}
+	}
+
+	return &checkConfig, nil
+}
+
+// ownedCommands returns the default check commands plus those in checkConfig.
+// Default commands are also recognized in the ~/.claude form written by older versions.
+func (m *Manager) ownedCommands(checkConfig *CheckConfig) commandSet {
+	owned := make(commandSet, 2*len(defaultCheckCommands))
+	for _, command := range defaultCheckCommands {
+		owned[hooks.ExpandCommand(command, m.claudeDir)] = true
+		owned[strings.ReplaceAll(command, hooks.ClaudeDirToken, "~/.claude")] = true
+	}
+
+	if checkConfig != nil {
+		for _, rules := range [][]*claude.HookRule{checkConfig.PreToolUse, checkConfig.PostToolUse} {
+			for _, rule := range rules {
+				for _, hook := range rule.Hooks {
+					owned[hook.Command] = true
+				}
+			}
+		}
+	}
+
+	return owned
+}
+
+// checkRules returns copies of rules holding only check-owned hooks
+func (c commandSet) checkRules(rules []*claude.HookRule) []*claude.HookRule {
+	return c.filterRules(rules, true)
+}
+
+// userRules returns copies of rules without check-owned hooks
+func (c commandSet) userRules(rules []*claude.HookRule) []*claude.HookRule {
+	return c.filterRules(rules, false)
+}
+
+// filterRules keeps hooks whose ownership matches owned, dropping rules left empty
+func (c commandSet) filterRules(rules []*claude.HookRule, owned bool) []*claude.HookRule {
+	var result []*claude.HookRule
+	for _, rule := range rules {
+		var hooks []*claude.HookItem
+		for _, hook := range rule.Hooks {
+			if c.owns(hook.Command) == owned {
+				hooks = append(hooks, hook)
+			}
+		}
+		if len(hooks) > 0 {
+			result = append(result, &claude.HookRule{Matcher: rule.Matcher, Hooks: hooks})
+		}
+	}
+	return result
+}
+
+// SetLanguageEnabled sets CLAUDE_HOOKS_<LANG>_ENABLED in settings.json env
+func (m *Manager) SetLanguageEnabled(_ context.Context, lang string, enabled bool) error {
+	key, err := languageEnvKey(lang)
+	if err != nil {
+		return err
+	}
+
+	settings, err := m.loadSettings()
+	if err != nil {
+		return fmt.Errorf("failed to load settings: %w", err)
+	}
+
+	if settings.Env == nil {
+		settings.Env = make(map[string]string)
+	}
+
+	if enabled {
+		settings.Env[key] = "true"
+	} else {
+		settings.Env[key] = "false"
+	}
+
+	if err := m.saveSettings(settings); err != nil {
+		return fmt.Errorf("failed to save settings: %w", err)
+	}
+
+	return nil
+}
+
+// ResetLanguage removes CLAUDE_HOOKS_<LANG>_ENABLED so the script default applies
+func (m *Manager) ResetLanguage(_ context.Context, lang string) error {
+	key, err := languageEnvKey(lang)
+	if err != nil {
+		return err
+	}
+
+	settings, err := m.loadSettings()
+	if err != nil {
+		return fmt.Errorf("failed to load settings: %w", err)
+	}
+
+	if settings.Env == nil {
+		return nil
+	}
+
+	delete(settings.Env, key)
+
+	// If env map is empty, set it to nil
+	if len(settings.Env) == 0 {
+		settings.Env = nil
+	}
+
+	if err := m.saveSettings(settings); err != nil {
+		return fmt.Errorf("failed to save settings: %w", err)
+	}
+
+	return nil
+}
+
+// languageEnvKey returns the smart-lint env key for a supported language
+func languageEnvKey(lang string) (string, error) {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	for _, supported := range SupportedLanguages {
+		if lang == supported {
+			return fmt.Sprintf("CLAUDE_HOOKS_%s_ENABLED", strings.ToUpper(lang)), nil
+		}
+	}
+	return "", fmt.Errorf("unsupported language: %s (supported: %s)", lang, strings.Join(SupportedLanguages, ", "))
+}
+
+// createDefaultHooksConfig creates a default hooks configuration.
+// With scopeStaged, smart-lint.sh only checks the files staged in git.
+func (m *Manager) createDefaultHooksConfig(scopeStaged bool) *claude.HooksConfig {
+	lintCommand := hooks.ExpandCommand("${CLAUDE_DIR}/hooks/smart-lint.sh", m.claudeDir)
+	if scopeStaged {
+		lintCommand += " --staged"
+	}
+
 	return &claude.HooksConfig{
+		PreToolUse: []*claude.HookRule{
+			{
+				Matcher: "Write|Edit|MultiEdit",
+				Hooks: []*claude.HookItem{
+					{
+						Type:    "command",
+						Command: hooks.ExpandCommand("${CLAUDE_DIR}/hooks/protect-files.sh", m.claudeDir),
+						Timeout: 10,
+					},
+				},
+			},
+		},
 		PostToolUse: []*claude.HookRule{
 			{
 				Matcher: "Write|Edit|MultiEdit",
 				Hooks: []*claude.HookItem{
 					{
 						Type:    "command",
-						Command: "~/.claude/hooks/smart-lint.sh",
+						Command: lintCommand,
 						Timeout: 120,
 					},
 					{
 						Type:    "command",
-						Command: "~/.claude/hooks/smarter-test.sh",
+						Command: hooks.ExpandCommand("${CLAUDE_DIR}/hooks/smart-test.sh", m.claudeDir),
 						Timeout: 120,
 					},
 				},
@@ -110,49 +470,29 @@ func (m *Manager) createDefaultHooksConfig() *claude.HooksConfig {
 	}
 }
 
-// loadSettings loads settings from settings.json
-func (m *Manager) loadSettings() (*claude.Settings, error) {
-	settingsPath := filepath.Join(m.claudeDir, "settings.json")
-
-	// If file doesn't exist, return default settings
-	if _, err := os.Stat(settingsPath); os.IsNotExist(err) {
-		return &claude.Settings{
-			IncludeCoAuthoredBy: false,
-		}, nil
-	}
-
-	data, err := os.ReadFile(settingsPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read settings file: %w", err)
+// replaceLegacyTestScript points hooks running legacyTestScript at smart-test.sh
+func (m *Manager) replaceLegacyTestScript(rules []*claude.HookRule) {
+	legacy := commandSet{
+		hooks.ExpandCommand(legacyTestScript, m.claudeDir):                      true,
+		strings.ReplaceAll(legacyTestScript, hooks.ClaudeDirToken, "~/.claude"): true,
 	}
-
-	var settings claude.Settings
-	if err := json.Unmarshal(data, &settings); err != nil {
-		return nil, fmt.Errorf("failed to parse settings file: %w", err)
+	for _, rule := range rules {
+		for _, hook := range rule.Hooks {
+			if hook != nil && legacy.owns(hook.Command) {
+				hook.Command = strings.Replace(hook.Command, "smarter-test.sh", "smart-test.sh", 1)
+			}
+		}
 	}
+}
 
-	return &settings, nil
+// loadSettings loads settings from settings.json
+func (m *Manager) loadSettings() (*claude.Settings, error) {
+	return settingsstore.Load(m.claudeDir)
 }
 
 // saveSettings saves settings to settings.json
 func (m *Manager) saveSettings(settings *claude.Settings) error {
-	settingsPath := filepath.Join(m.claudeDir, "settings.json")
-
-	// Ensure directory exists
-	if err := os.MkdirAll(m.claudeDir, 0755); err != nil {
-		return fmt.Errorf("failed to create claude directory: %w", err)
-	}
-
-	data, err := json.MarshalIndent(settings, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal settings: %w", err)
-	}
-
-	if err := os.WriteFile(settingsPath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write settings file: %w", err)
-	}
-
-	return nil
+	return settingsstore.Save(m.claudeDir, settings)
 }
 
 // saveHooksBackup saves hooks configuration to backup file
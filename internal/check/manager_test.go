@@ -0,0 +1,435 @@
+package check
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ooneko/claude-config/internal/claude"
+)
+
+// readSettings reads settings.json from the claude directory
+func readSettings(t *testing.T, claudeDir string) *claude.Settings {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join(claudeDir, "settings.json"))
+	require.NoError(t, err)
+
+	var settings claude.Settings
+	require.NoError(t, json.Unmarshal(data, &settings))
+	return &settings
+}
+
+// defaultClaudeDir points HOME at a temporary directory and returns its .claude
+// directory, for which hook commands keep the portable ~/.claude form
+func defaultClaudeDir(t *testing.T) string {
+	t.Helper()
+
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+	t.Setenv("USERPROFILE", homeDir)
+
+	claudeDir := filepath.Join(homeDir, ".claude")
+	require.NoError(t, os.MkdirAll(claudeDir, 0755))
+	return claudeDir
+}
+
+func TestManager_SetLanguageEnabled(t *testing.T) {
+	claudeDir := defaultClaudeDir(t)
+	manager := NewManager(claudeDir)
+	ctx := context.Background()
+
+	// Disable Go linting
+	err := manager.SetLanguageEnabled(ctx, "go", false)
+	require.NoError(t, err)
+	assert.Equal(t, "false", readSettings(t, claudeDir).Env["CLAUDE_HOOKS_GO_ENABLED"])
+
+	// Enable Go linting (case-insensitive language name)
+	err = manager.SetLanguageEnabled(ctx, "Go", true)
+	require.NoError(t, err)
+	assert.Equal(t, "true", readSettings(t, claudeDir).Env["CLAUDE_HOOKS_GO_ENABLED"])
+
+	// Reset clears the key and the empty env map
+	err = manager.ResetLanguage(ctx, "go")
+	require.NoError(t, err)
+	settings := readSettings(t, claudeDir)
+	_, exists := settings.Env["CLAUDE_HOOKS_GO_ENABLED"]
+	assert.False(t, exists)
+	assert.Nil(t, settings.Env)
+}
+
+func TestManager_SetLanguageEnabled_Unsupported(t *testing.T) {
+	manager := NewManager(defaultClaudeDir(t))
+
+	err := manager.SetLanguageEnabled(context.Background(), "cobol", false)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported language")
+}
+
+// hookCommands lists the commands of every hook in rules
+func hookCommands(rules []*claude.HookRule) []string {
+	var commands []string
+	for _, rule := range rules {
+		for _, hook := range rule.Hooks {
+			commands = append(commands, hook.Command)
+		}
+	}
+	return commands
+}
+
+func TestManager_EnableCheck_CustomClaudeDir(t *testing.T) {
+	defaultClaudeDir(t)
+	claudeDir := filepath.Join(t.TempDir(), "claude-work")
+	require.NoError(t, os.MkdirAll(claudeDir, 0755))
+	manager := NewManager(claudeDir)
+	ctx := context.Background()
+
+	// Hooks written by older versions use ~/.claude and are still replaced
+	legacy := &claude.Settings{Hooks: &claude.HooksConfig{
+		PostToolUse: []*claude.HookRule{{
+			Matcher: "Write|Edit|MultiEdit",
+			Hooks:   []*claude.HookItem{{Type: "command", Command: "~/.claude/hooks/smart-lint.sh"}},
+		}},
+	}}
+	data, err := json.Marshal(legacy)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(claudeDir, "settings.json"), data, 0644))
+
+	require.NoError(t, manager.EnableCheck(ctx))
+
+	dir := filepath.ToSlash(claudeDir)
+	hooks := readSettings(t, claudeDir).Hooks
+	require.NotNil(t, hooks)
+	assert.Equal(t, []string{dir + "/hooks/protect-files.sh"}, hookCommands(hooks.PreToolUse))
+	assert.Equal(t, []string{dir + "/hooks/smart-lint.sh", dir + "/hooks/smart-test.sh"}, hookCommands(hooks.PostToolUse))
+
+	// ${CLAUDE_DIR} in .check_config.json resolves to the same directory
+	require.NoError(t, os.WriteFile(filepath.Join(claudeDir, ".check_config.json"),
+		[]byte(`{"PostToolUse": [{"matcher": "Write", "hooks": [{"command": "${CLAUDE_DIR}/hooks/ruff.sh"}]}]}`), 0644))
+	checkConfig, err := manager.LoadCheckConfig()
+	require.NoError(t, err)
+	assert.Equal(t, dir+"/hooks/ruff.sh", checkConfig.PostToolUse[0].Hooks[0].Command)
+
+	require.NoError(t, manager.DisableCheck(ctx))
+	assert.Nil(t, readSettings(t, claudeDir).Hooks)
+}
+
+func TestManager_EnableDisableCheck_KeepsUserHooks(t *testing.T) {
+	claudeDir := defaultClaudeDir(t)
+	manager := NewManager(claudeDir)
+	ctx := context.Background()
+
+	userPost := &claude.HookRule{
+		Matcher: "Write",
+		Hooks:   []*claude.HookItem{{Type: "command", Command: "~/bin/format.sh"}},
+	}
+	userSession := &claude.HookRule{
+		Hooks: []*claude.HookItem{{Type: "command", Command: "~/bin/session.sh"}},
+	}
+	initial := &claude.Settings{Hooks: &claude.HooksConfig{
+		PostToolUse:  []*claude.HookRule{userPost},
+		SessionStart: []*claude.HookRule{userSession},
+	}}
+	data, err := json.Marshal(initial)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(claudeDir, "settings.json"), data, 0644))
+
+	// Enabling twice installs the check hooks once, alongside user hooks
+	require.NoError(t, manager.EnableCheck(ctx))
+	require.NoError(t, manager.EnableCheck(ctx))
+
+	hooks := readSettings(t, claudeDir).Hooks
+	require.NotNil(t, hooks)
+	assert.Equal(t, []string{"~/.claude/hooks/protect-files.sh"}, hookCommands(hooks.PreToolUse))
+	assert.Equal(t, []string{
+		"~/bin/format.sh",
+		"~/.claude/hooks/smart-lint.sh",
+		"~/.claude/hooks/smart-test.sh",
+	}, hookCommands(hooks.PostToolUse))
+	assert.Equal(t, []string{"~/bin/session.sh"}, hookCommands(hooks.SessionStart))
+
+	// Disabling strips only the check-owned hooks
+	require.NoError(t, manager.DisableCheck(ctx))
+
+	hooks = readSettings(t, claudeDir).Hooks
+	require.NotNil(t, hooks)
+	assert.Empty(t, hooks.PreToolUse)
+	assert.Equal(t, []string{"~/bin/format.sh"}, hookCommands(hooks.PostToolUse))
+	assert.Equal(t, []string{"~/bin/session.sh"}, hookCommands(hooks.SessionStart))
+}
+
+func TestManager_DisableCheck_RestoresCustomizedHooks(t *testing.T) {
+	claudeDir := defaultClaudeDir(t)
+	manager := NewManager(claudeDir)
+	ctx := context.Background()
+
+	require.NoError(t, manager.EnableCheck(ctx))
+
+	// Customize the lint timeout, then disable and re-enable
+	settings := readSettings(t, claudeDir)
+	settings.Hooks.PostToolUse[0].Hooks[0].Timeout = 300
+	require.NoError(t, manager.saveSettings(settings))
+
+	require.NoError(t, manager.DisableCheck(ctx))
+	assert.Nil(t, readSettings(t, claudeDir).Hooks)
+
+	require.NoError(t, manager.EnableCheck(ctx))
+	hooks := readSettings(t, claudeDir).Hooks
+	require.NotNil(t, hooks)
+	require.Len(t, hooks.PostToolUse, 1)
+	assert.Equal(t, 300, hooks.PostToolUse[0].Hooks[0].Timeout)
+	assert.Equal(t, []string{"~/.claude/hooks/protect-files.sh"}, hookCommands(hooks.PreToolUse))
+}
+
+func TestManager_DisableCheck_PreservesCustomPostToolUseHooks(t *testing.T) {
+	claudeDir := defaultClaudeDir(t)
+	manager := NewManager(claudeDir)
+	ctx := context.Background()
+
+	// A custom rule before the check rule, and a custom hook sharing the check rule's matcher
+	initial := &claude.Settings{Hooks: &claude.HooksConfig{
+		PostToolUse: []*claude.HookRule{
+			{
+				Matcher: "Bash",
+				Hooks:   []*claude.HookItem{{Type: "command", Command: "~/bin/audit.sh"}},
+			},
+			{
+				Matcher: "Write|Edit|MultiEdit",
+				Hooks: []*claude.HookItem{
+					{Type: "command", Command: "~/.claude/hooks/smart-lint.sh", Timeout: 120},
+					{Type: "command", Command: "~/bin/format.sh"},
+					{Type: "command", Command: "~/.claude/hooks/smarter-test.sh", Timeout: 120},
+				},
+			},
+		},
+	}}
+	data, err := json.Marshal(initial)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(claudeDir, "settings.json"), data, 0644))
+
+	require.NoError(t, manager.DisableCheck(ctx))
+
+	hooks := readSettings(t, claudeDir).Hooks
+	require.NotNil(t, hooks)
+	require.Len(t, hooks.PostToolUse, 2)
+	assert.Equal(t, "Bash", hooks.PostToolUse[0].Matcher)
+	assert.Equal(t, []string{"~/bin/audit.sh"}, hookCommands(hooks.PostToolUse[:1]))
+	assert.Equal(t, "Write|Edit|MultiEdit", hooks.PostToolUse[1].Matcher)
+	assert.Equal(t, []string{"~/bin/format.sh"}, hookCommands(hooks.PostToolUse[1:]))
+
+	// The backup only holds the check hooks, so re-enabling doesn't duplicate custom ones
+	backup, err := manager.loadHooksBackup()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"~/.claude/hooks/smart-lint.sh",
+		"~/.claude/hooks/smarter-test.sh",
+	}, hookCommands(backup.PostToolUse))
+
+	// Restoring points the legacy smarter-test.sh hook at the shipped smart-test.sh
+	require.NoError(t, manager.EnableCheck(ctx))
+	hooks = readSettings(t, claudeDir).Hooks
+	assert.Equal(t, []string{
+		"~/bin/audit.sh",
+		"~/bin/format.sh",
+		"~/.claude/hooks/smart-lint.sh",
+		"~/.claude/hooks/smart-test.sh",
+	}, hookCommands(hooks.PostToolUse))
+}
+
+func TestManager_IsEnabled(t *testing.T) {
+	claudeDir := defaultClaudeDir(t)
+	manager := NewManager(claudeDir)
+	ctx := context.Background()
+
+	enabled, err := manager.IsEnabled(ctx)
+	require.NoError(t, err)
+	assert.False(t, enabled, "missing settings.json means disabled")
+
+	require.NoError(t, manager.EnableCheck(ctx))
+	enabled, err = manager.IsEnabled(ctx)
+	require.NoError(t, err)
+	assert.True(t, enabled)
+
+	require.NoError(t, manager.DisableCheck(ctx))
+	enabled, err = manager.IsEnabled(ctx)
+	require.NoError(t, err)
+	assert.False(t, enabled)
+
+	// Unrelated PostToolUse hooks don't count as the check feature
+	hasHooks, err := manager.HasCheckHooks(&claude.HooksConfig{PostToolUse: []*claude.HookRule{{
+		Matcher: "Write|Edit|MultiEdit",
+		Hooks:   []*claude.HookItem{{Type: "command", Command: "~/bin/format.sh"}},
+	}}})
+	require.NoError(t, err)
+	assert.False(t, hasHooks)
+}
+
+func TestManager_IsComplete_UpgradesPostToolUseOnlyHooks(t *testing.T) {
+	claudeDir := defaultClaudeDir(t)
+	manager := NewManager(claudeDir)
+	ctx := context.Background()
+
+	// Hooks enabled before the PreToolUse guard existed
+	legacy := &claude.Settings{Hooks: &claude.HooksConfig{
+		PostToolUse: []*claude.HookRule{{
+			Matcher: "Write|Edit|MultiEdit",
+			Hooks: []*claude.HookItem{
+				{Type: "command", Command: "~/.claude/hooks/smart-lint.sh", Timeout: 120},
+				{Type: "command", Command: "~/.claude/hooks/smart-test.sh", Timeout: 120},
+			},
+		}},
+	}}
+	data, err := json.Marshal(legacy)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(claudeDir, "settings.json"), data, 0644))
+
+	enabled, err := manager.IsEnabled(ctx)
+	require.NoError(t, err)
+	assert.True(t, enabled)
+	complete, err := manager.IsComplete(ctx)
+	require.NoError(t, err)
+	assert.False(t, complete, "the PreToolUse guard is missing")
+
+	require.NoError(t, manager.EnableCheck(ctx))
+	hooks := readSettings(t, claudeDir).Hooks
+	assert.Equal(t, []string{"~/.claude/hooks/protect-files.sh"}, hookCommands(hooks.PreToolUse))
+	complete, err = manager.IsComplete(ctx)
+	require.NoError(t, err)
+	assert.True(t, complete)
+
+	// A backup made before the guard existed is restored with the default guard added
+	require.NoError(t, manager.saveHooksBackup(legacy.Hooks))
+	require.NoError(t, os.WriteFile(filepath.Join(claudeDir, "settings.json"), []byte(`{}`), 0644))
+	require.NoError(t, manager.EnableCheck(ctx))
+	hooks = readSettings(t, claudeDir).Hooks
+	assert.Equal(t, []string{"~/.claude/hooks/protect-files.sh"}, hookCommands(hooks.PreToolUse))
+	assert.Equal(t, []string{"~/.claude/hooks/smart-lint.sh", "~/.claude/hooks/smart-test.sh"}, hookCommands(hooks.PostToolUse))
+
+	// A PreToolUse guard alone also counts as enabled
+	hasHooks, err := manager.HasCheckHooks(&claude.HooksConfig{PreToolUse: hooks.PreToolUse})
+	require.NoError(t, err)
+	assert.True(t, hasHooks)
+}
+
+func TestManager_CheckConfig(t *testing.T) {
+	claudeDir := defaultClaudeDir(t)
+	manager := NewManager(claudeDir)
+	ctx := context.Background()
+
+	checkConfig, err := manager.LoadCheckConfig()
+	require.NoError(t, err)
+	assert.Nil(t, checkConfig, "missing config file means defaults")
+
+	custom := `{
+  "PostToolUse": [
+    {"matcher": "Write|Edit", "hooks": [
+      {"command": "~/bin/ruff.sh", "timeout": 30},
+      {"type": "command", "command": "~/bin/pytest.sh", "timeout": 600}
+    ]}
+  ]
+}`
+	require.NoError(t, os.WriteFile(filepath.Join(claudeDir, ".check_config.json"), []byte(custom), 0644))
+
+	require.NoError(t, manager.EnableCheck(ctx))
+	hooks := readSettings(t, claudeDir).Hooks
+	require.NotNil(t, hooks)
+	assert.Empty(t, hooks.PreToolUse)
+	require.Len(t, hooks.PostToolUse, 1)
+	assert.Equal(t, "Write|Edit", hooks.PostToolUse[0].Matcher)
+	assert.Equal(t, []string{"~/bin/ruff.sh", "~/bin/pytest.sh"}, hookCommands(hooks.PostToolUse))
+	assert.Equal(t, "command", hooks.PostToolUse[0].Hooks[0].Type)
+	assert.Equal(t, 30, hooks.PostToolUse[0].Hooks[0].Timeout)
+	assert.Equal(t, 600, hooks.PostToolUse[0].Hooks[1].Timeout)
+
+	enabled, err := manager.IsEnabled(ctx)
+	require.NoError(t, err)
+	assert.True(t, enabled)
+
+	// Configured commands are check-owned, so disable removes them
+	require.NoError(t, manager.DisableCheck(ctx))
+	assert.Nil(t, readSettings(t, claudeDir).Hooks)
+}
+
+func TestManager_CheckConfig_ScopeStaged(t *testing.T) {
+	claudeDir := defaultClaudeDir(t)
+	manager := NewManager(claudeDir)
+	ctx := context.Background()
+
+	require.NoError(t, os.WriteFile(filepath.Join(claudeDir, ".check_config.json"), []byte(`{"scope_staged": true}`), 0644))
+
+	checkConfig, err := manager.LoadCheckConfig()
+	require.NoError(t, err)
+	require.NotNil(t, checkConfig)
+	assert.True(t, checkConfig.ScopeStaged)
+	assert.False(t, checkConfig.HasHooks(), "scope_staged alone keeps the default hooks")
+
+	require.NoError(t, manager.EnableCheck(ctx))
+	hooks := readSettings(t, claudeDir).Hooks
+	require.NotNil(t, hooks)
+	assert.Equal(t, []string{"~/.claude/hooks/protect-files.sh"}, hookCommands(hooks.PreToolUse))
+	assert.Equal(t, []string{"~/.claude/hooks/smart-lint.sh --staged", "~/.claude/hooks/smart-test.sh"}, hookCommands(hooks.PostToolUse))
+
+	enabled, err := manager.IsEnabled(ctx)
+	require.NoError(t, err)
+	assert.True(t, enabled, "the command with arguments is still check-owned")
+
+	require.NoError(t, manager.DisableCheck(ctx))
+	assert.Nil(t, readSettings(t, claudeDir).Hooks)
+
+	// Turning the option off switches back to the plain command on the next enable
+	require.NoError(t, os.WriteFile(filepath.Join(claudeDir, ".check_config.json"), []byte(`{"scope_staged": false}`), 0644))
+	require.NoError(t, manager.EnableCheck(ctx))
+	assert.Contains(t, hookCommands(readSettings(t, claudeDir).Hooks.PostToolUse), "~/.claude/hooks/smart-lint.sh")
+}
+
+func TestManager_CreateDefaultHooksConfig_ScopeStaged(t *testing.T) {
+	manager := NewManager(defaultClaudeDir(t))
+
+	lintCommand := manager.createDefaultHooksConfig(true).PostToolUse[0].Hooks[0].Command
+	assert.Contains(t, lintCommand, "--staged")
+	assert.Equal(t, "~/.claude/hooks/smart-lint.sh --staged", lintCommand)
+
+	lintCommand = manager.createDefaultHooksConfig(false).PostToolUse[0].Hooks[0].Command
+	assert.NotContains(t, lintCommand, "--staged")
+}
+
+func TestManager_CheckConfig_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{"malformed", `{"PostToolUse": [`, "failed to parse check config file"},
+		{"unsupported event", `{"Stop": [{"matcher": "", "hooks": [{"command": "x"}]}]}`, `unknown field "Stop"`},
+		{"wrong option type", `{"scope_staged": "yes"}`, "failed to parse check config file"},
+		{"missing command", `{"PostToolUse": [{"matcher": "Write", "hooks": [{"timeout": 10}]}]}`, "without a command"},
+		{"negative timeout", `{"PostToolUse": [{"matcher": "Write", "hooks": [{"command": "x", "timeout": -1}]}]}`, "negative timeout"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claudeDir := defaultClaudeDir(t)
+			require.NoError(t, os.WriteFile(filepath.Join(claudeDir, ".check_config.json"), []byte(tt.config), 0644))
+
+			err := NewManager(claudeDir).EnableCheck(context.Background())
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestManager_EnableCheck_InstallsHookScripts(t *testing.T) {
+	claudeDir := defaultClaudeDir(t)
+	manager := NewManager(claudeDir)
+
+	require.NoError(t, manager.EnableCheck(context.Background()))
+
+	assert.FileExists(t, filepath.Join(claudeDir, "hooks", "smart-lint.sh"))
+	assert.FileExists(t, filepath.Join(claudeDir, "hooks", "protect-files.sh"))
+	assert.FileExists(t, filepath.Join(claudeDir, "hooks", "common-helpers.sh"))
+}
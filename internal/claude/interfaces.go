@@ -10,11 +10,26 @@ type ConfigManager interface {
 	// Save saves the configuration to settings.json
 	Save(ctx context.Context, config *Settings) error
 
+	// LoadEffective loads settings.json with its "includes" merged in, without rewriting it
+	LoadEffective(ctx context.Context) (*Settings, error)
+
 	// GetStatus returns current configuration status
 	GetStatus(ctx context.Context) (*ConfigStatus, error)
 
 	// Backup creates a backup of configuration
 	Backup(ctx context.Context) (*BackupInfo, error)
+
+	// BackupTo creates a backup of configuration in the given directory
+	BackupTo(ctx context.Context, destDir string, opts *BackupOptions) (*BackupInfo, error)
+
+	// Restore restores configuration from a backup archive
+	Restore(ctx context.Context, archivePath string, force bool) error
+
+	// BackupProfiles archives every profile directory under profilesRoot into one backup in destDir
+	BackupProfiles(ctx context.Context, profilesRoot, destDir string, opts *BackupOptions) (*BackupInfo, error)
+
+	// RestoreProfiles restores a backup made by BackupProfiles into profilesRoot
+	RestoreProfiles(ctx context.Context, archivePath, profilesRoot string, force bool) ([]string, error)
 }
 
 // ProxyManager defines the interface for proxy management
@@ -39,6 +54,9 @@ type ProxyManager interface {
 
 	// Reset removes saved proxy configuration and disables proxy
 	Reset(ctx context.Context) error
+
+	// TestProxy checks that the configured proxies accept connections
+	TestProxy(ctx context.Context, config *ProxyConfig) error
 }
 
 // AIProviderManager defines the interface for managing multiple AI providers
@@ -46,15 +64,37 @@ type AIProviderManager interface {
 	// Enable enables an AI provider with the given API key
 	Enable(ctx context.Context, provider ProviderType, apiKey string) error
 
-	// Reset removes the API key and disables the provider
+	// EnableProfile enables an AI provider with an API key stored under a named profile
+	EnableProfile(ctx context.Context, provider ProviderType, profile, apiKey string) error
+
+	// ListProfiles returns the profiles with a stored API key for the provider
+	ListProfiles(provider ProviderType) ([]string, error)
+
+	// LoadAPIKey returns the API key stored under a provider profile
+	LoadAPIKey(provider ProviderType, profile string) (string, error)
+
+	// SetKeyStore selects where API keys saved from now on are stored: "file" or "keychain"
+	SetKeyStore(name string) error
+
+	// Reset removes the API keys of all profiles and disables the provider
 	Reset(ctx context.Context, provider ProviderType) error
 
+	// RotateKey replaces the provider's stored API key, updating settings.json
+	// when the provider is active with that key
+	RotateKey(ctx context.Context, provider ProviderType, newKey string) error
+
+	// EnableEncryption encrypts stored API keys with the passphrase from now on
+	EnableEncryption(ctx context.Context, passphrase string) error
+
 	// Off disables all AI providers completely
 	Off(ctx context.Context) error
 
 	// On restores the previously active AI provider
 	On(ctx context.Context) error
 
+	// GetLastActiveProvider returns the provider On would restore
+	GetLastActiveProvider(ctx context.Context) (*LastActiveProvider, error)
+
 	// HasAPIKey returns whether an API key is stored for the provider
 	HasAPIKey(ctx context.Context, provider ProviderType) (bool, error)
 
@@ -64,8 +104,43 @@ type AIProviderManager interface {
 	// GetActiveProvider returns the currently active provider
 	GetActiveProvider(ctx context.Context) (ProviderType, error)
 
+	// ProviderForBaseURL returns the provider serving baseURL the way
+	// GetActiveProvider identifies it, or ProviderNone
+	ProviderForBaseURL(baseURL string) ProviderType
+
+	// Status returns the active provider, its configuration and stored keys
+	Status(ctx context.Context) (*ProviderStatus, error)
+
+	// CheckReachability sends an authenticated request to the provider's API
+	CheckReachability(ctx context.Context, provider ProviderType) (*ReachabilityResult, error)
+
 	// ListSupportedProviders returns all supported provider types
 	ListSupportedProviders() []ProviderType
+
+	// SetDefaultProvider persists the provider used by start without arguments;
+	// ProviderNone clears it
+	SetDefaultProvider(ctx context.Context, provider ProviderType) error
+
+	// GetDefaultProvider returns the persisted default provider, or ProviderNone
+	GetDefaultProvider(ctx context.Context) (ProviderType, error)
+
+	// SetEndpoint selects one of the provider's known base URLs
+	SetEndpoint(ctx context.Context, provider ProviderType, endpoint string) error
+
+	// GetEndpoint returns the selected endpoint name for the provider
+	GetEndpoint(ctx context.Context, provider ProviderType) (string, error)
+
+	// SetBaseURL overrides the provider's base URL until reset
+	SetBaseURL(ctx context.Context, provider ProviderType, baseURL string) error
+
+	// GetBaseURL returns the provider's base URL override, or "" when none is set
+	GetBaseURL(ctx context.Context, provider ProviderType) (string, error)
+
+	// ExportProviders serializes all stored API keys to JSON
+	ExportProviders(ctx context.Context) ([]byte, error)
+
+	// ImportProviders restores API keys produced by ExportProviders
+	ImportProviders(ctx context.Context, data []byte) error
 }
 
 // FileOperations defines the interface for file operations
@@ -76,20 +151,38 @@ type FileOperations interface {
 	// Compare compares source and destination files
 	Compare(ctx context.Context, sourcePath, destPath string) (*CompareResult, error)
 
+	// CompareDirectory compares two directory trees file by file
+	CompareDirectory(ctx context.Context, sourceDir, destDir string) (*DirCompareResult, error)
+
 	// MergeSettings intelligently merges settings.json files
 	MergeSettings(ctx context.Context, source, dest *Settings) (*Settings, error)
 }
 
 // CopyOptions represents options for copy operations
 type CopyOptions struct {
-	Agents   bool `json:"agents"`
-	Commands bool `json:"commands"`
-	Hooks    bool `json:"hooks"`
-	All      bool `json:"all"`
+	Agents       bool `json:"agents"`
+	Commands     bool `json:"commands"`
+	Hooks        bool `json:"hooks"`
+	OutputStyles bool `json:"output_styles"`
+	StatuslineJs bool `json:"statusline_js"`
+	All          bool `json:"all"`
+	DryRun       bool `json:"dry_run"` // Only report what would be copied and merged, without writing
 }
 
 // CompareResult represents the result of file comparison
 type CompareResult struct {
 	Same        bool     `json:"same"`
 	Differences []string `json:"differences,omitempty"`
+	// Diff is a unified diff from the destination to the source file, set
+	// when both exist, differ and are text
+	Diff string `json:"diff,omitempty"`
+}
+
+// DirCompareResult represents the result of comparing two directory trees.
+// Paths are relative to the compared directories, use "/" and are sorted.
+type DirCompareResult struct {
+	Same         bool     `json:"same"`
+	OnlyInSource []string `json:"only_in_source,omitempty"`
+	OnlyInDest   []string `json:"only_in_dest,omitempty"`
+	Differing    []string `json:"differing,omitempty"`
 }
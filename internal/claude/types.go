@@ -2,7 +2,12 @@ package claude
 
 import (
 	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -12,13 +17,131 @@ type Settings struct {
 	Env                 map[string]string `json:"env,omitempty"`
 	Hooks               *HooksConfig      `json:"hooks,omitempty"`
 	StatusLine          *StatusLineConfig `json:"statusLine,omitempty"`
+	Includes            []string          `json:"includes,omitempty"`
+	SchemaVersion       int               `json:"schemaVersion,omitempty"` // Maintained by claude-config migrate
+
+	// Extra holds top-level keys not modeled above so they survive a load/save round trip
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 // HooksConfig represents the hooks configuration
 type HooksConfig struct {
+	PreToolUse   []*HookRule `json:"PreToolUse,omitempty"`
 	PostToolUse  []*HookRule `json:"PostToolUse,omitempty"`
 	Stop         []*HookRule `json:"Stop,omitempty"`
 	Notification []*HookRule `json:"Notification,omitempty"`
+	SessionStart []*HookRule `json:"SessionStart,omitempty"`
+	SubagentStop []*HookRule `json:"SubagentStop,omitempty"`
+	PreCompact   []*HookRule `json:"PreCompact,omitempty"`
+}
+
+// Events returns the rules of every hook event that has at least one rule,
+// keyed by the event name used in settings.json. Events added to HooksConfig
+// are included automatically.
+func (h *HooksConfig) Events() map[string][]*HookRule {
+	if h == nil {
+		return nil
+	}
+
+	events := make(map[string][]*HookRule)
+	value := reflect.ValueOf(h).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		rules, ok := value.Field(i).Interface().([]*HookRule)
+		if !ok || len(rules) == 0 {
+			continue
+		}
+		name, _, _ := strings.Cut(value.Type().Field(i).Tag.Get("json"), ",")
+		events[name] = rules
+	}
+	return events
+}
+
+// HookEventNames returns the settings.json names of the hook events modeled by HooksConfig
+func HookEventNames() []string {
+	hooksType := reflect.TypeOf(HooksConfig{})
+	names := make([]string, 0, hooksType.NumField())
+	for i := 0; i < hooksType.NumField(); i++ {
+		name, _, _ := strings.Cut(hooksType.Field(i).Tag.Get("json"), ",")
+		names = append(names, name)
+	}
+	return names
+}
+
+// Rules returns a pointer to the rule list of the named event so it can be
+// modified in place, or nil when HooksConfig does not model the event
+func (h *HooksConfig) Rules(event string) *[]*HookRule {
+	value := reflect.ValueOf(h).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		name, _, _ := strings.Cut(value.Type().Field(i).Tag.Get("json"), ",")
+		if name != event {
+			continue
+		}
+		rules, _ := value.Field(i).Addr().Interface().(*[]*HookRule)
+		return rules
+	}
+	return nil
+}
+
+// normalizeHookEvents rereads a hooks block whose event keys are not all
+// spelled canonically, e.g. "postToolUse" or "post_tool_use". encoding/json
+// already matches keys case-insensitively but keeps only the last of several
+// spellings; here rules from every spelling are merged, the canonical one
+// first. It returns nil when every key is canonical.
+func normalizeHookEvents(data json.RawMessage) (*HooksConfig, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil || raw == nil {
+		return nil, nil
+	}
+
+	var aliases []string
+	for key := range raw {
+		if event := CanonicalHookEvent(key); event != "" && event != key {
+			aliases = append(aliases, key)
+		}
+	}
+	if len(aliases) == 0 {
+		return nil, nil
+	}
+	sort.Strings(aliases)
+
+	hooks := &HooksConfig{}
+	for _, event := range HookEventNames() {
+		keys := aliases
+		if _, ok := raw[event]; ok {
+			keys = append([]string{event}, aliases...)
+		}
+
+		for _, key := range keys {
+			if CanonicalHookEvent(key) != event {
+				continue
+			}
+			var rules []*HookRule
+			if err := json.Unmarshal(raw[key], &rules); err != nil {
+				return nil, fmt.Errorf("hooks.%s: %w", key, err)
+			}
+			target := hooks.Rules(event)
+			*target = append(*target, rules...)
+		}
+	}
+
+	return hooks, nil
+}
+
+// unmodeledHookEvents are the hook events Claude Code supports that
+// HooksConfig does not model; they are kept in raw settings only
+var unmodeledHookEvents = []string{"UserPromptSubmit", "SessionEnd"}
+
+// CanonicalHookEvent returns the canonical name of a settings.json hook event
+// key, e.g. PostToolUse for "postToolUse" or "post_tool_use", ignoring case,
+// "_" and "-". It returns an empty string for unknown events.
+func CanonicalHookEvent(key string) string {
+	normalized := strings.NewReplacer("_", "", "-", "").Replace(key)
+	for _, event := range append(HookEventNames(), unmodeledHookEvents...) {
+		if strings.EqualFold(normalized, event) {
+			return event
+		}
+	}
+	return ""
 }
 
 // HookRule represents a single hook rule with matcher and hooks
@@ -41,11 +164,12 @@ type StatusLineConfig map[string]interface{}
 type ProviderType string
 
 const (
-	ProviderNone     ProviderType = ""
-	ProviderDeepSeek ProviderType = "deepseek"
-	ProviderKimi     ProviderType = "kimi"
-	ProviderGLM      ProviderType = "GLM"
-	ProviderDoubao   ProviderType = "doubao"
+	ProviderNone      ProviderType = ""
+	ProviderDeepSeek  ProviderType = "deepseek"
+	ProviderKimi      ProviderType = "kimi"
+	ProviderGLM       ProviderType = "GLM"
+	ProviderDoubao    ProviderType = "doubao"
+	ProviderAnthropic ProviderType = "anthropic"
 )
 
 // String returns the string representation of ProviderType
@@ -56,16 +180,73 @@ func (p ProviderType) String() string {
 // IsValid checks if the provider type is valid
 func (p ProviderType) IsValid() bool {
 	switch p {
-	case ProviderDeepSeek, ProviderKimi, ProviderGLM, ProviderDoubao:
+	case ProviderDeepSeek, ProviderKimi, ProviderGLM, ProviderDoubao, ProviderAnthropic:
 		return true
 	default:
 		return false
 	}
 }
 
+// NativeProviderAliases are the names start accepts for native Claude Code.
+// anthropic is a real provider (ai on anthropic) and not one of them.
+var NativeProviderAliases = []string{"native", "claude"}
+
+// IsNativeProviderAlias reports whether input is one of NativeProviderAliases, ignoring case
+func IsNativeProviderAlias(input string) bool {
+	for _, alias := range NativeProviderAliases {
+		if strings.EqualFold(strings.TrimSpace(input), alias) {
+			return true
+		}
+	}
+	return false
+}
+
+// registeredProviders holds providers registered at runtime, keyed by lowercase name
+var (
+	registeredProvidersMu sync.RWMutex
+	registeredProviders   = map[string]ProviderType{}
+)
+
+// RegisterProviderName makes NormalizeProviderName resolve a provider that
+// isn't built in, such as one declared in providers.json. Names match
+// case-insensitively; built-in names and aliases can't be taken.
+func RegisterProviderName(provider ProviderType) error {
+	if provider == ProviderNone {
+		return fmt.Errorf("provider name is required")
+	}
+	if IsBuiltinProviderName(string(provider)) {
+		return fmt.Errorf("provider name %q is taken by a built-in provider", provider)
+	}
+	if IsNativeProviderAlias(string(provider)) {
+		return fmt.Errorf("provider name %q is reserved for native Claude Code", provider)
+	}
+
+	registeredProvidersMu.Lock()
+	defer registeredProvidersMu.Unlock()
+	registeredProviders[strings.ToLower(string(provider))] = provider
+	return nil
+}
+
+// IsBuiltinProviderName reports whether input names a built-in provider or one of its aliases
+func IsBuiltinProviderName(input string) bool {
+	return normalizeBuiltinProviderName(input) != ProviderNone
+}
+
 // NormalizeProviderName converts user input to the correct ProviderType
-// This allows case-insensitive provider names for better user experience
+// This allows case-insensitive provider names for better user experience.
+// Built-in names and aliases win over providers added with RegisterProviderName.
 func NormalizeProviderName(input string) ProviderType {
+	if provider := normalizeBuiltinProviderName(input); provider != ProviderNone {
+		return provider
+	}
+
+	registeredProvidersMu.RLock()
+	defer registeredProvidersMu.RUnlock()
+	return registeredProviders[strings.ToLower(input)]
+}
+
+// normalizeBuiltinProviderName resolves the built-in provider names and aliases
+func normalizeBuiltinProviderName(input string) ProviderType {
 	switch strings.ToLower(input) {
 	case "deepseek":
 		return ProviderDeepSeek
@@ -77,6 +258,8 @@ func NormalizeProviderName(input string) ProviderType {
 		return ProviderGLM
 	case "doubao":
 		return ProviderDoubao
+	case "anthropic":
+		return ProviderAnthropic
 	default:
 		// If exact match, return as-is for backwards compatibility
 		p := ProviderType(input)
@@ -87,6 +270,15 @@ func NormalizeProviderName(input string) ProviderType {
 	}
 }
 
+// APIKeyFileSuffix ends the name of every API key file, .{provider}[.profile]_api_key
+const APIKeyFileSuffix = "_api_key"
+
+// IsKeyFile reports whether the base name of path is an API key file
+func IsKeyFile(path string) bool {
+	name := filepath.Base(path)
+	return strings.HasPrefix(name, ".") && strings.HasSuffix(name, APIKeyFileSuffix)
+}
+
 // ProviderConfig represents configuration for an AI provider
 type ProviderConfig struct {
 	Type           ProviderType `json:"type"`
@@ -96,10 +288,50 @@ type ProviderConfig struct {
 	SmallFastModel string       `json:"small_fast_model"`
 }
 
+// LastActiveProvider represents the provider saved when AI providers were turned off
+type LastActiveProvider struct {
+	Provider       ProviderType `json:"provider"`
+	Profile        string       `json:"profile,omitempty"`
+	Model          string       `json:"model,omitempty"`
+	SmallFastModel string       `json:"smallFastModel,omitempty"`
+}
+
+// ProviderStatus represents the AI provider state for display or scripting
+type ProviderStatus struct {
+	ActiveProvider ProviderType          `json:"active_provider"`
+	Config         *ProviderConfig       `json:"config,omitempty"`
+	Keys           map[ProviderType]bool `json:"keys"`
+	// Reachability is only filled in when requested, see CheckReachability
+	Reachability *ReachabilityResult `json:"reachability,omitempty"`
+}
+
+// ReachabilityStatus is the outcome of a reachability check
+type ReachabilityStatus string
+
+const (
+	ReachabilityReachable    ReachabilityStatus = "reachable"    // the API answered and accepted the key
+	ReachabilityUnauthorized ReachabilityStatus = "unauthorized" // the API rejected the key
+	ReachabilityTimeout      ReachabilityStatus = "timeout"      // no answer before the timeout
+	ReachabilityUnreachable  ReachabilityStatus = "unreachable"  // the connection failed
+	ReachabilityNoKey        ReachabilityStatus = "no_key"       // no API key is stored, nothing was sent
+)
+
+// ReachabilityResult reports whether a provider's API is reachable with the stored key
+type ReachabilityResult struct {
+	Provider   ProviderType       `json:"provider"`
+	BaseURL    string             `json:"base_url,omitempty"`
+	Status     ReachabilityStatus `json:"status"`
+	StatusCode int                `json:"status_code,omitempty"`
+	Latency    time.Duration      `json:"latency,omitempty"`
+	Error      string             `json:"error,omitempty"`
+}
+
 // ProxyConfig represents proxy configuration
 type ProxyConfig struct {
 	HTTPProxy  string `json:"http_proxy"`
 	HTTPSProxy string `json:"https_proxy"`
+	NoProxy    string `json:"no_proxy,omitempty"`
+	AllProxy   string `json:"all_proxy,omitempty"`
 }
 
 // ConfigStatus represents configuration status information
@@ -111,26 +343,102 @@ type ConfigStatus struct {
 	HooksEnabled    bool         `json:"hooks_enabled"`
 	ProxyEnabled    bool         `json:"proxy_enabled"`
 	ProxyConfig     *ProxyConfig `json:"proxy_config,omitempty"`
-	DeepSeekEnabled bool         `json:"deepseek_enabled"`
+	ActiveProvider  ProviderType `json:"active_provider"`
+	IncludedFiles   []string     `json:"included_files,omitempty"`
+
+	// Deprecated: DeepSeekEnabled is kept for compatibility and equals
+	// ActiveProvider == ProviderDeepSeek. Use ActiveProvider instead.
+	DeepSeekEnabled bool `json:"deepseek_enabled"`
+}
+
+// BackupOptions represents options for backup operations
+type BackupOptions struct {
+	// IncludeSecrets packs API key files into the archive
+	IncludeSecrets bool `json:"include_secrets"`
 }
 
 // BackupInfo represents backup operation result
 type BackupInfo struct {
-	Filename    string    `json:"filename"`
-	FilePath    string    `json:"file_path"`
-	ContentType string    `json:"content_type"`
-	Size        int64     `json:"size"`
-	Timestamp   time.Time `json:"timestamp"`
+	Filename      string    `json:"filename"`
+	FilePath      string    `json:"file_path"`
+	ContentType   string    `json:"content_type"`
+	Size          int64     `json:"size"`
+	Timestamp     time.Time `json:"timestamp"`
+	ExcludedFiles []string  `json:"excluded_files,omitempty"`
+	// Profiles lists the profiles packed by a bulk profile backup
+	Profiles []string `json:"profiles,omitempty"`
 }
 
 // MarshalJSON implements json.Marshaler for Settings
 func (s *Settings) MarshalJSON() ([]byte, error) {
 	type alias Settings
-	return json.MarshalIndent((*alias)(s), "", "  ")
+	if len(s.Extra) == 0 {
+		return json.MarshalIndent((*alias)(s), "", "  ")
+	}
+
+	data, err := json.Marshal((*alias)(s))
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for key, value := range s.Extra {
+		// Known fields always win over a stale extra of the same name
+		if _, known := fields[key]; !known && !isSettingsField(key) {
+			fields[key] = value
+		}
+	}
+
+	return json.MarshalIndent(fields, "", "  ")
 }
 
 // UnmarshalJSON implements json.Unmarshaler for Settings
 func (s *Settings) UnmarshalJSON(data []byte) error {
 	type alias Settings
-	return json.Unmarshal(data, (*alias)(s))
+	if err := json.Unmarshal(data, (*alias)(s)); err != nil {
+		return err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	if hooks, ok := fields["hooks"]; ok {
+		normalized, err := normalizeHookEvents(hooks)
+		if err != nil {
+			return err
+		}
+		if normalized != nil {
+			s.Hooks = normalized
+		}
+	}
+
+	s.Extra = nil
+	for key, value := range fields {
+		if isSettingsField(key) {
+			continue
+		}
+		if s.Extra == nil {
+			s.Extra = make(map[string]json.RawMessage)
+		}
+		s.Extra[key] = value
+	}
+
+	return nil
+}
+
+// isSettingsField reports whether key is the JSON name of a field modeled by Settings
+func isSettingsField(key string) bool {
+	settingsType := reflect.TypeOf(Settings{})
+	for i := 0; i < settingsType.NumField(); i++ {
+		name, _, _ := strings.Cut(settingsType.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" && name == key {
+			return true
+		}
+	}
+	return false
 }
@@ -76,6 +76,94 @@ func TestSettings_UnmarshalJSON(t *testing.T) {
 	assert.Equal(t, "~/.claude/hooks/smart-lint.sh", settings.Hooks.PostToolUse[0].Hooks[0].Command)
 }
 
+func TestSettings_UnmarshalJSON_HookKeyCasing(t *testing.T) {
+	jsonData := `{"hooks": {
+		"postToolUse": [{"matcher": "Write", "hooks": [{"type": "command", "command": "lint.sh"}]}],
+		"PostToolUse": [{"matcher": "Edit", "hooks": [{"type": "command", "command": "fmt.sh"}]}],
+		"stop": [{"matcher": "", "hooks": [{"type": "command", "command": "notify.sh"}]}],
+		"pre_tool_use": [{"matcher": "Bash", "hooks": [{"type": "command", "command": "guard.sh"}]}]
+	}}`
+
+	var settings Settings
+	require.NoError(t, settings.UnmarshalJSON([]byte(jsonData)))
+	require.NotNil(t, settings.Hooks)
+
+	// Rules from every spelling are kept, the canonical key first
+	require.Len(t, settings.Hooks.PostToolUse, 2)
+	assert.Equal(t, "Edit", settings.Hooks.PostToolUse[0].Matcher)
+	assert.Equal(t, "Write", settings.Hooks.PostToolUse[1].Matcher)
+	require.Len(t, settings.Hooks.Stop, 1)
+	assert.Equal(t, "notify.sh", settings.Hooks.Stop[0].Hooks[0].Command)
+	require.Len(t, settings.Hooks.PreToolUse, 1)
+	assert.Equal(t, "Bash", settings.Hooks.PreToolUse[0].Matcher)
+
+	// Saving writes the canonical keys
+	data, err := settings.MarshalJSON()
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"PostToolUse"`)
+	assert.NotContains(t, string(data), `"postToolUse"`)
+	assert.NotContains(t, string(data), `"pre_tool_use"`)
+}
+
+func TestSettings_UnknownKeysRoundTrip(t *testing.T) {
+	jsonData := `{"includeCoAuthoredBy": true, "env": {"A": "1"}, "customSetting": {"level": 2}, "model": "opus"}`
+
+	var settings Settings
+	require.NoError(t, settings.UnmarshalJSON([]byte(jsonData)))
+	assert.Equal(t, map[string]string{"A": "1"}, settings.Env)
+	require.Len(t, settings.Extra, 2)
+	assert.JSONEq(t, `{"level": 2}`, string(settings.Extra["customSetting"]))
+	assert.JSONEq(t, `"opus"`, string(settings.Extra["model"]))
+
+	data, err := settings.MarshalJSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, jsonData, string(data))
+
+	// Modeled fields are never taken from Extra
+	settings.Extra["env"] = []byte(`{"STALE": "1"}`)
+	data, err = settings.MarshalJSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, jsonData, string(data))
+}
+
+func TestHooksConfig_Events(t *testing.T) {
+	assert.Nil(t, (*HooksConfig)(nil).Events())
+	assert.Empty(t, (&HooksConfig{}).Events())
+
+	stopRules := []*HookRule{{Matcher: "", Hooks: []*HookItem{{Type: "command", Command: "notify.sh"}}}}
+	compactRules := []*HookRule{{Matcher: "auto", Hooks: []*HookItem{{Type: "command", Command: "compact.sh"}}}}
+	hooks := &HooksConfig{
+		Stop:       stopRules,
+		PreCompact: compactRules,
+	}
+
+	assert.Equal(t, map[string][]*HookRule{
+		"Stop":       stopRules,
+		"PreCompact": compactRules,
+	}, hooks.Events())
+}
+
+func TestCanonicalHookEvent(t *testing.T) {
+	assert.Equal(t, "PostToolUse", CanonicalHookEvent("postToolUse"))
+	assert.Equal(t, "PostToolUse", CanonicalHookEvent("post_tool_use"))
+	assert.Equal(t, "SessionStart", CanonicalHookEvent("session-start"))
+	assert.Equal(t, "UserPromptSubmit", CanonicalHookEvent("user_prompt_submit"), "events HooksConfig doesn't model are known too")
+	assert.Equal(t, "SessionEnd", CanonicalHookEvent("SessionEnd"))
+	assert.Empty(t, CanonicalHookEvent("OnSave"))
+}
+
+func TestHooksConfig_Rules(t *testing.T) {
+	assert.Equal(t, []string{"PreToolUse", "PostToolUse", "Stop", "Notification", "SessionStart", "SubagentStop", "PreCompact"}, HookEventNames())
+
+	hooks := &HooksConfig{}
+	rules := hooks.Rules("SubagentStop")
+	require.NotNil(t, rules)
+	*rules = append(*rules, &HookRule{Matcher: ""})
+	assert.Len(t, hooks.SubagentStop, 1)
+
+	assert.Nil(t, hooks.Rules("UnknownEvent"))
+}
+
 func TestNormalizeProviderName(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -150,6 +238,11 @@ func TestNormalizeProviderName(t *testing.T) {
 			input:    "deep",
 			expected: ProviderNone,
 		},
+		{
+			name:     "anthropic",
+			input:    "Anthropic",
+			expected: ProviderAnthropic,
+		},
 	}
 
 	for _, tt := range tests {
@@ -186,6 +279,11 @@ func TestProviderType_IsValid(t *testing.T) {
 			provider: ProviderDoubao,
 			expected: true,
 		},
+		{
+			name:     "valid anthropic",
+			provider: ProviderAnthropic,
+			expected: true,
+		},
 		{
 			name:     "invalid none",
 			provider: ProviderNone,
@@ -206,6 +304,22 @@ func TestProviderType_IsValid(t *testing.T) {
 	}
 }
 
+func TestNormalizeProviderName_Registered(t *testing.T) {
+	assert.Equal(t, ProviderNone, NormalizeProviderName("test-lab"))
+
+	require.NoError(t, RegisterProviderName("test-lab"))
+	assert.Equal(t, ProviderType("test-lab"), NormalizeProviderName("test-lab"))
+	assert.Equal(t, ProviderType("test-lab"), NormalizeProviderName("Test-Lab"))
+
+	// Built-in names and aliases keep resolving and can't be registered
+	assert.Equal(t, ProviderGLM, NormalizeProviderName("zhipu"))
+	assert.Error(t, RegisterProviderName("zhipu"))
+	assert.Error(t, RegisterProviderName("DeepSeek"))
+	assert.Error(t, RegisterProviderName(ProviderNone))
+	assert.True(t, IsBuiltinProviderName("zhipu-ai"))
+	assert.False(t, IsBuiltinProviderName("test-lab"))
+}
+
 // TestNormalizeProviderName_GLM tests the new GLM unification feature
 func TestNormalizeProviderName_GLM(t *testing.T) {
 	tests := []struct {
@@ -255,3 +369,21 @@ func TestNormalizeProviderName_GLM(t *testing.T) {
 		})
 	}
 }
+
+func TestIsKeyFile(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{".deepseek_api_key", true},
+		{".kimi.work_api_key", true},
+		{"/home/user/.claude/.glm_api_key", true},
+		{"deepseek_api_key", false},
+		{".deepseek_endpoint", false},
+		{".deepseek_api_key.bak", false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, IsKeyFile(tt.path), tt.path)
+	}
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"sort"
+	"strings"
+)
+
+// ProxyEnvKeys are the proxy variables set by proxy on/off. Merges keep the
+// user's values for these instead of taking them from a template.
+var ProxyEnvKeys = []string{"http_proxy", "https_proxy", "no_proxy", "all_proxy"}
+
+// Env key owners reported by ClassifyEnvKey
+const (
+	EnvKeyUser     = "user"     // set by the user, merges may overwrite it
+	EnvKeyPinned   = "pinned"   // listed in .pinned_env
+	EnvKeyProxy    = "proxy"    // managed by proxy on/off
+	EnvKeyProvider = "provider" // ANTHROPIC_*, managed by ai on/off
+	EnvKeyNotify   = "notify"   // NTFY_TOPIC, managed by notify on
+)
+
+// providerEnvPrefix marks env keys written when switching AI providers
+const providerEnvPrefix = "ANTHROPIC_"
+
+// notifyEnvKey holds the NTFY topic written by notify on
+const notifyEnvKey = "NTFY_TOPIC"
+
+// EnvKeyInfo describes who controls an env key in settings.json
+type EnvKeyInfo struct {
+	Key   string
+	Owner string
+}
+
+// Managed reports whether the tool controls the key rather than the user
+func (i EnvKeyInfo) Managed() bool {
+	return i.Owner != EnvKeyUser
+}
+
+// IsProxyEnvKey reports whether key is one of ProxyEnvKeys
+func IsProxyEnvKey(key string) bool {
+	for _, proxyKey := range ProxyEnvKeys {
+		if key == proxyKey {
+			return true
+		}
+	}
+	return false
+}
+
+// ClassifyEnvKey returns the owner of key. Pinned keys take precedence since
+// pinning is an explicit user choice.
+func ClassifyEnvKey(key string, pinned []string) string {
+	for _, pinnedKey := range pinned {
+		if key == pinnedKey {
+			return EnvKeyPinned
+		}
+	}
+
+	switch {
+	case IsProxyEnvKey(key):
+		return EnvKeyProxy
+	case strings.HasPrefix(key, providerEnvPrefix):
+		return EnvKeyProvider
+	case key == notifyEnvKey:
+		return EnvKeyNotify
+	default:
+		return EnvKeyUser
+	}
+}
+
+// ClassifyEnv classifies every key in env, sorted by key
+func ClassifyEnv(env map[string]string, pinned []string) []EnvKeyInfo {
+	infos := make([]EnvKeyInfo, 0, len(env))
+	for key := range env {
+		infos = append(infos, EnvKeyInfo{Key: key, Owner: ClassifyEnvKey(key, pinned)})
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Key < infos[j].Key
+	})
+
+	return infos
+}
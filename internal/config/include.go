@@ -0,0 +1,188 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ooneko/claude-config/internal/claude"
+)
+
+// includesKey lists other settings files merged into the effective view
+const includesKey = "includes"
+
+// LoadEffective loads settings.json with every file listed under "includes"
+// merged in. Included files are layered first, in order, and the including
+// file is applied on top: objects merge recursively, arrays are concatenated
+// and other values are overridden. Include paths are resolved relative to the
+// claude directory and may not leave it. settings.json itself is never rewritten.
+func (m *Manager) LoadEffective(ctx context.Context) (*claude.Settings, error) {
+	settings, _, err := m.loadEffective(ctx)
+	return settings, err
+}
+
+// loadEffective returns the effective settings and the included files in load order
+func (m *Manager) loadEffective(_ context.Context) (*claude.Settings, []string, error) {
+	settingsPath := filepath.Join(m.claudeDir, "settings.json")
+
+	if _, err := os.Stat(settingsPath); os.IsNotExist(err) {
+		return &claude.Settings{
+			IncludeCoAuthoredBy: false,
+		}, nil, nil
+	}
+
+	var included []string
+	merged, err := m.loadLayered(settingsPath, nil, &included)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal effective settings: %w", err)
+	}
+
+	var settings claude.Settings
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse effective settings: %w", err)
+	}
+
+	return &settings, included, nil
+}
+
+// loadLayered reads path and merges its includes beneath it.
+// stack holds the files currently being loaded, for cycle detection.
+func (m *Manager) loadLayered(path string, stack []string, included *[]string) (map[string]interface{}, error) {
+	for _, visiting := range stack {
+		if visiting == path {
+			chain := append(append([]string{}, stack...), path)
+			for i := range chain {
+				chain[i] = m.displayPath(chain[i])
+			}
+			return nil, fmt.Errorf("include cycle detected: %s", strings.Join(chain, " -> "))
+		}
+	}
+	stack = append(stack, path)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read settings file %s: %w", m.displayPath(path), err)
+	}
+
+	var layer map[string]interface{}
+	if err := json.Unmarshal(data, &layer); err != nil {
+		return nil, fmt.Errorf("failed to parse settings file %s: %w", m.displayPath(path), err)
+	}
+
+	includes, err := parseIncludes(layer[includesKey])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", m.displayPath(path), err)
+	}
+
+	result := map[string]interface{}{}
+	for _, include := range includes {
+		includePath, err := m.resolveIncludePath(include)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", m.displayPath(path), err)
+		}
+
+		includedLayer, err := m.loadLayered(includePath, stack, included)
+		if err != nil {
+			return nil, err
+		}
+		*included = append(*included, m.displayPath(includePath))
+		result = mergeLayer(result, includedLayer)
+	}
+
+	// Only the top-level file's includes describe the effective view
+	if len(stack) > 1 {
+		delete(layer, includesKey)
+	}
+
+	return mergeLayer(result, layer), nil
+}
+
+// parseIncludes validates the "includes" value, which must be a list of strings
+func parseIncludes(value interface{}) ([]string, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%q must be a list of file paths", includesKey)
+	}
+
+	includes := make([]string, 0, len(list))
+	for _, item := range list {
+		include, ok := item.(string)
+		if !ok || include == "" {
+			return nil, fmt.Errorf("%q must be a list of file paths", includesKey)
+		}
+		includes = append(includes, include)
+	}
+
+	return includes, nil
+}
+
+// resolveIncludePath resolves an include relative to the claude directory and
+// rejects paths outside it
+func (m *Manager) resolveIncludePath(include string) (string, error) {
+	path := include
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(m.claudeDir, path)
+	}
+	path = filepath.Clean(path)
+
+	rel, err := filepath.Rel(filepath.Clean(m.claudeDir), path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("include %q is outside the claude directory", include)
+	}
+
+	return path, nil
+}
+
+// displayPath shows a path relative to the claude directory when possible
+func (m *Manager) displayPath(path string) string {
+	if rel, err := filepath.Rel(m.claudeDir, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
+
+// mergeLayer applies overlay on top of base: objects merge recursively,
+// arrays are concatenated and other values are replaced
+func mergeLayer(base, overlay map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(base)+len(overlay))
+	for key, value := range base {
+		result[key] = value
+	}
+
+	for key, value := range overlay {
+		existing, exists := result[key]
+		if !exists {
+			result[key] = value
+			continue
+		}
+
+		switch overlayValue := value.(type) {
+		case map[string]interface{}:
+			if baseMap, ok := existing.(map[string]interface{}); ok {
+				result[key] = mergeLayer(baseMap, overlayValue)
+				continue
+			}
+		case []interface{}:
+			if baseSlice, ok := existing.([]interface{}); ok {
+				combined := make([]interface{}, 0, len(baseSlice)+len(overlayValue))
+				result[key] = append(append(combined, baseSlice...), overlayValue...)
+				continue
+			}
+		}
+		result[key] = value
+	}
+
+	return result
+}
@@ -4,72 +4,44 @@ import (
 	"archive/tar"
 	"compress/gzip"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"time"
 
+	"github.com/ooneko/claude-config/internal/aiprovider"
 	"github.com/ooneko/claude-config/internal/claude"
+	"github.com/ooneko/claude-config/internal/proxy"
+	"github.com/ooneko/claude-config/internal/settingsstore"
 )
 
 // Manager implements the ConfigManager interface
 type Manager struct {
 	claudeDir string
+	// aiProviders identifies the active AI provider for GetStatus
+	aiProviders claude.AIProviderManager
 }
 
 // NewManager creates a new configuration manager
 func NewManager(claudeDir string) *Manager {
 	return &Manager{
-		claudeDir: claudeDir,
+		claudeDir:   claudeDir,
+		aiProviders: aiprovider.NewManager(claudeDir),
 	}
 }
 
 // Load loads the current configuration from settings.json
 func (m *Manager) Load(_ context.Context) (*claude.Settings, error) {
-	settingsPath := filepath.Join(m.claudeDir, "settings.json")
-
-	// If file doesn't exist, return default settings
-	if _, err := os.Stat(settingsPath); os.IsNotExist(err) {
-		return &claude.Settings{
-			IncludeCoAuthoredBy: false,
-		}, nil
-	}
-
-	data, err := os.ReadFile(settingsPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read settings file: %w", err)
-	}
-
-	var settings claude.Settings
-	if err := json.Unmarshal(data, &settings); err != nil {
-		return nil, fmt.Errorf("failed to parse settings file: %w", err)
-	}
-
-	return &settings, nil
+	return settingsstore.Load(m.claudeDir)
 }
 
 // Save saves the configuration to settings.json
 func (m *Manager) Save(_ context.Context, config *claude.Settings) error {
-	settingsPath := filepath.Join(m.claudeDir, "settings.json")
-
-	// Ensure directory exists
-	if err := os.MkdirAll(m.claudeDir, 0755); err != nil {
-		return fmt.Errorf("failed to create claude directory: %w", err)
-	}
-
-	data, err := json.MarshalIndent(config, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal settings: %w", err)
-	}
-
-	if err := os.WriteFile(settingsPath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write settings file: %w", err)
-	}
-
-	return nil
+	return settingsstore.Save(m.claudeDir, config)
 }
 
 // GetStatus returns current configuration status
@@ -90,48 +62,68 @@ func (m *Manager) GetStatus(ctx context.Context) (*claude.ConfigStatus, error) {
 		return nil, fmt.Errorf("failed to check config file: %w", err)
 	}
 
-	// Check hooks configuration and other settings
+	// Check hooks configuration and other settings against the effective view
 	if status.ConfigExists {
-		settings, err := m.Load(ctx)
+		settings, included, err := m.loadEffective(ctx)
 		if err != nil {
 			return nil, fmt.Errorf("failed to load settings: %w", err)
 		}
+		status.IncludedFiles = included
 
 		status.HooksConfigured = settings.Hooks != nil && len(settings.Hooks.PostToolUse) > 0
 		status.HooksEnabled = status.HooksConfigured
-		status.ProxyEnabled = settings.Env != nil && (settings.Env["http_proxy"] != "" || settings.Env["https_proxy"] != "")
+		status.ProxyEnabled = proxy.EnabledInEnv(settings.Env)
 
 		// Set proxy config if enabled
 		if status.ProxyEnabled {
 			status.ProxyConfig = &claude.ProxyConfig{
 				HTTPProxy:  settings.Env["http_proxy"],
 				HTTPSProxy: settings.Env["https_proxy"],
+				NoProxy:    settings.Env["no_proxy"],
+				AllProxy:   settings.Env["all_proxy"],
 			}
 		}
 
-		// Check if DeepSeek is enabled by looking for DeepSeek-specific configuration
-		status.DeepSeekEnabled = settings.Env["ANTHROPIC_AUTH_TOKEN"] != "" &&
-			settings.Env["ANTHROPIC_BASE_URL"] != "" &&
-			strings.Contains(settings.Env["ANTHROPIC_BASE_URL"], "deepseek")
+		// Identify the active AI provider the same way ai status does
+		status.ActiveProvider = m.aiProviders.ProviderForBaseURL(settings.Env["ANTHROPIC_BASE_URL"])
+		status.DeepSeekEnabled = status.ActiveProvider == claude.ProviderDeepSeek
 	}
 
 	return status, nil
 }
 
-// Backup creates a backup of configuration
-func (m *Manager) Backup(_ context.Context) (*claude.BackupInfo, error) {
+// Backup creates a backup of configuration in the home directory
+func (m *Manager) Backup(ctx context.Context) (*claude.BackupInfo, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get home directory: %w", err)
 	}
 
+	return m.BackupTo(ctx, homeDir, nil)
+}
+
+// BackupTo creates a backup of configuration in destDir, creating it if needed.
+// API key files are left out unless opts.IncludeSecrets is set.
+func (m *Manager) BackupTo(_ context.Context, destDir string, opts *claude.BackupOptions) (*claude.BackupInfo, error) {
+	if opts == nil {
+		opts = &claude.BackupOptions{}
+	}
+
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
 	// Generate backup filename with timestamp
 	timestamp := time.Now().Format("20060102_150405")
 	filename := fmt.Sprintf("claude-config-backup-%s.tar.gz", timestamp)
-	backupPath := filepath.Join(homeDir, filename)
+	backupPath, err := filepath.Abs(filepath.Join(destDir, filename))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve backup path: %w", err)
+	}
 
 	// Create tar.gz archive of claude directory
-	if err := m.createTarGzArchive(m.claudeDir, backupPath); err != nil {
+	excluded, err := m.createTarGzArchive(m.claudeDir, backupPath, archiveOptions{excludeSecrets: !opts.IncludeSecrets})
+	if err != nil {
 		return nil, fmt.Errorf("failed to create backup archive: %w", err)
 	}
 
@@ -142,20 +134,191 @@ func (m *Manager) Backup(_ context.Context) (*claude.BackupInfo, error) {
 	}
 
 	return &claude.BackupInfo{
-		Filename:    filename,
-		FilePath:    backupPath,
-		ContentType: "directory",
-		Size:        stat.Size(),
-		Timestamp:   time.Now(),
+		Filename:      filename,
+		FilePath:      backupPath,
+		ContentType:   "directory",
+		Size:          stat.Size(),
+		Timestamp:     time.Now(),
+		ExcludedFiles: excluded,
 	}, nil
 }
 
-// createTarGzArchive creates a tar.gz archive of the source directory
-func (m *Manager) createTarGzArchive(sourceDir, destPath string) error {
+// Restore extracts a backup archive created by Backup into claudeDir.
+// An existing non-empty claudeDir is only overwritten when force is true.
+func (m *Manager) Restore(_ context.Context, archivePath string, force bool) error {
+	// Validate the archive before touching anything
+	hasSettings, err := archiveContainsSettings(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to read backup archive: %w", err)
+	}
+	if !hasSettings {
+		return fmt.Errorf("backup archive does not contain settings.json: %s", archivePath)
+	}
+
+	if !force {
+		entries, err := os.ReadDir(m.claudeDir)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to read claude directory: %w", err)
+		}
+		if len(entries) > 0 {
+			return fmt.Errorf("claude directory %s already exists, use force to overwrite", m.claudeDir)
+		}
+	}
+
+	if err := m.extractTarGzArchive(archivePath, m.claudeDir, nil); err != nil {
+		return fmt.Errorf("failed to extract backup archive: %w", err)
+	}
+
+	return nil
+}
+
+// archiveContainsSettings checks whether a tar.gz archive has a top-level settings.json
+func archiveContainsSettings(archivePath string) (bool, error) {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	gzReader, err := gzip.NewReader(file)
+	if err != nil {
+		return false, err
+	}
+	defer gzReader.Close()
+
+	tarReader := tar.NewReader(gzReader)
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if header.Typeflag == tar.TypeReg && path.Clean(header.Name) == "settings.json" {
+			return true, nil
+		}
+	}
+}
+
+// extractTarGzArchive extracts a tar.gz archive into destDir, restoring file
+// modes. When include is set, only the entries it accepts are extracted.
+func (m *Manager) extractTarGzArchive(archivePath, destDir string, include func(name string) bool) error {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to open archive file: %w", err)
+	}
+	defer file.Close()
+
+	gzReader, err := gzip.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("failed to create gzip reader: %w", err)
+	}
+	defer gzReader.Close()
+
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return fmt.Errorf("failed to create destination directory: %w", err)
+	}
+
+	tarReader := tar.NewReader(gzReader)
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if include != nil && !include(header.Name) {
+			continue
+		}
+
+		targetPath, err := safeJoin(destDir, header.Name)
+		if err != nil {
+			return err
+		}
+
+		mode := os.FileMode(header.Mode).Perm()
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(targetPath, mode); err != nil {
+				return err
+			}
+			if err := os.Chmod(targetPath, mode); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := writeArchiveFile(tarReader, targetPath, mode); err != nil {
+				return err
+			}
+		default:
+			// Skip symlinks and other special entries
+			continue
+		}
+	}
+}
+
+// writeArchiveFile writes a single archive entry to disk with the given mode
+func writeArchiveFile(r io.Reader, targetPath string, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+		return err
+	}
+
+	outFile, err := os.OpenFile(targetPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer outFile.Close()
+
+	if _, err := io.Copy(outFile, r); err != nil {
+		return err
+	}
+
+	// OpenFile only applies mode on creation, so enforce it for overwritten files
+	return os.Chmod(targetPath, mode)
+}
+
+// safeJoin joins an archive entry name to destDir, rejecting paths that escape it
+func safeJoin(destDir, name string) (string, error) {
+	targetPath := filepath.Join(destDir, filepath.FromSlash(name))
+	relPath, err := filepath.Rel(destDir, targetPath)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid path in archive: %s", name)
+	}
+	return targetPath, nil
+}
+
+// isSecretFile reports whether a top-level file in the claude directory holds credentials
+func isSecretFile(relPath string) bool {
+	if strings.ContainsRune(relPath, filepath.Separator) {
+		return false
+	}
+	if relPath == ".last_active_provider" {
+		return true
+	}
+	return claude.IsKeyFile(relPath)
+}
+
+// archiveOptions controls what createTarGzArchive packs
+type archiveOptions struct {
+	// excludeSecrets leaves API key files out of the archive
+	excludeSecrets bool
+	// profiles limits the archive to these subdirectories of the source
+	// directory; secrets are then matched inside each profile
+	profiles []string
+	// manifest, when set, is written first under profilesManifestName
+	manifest []byte
+}
+
+// createTarGzArchive creates a tar.gz archive of the source directory and
+// returns the relative paths of the secret files that were skipped
+func (m *Manager) createTarGzArchive(sourceDir, destPath string, opts archiveOptions) ([]string, error) {
 	// Create destination file
 	outFile, err := os.Create(destPath)
 	if err != nil {
-		return fmt.Errorf("failed to create archive file: %w", err)
+		return nil, fmt.Errorf("failed to create archive file: %w", err)
 	}
 	defer outFile.Close()
 
@@ -167,8 +330,33 @@ func (m *Manager) createTarGzArchive(sourceDir, destPath string) error {
 	tarWriter := tar.NewWriter(gzWriter)
 	defer tarWriter.Close()
 
+	if opts.manifest != nil {
+		header := &tar.Header{
+			Name:    profilesManifestName,
+			Mode:    0644,
+			Size:    int64(len(opts.manifest)),
+			ModTime: time.Now(),
+		}
+		if err := tarWriter.WriteHeader(header); err != nil {
+			return nil, err
+		}
+		if _, err := tarWriter.Write(opts.manifest); err != nil {
+			return nil, err
+		}
+	}
+
+	var profiles map[string]bool
+	if opts.profiles != nil {
+		profiles = make(map[string]bool, len(opts.profiles))
+		for _, profile := range opts.profiles {
+			profiles[profile] = true
+		}
+	}
+
+	var excluded []string
+
 	// Walk through source directory
-	return filepath.Walk(sourceDir, func(filePath string, info os.FileInfo, err error) error {
+	err = filepath.Walk(sourceDir, func(filePath string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -184,6 +372,29 @@ func (m *Manager) createTarGzArchive(sourceDir, destPath string) error {
 			return nil
 		}
 
+		// Skip the in-progress archive when backing up into the source directory
+		if absPath, err := filepath.Abs(filePath); err == nil && absPath == destPath {
+			return nil
+		}
+
+		// Secrets are matched relative to the claude directory they belong to
+		secretPath := relPath
+		if profiles != nil {
+			profile, rest, _ := strings.Cut(relPath, string(filepath.Separator))
+			if !profiles[profile] {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			secretPath = rest
+		}
+
+		if opts.excludeSecrets && !info.IsDir() && secretPath != "" && isSecretFile(secretPath) {
+			excluded = append(excluded, relPath)
+			return nil
+		}
+
 		// Create tar header
 		header, err := tar.FileInfoHeader(info, "")
 		if err != nil {
@@ -210,4 +421,6 @@ func (m *Manager) createTarGzArchive(sourceDir, destPath string) error {
 
 		return nil
 	})
+
+	return excluded, err
 }
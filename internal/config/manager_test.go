@@ -3,6 +3,7 @@ package config
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -11,6 +12,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/ooneko/claude-config/internal/claude"
+	"github.com/ooneko/claude-config/internal/proxy"
 )
 
 func TestConfigManager_Load(t *testing.T) {
@@ -217,6 +219,7 @@ func TestConfigManager_GetStatus(t *testing.T) {
 
 	// Verify status
 	assert.True(t, status.ProxyEnabled)
+	assert.Equal(t, claude.ProviderDeepSeek, status.ActiveProvider)
 	assert.True(t, status.DeepSeekEnabled)
 	assert.True(t, status.HooksEnabled)
 
@@ -226,6 +229,86 @@ func TestConfigManager_GetStatus(t *testing.T) {
 	assert.Equal(t, "http://127.0.0.1:7890", status.ProxyConfig.HTTPSProxy)
 }
 
+func TestConfigManager_GetStatus_AllProxyOnly(t *testing.T) {
+	claudeDir := t.TempDir()
+	settings := `{"env": {"all_proxy": "socks5://127.0.0.1:1080", "no_proxy": "localhost"}}`
+	require.NoError(t, os.WriteFile(filepath.Join(claudeDir, "settings.json"), []byte(settings), 0644))
+
+	status, err := NewManager(claudeDir).GetStatus(context.Background())
+	require.NoError(t, err)
+
+	assert.True(t, status.ProxyEnabled)
+	require.NotNil(t, status.ProxyConfig)
+	assert.Equal(t, "socks5://127.0.0.1:1080", status.ProxyConfig.AllProxy)
+	assert.Equal(t, "localhost", status.ProxyConfig.NoProxy)
+	assert.Empty(t, status.ProxyConfig.HTTPProxy)
+}
+
+func TestConfigManager_GetStatus_ProxyMatchesProxyManager(t *testing.T) {
+	tests := []struct {
+		name string
+		env  string
+		want bool
+	}{
+		{name: "http only", env: `{"http_proxy": "http://127.0.0.1:7890"}`, want: false},
+		{name: "http and https", env: `{"http_proxy": "http://127.0.0.1:7890", "https_proxy": "http://127.0.0.1:7890"}`, want: true},
+		{name: "all_proxy only", env: `{"all_proxy": "socks5://127.0.0.1:1080"}`, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claudeDir := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(claudeDir, "settings.json"), []byte(`{"env": `+tt.env+`}`), 0644))
+
+			status, err := NewManager(claudeDir).GetStatus(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, status.ProxyEnabled)
+
+			enabled, err := proxy.NewManager(claudeDir).IsEnabled(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, enabled, status.ProxyEnabled, "status and proxy must agree")
+		})
+	}
+}
+
+func TestConfigManager_GetStatus_ActiveProvider(t *testing.T) {
+	claudeDir := t.TempDir()
+	manager := NewManager(claudeDir)
+	settingsPath := filepath.Join(claudeDir, "settings.json")
+
+	tests := []struct {
+		baseURL string
+		want    claude.ProviderType
+	}{
+		{"https://api.kimi.com/coding/", claude.ProviderKimi},
+		{"https://open.bigmodel.cn/api/anthropic", claude.ProviderGLM},
+		{"https://api.deepseek.com/anthropic", claude.ProviderDeepSeek},
+		{"https://proxy.example.com", claude.ProviderNone},
+	}
+
+	for _, tt := range tests {
+		settings := fmt.Sprintf(`{"env": {"ANTHROPIC_BASE_URL": %q}}`, tt.baseURL)
+		require.NoError(t, os.WriteFile(settingsPath, []byte(settings), 0644))
+
+		status, err := manager.GetStatus(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, status.ActiveProvider, tt.baseURL)
+		assert.Equal(t, tt.want == claude.ProviderDeepSeek, status.DeepSeekEnabled, tt.baseURL)
+	}
+
+	// A --base-url override identifies its provider
+	require.NoError(t, os.WriteFile(filepath.Join(claudeDir, fmt.Sprintf(".%s_base_url", claude.ProviderGLM)), []byte("https://proxy.example.com"), 0644))
+	status, err := manager.GetStatus(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, claude.ProviderGLM, status.ActiveProvider)
+
+	// The provider recorded by ai on wins over the base URL
+	require.NoError(t, os.WriteFile(filepath.Join(claudeDir, ".active_provider"), []byte(claude.ProviderDoubao), 0644))
+	status, err = manager.GetStatus(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, claude.ProviderDoubao, status.ActiveProvider)
+}
+
 func TestConfigManager_Backup_DirectoryBackup(t *testing.T) {
 	// Setup temp directories
 	tempDir := t.TempDir()
@@ -399,3 +482,402 @@ func TestConfigManager_Backup_SettingsOnly(t *testing.T) {
 	assert.True(t, backupInfo.Size > 0)
 	assert.False(t, backupInfo.Timestamp.IsZero())
 }
+
+// setupBackupSource creates a claude directory with settings, a script and a key file
+func setupBackupSource(t *testing.T, claudeDir string) {
+	t.Helper()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(claudeDir, "hooks"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(claudeDir, "settings.json"), []byte(`{"includeCoAuthoredBy": true}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(claudeDir, "hooks", "smart-lint.sh"), []byte("#!/bin/bash\n"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(claudeDir, ".deepseek_api_key"), []byte("sk-test"), 0600))
+}
+
+func TestConfigManager_Restore(t *testing.T) {
+	tempDir := t.TempDir()
+	homeDir := filepath.Join(tempDir, "home")
+	sourceDir := filepath.Join(homeDir, ".claude")
+	setupBackupSource(t, sourceDir)
+
+	originalHome := os.Getenv("HOME")
+	os.Setenv("HOME", homeDir)
+	defer os.Setenv("HOME", originalHome)
+
+	ctx := context.Background()
+	backupInfo, err := NewManager(sourceDir).Backup(ctx)
+	require.NoError(t, err)
+
+	t.Run("restore into empty directory", func(t *testing.T) {
+		restoreDir := filepath.Join(tempDir, "restored")
+		err := NewManager(restoreDir).Restore(ctx, backupInfo.FilePath, false)
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(filepath.Join(restoreDir, "settings.json"))
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "includeCoAuthoredBy")
+
+		info, err := os.Stat(filepath.Join(restoreDir, "hooks", "smart-lint.sh"))
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+	})
+
+	t.Run("refuse existing directory without force", func(t *testing.T) {
+		restoreDir := filepath.Join(tempDir, "existing")
+		require.NoError(t, os.MkdirAll(restoreDir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(restoreDir, "settings.json"), []byte(`{}`), 0644))
+
+		err := NewManager(restoreDir).Restore(ctx, backupInfo.FilePath, false)
+		assert.Error(t, err)
+
+		data, err := os.ReadFile(filepath.Join(restoreDir, "settings.json"))
+		require.NoError(t, err)
+		assert.Equal(t, `{}`, string(data))
+
+		err = NewManager(restoreDir).Restore(ctx, backupInfo.FilePath, true)
+		require.NoError(t, err)
+
+		data, err = os.ReadFile(filepath.Join(restoreDir, "settings.json"))
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "includeCoAuthoredBy")
+	})
+}
+
+func TestConfigManager_Restore_MissingSettings(t *testing.T) {
+	tempDir := t.TempDir()
+	homeDir := filepath.Join(tempDir, "home")
+	sourceDir := filepath.Join(homeDir, ".claude")
+	require.NoError(t, os.MkdirAll(sourceDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "CLAUDE.md"), []byte("# test"), 0644))
+
+	originalHome := os.Getenv("HOME")
+	os.Setenv("HOME", homeDir)
+	defer os.Setenv("HOME", originalHome)
+
+	ctx := context.Background()
+	backupInfo, err := NewManager(sourceDir).Backup(ctx)
+	require.NoError(t, err)
+
+	restoreDir := filepath.Join(tempDir, "restored")
+	err = NewManager(restoreDir).Restore(ctx, backupInfo.FilePath, true)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "settings.json")
+
+	_, err = os.Stat(restoreDir)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestConfigManager_BackupTo(t *testing.T) {
+	tempDir := t.TempDir()
+	claudeDir := filepath.Join(tempDir, ".claude")
+	setupBackupSource(t, claudeDir)
+
+	manager := NewManager(claudeDir)
+	ctx := context.Background()
+
+	t.Run("creates destination directory", func(t *testing.T) {
+		destDir := filepath.Join(tempDir, "external", "backups")
+
+		backupInfo, err := manager.BackupTo(ctx, destDir, nil)
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(destDir, backupInfo.Filename), backupInfo.FilePath)
+		assert.FileExists(t, backupInfo.FilePath)
+	})
+
+	t.Run("backup into claude directory", func(t *testing.T) {
+		backupInfo, err := manager.BackupTo(ctx, claudeDir, nil)
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(claudeDir, backupInfo.Filename), backupInfo.FilePath)
+
+		// The archive must not contain itself and must still restore cleanly
+		restoreDir := filepath.Join(tempDir, "restored")
+		require.NoError(t, NewManager(restoreDir).Restore(ctx, backupInfo.FilePath, false))
+		assert.FileExists(t, filepath.Join(restoreDir, "settings.json"))
+		assert.NoFileExists(t, filepath.Join(restoreDir, backupInfo.Filename))
+	})
+}
+
+func TestConfigManager_BackupTo_ExcludesSecrets(t *testing.T) {
+	tempDir := t.TempDir()
+	claudeDir := filepath.Join(tempDir, ".claude")
+	setupBackupSource(t, claudeDir)
+	require.NoError(t, os.WriteFile(filepath.Join(claudeDir, ".last_active_provider"), []byte("deepseek"), 0644))
+
+	manager := NewManager(claudeDir)
+	ctx := context.Background()
+
+	t.Run("secrets excluded by default", func(t *testing.T) {
+		backupInfo, err := manager.BackupTo(ctx, filepath.Join(tempDir, "default"), nil)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{".deepseek_api_key", ".last_active_provider"}, backupInfo.ExcludedFiles)
+
+		restoreDir := filepath.Join(tempDir, "restored-default")
+		require.NoError(t, NewManager(restoreDir).Restore(ctx, backupInfo.FilePath, false))
+		assert.FileExists(t, filepath.Join(restoreDir, "settings.json"))
+		assert.NoFileExists(t, filepath.Join(restoreDir, ".deepseek_api_key"))
+		assert.NoFileExists(t, filepath.Join(restoreDir, ".last_active_provider"))
+	})
+
+	t.Run("secrets included on request", func(t *testing.T) {
+		opts := &claude.BackupOptions{IncludeSecrets: true}
+		backupInfo, err := manager.BackupTo(ctx, filepath.Join(tempDir, "secrets"), opts)
+		require.NoError(t, err)
+		assert.Empty(t, backupInfo.ExcludedFiles)
+
+		restoreDir := filepath.Join(tempDir, "restored-secrets")
+		require.NoError(t, NewManager(restoreDir).Restore(ctx, backupInfo.FilePath, false))
+		assert.FileExists(t, filepath.Join(restoreDir, ".deepseek_api_key"))
+		assert.FileExists(t, filepath.Join(restoreDir, ".last_active_provider"))
+	})
+}
+
+func TestConfigManager_BackupProfiles(t *testing.T) {
+	tempDir := t.TempDir()
+	profilesRoot := filepath.Join(tempDir, "profiles")
+	setupBackupSource(t, filepath.Join(profilesRoot, "personal"))
+	setupBackupSource(t, filepath.Join(profilesRoot, "work"))
+	require.NoError(t, os.WriteFile(filepath.Join(profilesRoot, "work", "settings.json"), []byte(`{"model": "work"}`), 0644))
+	// Neither a hidden directory nor one without settings.json is a profile
+	require.NoError(t, os.MkdirAll(filepath.Join(profilesRoot, "scratch"), 0755))
+	setupBackupSource(t, filepath.Join(profilesRoot, ".trash"))
+
+	manager := NewManager(filepath.Join(tempDir, ".claude"))
+	ctx := context.Background()
+
+	backupInfo, err := manager.BackupProfiles(ctx, profilesRoot, filepath.Join(tempDir, "backups"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"personal", "work"}, backupInfo.Profiles)
+	assert.ElementsMatch(t, []string{"personal/.deepseek_api_key", "work/.deepseek_api_key"}, backupInfo.ExcludedFiles)
+
+	// A plain restore doesn't accept a profiles backup, and vice versa
+	assert.Error(t, NewManager(filepath.Join(tempDir, "plain")).Restore(ctx, backupInfo.FilePath, false))
+	plainBackup, err := NewManager(filepath.Join(profilesRoot, "work")).BackupTo(ctx, filepath.Join(tempDir, "plain-backups"), nil)
+	require.NoError(t, err)
+	_, err = manager.RestoreProfiles(ctx, plainBackup.FilePath, filepath.Join(tempDir, "plain"), false)
+	assert.ErrorContains(t, err, "not a profiles backup")
+
+	t.Run("restore into distinct directories", func(t *testing.T) {
+		restoreRoot := filepath.Join(tempDir, "restored")
+		restored, err := manager.RestoreProfiles(ctx, backupInfo.FilePath, restoreRoot, false)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"personal", "work"}, restored)
+
+		data, err := os.ReadFile(filepath.Join(restoreRoot, "personal", "settings.json"))
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "includeCoAuthoredBy")
+		data, err = os.ReadFile(filepath.Join(restoreRoot, "work", "settings.json"))
+		require.NoError(t, err)
+		assert.Equal(t, `{"model": "work"}`, string(data))
+
+		info, err := os.Stat(filepath.Join(restoreRoot, "work", "hooks", "smart-lint.sh"))
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+
+		assert.NoFileExists(t, filepath.Join(restoreRoot, "work", ".deepseek_api_key"))
+		assert.NoFileExists(t, filepath.Join(restoreRoot, profilesManifestName))
+		assert.NoDirExists(t, filepath.Join(restoreRoot, "scratch"))
+		assert.NoDirExists(t, filepath.Join(restoreRoot, ".trash"))
+	})
+
+	t.Run("refuse existing profile without force", func(t *testing.T) {
+		restoreRoot := filepath.Join(tempDir, "existing")
+		require.NoError(t, os.MkdirAll(filepath.Join(restoreRoot, "work"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(restoreRoot, "work", "settings.json"), []byte(`{}`), 0644))
+
+		_, err := manager.RestoreProfiles(ctx, backupInfo.FilePath, restoreRoot, false)
+		assert.Error(t, err)
+		assert.NoDirExists(t, filepath.Join(restoreRoot, "personal"))
+
+		_, err = manager.RestoreProfiles(ctx, backupInfo.FilePath, restoreRoot, true)
+		require.NoError(t, err)
+		data, err := os.ReadFile(filepath.Join(restoreRoot, "work", "settings.json"))
+		require.NoError(t, err)
+		assert.Equal(t, `{"model": "work"}`, string(data))
+	})
+
+	t.Run("secrets included on request", func(t *testing.T) {
+		opts := &claude.BackupOptions{IncludeSecrets: true}
+		backupInfo, err := manager.BackupProfiles(ctx, profilesRoot, filepath.Join(tempDir, "secrets"), opts)
+		require.NoError(t, err)
+		assert.Empty(t, backupInfo.ExcludedFiles)
+
+		restoreRoot := filepath.Join(tempDir, "restored-secrets")
+		_, err = manager.RestoreProfiles(ctx, backupInfo.FilePath, restoreRoot, false)
+		require.NoError(t, err)
+		assert.FileExists(t, filepath.Join(restoreRoot, "personal", ".deepseek_api_key"))
+		assert.FileExists(t, filepath.Join(restoreRoot, "work", ".deepseek_api_key"))
+	})
+
+	_, err = manager.BackupProfiles(ctx, filepath.Join(tempDir, "backups"), tempDir, nil)
+	assert.ErrorContains(t, err, "no profiles")
+}
+
+func TestPinEnv(t *testing.T) {
+	tempDir := t.TempDir()
+
+	keys, err := LoadPinnedEnv(tempDir)
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	require.NoError(t, PinEnv(tempDir, "ANTHROPIC_BASE_URL"))
+	require.NoError(t, PinEnv(tempDir, "ANTHROPIC_BASE_URL"))
+	require.NoError(t, PinEnv(tempDir, "API_TIMEOUT_MS"))
+	assert.Error(t, PinEnv(tempDir, "BAD KEY"))
+
+	keys, err = LoadPinnedEnv(tempDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ANTHROPIC_BASE_URL", "API_TIMEOUT_MS"}, keys)
+
+	removed, err := UnpinEnv(tempDir, "ANTHROPIC_BASE_URL")
+	require.NoError(t, err)
+	assert.True(t, removed)
+
+	removed, err = UnpinEnv(tempDir, "ANTHROPIC_BASE_URL")
+	require.NoError(t, err)
+	assert.False(t, removed)
+
+	_, err = UnpinEnv(tempDir, "API_TIMEOUT_MS")
+	require.NoError(t, err)
+	_, err = os.Stat(filepath.Join(tempDir, ".pinned_env"))
+	assert.True(t, os.IsNotExist(err), "empty pin list should remove the file")
+}
+
+func TestClassifyEnv(t *testing.T) {
+	env := map[string]string{
+		"ANTHROPIC_BASE_URL":   "https://api.deepseek.com/anthropic",
+		"ANTHROPIC_AUTH_TOKEN": "sk-test",
+		"http_proxy":           "http://127.0.0.1:7890",
+		"no_proxy":             "localhost",
+		"NTFY_TOPIC":           "my-topic",
+		"API_TIMEOUT_MS":       "600000",
+		"MY_TOOL_HOME":         "/opt/tool",
+		"HTTP_PROXY_HOST":      "ignored",
+	}
+
+	infos := ClassifyEnv(env, []string{"API_TIMEOUT_MS", "ANTHROPIC_BASE_URL"})
+
+	owners := make(map[string]string, len(infos))
+	var keys []string
+	for _, info := range infos {
+		owners[info.Key] = info.Owner
+		keys = append(keys, info.Key)
+	}
+
+	assert.Equal(t, map[string]string{
+		"ANTHROPIC_BASE_URL":   EnvKeyPinned, // pinning wins over the provider prefix
+		"ANTHROPIC_AUTH_TOKEN": EnvKeyProvider,
+		"http_proxy":           EnvKeyProxy,
+		"no_proxy":             EnvKeyProxy,
+		"NTFY_TOPIC":           EnvKeyNotify,
+		"API_TIMEOUT_MS":       EnvKeyPinned,
+		"MY_TOOL_HOME":         EnvKeyUser,
+		"HTTP_PROXY_HOST":      EnvKeyUser,
+	}, owners)
+	assert.IsIncreasing(t, keys, "keys are sorted")
+
+	for _, info := range infos {
+		assert.Equal(t, info.Owner != EnvKeyUser, info.Managed(), info.Key)
+	}
+}
+
+func TestConfigManager_LoadEffective_IncludeChain(t *testing.T) {
+	tempDir := t.TempDir()
+	writeFile := func(name, content string) {
+		path := filepath.Join(tempDir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	writeFile("settings.json", `{"includes": ["shared/team.json"], "env": {"NTFY_TOPIC": "mine"}}`)
+	writeFile("shared/team.json", `{"includes": ["base.json"], "env": {"NTFY_TOPIC": "team", "API_TIMEOUT_MS": "60000"}}`)
+	writeFile("base.json", `{"includeCoAuthoredBy": true, "env": {"http_proxy": "http://127.0.0.1:7890", "https_proxy": "http://127.0.0.1:7890"}}`)
+
+	manager := NewManager(tempDir)
+	settings, err := manager.LoadEffective(context.Background())
+	require.NoError(t, err)
+
+	assert.True(t, settings.IncludeCoAuthoredBy)
+	assert.Equal(t, map[string]string{
+		"NTFY_TOPIC":     "mine",
+		"API_TIMEOUT_MS": "60000",
+		"http_proxy":     "http://127.0.0.1:7890",
+		"https_proxy":    "http://127.0.0.1:7890",
+	}, settings.Env)
+	assert.Equal(t, []string{"shared/team.json"}, settings.Includes)
+
+	status, err := manager.GetStatus(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"base.json", "shared/team.json"}, status.IncludedFiles)
+	assert.True(t, status.ProxyEnabled)
+
+	// settings.json itself is left untouched
+	raw, err := manager.Load(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"NTFY_TOPIC": "mine"}, raw.Env)
+}
+
+func TestConfigManager_LoadEffective_Rejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{
+		{
+			name: "cycle",
+			files: map[string]string{
+				"settings.json": `{"includes": ["a.json"]}`,
+				"a.json":        `{"includes": ["b.json"]}`,
+				"b.json":        `{"includes": ["a.json"]}`,
+			},
+			wantErr: "include cycle detected: settings.json -> a.json -> b.json -> a.json",
+		},
+		{
+			name: "path traversal",
+			files: map[string]string{
+				"settings.json": `{"includes": ["../outside.json"]}`,
+			},
+			wantErr: "outside the claude directory",
+		},
+		{
+			name: "invalid includes value",
+			files: map[string]string{
+				"settings.json": `{"includes": "a.json"}`,
+			},
+			wantErr: "must be a list of file paths",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			for name, content := range tt.files {
+				require.NoError(t, os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0644))
+			}
+
+			_, err := NewManager(tempDir).LoadEffective(context.Background())
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestValidateSettings(t *testing.T) {
+	assert.NoError(t, ValidateSettings([]byte(`{"env": {"A": "1"}, "custom": true}`)))
+
+	tests := []struct {
+		name     string
+		data     string
+		position string
+	}{
+		{"missing comma", "{\n  \"env\": {}\n  \"model\": \"x\"\n}", "line 3, column 3"},
+		{"trailing comma", "{\n  \"env\": {\"A\": \"1\",}\n}", "line 2, column 20"},
+		{"wrong type", "{\n  \"env\": {\"A\": 1}\n}", "line 2, column 16"},
+		{"truncated", "{\"env\": {", "line 1, column 9"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSettings([]byte(tt.data))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.position)
+		})
+	}
+}
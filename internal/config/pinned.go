@@ -0,0 +1,100 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// pinnedEnvFile stores env keys that merges must never overwrite, one per line
+const pinnedEnvFile = ".pinned_env"
+
+// LoadPinnedEnv returns the pinned env keys stored in claudeDir.
+// A missing file means nothing is pinned.
+func LoadPinnedEnv(claudeDir string) ([]string, error) {
+	data, err := os.ReadFile(filepath.Join(claudeDir, pinnedEnvFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pinned env file: %w", err)
+	}
+
+	var keys []string
+	for _, line := range strings.Split(string(data), "\n") {
+		key := strings.TrimSpace(line)
+		if key == "" || strings.HasPrefix(key, "#") {
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// PinEnv adds key to the pinned env keys. Pinning an already pinned key is a no-op.
+func PinEnv(claudeDir, key string) error {
+	key = strings.TrimSpace(key)
+	if key == "" || strings.ContainsAny(key, " \t=") {
+		return fmt.Errorf("invalid env key: %q", key)
+	}
+
+	keys, err := LoadPinnedEnv(claudeDir)
+	if err != nil {
+		return err
+	}
+	for _, existing := range keys {
+		if existing == key {
+			return nil
+		}
+	}
+
+	return savePinnedEnv(claudeDir, append(keys, key))
+}
+
+// UnpinEnv removes key from the pinned env keys and reports whether it was pinned
+func UnpinEnv(claudeDir, key string) (bool, error) {
+	keys, err := LoadPinnedEnv(claudeDir)
+	if err != nil {
+		return false, err
+	}
+
+	key = strings.TrimSpace(key)
+	remaining := make([]string, 0, len(keys))
+	for _, existing := range keys {
+		if existing != key {
+			remaining = append(remaining, existing)
+		}
+	}
+	if len(remaining) == len(keys) {
+		return false, nil
+	}
+
+	return true, savePinnedEnv(claudeDir, remaining)
+}
+
+// savePinnedEnv writes the pinned keys sorted, removing the file when empty
+func savePinnedEnv(claudeDir string, keys []string) error {
+	path := filepath.Join(claudeDir, pinnedEnvFile)
+
+	if len(keys) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove pinned env file: %w", err)
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(claudeDir, 0755); err != nil {
+		return fmt.Errorf("failed to create claude directory: %w", err)
+	}
+
+	sort.Strings(keys)
+	data := strings.Join(keys, "\n") + "\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		return fmt.Errorf("failed to write pinned env file: %w", err)
+	}
+
+	return nil
+}
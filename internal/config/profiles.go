@@ -0,0 +1,198 @@
+package config
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ooneko/claude-config/internal/claude"
+)
+
+// profilesManifestName is the manifest at the top of a bulk profile backup
+const profilesManifestName = ".claude-config-profiles.json"
+
+// profilesManifest lists the profiles packed in a bulk profile backup
+type profilesManifest struct {
+	Version  int       `json:"version"`
+	Created  time.Time `json:"created"`
+	Profiles []string  `json:"profiles"`
+}
+
+// ListProfiles returns the profile directories under profilesRoot: every
+// non-hidden subdirectory holding a settings.json, sorted by name
+func ListProfiles(profilesRoot string) ([]string, error) {
+	entries, err := os.ReadDir(profilesRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profiles directory: %w", err)
+	}
+
+	var profiles []string
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		settingsPath := filepath.Join(profilesRoot, entry.Name(), "settings.json")
+		if info, err := os.Stat(settingsPath); err == nil && info.Mode().IsRegular() {
+			profiles = append(profiles, entry.Name())
+		}
+	}
+	sort.Strings(profiles)
+	return profiles, nil
+}
+
+// BackupProfiles archives every profile directory under profilesRoot into a
+// single tar.gz in destDir, each under its own subpath, together with a
+// manifest listing them. API key files are left out unless opts.IncludeSecrets is set.
+func (m *Manager) BackupProfiles(_ context.Context, profilesRoot, destDir string, opts *claude.BackupOptions) (*claude.BackupInfo, error) {
+	if opts == nil {
+		opts = &claude.BackupOptions{}
+	}
+
+	profiles, err := ListProfiles(profilesRoot)
+	if err != nil {
+		return nil, err
+	}
+	if len(profiles) == 0 {
+		return nil, fmt.Errorf("no profiles with settings.json found in %s", profilesRoot)
+	}
+
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	now := time.Now()
+	manifest, err := json.MarshalIndent(&profilesManifest{Version: 1, Created: now, Profiles: profiles}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode profiles manifest: %w", err)
+	}
+
+	filename := fmt.Sprintf("claude-config-profiles-backup-%s.tar.gz", now.Format("20060102_150405"))
+	backupPath, err := filepath.Abs(filepath.Join(destDir, filename))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve backup path: %w", err)
+	}
+
+	excluded, err := m.createTarGzArchive(profilesRoot, backupPath, archiveOptions{
+		excludeSecrets: !opts.IncludeSecrets,
+		profiles:       profiles,
+		manifest:       manifest,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create backup archive: %w", err)
+	}
+
+	stat, err := os.Stat(backupPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get backup file stats: %w", err)
+	}
+
+	for i, relPath := range excluded {
+		excluded[i] = filepath.ToSlash(relPath)
+	}
+
+	return &claude.BackupInfo{
+		Filename:      filename,
+		FilePath:      backupPath,
+		ContentType:   "profiles",
+		Size:          stat.Size(),
+		Timestamp:     now,
+		ExcludedFiles: excluded,
+		Profiles:      profiles,
+	}, nil
+}
+
+// RestoreProfiles extracts a backup created by BackupProfiles into
+// profilesRoot, one directory per profile, and returns the restored profiles.
+// Existing non-empty profile directories are only overwritten when force is
+// true; nothing is extracted if any of them would be.
+func (m *Manager) RestoreProfiles(_ context.Context, archivePath, profilesRoot string, force bool) ([]string, error) {
+	manifest, err := readProfilesManifest(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup archive: %w", err)
+	}
+
+	profiles := make(map[string]bool, len(manifest.Profiles))
+	for _, profile := range manifest.Profiles {
+		if !validProfileName(profile) {
+			return nil, fmt.Errorf("invalid profile name in backup archive: %q", profile)
+		}
+		profiles[profile] = true
+	}
+
+	if !force {
+		for _, profile := range manifest.Profiles {
+			profileDir := filepath.Join(profilesRoot, profile)
+			entries, err := os.ReadDir(profileDir)
+			if err != nil && !os.IsNotExist(err) {
+				return nil, fmt.Errorf("failed to read profile directory: %w", err)
+			}
+			if len(entries) > 0 {
+				return nil, fmt.Errorf("profile directory %s already exists, use force to overwrite", profileDir)
+			}
+		}
+	}
+
+	include := func(name string) bool {
+		profile, _, _ := strings.Cut(path.Clean(name), "/")
+		return profiles[profile]
+	}
+	if err := m.extractTarGzArchive(archivePath, profilesRoot, include); err != nil {
+		return nil, fmt.Errorf("failed to extract backup archive: %w", err)
+	}
+
+	return manifest.Profiles, nil
+}
+
+// readProfilesManifest reads the manifest of a bulk profile backup. Archives
+// made by a plain backup have none and are rejected.
+func readProfilesManifest(archivePath string) (*profilesManifest, error) {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	gzReader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer gzReader.Close()
+
+	tarReader := tar.NewReader(gzReader)
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%s is not a profiles backup: %s is missing", archivePath, profilesManifestName)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg || path.Clean(header.Name) != profilesManifestName {
+			continue
+		}
+
+		var manifest profilesManifest
+		if err := json.NewDecoder(tarReader).Decode(&manifest); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", profilesManifestName, err)
+		}
+		if len(manifest.Profiles) == 0 {
+			return nil, fmt.Errorf("invalid %s: no profiles listed", profilesManifestName)
+		}
+		return &manifest, nil
+	}
+}
+
+// validProfileName reports whether name is a single, non-hidden path element
+func validProfileName(name string) bool {
+	return name != "" && !strings.HasPrefix(name, ".") && !strings.ContainsAny(name, `/\`)
+}
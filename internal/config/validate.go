@@ -0,0 +1,52 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/ooneko/claude-config/internal/claude"
+)
+
+// ValidateSettings checks that data parses into claude.Settings. Syntax and
+// type errors include the line and column where parsing stopped.
+func ValidateSettings(data []byte) error {
+	var settings claude.Settings
+	err := json.Unmarshal(data, &settings)
+	if err == nil {
+		return nil
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var offset int64
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset
+	default:
+		return err
+	}
+
+	line, column := textPosition(data, offset)
+	return fmt.Errorf("line %d, column %d: %w", line, column, err)
+}
+
+// textPosition converts the byte offset reported by encoding/json, which
+// counts the offending byte, into a 1-based line and column
+func textPosition(data []byte, offset int64) (line, column int) {
+	end := int(offset) - 1
+	if end < 0 {
+		end = 0
+	}
+	if end > len(data) {
+		end = len(data)
+	}
+
+	before := data[:end]
+	lineStart := bytes.LastIndexByte(before, '\n') + 1
+	return bytes.Count(before, []byte("\n")) + 1, utf8.RuneCount(before[lineStart:]) + 1
+}
@@ -0,0 +1,264 @@
+package doctor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ooneko/claude-config/internal/aiprovider"
+	"github.com/ooneko/claude-config/internal/claude"
+	"github.com/ooneko/claude-config/internal/hooks"
+)
+
+// Severity 诊断结果的严重程度
+type Severity string
+
+const (
+	SeverityOK      Severity = "ok"      // 检查通过
+	SeverityWarning Severity = "warning" // 不影响使用，但建议处理
+	SeverityError   Severity = "error"   // 会导致功能异常
+)
+
+// Diagnostic 单项检查的结果
+type Diagnostic struct {
+	Check    string   `json:"check"`         // 检查项名称
+	Severity Severity `json:"severity"`      // 严重程度
+	Message  string   `json:"message"`       // 检查结果说明
+	Fix      string   `json:"fix,omitempty"` // 建议的修复方式，检查通过时为空
+}
+
+// Manager 检查整个配置目录，定位常见的配置问题
+type Manager struct {
+	claudeDir string
+	homeDir   string
+	pinger    *aiprovider.Pinger
+}
+
+// NewManager 创建新的诊断管理器
+func NewManager(claudeDir string) *Manager {
+	homeDir, _ := os.UserHomeDir()
+	return &Manager{
+		claudeDir: claudeDir,
+		homeDir:   homeDir,
+		pinger:    aiprovider.NewPinger(),
+	}
+}
+
+// Doctor 依次检查 settings.json 能否解析、hook 脚本是否存在且可执行、
+// API 密钥文件权限是否为 0600，以及当前 AI 提供商的接入点能否连通。
+// 返回的错误只表示检查本身无法进行，配置问题通过 Diagnostic 报告。
+func (m *Manager) Doctor(ctx context.Context) ([]Diagnostic, error) {
+	var diagnostics []Diagnostic
+
+	settings, diagnostic := m.checkSettings()
+	diagnostics = append(diagnostics, diagnostic)
+
+	diagnostics = append(diagnostics, m.checkHookCommands(settings)...)
+
+	keyDiagnostics, err := m.checkAPIKeyFiles()
+	if err != nil {
+		return diagnostics, err
+	}
+	diagnostics = append(diagnostics, keyDiagnostics...)
+
+	diagnostics = append(diagnostics, m.checkProvider(ctx, settings))
+
+	return diagnostics, nil
+}
+
+// checkSettings 检查 settings.json 能否解析，无法解析时返回的配置为 nil
+func (m *Manager) checkSettings() (map[string]interface{}, Diagnostic) {
+	const name = "settings.json"
+	path := filepath.Join(m.claudeDir, "settings.json")
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, Diagnostic{
+			Check:    name,
+			Severity: SeverityWarning,
+			Message:  "未找到 settings.json",
+			Fix:      "运行 claude-config install --settings 安装默认配置",
+		}
+	}
+	if err != nil {
+		return nil, Diagnostic{Check: name, Severity: SeverityError, Message: fmt.Sprintf("读取失败: %v", err)}
+	}
+
+	var settings map[string]interface{}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, Diagnostic{
+			Check:    name,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("JSON 格式错误: %v", err),
+			Fix:      fmt.Sprintf("修正 %s 的语法错误，或使用 claude-config restore 恢复备份", path),
+		}
+	}
+
+	return settings, Diagnostic{Check: name, Severity: SeverityOK, Message: "格式正确"}
+}
+
+// checkHookCommands 检查 settings.json 中引用的 hook 脚本是否存在且可执行。
+// 只检查以路径形式引用的脚本，npx 等依赖 PATH 的命令不在检查范围内。
+func (m *Manager) checkHookCommands(settings map[string]interface{}) []Diagnostic {
+	const name = "hook脚本"
+
+	var diagnostics []Diagnostic
+	checked := 0
+	for _, command := range hookCommands(settings) {
+		path, ok := hooks.ScriptPath(command, m.claudeDir, m.homeDir)
+		if !ok {
+			continue
+		}
+		checked++
+
+		info, err := os.Stat(path)
+		switch {
+		case os.IsNotExist(err):
+			diagnostics = append(diagnostics, Diagnostic{
+				Check:    name,
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("%s 不存在", command),
+				Fix:      "运行 claude-config install --hooks --force 重新安装 hook 脚本",
+			})
+		case err != nil:
+			diagnostics = append(diagnostics, Diagnostic{
+				Check:    name,
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("%s 无法访问: %v", command, err),
+			})
+		case info.IsDir() || info.Mode().Perm()&0111 == 0:
+			diagnostics = append(diagnostics, Diagnostic{
+				Check:    name,
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("%s 不可执行", command),
+				Fix:      fmt.Sprintf("chmod +x %s", path),
+			})
+		}
+	}
+
+	if len(diagnostics) == 0 {
+		return []Diagnostic{{Check: name, Severity: SeverityOK, Message: fmt.Sprintf("%d 个脚本均存在且可执行", checked)}}
+	}
+	return diagnostics
+}
+
+// hookCommands 返回 settings.json 所有 hook 事件中出现的命令，去重并排序
+func hookCommands(settings map[string]interface{}) []string {
+	hooks, _ := settings["hooks"].(map[string]interface{})
+
+	seen := make(map[string]bool)
+	var commands []string
+	for _, rules := range hooks {
+		ruleList, _ := rules.([]interface{})
+		for _, rule := range ruleList {
+			ruleMap, _ := rule.(map[string]interface{})
+			items, _ := ruleMap["hooks"].([]interface{})
+			for _, item := range items {
+				itemMap, _ := item.(map[string]interface{})
+				command, _ := itemMap["command"].(string)
+				command = strings.TrimSpace(command)
+				if command != "" && !seen[command] {
+					seen[command] = true
+					commands = append(commands, command)
+				}
+			}
+		}
+	}
+
+	sort.Strings(commands)
+	return commands
+}
+
+// checkAPIKeyFiles 检查 API 密钥文件的权限是否为 0600
+func (m *Manager) checkAPIKeyFiles() ([]Diagnostic, error) {
+	const name = "API密钥权限"
+
+	entries, err := os.ReadDir(m.claudeDir)
+	if os.IsNotExist(err) {
+		return []Diagnostic{{Check: name, Severity: SeverityOK, Message: "没有API密钥文件"}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取配置目录失败: %w", err)
+	}
+
+	var diagnostics []Diagnostic
+	checked := 0
+	for _, entry := range entries {
+		fileName := entry.Name()
+		if !entry.Type().IsRegular() || !claude.IsKeyFile(fileName) {
+			continue
+		}
+		checked++
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("读取 %s 失败: %w", fileName, err)
+		}
+		if perm := info.Mode().Perm(); perm != 0600 {
+			path := filepath.Join(m.claudeDir, fileName)
+			diagnostics = append(diagnostics, Diagnostic{
+				Check:    name,
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("%s 的权限为 %#o，其他用户可能读取密钥", fileName, perm),
+				Fix:      fmt.Sprintf("chmod 600 %s", path),
+			})
+		}
+	}
+
+	if len(diagnostics) == 0 {
+		return []Diagnostic{{Check: name, Severity: SeverityOK, Message: fmt.Sprintf("%d 个密钥文件权限正确", checked)}}, nil
+	}
+	return diagnostics, nil
+}
+
+// checkProvider 检查当前 AI 提供商的接入点能否连通，经由 settings.json 中配置的代理
+func (m *Manager) checkProvider(ctx context.Context, settings map[string]interface{}) Diagnostic {
+	const name = "AI提供商连通性"
+
+	env, _ := settings["env"].(map[string]interface{})
+	envValue := func(key string) string {
+		value, _ := env[key].(string)
+		return value
+	}
+
+	baseURL := envValue("ANTHROPIC_BASE_URL")
+	if baseURL == "" {
+		return Diagnostic{Check: name, Severity: SeverityOK, Message: "未启用第三方AI提供商，跳过"}
+	}
+
+	proxyConfig := &claude.ProxyConfig{
+		HTTPProxy:  envValue("http_proxy"),
+		HTTPSProxy: envValue("https_proxy"),
+		NoProxy:    envValue("no_proxy"),
+		AllProxy:   envValue("all_proxy"),
+	}
+
+	result := m.pinger.Ping(ctx, baseURL, proxyConfig)
+	switch {
+	case result.Err != nil:
+		return Diagnostic{
+			Check:    name,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("无法连接 %s: %v", baseURL, result.Err),
+			Fix:      "检查网络和代理设置，可使用 claude-config ai ping 查看详情",
+		}
+	case result.TLSChecked && !result.TLSValid:
+		return Diagnostic{
+			Check:    name,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("%s TLS 证书校验失败: %v", baseURL, result.TLSError),
+			Fix:      "检查代理是否拦截了 HTTPS 流量，或系统时间是否正确",
+		}
+	default:
+		return Diagnostic{
+			Check:    name,
+			Severity: SeverityOK,
+			Message:  fmt.Sprintf("%s 可连通 (%s)", baseURL, result.Latency.Round(time.Millisecond)),
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package doctor
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeSettings writes settings.json into claudeDir
+func writeSettings(t *testing.T, claudeDir, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(claudeDir, "settings.json"), []byte(content), 0644))
+}
+
+// findDiagnostics returns the diagnostics reported for a check
+func findDiagnostics(diagnostics []Diagnostic, check string) []Diagnostic {
+	var found []Diagnostic
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Check == check {
+			found = append(found, diagnostic)
+		}
+	}
+	return found
+}
+
+func TestManager_Doctor_Healthy(t *testing.T) {
+	claudeDir := t.TempDir()
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	hooksDir := filepath.Join(claudeDir, "hooks")
+	require.NoError(t, os.MkdirAll(hooksDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(hooksDir, "smart-lint.sh"), []byte("#!/bin/sh\n"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(claudeDir, ".deepseek_api_key"), []byte("sk-test"), 0600))
+	writeSettings(t, claudeDir, `{
+		"env": {"ANTHROPIC_BASE_URL": "`+server.URL+`"},
+		"hooks": {"PostToolUse": [{"matcher": "Write", "hooks": [
+			{"type": "command", "command": "~/.claude/hooks/smart-lint.sh --staged"},
+			{"type": "command", "command": "npx prettier --check ."}
+		]}]}
+	}`)
+
+	diagnostics, err := NewManager(claudeDir).Doctor(context.Background())
+	require.NoError(t, err)
+	require.Len(t, diagnostics, 4)
+	for _, diagnostic := range diagnostics {
+		assert.Equal(t, SeverityOK, diagnostic.Severity, "%s: %s", diagnostic.Check, diagnostic.Message)
+		assert.Empty(t, diagnostic.Fix)
+	}
+	assert.Contains(t, findDiagnostics(diagnostics, "hook脚本")[0].Message, "1 个脚本")
+}
+
+func TestManager_Doctor_Problems(t *testing.T) {
+	claudeDir := t.TempDir()
+
+	hooksDir := filepath.Join(claudeDir, "hooks")
+	require.NoError(t, os.MkdirAll(hooksDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(hooksDir, "ntfy-notifier.sh"), []byte("#!/bin/sh\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(claudeDir, ".kimi_api_key"), []byte("sk-test"), 0644))
+	writeSettings(t, claudeDir, `{
+		"env": {"ANTHROPIC_BASE_URL": "https://api.kimi.com/coding/"},
+		"hooks": {
+			"PostToolUse": [{"matcher": "Write", "hooks": [{"type": "command", "command": "~/.claude/hooks/smart-lint.sh"}]}],
+			"Stop": [{"matcher": "", "hooks": [{"type": "command", "command": "~/.claude/hooks/ntfy-notifier.sh"}]}]
+		}
+	}`)
+
+	manager := NewManager(claudeDir)
+	manager.pinger.Dial = func(context.Context, string, string) (net.Conn, error) {
+		return nil, errors.New("connection refused")
+	}
+
+	diagnostics, err := manager.Doctor(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, SeverityOK, findDiagnostics(diagnostics, "settings.json")[0].Severity)
+
+	hookDiagnostics := findDiagnostics(diagnostics, "hook脚本")
+	require.Len(t, hookDiagnostics, 2)
+	assert.Contains(t, hookDiagnostics[0].Message, "ntfy-notifier.sh 不可执行")
+	assert.Equal(t, "chmod +x "+filepath.Join(hooksDir, "ntfy-notifier.sh"), hookDiagnostics[0].Fix)
+	assert.Contains(t, hookDiagnostics[1].Message, "smart-lint.sh 不存在")
+	assert.Contains(t, hookDiagnostics[1].Fix, "install --hooks")
+
+	keyDiagnostics := findDiagnostics(diagnostics, "API密钥权限")
+	require.Len(t, keyDiagnostics, 1)
+	assert.Equal(t, SeverityError, keyDiagnostics[0].Severity)
+	assert.Contains(t, keyDiagnostics[0].Message, ".kimi_api_key")
+	assert.Contains(t, keyDiagnostics[0].Fix, "chmod 600")
+
+	providerDiagnostics := findDiagnostics(diagnostics, "AI提供商连通性")
+	require.Len(t, providerDiagnostics, 1)
+	assert.Equal(t, SeverityError, providerDiagnostics[0].Severity)
+	assert.Contains(t, providerDiagnostics[0].Message, "connection refused")
+}
+
+func TestManager_Doctor_InvalidSettings(t *testing.T) {
+	claudeDir := t.TempDir()
+	writeSettings(t, claudeDir, `{"env": {`)
+
+	diagnostics, err := NewManager(claudeDir).Doctor(context.Background())
+	require.NoError(t, err)
+
+	settingsDiagnostic := findDiagnostics(diagnostics, "settings.json")[0]
+	assert.Equal(t, SeverityError, settingsDiagnostic.Severity)
+	assert.Contains(t, settingsDiagnostic.Message, "JSON")
+	assert.NotEmpty(t, settingsDiagnostic.Fix)
+
+	// 无法解析时不检查 hook 和连通性
+	assert.Equal(t, SeverityOK, findDiagnostics(diagnostics, "hook脚本")[0].Severity)
+	assert.Equal(t, SeverityOK, findDiagnostics(diagnostics, "AI提供商连通性")[0].Severity)
+}
+
+func TestManager_Doctor_MissingSettings(t *testing.T) {
+	diagnostics, err := NewManager(filepath.Join(t.TempDir(), ".claude")).Doctor(context.Background())
+	require.NoError(t, err)
+
+	settingsDiagnostic := findDiagnostics(diagnostics, "settings.json")[0]
+	assert.Equal(t, SeverityWarning, settingsDiagnostic.Severity)
+	assert.Contains(t, settingsDiagnostic.Fix, "install --settings")
+}
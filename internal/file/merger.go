@@ -1,18 +1,40 @@
 package file
 
 import (
+	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/ooneko/claude-config/internal/claude"
+	"github.com/ooneko/claude-config/internal/config"
+	"github.com/ooneko/claude-config/internal/logging"
 )
 
 // SettingsJSONMerger implements intelligent merging of settings.json files
-type SettingsJSONMerger struct{}
+type SettingsJSONMerger struct {
+	// pinned holds env keys whose destination value always wins
+	pinned map[string]bool
+	// logger receives merge warnings
+	logger logging.Logger
+}
 
 // NewSettingsJSONMerger creates a new settings merger
 func NewSettingsJSONMerger() *SettingsJSONMerger {
-	return &SettingsJSONMerger{}
+	return &SettingsJSONMerger{logger: logging.Default()}
+}
+
+// SetLogger sets where merge warnings go
+func (m *SettingsJSONMerger) SetLogger(logger logging.Logger) {
+	m.logger = logger
+}
+
+// SetPinnedKeys sets the env keys that are protected like proxy settings
+func (m *SettingsJSONMerger) SetPinnedKeys(keys []string) {
+	m.pinned = make(map[string]bool, len(keys))
+	for _, key := range keys {
+		m.pinned[key] = true
+	}
 }
 
 // MergeSettings intelligently merges source settings into destination settings
@@ -28,25 +50,83 @@ func (m *SettingsJSONMerger) MergeSettings(dest, source *claude.Settings) (*clau
 		return dest, nil
 	}
 
-	// Create result by copying destination
-	result := &claude.Settings{
-		IncludeCoAuthoredBy: source.IncludeCoAuthoredBy, // Use source value
-		StatusLine:          dest.StatusLine,            // Keep destination status line
+	// Start from a deep copy of the destination so fields the merge doesn't
+	// touch, including unknown top-level keys, survive unchanged
+	result, err := copySettings(dest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy settings: %w", err)
 	}
 
+	// Overlay the known fields
+	result.IncludeCoAuthoredBy = source.IncludeCoAuthoredBy // Use source value
+
 	// Merge environment variables with proxy protection
-	result.Env = m.mergeEnvironmentVariables(dest.Env, source.Env)
+	result.Env = m.mergeEnvironmentVariables(result.Env, source.Env)
 
 	// Merge hooks intelligently
-	var err error
-	result.Hooks, err = m.mergeHooksConfig(dest.Hooks, source.Hooks)
+	result.Hooks, err = m.mergeHooksConfig(result.Hooks, source.Hooks)
 	if err != nil {
 		return nil, fmt.Errorf("failed to merge hooks: %w", err)
 	}
 
+	// The same command under several events runs once per event
+	if duplicates := m.DetectDuplicateCommands(result); len(duplicates) > 0 {
+		m.logger.Warn("⚠️  以下hook命令出现在多个事件中，将被重复执行: %s", strings.Join(duplicates, ", "))
+	}
+
+	return result, nil
+}
+
+// copySettings returns a deep copy of settings
+func copySettings(settings *claude.Settings) (*claude.Settings, error) {
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &claude.Settings{}
+	if err := json.Unmarshal(data, result); err != nil {
+		return nil, err
+	}
 	return result, nil
 }
 
+// DetectDuplicateCommands returns the hook commands that appear in more than one
+// event array, sorted. Repeats within a single event are not reported.
+func (m *SettingsJSONMerger) DetectDuplicateCommands(settings *claude.Settings) []string {
+	if settings == nil || settings.Hooks == nil {
+		return nil
+	}
+
+	// Count each command once per event
+	eventCounts := make(map[string]int)
+	for _, rules := range settings.Hooks.Events() {
+		seen := make(map[string]bool)
+		for _, rule := range rules {
+			if rule == nil {
+				continue
+			}
+			for _, hook := range rule.Hooks {
+				if hook == nil || hook.Command == "" || seen[hook.Command] {
+					continue
+				}
+				seen[hook.Command] = true
+				eventCounts[hook.Command]++
+			}
+		}
+	}
+
+	var duplicates []string
+	for command, count := range eventCounts {
+		if count > 1 {
+			duplicates = append(duplicates, command)
+		}
+	}
+	sort.Strings(duplicates)
+
+	return duplicates
+}
+
 // mergeEnvironmentVariables merges env vars with proxy configuration protection
 func (m *SettingsJSONMerger) mergeEnvironmentVariables(destEnv, sourceEnv map[string]string) map[string]string {
 	if destEnv == nil && sourceEnv == nil {
@@ -66,6 +146,14 @@ func (m *SettingsJSONMerger) mergeEnvironmentVariables(destEnv, sourceEnv map[st
 		if m.isProxyVar(key) && destEnv != nil && destEnv[key] != "" {
 			continue // Keep destination proxy settings
 		}
+		// Pinned keys are protected the same way
+		if m.pinned[key] && destEnv != nil && destEnv[key] != "" {
+			continue
+		}
+		// Secret protection: templates must never inject credentials
+		if m.isSecretVar(key) {
+			continue
+		}
 		result[key] = value
 	}
 
@@ -90,8 +178,14 @@ func (m *SettingsJSONMerger) mergeHooksConfig(destHooks, sourceHooks *claude.Hoo
 
 	result := &claude.HooksConfig{}
 
-	// Merge PostToolUse hooks
+	// Merge PreToolUse hooks
 	var err error
+	result.PreToolUse, err = m.mergeHookRules(destHooks.PreToolUse, sourceHooks.PreToolUse)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge PreToolUse hooks: %w", err)
+	}
+
+	// Merge PostToolUse hooks
 	result.PostToolUse, err = m.mergeHookRules(destHooks.PostToolUse, sourceHooks.PostToolUse)
 	if err != nil {
 		return nil, fmt.Errorf("failed to merge PostToolUse hooks: %w", err)
@@ -103,6 +197,24 @@ func (m *SettingsJSONMerger) mergeHooksConfig(destHooks, sourceHooks *claude.Hoo
 		return nil, fmt.Errorf("failed to merge Stop hooks: %w", err)
 	}
 
+	// Merge SessionStart hooks
+	result.SessionStart, err = m.mergeHookRules(destHooks.SessionStart, sourceHooks.SessionStart)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge SessionStart hooks: %w", err)
+	}
+
+	// Merge SubagentStop hooks
+	result.SubagentStop, err = m.mergeHookRules(destHooks.SubagentStop, sourceHooks.SubagentStop)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge SubagentStop hooks: %w", err)
+	}
+
+	// Merge PreCompact hooks
+	result.PreCompact, err = m.mergeHookRules(destHooks.PreCompact, sourceHooks.PreCompact)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge PreCompact hooks: %w", err)
+	}
+
 	return result, nil
 }
 
@@ -135,8 +247,9 @@ func (m *SettingsJSONMerger) mergeHookRules(destRules, sourceRules []*claude.Hoo
 
 			destMatcher := m.normalizeMatcherPattern(destRule.Matcher)
 
-			// Check if matchers overlap
-			if m.matchersOverlap(destMatcher, sourceMatcher) {
+			// Only merge when one rule covers every tool of the other, so no
+			// hook starts running on a tool it wasn't configured for
+			if m.matchersNested(destMatcher, sourceMatcher) {
 				// Merge these two rules
 				mergedRule, err := m.mergeHookRule(destRule, sourceRule)
 				if err != nil {
@@ -149,7 +262,7 @@ func (m *SettingsJSONMerger) mergeHookRules(destRules, sourceRules []*claude.Hoo
 			}
 		}
 
-		// If no overlap found, add source rule as-is
+		// If no rule covers it, add source rule as-is
 		if !merged {
 			result = append(result, sourceRule)
 		}
@@ -165,38 +278,12 @@ func (m *SettingsJSONMerger) mergeHookRules(destRules, sourceRules []*claude.Hoo
 	return result, nil
 }
 
-// matchersOverlap checks if two normalized matcher patterns overlap
-func (m *SettingsJSONMerger) matchersOverlap(matcher1, matcher2 string) bool {
-	if matcher1 == matcher2 {
-		return true
-	}
-
-	// Check if one is a subset of the other
-	parts1 := strings.Split(matcher1, "|")
-	parts2 := strings.Split(matcher2, "|")
-
-	// Create sets for easier comparison
-	set1 := make(map[string]bool)
-	for _, part := range parts1 {
-		set1[part] = true
-	}
-
-	set2 := make(map[string]bool)
-	for _, part := range parts2 {
-		set2[part] = true
-	}
-
-	// Check if there's any overlap
-	for part := range set1 {
-		if set2[part] {
-			return true
-		}
-	}
-
-	return false
+// matchersNested checks if one normalized matcher pattern contains every tool of the other
+func (m *SettingsJSONMerger) matchersNested(matcher1, matcher2 string) bool {
+	return m.matcherIsSuperset(matcher1, matcher2) || m.matcherIsSuperset(matcher2, matcher1)
 }
 
-// mergeHookRule merges two hook rules with the same matcher
+// mergeHookRule merges two hook rules whose matchers are nested
 func (m *SettingsJSONMerger) mergeHookRule(destRule, sourceRule *claude.HookRule) (*claude.HookRule, error) {
 	// Use the more comprehensive matcher pattern
 	matcher := m.choosePreferredMatcher(destRule.Matcher, sourceRule.Matcher)
@@ -259,30 +346,36 @@ func (m *SettingsJSONMerger) normalizeMatcherPattern(matcher string) string {
 	return strings.Join(unique, "|")
 }
 
-// choosePreferredMatcher chooses the more comprehensive matcher pattern
+// choosePreferredMatcher chooses the matcher for two nested rules: the one
+// covering every tool of the other
 func (m *SettingsJSONMerger) choosePreferredMatcher(matcher1, matcher2 string) string {
-	norm1 := m.normalizeMatcherPattern(matcher1)
-	norm2 := m.normalizeMatcherPattern(matcher2)
-
-	parts1 := strings.Split(norm1, "|")
-	parts2 := strings.Split(norm2, "|")
-
-	// Choose the one with more parts (more comprehensive)
-	if len(parts2) > len(parts1) {
-		return matcher2
-	}
-	if len(parts1) > len(parts2) {
+	if m.matcherIsSuperset(m.normalizeMatcherPattern(matcher1), m.normalizeMatcherPattern(matcher2)) {
 		return matcher1
 	}
+	return matcher2
+}
+
+// matcherIsSuperset checks if normalized matcher a contains every tool of normalized matcher b
+func (m *SettingsJSONMerger) matcherIsSuperset(a, b string) bool {
+	tools := make(map[string]bool)
+	for _, part := range strings.Split(a, "|") {
+		tools[part] = true
+	}
 
-	// Same length, choose alphabetically last (arbitrary but consistent)
-	if norm2 > norm1 {
-		return matcher2
+	for _, part := range strings.Split(b, "|") {
+		if !tools[part] {
+			return false
+		}
 	}
-	return matcher1
+	return true
 }
 
 // isProxyVar checks if a variable is a proxy-related variable
 func (m *SettingsJSONMerger) isProxyVar(key string) bool {
-	return key == "http_proxy" || key == "https_proxy"
+	return config.IsProxyEnvKey(key)
+}
+
+// isSecretVar checks if a variable holds a credential that must not come from a template
+func (m *SettingsJSONMerger) isSecretVar(key string) bool {
+	return key == "ANTHROPIC_AUTH_TOKEN" || key == "ANTHROPIC_API_KEY" || strings.HasSuffix(key, "_API_KEY")
 }
@@ -1,6 +1,7 @@
 package file
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -352,6 +353,16 @@ func TestSettingsJsonMerger_IsProxyVar(t *testing.T) {
 			variable: "https_proxy",
 			expected: true,
 		},
+		{
+			name:     "all_proxy",
+			variable: "all_proxy",
+			expected: true,
+		},
+		{
+			name:     "no_proxy",
+			variable: "no_proxy",
+			expected: true,
+		},
 		{
 			name:     "other variable",
 			variable: "CLAUDE_HOOKS_GO_ENABLED",
@@ -371,3 +382,202 @@ func TestSettingsJsonMerger_IsProxyVar(t *testing.T) {
 		})
 	}
 }
+
+func TestSettingsJsonMerger_MergeSettings_PinnedEnv(t *testing.T) {
+	merger := NewSettingsJSONMerger()
+	merger.SetPinnedKeys([]string{"ANTHROPIC_BASE_URL", "UNSET_PINNED"})
+
+	dest := &claude.Settings{
+		Env: map[string]string{
+			"ANTHROPIC_BASE_URL": "https://my-gateway.example.com",
+		},
+	}
+	source := &claude.Settings{
+		Env: map[string]string{
+			"ANTHROPIC_BASE_URL": "https://template.example.com",
+			"UNSET_PINNED":       "from_template",
+			"NEW_VAR":            "new_value",
+		},
+	}
+
+	result, err := merger.MergeSettings(dest, source)
+	require.NoError(t, err)
+
+	// Pinned key already set in destination survives the merge
+	assert.Equal(t, "https://my-gateway.example.com", result.Env["ANTHROPIC_BASE_URL"])
+	// Pinned key missing from destination is still added
+	assert.Equal(t, "from_template", result.Env["UNSET_PINNED"])
+	assert.Equal(t, "new_value", result.Env["NEW_VAR"])
+}
+
+func TestSettingsJsonMerger_DetectDuplicateCommands(t *testing.T) {
+	merger := NewSettingsJSONMerger()
+
+	settings := &claude.Settings{
+		Hooks: &claude.HooksConfig{
+			PostToolUse: []*claude.HookRule{
+				{
+					Matcher: "Write|Edit",
+					Hooks: []*claude.HookItem{
+						{Type: "command", Command: "~/.claude/hooks/smart-lint.sh"},
+						{Type: "command", Command: "~/.claude/hooks/smart-test.sh"},
+					},
+				},
+				{
+					// Repeats within one event are not reported
+					Matcher: "MultiEdit",
+					Hooks:   []*claude.HookItem{{Type: "command", Command: "~/.claude/hooks/smart-test.sh"}},
+				},
+			},
+			Stop: []*claude.HookRule{
+				{
+					Matcher: "",
+					Hooks: []*claude.HookItem{
+						{Type: "command", Command: "~/.claude/hooks/smart-lint.sh"},
+						{Type: "command", Command: "~/.claude/hooks/ntfy-notifier.sh"},
+					},
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, []string{"~/.claude/hooks/smart-lint.sh"}, merger.DetectDuplicateCommands(settings))
+	assert.Empty(t, merger.DetectDuplicateCommands(&claude.Settings{}))
+	assert.Empty(t, merger.DetectDuplicateCommands(nil))
+}
+
+func TestSettingsJsonMerger_MergeSettings_SubagentStopAndPreCompact(t *testing.T) {
+	merger := NewSettingsJSONMerger()
+
+	dest := &claude.Settings{
+		Hooks: &claude.HooksConfig{
+			SubagentStop: []*claude.HookRule{
+				{Matcher: "", Hooks: []*claude.HookItem{{Type: "command", Command: "~/.claude/hooks/ntfy-notifier.sh subagent_stop"}}},
+			},
+		},
+	}
+	source := &claude.Settings{
+		Hooks: &claude.HooksConfig{
+			PreCompact: []*claude.HookRule{
+				{Matcher: "", Hooks: []*claude.HookItem{{Type: "command", Command: "~/.claude/hooks/ntfy-notifier.sh pre_compact"}}},
+			},
+		},
+	}
+
+	result, err := merger.MergeSettings(dest, source)
+	require.NoError(t, err)
+	require.NotNil(t, result.Hooks)
+	require.Len(t, result.Hooks.SubagentStop, 1)
+	assert.Equal(t, "~/.claude/hooks/ntfy-notifier.sh subagent_stop", result.Hooks.SubagentStop[0].Hooks[0].Command)
+	require.Len(t, result.Hooks.PreCompact, 1)
+	assert.Equal(t, "~/.claude/hooks/ntfy-notifier.sh pre_compact", result.Hooks.PreCompact[0].Hooks[0].Command)
+}
+
+func TestSettingsJsonMerger_MergeSettings_PreservesUnknownKeys(t *testing.T) {
+	merger := NewSettingsJSONMerger()
+
+	var dest claude.Settings
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"includeCoAuthoredBy": false,
+		"env": {"KEEP": "1"},
+		"statusLine": {"type": "command", "command": "~/.claude/statusline.js"},
+		"customSetting": {"nested": [1, 2, 3], "enabled": true},
+		"permissions": {"allow": ["Bash(go test:*)"]}
+	}`), &dest))
+
+	source := &claude.Settings{
+		IncludeCoAuthoredBy: true,
+		Env:                 map[string]string{"NEW_VAR": "new"},
+	}
+
+	result, err := merger.MergeSettings(&dest, source)
+	require.NoError(t, err)
+
+	assert.True(t, result.IncludeCoAuthoredBy)
+	assert.Equal(t, map[string]string{"KEEP": "1", "NEW_VAR": "new"}, result.Env)
+	assert.Equal(t, "~/.claude/statusline.js", (*result.StatusLine)["command"])
+
+	data, err := json.Marshal(result)
+	require.NoError(t, err)
+
+	var raw map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &raw))
+	assert.Equal(t, map[string]interface{}{"nested": []interface{}{1.0, 2.0, 3.0}, "enabled": true}, raw["customSetting"])
+	assert.Contains(t, raw, "permissions")
+
+	// The destination must not be modified by the merge
+	assert.Equal(t, map[string]string{"KEEP": "1"}, dest.Env)
+}
+
+func TestSettingsJsonMerger_MergeSettings_MatcherSubsets(t *testing.T) {
+	rule := func(matcher, command string) *claude.HookRule {
+		return &claude.HookRule{
+			Matcher: matcher,
+			Hooks:   []*claude.HookItem{{Type: "command", Command: command}},
+		}
+	}
+
+	tests := []struct {
+		name         string
+		destMatcher  string
+		srcMatcher   string
+		wantMatchers []string
+	}{
+		{
+			name:         "source is a subset of destination",
+			destMatcher:  "Write|Edit|MultiEdit",
+			srcMatcher:   "Edit",
+			wantMatchers: []string{"Write|Edit|MultiEdit"},
+		},
+		{
+			name:         "source is a superset of destination",
+			destMatcher:  "Edit",
+			srcMatcher:   "MultiEdit|Edit|Write",
+			wantMatchers: []string{"MultiEdit|Edit|Write"},
+		},
+		{
+			name:         "same tools in a different order",
+			destMatcher:  "Edit|Write",
+			srcMatcher:   "Write|Edit",
+			wantMatchers: []string{"Edit|Write"},
+		},
+		{
+			name:         "partial overlap stays separate",
+			destMatcher:  "Write|Edit",
+			srcMatcher:   "Edit|Bash",
+			wantMatchers: []string{"Edit|Bash", "Write|Edit"},
+		},
+		{
+			name:         "disjoint matchers stay separate",
+			destMatcher:  "Write|Edit",
+			srcMatcher:   "MultiEdit|Notebook",
+			wantMatchers: []string{"MultiEdit|Notebook", "Write|Edit"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merger := NewSettingsJSONMerger()
+			dest := &claude.Settings{Hooks: &claude.HooksConfig{
+				PostToolUse: []*claude.HookRule{rule(tt.destMatcher, "dest.sh")},
+			}}
+			source := &claude.Settings{Hooks: &claude.HooksConfig{
+				PostToolUse: []*claude.HookRule{rule(tt.srcMatcher, "source.sh")},
+			}}
+
+			result, err := merger.MergeSettings(dest, source)
+			require.NoError(t, err)
+
+			var matchers []string
+			var commands []string
+			for _, r := range result.Hooks.PostToolUse {
+				matchers = append(matchers, r.Matcher)
+				for _, hook := range r.Hooks {
+					commands = append(commands, hook.Command)
+				}
+			}
+			assert.Equal(t, tt.wantMatchers, matchers)
+			assert.ElementsMatch(t, []string{"dest.sh", "source.sh"}, commands)
+		})
+	}
+}
@@ -1,12 +1,20 @@
 package file
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/pmezard/go-difflib/difflib"
 
 	"github.com/ooneko/claude-config/internal/claude"
+	"github.com/ooneko/claude-config/internal/config"
+	"github.com/ooneko/claude-config/internal/fsutil"
 )
 
 // Operations implements the FileOperations interface
@@ -14,6 +22,7 @@ type Operations struct {
 	sourceDir string
 	claudeDir string
 	merger    *SettingsJSONMerger
+	out       io.Writer // receives the dry-run report
 }
 
 // NewOperations creates a new file operations manager
@@ -22,6 +31,7 @@ func NewOperations(sourceDir, claudeDir string) *Operations {
 		sourceDir: sourceDir,
 		claudeDir: claudeDir,
 		merger:    NewSettingsJSONMerger(),
+		out:       os.Stdout,
 	}
 }
 
@@ -31,9 +41,11 @@ func (o *Operations) Copy(ctx context.Context, options *claude.CopyOptions) erro
 		options = &claude.CopyOptions{All: true}
 	}
 
-	// Ensure target directory exists
-	if err := os.MkdirAll(o.claudeDir, 0755); err != nil {
-		return fmt.Errorf("failed to create claude directory: %w", err)
+	// Ensure target directory exists, dry runs don't write anything
+	if !options.DryRun {
+		if err := os.MkdirAll(o.claudeDir, 0755); err != nil {
+			return fmt.Errorf("failed to create claude directory: %w", err)
+		}
 	}
 
 	var copyTargets []string
@@ -50,10 +62,16 @@ func (o *Operations) Copy(ctx context.Context, options *claude.CopyOptions) erro
 		if options.Hooks {
 			copyTargets = append(copyTargets, "hooks")
 		}
+		if options.OutputStyles {
+			copyTargets = append(copyTargets, "output-styles")
+		}
+		if options.StatuslineJs {
+			copyTargets = append(copyTargets, "statusline.js")
+		}
 	}
 
 	// Always process settings.json specially
-	if err := o.handleSettingsJSON(ctx); err != nil {
+	if err := o.handleSettingsJSON(ctx, options.DryRun); err != nil {
 		return fmt.Errorf("failed to handle settings.json: %w", err)
 	}
 
@@ -73,7 +91,14 @@ func (o *Operations) Copy(ctx context.Context, options *claude.CopyOptions) erro
 			destPath = filepath.Join(o.claudeDir, target)
 		}
 
-		if err := o.copyItem(sourcePath, destPath); err != nil {
+		if options.DryRun {
+			if err := o.previewItem(sourcePath, destPath); err != nil {
+				return fmt.Errorf("failed to preview %s: %w", target, err)
+			}
+			continue
+		}
+
+		if err := o.copyItem(ctx, sourcePath, destPath); err != nil {
 			return fmt.Errorf("failed to copy %s: %w", target, err)
 		}
 	}
@@ -81,8 +106,9 @@ func (o *Operations) Copy(ctx context.Context, options *claude.CopyOptions) erro
 	return nil
 }
 
-// handleSettingsJSON handles intelligent merging of settings.json
-func (o *Operations) handleSettingsJSON(_ context.Context) error {
+// handleSettingsJSON handles intelligent merging of settings.json. A dry run
+// prints a diff of the merge result instead of saving it.
+func (o *Operations) handleSettingsJSON(_ context.Context, dryRun bool) error {
 	sourcePath := filepath.Join(o.sourceDir, "settings.json")
 	destPath := filepath.Join(o.claudeDir, "settings.json")
 
@@ -106,12 +132,21 @@ func (o *Operations) handleSettingsJSON(_ context.Context) error {
 		}
 	}
 
+	// Pinned env keys may change between runs, so reload them per merge
+	if err := o.loadPinnedKeys(); err != nil {
+		return err
+	}
+
 	// Merge settings
 	mergedSettings, err := o.merger.MergeSettings(destSettings, sourceSettings)
 	if err != nil {
 		return fmt.Errorf("failed to merge settings: %w", err)
 	}
 
+	if dryRun {
+		return o.previewSettings(destPath, mergedSettings)
+	}
+
 	// Save merged settings
 	if err := o.saveSettings(destPath, mergedSettings); err != nil {
 		return fmt.Errorf("failed to save merged settings: %w", err)
@@ -120,8 +155,89 @@ func (o *Operations) handleSettingsJSON(_ context.Context) error {
 	return nil
 }
 
-// copyItem copies a file or directory recursively
-func (o *Operations) copyItem(src, dest string) error {
+// previewSettings prints how merging would change settings.json
+func (o *Operations) previewSettings(destPath string, merged *claude.Settings) error {
+	data, err := merged.MarshalJSON()
+	if err != nil {
+		return fmt.Errorf("failed to marshal settings: %w", err)
+	}
+
+	existing, err := os.ReadFile(destPath)
+	switch {
+	case os.IsNotExist(err):
+		fmt.Fprintln(o.out, "➕ 新建: settings.json")
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed to read settings file: %w", err)
+	case bytes.Equal(existing, data):
+		return nil
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(existing)),
+		B:        difflib.SplitLines(string(data)),
+		FromFile: "settings.json",
+		ToFile:   "settings.json (merged)",
+		Context:  3,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to diff settings: %w", err)
+	}
+
+	fmt.Fprintln(o.out, "🔀 合并: settings.json")
+	fmt.Fprint(o.out, diff)
+	return nil
+}
+
+// previewItem prints whether copying src would create or overwrite each file
+// under dest, skipping files whose content is unchanged
+func (o *Operations) previewItem(src, dest string) error {
+	if _, err := os.Stat(src); os.IsNotExist(err) {
+		return nil
+	}
+
+	return filepath.WalkDir(src, func(path string, entry os.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		relPath, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		destPath := filepath.Join(dest, relPath)
+		if relPath == "." {
+			destPath = dest
+		}
+		display, err := filepath.Rel(o.claudeDir, destPath)
+		if err != nil {
+			display = destPath
+		}
+		display = filepath.ToSlash(display)
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read source file: %w", err)
+		}
+		existing, err := os.ReadFile(destPath)
+		switch {
+		case os.IsNotExist(err):
+			fmt.Fprintf(o.out, "➕ 新建: %s\n", display)
+		case err != nil:
+			return fmt.Errorf("failed to read destination file: %w", err)
+		case !bytes.Equal(existing, data):
+			fmt.Fprintf(o.out, "📝 覆盖: %s\n", display)
+		}
+		return nil
+	})
+}
+
+// copyItem copies a file or directory recursively, stopping with ctx.Err()
+// once ctx is cancelled
+func (o *Operations) copyItem(ctx context.Context, src, dest string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	srcInfo, err := os.Stat(src)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -131,7 +247,7 @@ func (o *Operations) copyItem(src, dest string) error {
 	}
 
 	if srcInfo.IsDir() {
-		return o.copyDirectory(src, dest)
+		return o.copyDirectory(ctx, src, dest)
 	}
 
 	return o.copyFile(src, dest)
@@ -158,24 +274,15 @@ func (o *Operations) copyFile(src, dest string) error {
 	defer destFile.Close()
 
 	// Copy content
-	buffer := make([]byte, 32*1024)
-	for {
-		n, err := sourceFile.Read(buffer)
-		if n == 0 {
-			break
-		}
-		if err != nil && err.Error() != "EOF" {
-			return fmt.Errorf("failed to read source file: %w", err)
-		}
-
-		_, err = destFile.Write(buffer[:n])
-		if err != nil {
-			return fmt.Errorf("failed to write destination file: %w", err)
-		}
-
-		if err != nil && err.Error() == "EOF" {
-			break
-		}
+	if _, err := io.Copy(destFile, sourceFile); err != nil {
+		return fmt.Errorf("failed to copy file: %w", err)
+	}
+	// Flush to disk so a crash can't leave an empty file behind
+	if err := destFile.Sync(); err != nil {
+		return fmt.Errorf("failed to sync destination file: %w", err)
+	}
+	if err := destFile.Close(); err != nil {
+		return fmt.Errorf("failed to write destination file: %w", err)
 	}
 
 	// Copy permissions
@@ -188,7 +295,7 @@ func (o *Operations) copyFile(src, dest string) error {
 }
 
 // copyDirectory copies a directory recursively
-func (o *Operations) copyDirectory(src, dest string) error {
+func (o *Operations) copyDirectory(ctx context.Context, src, dest string) error {
 	srcInfo, err := os.Stat(src)
 	if err != nil {
 		return fmt.Errorf("failed to stat source directory: %w", err)
@@ -210,7 +317,7 @@ func (o *Operations) copyDirectory(src, dest string) error {
 		srcPath := filepath.Join(src, entry.Name())
 		destPath := filepath.Join(dest, entry.Name())
 
-		if err := o.copyItem(srcPath, destPath); err != nil {
+		if err := o.copyItem(ctx, srcPath, destPath); err != nil {
 			return fmt.Errorf("failed to copy %s: %w", entry.Name(), err)
 		}
 	}
@@ -240,7 +347,7 @@ func (o *Operations) saveSettings(path string, settings *claude.Settings) error
 		return fmt.Errorf("failed to marshal settings: %w", err)
 	}
 
-	if err := os.WriteFile(path, data, 0644); err != nil {
+	if err := fsutil.AtomicWriteFile(path, data, 0644); err != nil {
 		return fmt.Errorf("failed to write settings file: %w", err)
 	}
 
@@ -271,15 +378,6 @@ func (o *Operations) Compare(_ context.Context, sourcePath, destPath string) (*c
 		}, nil
 	}
 
-	// Compare file sizes
-	if sourceInfo.Size() != destInfo.Size() {
-		return &claude.CompareResult{
-			Same: false,
-			Differences: []string{fmt.Sprintf("File sizes differ: source=%d, dest=%d",
-				sourceInfo.Size(), destInfo.Size())},
-		}, nil
-	}
-
 	// Compare file contents
 	sourceData, err := os.ReadFile(sourcePath)
 	if err != nil {
@@ -291,19 +389,125 @@ func (o *Operations) Compare(_ context.Context, sourcePath, destPath string) (*c
 		return nil, fmt.Errorf("failed to read destination file: %w", err)
 	}
 
-	// Simple byte comparison
-	same := string(sourceData) == string(destData)
-	if same {
+	if bytes.Equal(sourceData, destData) {
 		return &claude.CompareResult{Same: true}, nil
 	}
 
+	// Binary files only get a summary
+	if !isText(sourceData) || !isText(destData) {
+		difference := "File contents differ"
+		if sourceInfo.Size() != destInfo.Size() {
+			difference = fmt.Sprintf("File sizes differ: source=%d, dest=%d", sourceInfo.Size(), destInfo.Size())
+		}
+		return &claude.CompareResult{
+			Same:        false,
+			Differences: []string{difference},
+		}, nil
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(destData)),
+		B:        difflib.SplitLines(string(sourceData)),
+		FromFile: destPath,
+		ToFile:   sourcePath,
+		Context:  3,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff files: %w", err)
+	}
+
 	return &claude.CompareResult{
 		Same:        false,
 		Differences: []string{"File contents differ"},
+		Diff:        diff,
 	}, nil
 }
 
+// CompareDirectory compares every regular file under sourceDir and destDir.
+// A missing directory counts as empty, so installing into a fresh directory
+// reports every source file as only in the source.
+func (o *Operations) CompareDirectory(ctx context.Context, sourceDir, destDir string) (*claude.DirCompareResult, error) {
+	sourceFiles, err := listFiles(sourceDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list source directory: %w", err)
+	}
+	destFiles, err := listFiles(destDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list destination directory: %w", err)
+	}
+
+	result := &claude.DirCompareResult{}
+	for file := range sourceFiles {
+		if !destFiles[file] {
+			result.OnlyInSource = append(result.OnlyInSource, file)
+			continue
+		}
+
+		fileResult, err := o.Compare(ctx, filepath.Join(sourceDir, file), filepath.Join(destDir, file))
+		if err != nil {
+			return nil, err
+		}
+		if !fileResult.Same {
+			result.Differing = append(result.Differing, file)
+		}
+	}
+	for file := range destFiles {
+		if !sourceFiles[file] {
+			result.OnlyInDest = append(result.OnlyInDest, file)
+		}
+	}
+
+	sort.Strings(result.OnlyInSource)
+	sort.Strings(result.OnlyInDest)
+	sort.Strings(result.Differing)
+	result.Same = len(result.OnlyInSource) == 0 && len(result.OnlyInDest) == 0 && len(result.Differing) == 0
+	return result, nil
+}
+
+// listFiles returns the regular files under dir as "/"-separated relative paths,
+// or none when dir doesn't exist
+func listFiles(dir string) (map[string]bool, error) {
+	files := make(map[string]bool)
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return files, nil
+	}
+
+	err := filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(relPath)] = true
+		return nil
+	})
+	return files, err
+}
+
+// isText reports whether data looks like text: valid UTF-8 without NUL bytes
+func isText(data []byte) bool {
+	return utf8.Valid(data) && bytes.IndexByte(data, 0) == -1
+}
+
 // MergeSettings provides direct access to settings merging
 func (o *Operations) MergeSettings(_ context.Context, source, dest *claude.Settings) (*claude.Settings, error) {
+	if err := o.loadPinnedKeys(); err != nil {
+		return nil, err
+	}
 	return o.merger.MergeSettings(dest, source)
 }
+
+// loadPinnedKeys passes the pinned env keys from the claude directory to the merger
+func (o *Operations) loadPinnedKeys() error {
+	keys, err := config.LoadPinnedEnv(o.claudeDir)
+	if err != nil {
+		return fmt.Errorf("failed to load pinned env keys: %w", err)
+	}
+	o.merger.SetPinnedKeys(keys)
+	return nil
+}
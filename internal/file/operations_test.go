@@ -1,8 +1,10 @@
 package file
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -107,6 +109,79 @@ func TestFileOperations_Copy_Selective(t *testing.T) {
 	assert.NoFileExists(t, filepath.Join(claudeDir, "hooks", "test-hook.sh"))
 }
 
+func TestFileOperations_Copy_SelectiveOutputStyles(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	claudeDir := filepath.Join(tempDir, ".claude")
+
+	require.NoError(t, os.MkdirAll(filepath.Join(sourceDir, "output-styles"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(sourceDir, "agents"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "output-styles", "concise.md"), []byte("style content"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "agents", "test-agent.md"), []byte("agent content"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "statusline.js"), []byte("console.log()"), 0644))
+
+	ops := NewOperations(sourceDir, claudeDir)
+	require.NoError(t, ops.Copy(context.Background(), &claude.CopyOptions{OutputStyles: true}))
+
+	assert.FileExists(t, filepath.Join(claudeDir, "output-styles", "concise.md"))
+	assert.NoDirExists(t, filepath.Join(claudeDir, "agents"))
+	assert.NoFileExists(t, filepath.Join(claudeDir, "statusline.js"))
+}
+
+func TestFileOperations_Copy_SelectiveStatuslineJs(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	claudeDir := filepath.Join(tempDir, ".claude")
+
+	require.NoError(t, os.MkdirAll(filepath.Join(sourceDir, "output-styles"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "output-styles", "concise.md"), []byte("style content"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "statusline.js"), []byte("console.log()"), 0644))
+
+	ops := NewOperations(sourceDir, claudeDir)
+	require.NoError(t, ops.Copy(context.Background(), &claude.CopyOptions{StatuslineJs: true}))
+
+	content, err := os.ReadFile(filepath.Join(claudeDir, "statusline.js"))
+	require.NoError(t, err)
+	assert.Equal(t, "console.log()", string(content))
+	assert.NoDirExists(t, filepath.Join(claudeDir, "output-styles"))
+}
+
+func TestFileOperations_Copy_Cancelled(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	claudeDir := filepath.Join(tempDir, ".claude")
+
+	agentsDir := filepath.Join(sourceDir, "agents")
+	require.NoError(t, os.MkdirAll(agentsDir, 0755))
+	for i := 0; i < 10; i++ {
+		require.NoError(t, os.WriteFile(filepath.Join(agentsDir, fmt.Sprintf("agent-%d.md", i)), []byte("agent"), 0644))
+	}
+
+	ops := NewOperations(sourceDir, claudeDir)
+	err := ops.Copy(&cancelAfter{Context: context.Background(), remaining: 4}, &claude.CopyOptions{Agents: true})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	entries, err := os.ReadDir(filepath.Join(claudeDir, "agents"))
+	require.NoError(t, err)
+	assert.NotEmpty(t, entries)
+	assert.Less(t, len(entries), 10, "cancellation should stop the copy partway")
+}
+
+// cancelAfter reports cancellation once Err has been called remaining times
+type cancelAfter struct {
+	context.Context
+	remaining int
+}
+
+func (c *cancelAfter) Err() error {
+	if c.remaining <= 0 {
+		return context.Canceled
+	}
+	c.remaining--
+	return nil
+}
+
 func TestFileOperations_Copy_SettingsIntelligentMerge(t *testing.T) {
 	// Setup temp directories
 	tempDir := t.TempDir()
@@ -220,6 +295,46 @@ func TestFileOperations_Copy_SettingsIntelligentMerge(t *testing.T) {
 	assert.Equal(t, "~/.claude/hooks/ntfy-notifier.sh", mergedSettings.Hooks.Stop[0].Hooks[0].Command)
 }
 
+func TestFileOperations_Copy_DryRun(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	claudeDir := filepath.Join(tempDir, ".claude")
+	require.NoError(t, os.MkdirAll(filepath.Join(sourceDir, "agents"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(claudeDir, "agents"), 0755))
+
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "agents", "new.md"), []byte("new"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "agents", "changed.md"), []byte("v2"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "agents", "same.md"), []byte("same"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(claudeDir, "agents", "changed.md"), []byte("v1"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(claudeDir, "agents", "same.md"), []byte("same"), 0644))
+
+	destSettings := []byte(`{"env": {"http_proxy": "http://127.0.0.1:7890"}}`)
+	require.NoError(t, os.WriteFile(filepath.Join(claudeDir, "settings.json"), destSettings, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "settings.json"), []byte(`{"includeCoAuthoredBy": true}`), 0644))
+
+	var out bytes.Buffer
+	ops := NewOperations(sourceDir, claudeDir)
+	ops.out = &out
+
+	require.NoError(t, ops.Copy(context.Background(), &claude.CopyOptions{All: true, DryRun: true}))
+
+	// Nothing is written
+	assert.NoFileExists(t, filepath.Join(claudeDir, "agents", "new.md"))
+	data, err := os.ReadFile(filepath.Join(claudeDir, "agents", "changed.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "v1", string(data))
+	data, err = os.ReadFile(filepath.Join(claudeDir, "settings.json"))
+	require.NoError(t, err)
+	assert.Equal(t, destSettings, data)
+
+	report := out.String()
+	assert.Contains(t, report, "➕ 新建: agents/new.md")
+	assert.Contains(t, report, "📝 覆盖: agents/changed.md")
+	assert.NotContains(t, report, "same.md")
+	assert.Contains(t, report, "🔀 合并: settings.json")
+	assert.Contains(t, report, `+  "includeCoAuthoredBy": true`)
+}
+
 func TestFileOperations_Compare(t *testing.T) {
 	tempDir := t.TempDir()
 
@@ -255,6 +370,36 @@ func TestFileOperations_Compare(t *testing.T) {
 	assert.Contains(t, result.Differences[0], "Destination file does not exist")
 }
 
+func TestFileOperations_Compare_Diff(t *testing.T) {
+	tempDir := t.TempDir()
+	sourcePath := filepath.Join(tempDir, "source.json")
+	destPath := filepath.Join(tempDir, "dest.json")
+	require.NoError(t, os.WriteFile(sourcePath, []byte("{\n  \"a\": 1,\n  \"b\": 3\n}\n"), 0644))
+	require.NoError(t, os.WriteFile(destPath, []byte("{\n  \"a\": 1,\n  \"b\": 2\n}\n"), 0644))
+
+	ops := NewOperations("", "")
+	ctx := context.Background()
+
+	// Text files get a unified diff from the destination to the source
+	result, err := ops.Compare(ctx, sourcePath, destPath)
+	require.NoError(t, err)
+	assert.False(t, result.Same)
+	assert.Contains(t, result.Diff, "--- "+destPath)
+	assert.Contains(t, result.Diff, "+++ "+sourcePath)
+	assert.Contains(t, result.Diff, "-  \"b\": 2\n")
+	assert.Contains(t, result.Diff, "+  \"b\": 3\n")
+	assert.Contains(t, result.Diff, "   \"a\": 1,\n")
+
+	// Binary files fall back to the summary
+	binaryPath := filepath.Join(tempDir, "image.bin")
+	require.NoError(t, os.WriteFile(binaryPath, []byte{0x89, 'P', 'N', 'G', 0x00, 0x01}, 0644))
+	result, err = ops.Compare(ctx, binaryPath, destPath)
+	require.NoError(t, err)
+	assert.False(t, result.Same)
+	assert.Empty(t, result.Diff)
+	assert.Contains(t, result.Differences[0], "File sizes differ")
+}
+
 func TestFileOperations_CopyFilePermissions(t *testing.T) {
 	tempDir := t.TempDir()
 	sourceDir := filepath.Join(tempDir, "source")
@@ -288,3 +433,60 @@ func TestFileOperations_CopyFilePermissions(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, os.FileMode(0644), regularInfo.Mode().Perm())
 }
+
+func TestFileOperations_CopyFile_PartialBuffer(t *testing.T) {
+	tempDir := t.TempDir()
+	src := filepath.Join(tempDir, "large.bin")
+	dest := filepath.Join(tempDir, "copy", "large.bin")
+
+	// Two full 32KB reads plus a short final read
+	data := make([]byte, 2*32*1024+123)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	require.NoError(t, os.WriteFile(src, data, 0644))
+
+	require.NoError(t, NewOperations("", "").copyFile(src, dest))
+
+	copied, err := os.ReadFile(dest)
+	require.NoError(t, err)
+	assert.Equal(t, data, copied)
+}
+
+func TestFileOperations_CompareDirectory(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	destDir := filepath.Join(tempDir, "dest")
+
+	write := func(dir, name, content string) {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	write(sourceDir, "same.md", "same")
+	write(destDir, "same.md", "same")
+	write(sourceDir, "nested/changed.md", "new")
+	write(destDir, "nested/changed.md", "old")
+	write(sourceDir, "nested/added.md", "added")
+	write(destDir, "custom.md", "user file")
+
+	ops := NewOperations("", "")
+	ctx := context.Background()
+
+	result, err := ops.CompareDirectory(ctx, sourceDir, destDir)
+	require.NoError(t, err)
+	assert.False(t, result.Same)
+	assert.Equal(t, []string{"nested/added.md"}, result.OnlyInSource)
+	assert.Equal(t, []string{"custom.md"}, result.OnlyInDest)
+	assert.Equal(t, []string{"nested/changed.md"}, result.Differing)
+
+	// A missing destination counts as empty
+	result, err = ops.CompareDirectory(ctx, sourceDir, filepath.Join(tempDir, "missing"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"nested/added.md", "nested/changed.md", "same.md"}, result.OnlyInSource)
+	assert.Empty(t, result.Differing)
+
+	result, err = ops.CompareDirectory(ctx, sourceDir, sourceDir)
+	require.NoError(t, err)
+	assert.True(t, result.Same)
+}
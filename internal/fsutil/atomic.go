@@ -0,0 +1,52 @@
+// Package fsutil provides file system helpers shared by the managers.
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// AtomicWriteFile writes data to path so that readers, and path itself after
+// a crash, see either the old content or the new content but never a partial
+// write. The data goes to a temporary file in the same directory, which is
+// synced and then renamed over path. A symlinked path is resolved first so
+// the link is kept and its target is replaced.
+//
+// Like os.WriteFile, perm only applies when path is created: an existing
+// file keeps its mode, so a settings.json the user restricted to 0600 stays so.
+func AtomicWriteFile(path string, data []byte, perm os.FileMode) (err error) {
+	if resolved, evalErr := filepath.EvalSymlinks(path); evalErr == nil {
+		path = resolved
+	}
+	if info, statErr := os.Stat(path); statErr == nil && info.Mode().IsRegular() {
+		perm = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmpPath)
+		}
+	}()
+
+	if _, err = tmp.Write(data); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	// CreateTemp always uses 0600
+	if err = os.Chmod(tmpPath, perm); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
@@ -0,0 +1,87 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dirEntries returns the names of the files in dir
+func dirEntries(t *testing.T, dir string) []string {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+func TestAtomicWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "settings.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"old": true}`), 0600))
+
+	require.NoError(t, AtomicWriteFile(path, []byte(`{"new": true}`), 0644))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, `{"new": true}`, string(data))
+	assert.Equal(t, []string{"settings.json"}, dirEntries(t, dir))
+
+	if runtime.GOOS != "windows" {
+		// An existing file keeps its mode
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+		// A new file gets perm
+		created := filepath.Join(dir, "created.json")
+		require.NoError(t, AtomicWriteFile(created, []byte(`{}`), 0644))
+		info, err = os.Stat(created)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0644), info.Mode().Perm())
+	}
+}
+
+func TestAtomicWriteFile_FailureLeavesNoTempFile(t *testing.T) {
+	dir := t.TempDir()
+
+	// Renaming a file over a non-empty directory fails after the data is written
+	path := filepath.Join(dir, "settings.json")
+	require.NoError(t, os.MkdirAll(filepath.Join(path, "child"), 0755))
+
+	require.Error(t, AtomicWriteFile(path, []byte(`{}`), 0644))
+	assert.Equal(t, []string{"settings.json"}, dirEntries(t, dir))
+
+	// A missing directory fails before anything is written
+	require.Error(t, AtomicWriteFile(filepath.Join(dir, "missing", "settings.json"), []byte(`{}`), 0644))
+	assert.Equal(t, []string{"settings.json"}, dirEntries(t, dir))
+}
+
+func TestAtomicWriteFile_KeepsSymlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("creating symlinks needs extra privileges on Windows")
+	}
+
+	dir := t.TempDir()
+	target := filepath.Join(dir, "dotfiles-settings.json")
+	link := filepath.Join(dir, "settings.json")
+	require.NoError(t, os.WriteFile(target, []byte(`{}`), 0644))
+	require.NoError(t, os.Symlink(target, link))
+
+	require.NoError(t, AtomicWriteFile(link, []byte(`{"new": true}`), 0644))
+
+	info, err := os.Lstat(link)
+	require.NoError(t, err)
+	assert.NotZero(t, info.Mode()&os.ModeSymlink)
+	data, err := os.ReadFile(target)
+	require.NoError(t, err)
+	assert.Equal(t, `{"new": true}`, string(data))
+}
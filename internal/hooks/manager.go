@@ -0,0 +1,212 @@
+package hooks
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ooneko/claude-config/internal/claude"
+	"github.com/ooneko/claude-config/internal/settingsstore"
+)
+
+// Hook event names used as defaults; every event in claude.HookEventNames can be edited
+const (
+	EventPostToolUse  = "PostToolUse"
+	EventStop         = "Stop"
+	EventNotification = "Notification"
+)
+
+// Manager edits hook rules in settings.json
+type Manager struct {
+	claudeDir string
+}
+
+// NewManager creates a new hooks manager
+func NewManager(claudeDir string) *Manager {
+	return &Manager{
+		claudeDir: claudeDir,
+	}
+}
+
+// RemoveMatcherToken drops token from the matcher of the rule identified by
+// event and matcher, keeping the rule's hooks. Matchers are compared without
+// regard to token order. It returns the resulting matcher and whether the
+// settings were changed; a token that is not part of the matcher is a no-op.
+func (m *Manager) RemoveMatcherToken(_ context.Context, event, matcher, token string) (string, bool, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return "", false, fmt.Errorf("token cannot be empty")
+	}
+
+	settings, err := m.loadSettings()
+	if err != nil {
+		return "", false, fmt.Errorf("failed to load settings: %w", err)
+	}
+
+	if settings.Hooks == nil {
+		settings.Hooks = &claude.HooksConfig{}
+	}
+	rules := settings.Hooks.Rules(event)
+	if rules == nil {
+		return "", false, unsupportedEventError(event)
+	}
+
+	rule := findRule(*rules, matcher)
+	if rule == nil {
+		return "", false, fmt.Errorf("no %s rule with matcher %q", event, matcher)
+	}
+
+	tokens := splitMatcher(rule.Matcher)
+	remaining := make([]string, 0, len(tokens))
+	for _, t := range tokens {
+		if t != token {
+			remaining = append(remaining, t)
+		}
+	}
+
+	newMatcher := strings.Join(remaining, "|")
+	if len(remaining) == len(tokens) {
+		return newMatcher, false, nil
+	}
+
+	// An empty matcher would match every tool, which is never what removing a token means
+	if len(remaining) == 0 {
+		return "", false, fmt.Errorf("cannot remove the only token %q from matcher", token)
+	}
+
+	rule.Matcher = newMatcher
+	if err := m.saveSettings(settings); err != nil {
+		return "", false, fmt.Errorf("failed to save settings: %w", err)
+	}
+
+	return newMatcher, true, nil
+}
+
+// AddHook adds hook to the event rule whose matcher has the same tools as
+// matcher, creating the rule when there is none. Like the settings merger it
+// de-duplicates by command: if the rule already runs hook.Command nothing is
+// changed and false is returned.
+func AddHook(hooksConfig *claude.HooksConfig, event, matcher string, hook *claude.HookItem) (bool, error) {
+	if strings.TrimSpace(hook.Command) == "" {
+		return false, fmt.Errorf("command cannot be empty")
+	}
+
+	rules := hooksConfig.Rules(event)
+	if rules == nil {
+		return false, unsupportedEventError(event)
+	}
+
+	rule := findRule(*rules, matcher)
+	if rule == nil {
+		*rules = append(*rules, &claude.HookRule{
+			Matcher: matcher,
+			Hooks:   []*claude.HookItem{hook},
+		})
+		return true, nil
+	}
+
+	for _, existing := range rule.Hooks {
+		if existing.Command == hook.Command {
+			return false, nil
+		}
+	}
+	rule.Hooks = append(rule.Hooks, hook)
+	return true, nil
+}
+
+// RemoveHook removes command from every rule of the event, dropping rules
+// left without hooks. It returns the number of hooks removed.
+func RemoveHook(hooksConfig *claude.HooksConfig, event, command string) (int, error) {
+	if hooksConfig == nil {
+		hooksConfig = &claude.HooksConfig{}
+	}
+
+	rules := hooksConfig.Rules(event)
+	if rules == nil {
+		return 0, unsupportedEventError(event)
+	}
+
+	removed := 0
+	remainingRules := (*rules)[:0]
+	for _, rule := range *rules {
+		remainingHooks := rule.Hooks[:0]
+		for _, hook := range rule.Hooks {
+			if hook.Command == command {
+				removed++
+				continue
+			}
+			remainingHooks = append(remainingHooks, hook)
+		}
+		rule.Hooks = remainingHooks
+
+		if len(rule.Hooks) > 0 {
+			remainingRules = append(remainingRules, rule)
+		}
+	}
+
+	if len(remainingRules) == 0 {
+		remainingRules = nil
+	}
+	*rules = remainingRules
+	return removed, nil
+}
+
+// unsupportedEventError reports an event that HooksConfig does not model
+func unsupportedEventError(event string) error {
+	return fmt.Errorf("unsupported hook event: %s (supported: %s)", event, strings.Join(claude.HookEventNames(), ", "))
+}
+
+// findRule returns the rule whose matcher has the same tokens as matcher
+func findRule(rules []*claude.HookRule, matcher string) *claude.HookRule {
+	want := tokenSet(matcher)
+	for _, rule := range rules {
+		got := tokenSet(rule.Matcher)
+		if len(got) != len(want) {
+			continue
+		}
+		same := true
+		for t := range want {
+			if !got[t] {
+				same = false
+				break
+			}
+		}
+		if same {
+			return rule
+		}
+	}
+	return nil
+}
+
+// splitMatcher splits a matcher into trimmed, de-duplicated tokens in their original order
+func splitMatcher(matcher string) []string {
+	seen := make(map[string]bool)
+	var tokens []string
+	for _, part := range strings.Split(matcher, "|") {
+		part = strings.TrimSpace(part)
+		if part != "" && !seen[part] {
+			tokens = append(tokens, part)
+			seen[part] = true
+		}
+	}
+	return tokens
+}
+
+// tokenSet returns the matcher tokens as a set
+func tokenSet(matcher string) map[string]bool {
+	set := make(map[string]bool)
+	for _, t := range splitMatcher(matcher) {
+		set[t] = true
+	}
+	return set
+}
+
+// loadSettings loads settings from settings.json
+func (m *Manager) loadSettings() (*claude.Settings, error) {
+	return settingsstore.Load(m.claudeDir)
+}
+
+// saveSettings saves settings to settings.json
+func (m *Manager) saveSettings(settings *claude.Settings) error {
+	return settingsstore.Save(m.claudeDir, settings)
+}
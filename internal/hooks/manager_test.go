@@ -0,0 +1,186 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ooneko/claude-config/internal/claude"
+)
+
+// writeSettings writes settings.json into claudeDir
+func writeSettings(t *testing.T, claudeDir string, settings *claude.Settings) {
+	t.Helper()
+
+	require.NoError(t, os.MkdirAll(claudeDir, 0755))
+	data, err := json.MarshalIndent(settings, "", "  ")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(claudeDir, "settings.json"), data, 0644))
+}
+
+// readSettings reads settings.json from claudeDir
+func readSettings(t *testing.T, claudeDir string) *claude.Settings {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join(claudeDir, "settings.json"))
+	require.NoError(t, err)
+	var settings claude.Settings
+	require.NoError(t, json.Unmarshal(data, &settings))
+	return &settings
+}
+
+func lintSettings() *claude.Settings {
+	return &claude.Settings{
+		Hooks: &claude.HooksConfig{
+			PostToolUse: []*claude.HookRule{
+				{
+					Matcher: "Write|Edit|MultiEdit",
+					Hooks: []*claude.HookItem{
+						{Type: "command", Command: "~/.claude/hooks/smart-lint.sh"},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestManager_RemoveMatcherToken(t *testing.T) {
+	claudeDir := filepath.Join(t.TempDir(), ".claude")
+	writeSettings(t, claudeDir, lintSettings())
+	manager := NewManager(claudeDir)
+	ctx := context.Background()
+
+	// Token order in the lookup matcher does not matter
+	matcher, changed, err := manager.RemoveMatcherToken(ctx, EventPostToolUse, "MultiEdit|Edit|Write", "MultiEdit")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "Write|Edit", matcher)
+
+	settings := readSettings(t, claudeDir)
+	require.Len(t, settings.Hooks.PostToolUse, 1)
+	rule := settings.Hooks.PostToolUse[0]
+	assert.Equal(t, "Write|Edit", rule.Matcher)
+	require.Len(t, rule.Hooks, 1)
+	assert.Equal(t, "~/.claude/hooks/smart-lint.sh", rule.Hooks[0].Command)
+}
+
+func TestManager_RemoveMatcherToken_TokenAbsent(t *testing.T) {
+	claudeDir := filepath.Join(t.TempDir(), ".claude")
+	writeSettings(t, claudeDir, lintSettings())
+	manager := NewManager(claudeDir)
+
+	before, err := os.ReadFile(filepath.Join(claudeDir, "settings.json"))
+	require.NoError(t, err)
+
+	matcher, changed, err := manager.RemoveMatcherToken(context.Background(), EventPostToolUse, "Write|Edit|MultiEdit", "Bash")
+	require.NoError(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, "Write|Edit|MultiEdit", matcher)
+
+	after, err := os.ReadFile(filepath.Join(claudeDir, "settings.json"))
+	require.NoError(t, err)
+	assert.Equal(t, string(before), string(after))
+}
+
+func TestManager_RemoveMatcherToken_PreToolUse(t *testing.T) {
+	claudeDir := filepath.Join(t.TempDir(), ".claude")
+	settings := &claude.Settings{Hooks: &claude.HooksConfig{
+		PreToolUse: []*claude.HookRule{{
+			Matcher: "Write|Edit|Bash",
+			Hooks:   []*claude.HookItem{{Type: "command", Command: "~/.claude/hooks/protect-files.sh"}},
+		}},
+	}}
+	writeSettings(t, claudeDir, settings)
+
+	matcher, changed, err := NewManager(claudeDir).RemoveMatcherToken(context.Background(), "PreToolUse", "Bash|Edit|Write", "Bash")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "Write|Edit", matcher)
+}
+
+func TestManager_RemoveMatcherToken_Errors(t *testing.T) {
+	claudeDir := filepath.Join(t.TempDir(), ".claude")
+	settings := lintSettings()
+	settings.Hooks.PostToolUse = append(settings.Hooks.PostToolUse, &claude.HookRule{
+		Matcher: "Bash",
+		Hooks:   []*claude.HookItem{{Type: "command", Command: "echo bash"}},
+	})
+	writeSettings(t, claudeDir, settings)
+	manager := NewManager(claudeDir)
+	ctx := context.Background()
+
+	_, _, err := manager.RemoveMatcherToken(ctx, "UserPromptSubmit", "Bash", "Bash")
+	assert.ErrorContains(t, err, "unsupported hook event")
+
+	_, _, err = manager.RemoveMatcherToken(ctx, "PreToolUse", "Bash", "Bash")
+	assert.ErrorContains(t, err, "no PreToolUse rule")
+
+	_, _, err = manager.RemoveMatcherToken(ctx, EventPostToolUse, "Read", "Read")
+	assert.ErrorContains(t, err, "no PostToolUse rule")
+
+	_, _, err = manager.RemoveMatcherToken(ctx, EventPostToolUse, "Bash", "Bash")
+	assert.ErrorContains(t, err, "only token")
+}
+
+func TestAddHook(t *testing.T) {
+	hooksConfig := lintSettings().Hooks
+
+	// Matchers are compared without regard to tool order
+	added, err := AddHook(hooksConfig, EventPostToolUse, "Edit|Write|MultiEdit", &claude.HookItem{Type: "command", Command: "~/foo.sh", Timeout: 60})
+	require.NoError(t, err)
+	assert.True(t, added)
+	require.Len(t, hooksConfig.PostToolUse, 1)
+	require.Len(t, hooksConfig.PostToolUse[0].Hooks, 2)
+	assert.Equal(t, 60, hooksConfig.PostToolUse[0].Hooks[1].Timeout)
+
+	// Adding the same command again is a no-op
+	added, err = AddHook(hooksConfig, EventPostToolUse, "Write|Edit|MultiEdit", &claude.HookItem{Type: "command", Command: "~/foo.sh"})
+	require.NoError(t, err)
+	assert.False(t, added)
+	assert.Len(t, hooksConfig.PostToolUse[0].Hooks, 2)
+
+	// A new matcher or event gets its own rule
+	added, err = AddHook(hooksConfig, "PreCompact", "", &claude.HookItem{Type: "command", Command: "~/foo.sh"})
+	require.NoError(t, err)
+	assert.True(t, added)
+	require.Len(t, hooksConfig.PreCompact, 1)
+	assert.Equal(t, "", hooksConfig.PreCompact[0].Matcher)
+
+	_, err = AddHook(hooksConfig, "Unknown", "", &claude.HookItem{Type: "command", Command: "~/foo.sh"})
+	assert.ErrorContains(t, err, "unsupported hook event")
+	_, err = AddHook(hooksConfig, EventStop, "", &claude.HookItem{Type: "command", Command: " "})
+	assert.ErrorContains(t, err, "command cannot be empty")
+}
+
+func TestRemoveHook(t *testing.T) {
+	hooksConfig := lintSettings().Hooks
+	_, err := AddHook(hooksConfig, EventPostToolUse, "Bash", &claude.HookItem{Type: "command", Command: "~/foo.sh"})
+	require.NoError(t, err)
+	_, err = AddHook(hooksConfig, EventPostToolUse, "Write", &claude.HookItem{Type: "command", Command: "~/foo.sh"})
+	require.NoError(t, err)
+	require.Len(t, hooksConfig.PostToolUse, 3)
+
+	removed, err := RemoveHook(hooksConfig, EventPostToolUse, "~/foo.sh")
+	require.NoError(t, err)
+	assert.Equal(t, 2, removed)
+
+	// Rules left without hooks are dropped, other rules are kept
+	require.Len(t, hooksConfig.PostToolUse, 1)
+	assert.Equal(t, "Write|Edit|MultiEdit", hooksConfig.PostToolUse[0].Matcher)
+
+	removed, err = RemoveHook(hooksConfig, EventPostToolUse, "~/foo.sh")
+	require.NoError(t, err)
+	assert.Zero(t, removed)
+
+	removed, err = RemoveHook(nil, EventStop, "~/foo.sh")
+	require.NoError(t, err)
+	assert.Zero(t, removed)
+
+	_, err = RemoveHook(hooksConfig, "Unknown", "~/foo.sh")
+	assert.ErrorContains(t, err, "unsupported hook event")
+}
@@ -0,0 +1,155 @@
+package hooks
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/ooneko/claude-config/internal/claude"
+)
+
+// ClaudeDirToken stands for the claude directory in hook command templates
+const ClaudeDirToken = "${CLAUDE_DIR}"
+
+// defaultClaudeDirRef is how commands refer to the default claude directory
+const defaultClaudeDirRef = "~/.claude"
+
+// ExpandCommand resolves ${CLAUDE_DIR} and ~/.claude in a hook command template
+// against claudeDir. The default directory is kept as ~/.claude so settings.json
+// stays portable between machines; any other directory is written out in full,
+// single-quoted when it contains whitespace so the shell keeps it as one word.
+func ExpandCommand(command, claudeDir string) string {
+	ref := claudeDirRef(claudeDir)
+	command = strings.ReplaceAll(command, ClaudeDirToken, ref)
+	if ref != defaultClaudeDirRef {
+		command = strings.ReplaceAll(command, defaultClaudeDirRef+"/", ref+"/")
+	}
+	return command
+}
+
+// claudeDirRef returns how hook commands refer to claudeDir
+func claudeDirRef(claudeDir string) string {
+	homeDir, err := os.UserHomeDir()
+	if err == nil && filepath.Clean(claudeDir) == filepath.Join(homeDir, ".claude") {
+		return defaultClaudeDirRef
+	}
+	ref := filepath.ToSlash(filepath.Clean(claudeDir))
+	if strings.IndexFunc(ref, unicode.IsSpace) >= 0 {
+		return shellQuote(ref)
+	}
+	return ref
+}
+
+// shellQuote wraps s in single quotes for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// firstWord returns the first shell word of command with quotes and
+// backslash escapes removed, so quoted script paths containing spaces are
+// returned whole. ok is false when command is empty or a quote is unterminated.
+func firstWord(command string) (word string, ok bool) {
+	command = strings.TrimLeftFunc(command, unicode.IsSpace)
+	var b strings.Builder
+	var quote rune
+	escaped := false
+	for _, r := range command {
+		switch {
+		case escaped:
+			b.WriteRune(r)
+			escaped = false
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else if r == '\\' && quote == '"' {
+				escaped = true
+			} else {
+				b.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '\\':
+			escaped = true
+		case unicode.IsSpace(r):
+			return b.String(), b.Len() > 0
+		default:
+			b.WriteRune(r)
+		}
+	}
+	if quote != 0 || escaped {
+		return "", false
+	}
+	return b.String(), b.Len() > 0
+}
+
+// ScriptPath returns the script file run by a hook command, or false when the
+// command doesn't reference a script by path (e.g. "npx prettier"). Scripts
+// under ~/.claude/ resolve to claudeDir so non-default config directories work.
+// The script may be quoted, as ExpandCommand does for directories with spaces.
+func ScriptPath(command, claudeDir, homeDir string) (string, bool) {
+	script, ok := firstWord(command)
+	if !ok {
+		return "", false
+	}
+
+	switch {
+	case strings.HasPrefix(script, "~/.claude/"):
+		return filepath.Join(claudeDir, strings.TrimPrefix(script, "~/.claude/")), true
+	case strings.HasPrefix(script, "~/"):
+		if homeDir == "" {
+			return "", false
+		}
+		return filepath.Join(homeDir, strings.TrimPrefix(script, "~/")), true
+	case filepath.IsAbs(script):
+		return script, true
+	default:
+		return "", false
+	}
+}
+
+// ValidateCommands checks that the scripts referenced by hook commands in
+// settings exist and are executable. It returns one warning per problem,
+// sorted by command; commands that don't reference a script are skipped.
+func ValidateCommands(settings *claude.Settings, claudeDir string) []string {
+	if settings == nil {
+		return nil
+	}
+	homeDir, _ := os.UserHomeDir()
+
+	seen := make(map[string]bool)
+	var commands []string
+	for _, rules := range settings.Hooks.Events() {
+		for _, rule := range rules {
+			for _, hook := range rule.Hooks {
+				if hook != nil && hook.Command != "" && !seen[hook.Command] {
+					seen[hook.Command] = true
+					commands = append(commands, hook.Command)
+				}
+			}
+		}
+	}
+	sort.Strings(commands)
+
+	var warnings []string
+	for _, command := range commands {
+		path, ok := ScriptPath(command, claudeDir, homeDir)
+		if !ok {
+			continue
+		}
+
+		info, err := os.Stat(path)
+		switch {
+		case os.IsNotExist(err):
+			warnings = append(warnings, fmt.Sprintf("%s: 脚本 %s 不存在", command, path))
+		case err != nil:
+			warnings = append(warnings, fmt.Sprintf("%s: 无法访问脚本 %s: %v", command, path, err))
+		case info.IsDir() || info.Mode().Perm()&0111 == 0:
+			warnings = append(warnings, fmt.Sprintf("%s: 脚本 %s 不可执行", command, path))
+		}
+	}
+
+	return warnings
+}
@@ -0,0 +1,101 @@
+package hooks
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ooneko/claude-config/internal/claude"
+)
+
+func TestScriptPath(t *testing.T) {
+	tests := []struct {
+		command string
+		want    string
+		ok      bool
+	}{
+		{"~/.claude/hooks/smart-lint.sh --staged", "/cfg/hooks/smart-lint.sh", true},
+		{"~/bin/notify.sh", "/home/me/bin/notify.sh", true},
+		{"/usr/local/bin/hook", "/usr/local/bin/hook", true},
+		{"'/my cfg/hooks/smart-lint.sh' --staged", "/my cfg/hooks/smart-lint.sh", true},
+		{"'/my cfg'/hooks/ntfy-notifier.sh stop", "/my cfg/hooks/ntfy-notifier.sh", true},
+		{`"/my cfg/hooks/smart-test.sh"`, "/my cfg/hooks/smart-test.sh", true},
+		{`/my\ cfg/hooks/protect-files.sh`, "/my cfg/hooks/protect-files.sh", true},
+		{"'/my cfg/hooks/smart-lint.sh", "", false},
+		{"npx prettier --check .", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ScriptPath(tt.command, "/cfg", "/home/me")
+		assert.Equal(t, tt.ok, ok, tt.command)
+		assert.Equal(t, tt.want, got, tt.command)
+	}
+
+	_, ok := ScriptPath("~/bin/notify.sh", "/cfg", "")
+	assert.False(t, ok)
+}
+
+func TestExpandCommand(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+	t.Setenv("USERPROFILE", homeDir)
+
+	// The default directory keeps the portable ~/.claude form
+	defaultDir := filepath.Join(homeDir, ".claude")
+	assert.Equal(t, "~/.claude/hooks/smart-lint.sh --staged", ExpandCommand("${CLAUDE_DIR}/hooks/smart-lint.sh --staged", defaultDir))
+	assert.Equal(t, "~/.claude/hooks/smart-lint.sh", ExpandCommand("~/.claude/hooks/smart-lint.sh", defaultDir))
+
+	customDir := filepath.Join(t.TempDir(), "claude-work")
+	customRef := filepath.ToSlash(customDir)
+	assert.Equal(t, customRef+"/hooks/smart-lint.sh --staged", ExpandCommand("${CLAUDE_DIR}/hooks/smart-lint.sh --staged", customDir))
+	assert.Equal(t, customRef+"/hooks/ntfy-notifier.sh stop", ExpandCommand("~/.claude/hooks/ntfy-notifier.sh stop", customDir))
+	assert.Equal(t, "powershell.exe -File "+customRef+"/hooks/toast-notifier.ps1", ExpandCommand("powershell.exe -File ${CLAUDE_DIR}/hooks/toast-notifier.ps1", customDir))
+
+	// Directories with spaces are quoted so the shell sees one word
+	spacedDir := filepath.Join(t.TempDir(), "claude work")
+	spacedRef := "'" + filepath.ToSlash(spacedDir) + "'"
+	command := ExpandCommand("${CLAUDE_DIR}/hooks/ntfy-notifier.sh stop", spacedDir)
+	assert.Equal(t, spacedRef+"/hooks/ntfy-notifier.sh stop", command)
+	assert.Equal(t, spacedRef+"/hooks/smart-lint.sh", ExpandCommand("~/.claude/hooks/smart-lint.sh", spacedDir))
+	path, ok := ScriptPath(command, spacedDir, homeDir)
+	assert.True(t, ok)
+	assert.Equal(t, filepath.ToSlash(filepath.Join(spacedDir, "hooks", "ntfy-notifier.sh")), path)
+
+	// Other home-relative paths are left for the shell
+	assert.Equal(t, "~/bin/notify.sh", ExpandCommand("~/bin/notify.sh", customDir))
+}
+
+func TestValidateCommands(t *testing.T) {
+	claudeDir := t.TempDir()
+	hooksDir := filepath.Join(claudeDir, "hooks")
+	require.NoError(t, os.MkdirAll(hooksDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(hooksDir, "smart-lint.sh"), []byte("#!/bin/sh\n"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(hooksDir, "ntfy-notifier.sh"), []byte("#!/bin/sh\n"), 0644))
+
+	settings := &claude.Settings{
+		Hooks: &claude.HooksConfig{
+			PostToolUse: []*claude.HookRule{
+				{Matcher: "Write", Hooks: []*claude.HookItem{
+					{Type: "command", Command: "~/.claude/hooks/smart-lint.sh"},
+					{Type: "command", Command: "~/.claude/hooks/smart-test.sh"},
+					{Type: "command", Command: "npx prettier --check ."},
+				}},
+			},
+			Stop: []*claude.HookRule{
+				{Matcher: "", Hooks: []*claude.HookItem{{Type: "command", Command: "~/.claude/hooks/ntfy-notifier.sh stop"}}},
+			},
+		},
+	}
+
+	assert.Equal(t, []string{
+		"~/.claude/hooks/ntfy-notifier.sh stop: 脚本 " + filepath.Join(hooksDir, "ntfy-notifier.sh") + " 不可执行",
+		"~/.claude/hooks/smart-test.sh: 脚本 " + filepath.Join(hooksDir, "smart-test.sh") + " 不存在",
+	}, ValidateCommands(settings, claudeDir))
+
+	assert.Empty(t, ValidateCommands(&claude.Settings{}, claudeDir))
+	assert.Empty(t, ValidateCommands(nil, claudeDir))
+}
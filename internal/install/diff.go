@@ -0,0 +1,125 @@
+package install
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// 差异类型
+const (
+	DiffAdded   = "added"   // 仅存在于嵌入模板中
+	DiffRemoved = "removed" // 仅存在于已安装配置中
+	DiffChanged = "changed" // 两边都存在但值不同
+)
+
+// 差异范围
+const (
+	DiffScopeAll   = "all"
+	DiffScopeEnv   = "env"
+	DiffScopeHooks = "hooks"
+)
+
+// DiffEntry settings.json 中单个键的差异
+type DiffEntry struct {
+	Path      string      `json:"path"`
+	Kind      string      `json:"kind"`
+	Installed interface{} `json:"installed,omitempty"`
+	Embedded  interface{} `json:"embedded,omitempty"`
+}
+
+// DiffJSON 递归比较两个JSON对象，返回按路径排序的差异列表
+func DiffJSON(installed, embedded map[string]interface{}) []DiffEntry {
+	var entries []DiffEntry
+	diffJSONValue("", installed, embedded, &entries)
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Path < entries[j].Path
+	})
+	return entries
+}
+
+// diffJSONValue 比较两个对象的键，遇到嵌套对象时递归
+func diffJSONValue(prefix string, installed, embedded map[string]interface{}, entries *[]DiffEntry) {
+	for key, installedValue := range installed {
+		path := joinDiffPath(prefix, key)
+		embeddedValue, exists := embedded[key]
+		if !exists {
+			*entries = append(*entries, DiffEntry{Path: path, Kind: DiffRemoved, Installed: installedValue})
+			continue
+		}
+
+		installedMap, installedIsMap := installedValue.(map[string]interface{})
+		embeddedMap, embeddedIsMap := embeddedValue.(map[string]interface{})
+		if installedIsMap && embeddedIsMap {
+			diffJSONValue(path, installedMap, embeddedMap, entries)
+			continue
+		}
+
+		if !reflect.DeepEqual(installedValue, embeddedValue) {
+			*entries = append(*entries, DiffEntry{Path: path, Kind: DiffChanged, Installed: installedValue, Embedded: embeddedValue})
+		}
+	}
+
+	for key, embeddedValue := range embedded {
+		if _, exists := installed[key]; !exists {
+			*entries = append(*entries, DiffEntry{Path: joinDiffPath(prefix, key), Kind: DiffAdded, Embedded: embeddedValue})
+		}
+	}
+}
+
+// joinDiffPath 拼接差异路径
+func joinDiffPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// FilterDiffByScope 按顶层键过滤差异，scope 为 all/env/hooks
+func FilterDiffByScope(entries []DiffEntry, scope string) ([]DiffEntry, error) {
+	switch scope {
+	case "", DiffScopeAll:
+		return entries, nil
+	case DiffScopeEnv, DiffScopeHooks:
+	default:
+		return nil, fmt.Errorf("无效的差异范围: %s (支持: all, env, hooks)", scope)
+	}
+
+	var filtered []DiffEntry
+	for _, entry := range entries {
+		if entry.Path == scope || strings.HasPrefix(entry.Path, scope+".") {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered, nil
+}
+
+// DiffSettings 比较已安装的settings.json与嵌入的模板
+func (m *Manager) DiffSettings(scope string) ([]DiffEntry, error) {
+	embeddedData, err := m.resources.ReadFile("settings.json")
+	if err != nil {
+		return nil, err
+	}
+
+	var embedded map[string]interface{}
+	if err := json.Unmarshal(embeddedData, &embedded); err != nil {
+		return nil, fmt.Errorf("解析嵌入的settings.json失败: %w", err)
+	}
+
+	installed := map[string]interface{}{}
+	installedData, err := os.ReadFile(filepath.Join(m.claudeDir, "settings.json"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("读取已安装的settings.json失败: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(installedData, &installed); err != nil {
+			return nil, fmt.Errorf("解析已安装的settings.json失败: %w", err)
+		}
+	}
+
+	return FilterDiffByScope(DiffJSON(installed, embedded), scope)
+}
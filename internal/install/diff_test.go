@@ -0,0 +1,83 @@
+package install
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffJSON(t *testing.T) {
+	installed := map[string]interface{}{
+		"includeCoAuthoredBy": true,
+		"env": map[string]interface{}{
+			"USER_VAR": "user",
+			"SHARED":   "old",
+		},
+	}
+	embedded := map[string]interface{}{
+		"includeCoAuthoredBy": false,
+		"env": map[string]interface{}{
+			"SHARED": "new",
+		},
+		"statusLine": map[string]interface{}{"type": "command"},
+	}
+
+	entries := DiffJSON(installed, embedded)
+
+	assert.Equal(t, []DiffEntry{
+		{Path: "env.SHARED", Kind: DiffChanged, Installed: "old", Embedded: "new"},
+		{Path: "env.USER_VAR", Kind: DiffRemoved, Installed: "user"},
+		{Path: "includeCoAuthoredBy", Kind: DiffChanged, Installed: true, Embedded: false},
+		{Path: "statusLine", Kind: DiffAdded, Embedded: map[string]interface{}{"type": "command"}},
+	}, entries)
+}
+
+func TestFilterDiffByScope(t *testing.T) {
+	entries := []DiffEntry{
+		{Path: "env.FOO", Kind: DiffAdded},
+		{Path: "hooks.PostToolUse", Kind: DiffChanged},
+		{Path: "hooksExtra", Kind: DiffAdded},
+		{Path: "statusLine", Kind: DiffAdded},
+	}
+
+	hooks, err := FilterDiffByScope(entries, DiffScopeHooks)
+	require.NoError(t, err)
+	assert.Equal(t, []DiffEntry{{Path: "hooks.PostToolUse", Kind: DiffChanged}}, hooks)
+
+	all, err := FilterDiffByScope(entries, DiffScopeAll)
+	require.NoError(t, err)
+	assert.Len(t, all, 4)
+
+	_, err = FilterDiffByScope(entries, "statusLine")
+	assert.Error(t, err)
+}
+
+func TestManager_DiffSettings_OnlyHooks(t *testing.T) {
+	claudeDir := t.TempDir()
+	installed := `{
+  "includeCoAuthoredBy": false,
+  "statusLine": {"type": "command", "command": "~/.claude/statusline.js", "padding": 0},
+  "env": {"MY_VAR": "value"},
+  "hooks": {"Stop": [{"matcher": "", "hooks": [{"type": "command", "command": "notify.sh"}]}]}
+}`
+	require.NoError(t, os.WriteFile(filepath.Join(claudeDir, "settings.json"), []byte(installed), 0644))
+
+	manager := NewManager(claudeDir)
+
+	entries, err := manager.DiffSettings(DiffScopeHooks)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "hooks", entries[0].Path)
+	assert.Equal(t, DiffRemoved, entries[0].Kind)
+
+	for _, entry := range entries {
+		assert.NotContains(t, entry.Path, "env")
+	}
+
+	entries, err = manager.DiffSettings(DiffScopeAll)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
@@ -0,0 +1,74 @@
+package install
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/ooneko/claude-config/internal/claude"
+	"github.com/ooneko/claude-config/internal/hooks"
+	"github.com/ooneko/claude-config/internal/logging"
+)
+
+// InstallHookScripts 安装 settings 中 hooks 引用但缺失的内置 hook 脚本，供 check on 和 notify on 使用。
+// 内置脚本会 source 同目录下的辅助脚本（如 common-helpers.sh），因此只要有引用的脚本缺失，
+// 就补齐 hooks 组件中所有缺失的文件；已存在的文件不会被覆盖。返回安装的文件，相对于配置目录
+func (m *Manager) InstallHookScripts(ctx context.Context, settings *claude.Settings) ([]string, error) {
+	if settings == nil || settings.Hooks == nil {
+		return nil, nil
+	}
+
+	embedded, err := m.listEmbeddedFilesForComponent("hooks")
+	if err != nil {
+		return nil, err
+	}
+	builtin := make(map[string]bool, len(embedded))
+	for _, file := range embedded {
+		builtin[filepath.ToSlash(file)] = true
+	}
+
+	homeDir, _ := os.UserHomeDir()
+	missing := false
+	for _, rules := range settings.Hooks.Events() {
+		for _, rule := range rules {
+			for _, hook := range rule.Hooks {
+				if hook == nil {
+					continue
+				}
+				path, ok := hooks.ScriptPath(hook.Command, m.claudeDir, homeDir)
+				if !ok {
+					continue
+				}
+				relPath, err := filepath.Rel(m.claudeDir, path)
+				if err != nil || !builtin[filepath.ToSlash(relPath)] {
+					continue
+				}
+				if _, err := os.Stat(path); os.IsNotExist(err) {
+					missing = true
+				}
+			}
+		}
+	}
+	if !missing {
+		return nil, nil
+	}
+
+	// 逐个文件的提示合并为一条
+	quiet := &Manager{claudeDir: m.claudeDir, resources: m.resources, logger: logging.Nop()}
+
+	var installed []string
+	for _, file := range embedded {
+		relPath := filepath.ToSlash(file)
+		if _, err := os.Stat(filepath.Join(m.claudeDir, filepath.FromSlash(relPath))); !os.IsNotExist(err) {
+			continue
+		}
+		if err := quiet.InstallFile(ctx, relPath, false); err != nil {
+			return installed, err
+		}
+		installed = append(installed, relPath)
+	}
+	if len(installed) > 0 {
+		m.logger.Info("📦 已安装 %d 个缺失的hook脚本到 %s", len(installed), filepath.Join(m.claudeDir, "hooks"))
+	}
+	return installed, nil
+}
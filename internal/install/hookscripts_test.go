@@ -0,0 +1,64 @@
+package install
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ooneko/claude-config/internal/claude"
+)
+
+// hookSettings 构造引用给定命令的 PostToolUse hooks 配置
+func hookSettings(commands ...string) *claude.Settings {
+	rule := &claude.HookRule{Matcher: "Write"}
+	for _, command := range commands {
+		rule.Hooks = append(rule.Hooks, &claude.HookItem{Type: "command", Command: command})
+	}
+	return &claude.Settings{Hooks: &claude.HooksConfig{PostToolUse: []*claude.HookRule{rule}}}
+}
+
+func TestManager_InstallHookScripts(t *testing.T) {
+	claudeDir := filepath.Join(t.TempDir(), ".claude")
+	manager := NewManager(claudeDir)
+	ctx := context.Background()
+	hooksDir := filepath.Join(claudeDir, "hooks")
+
+	// 没有引用内置脚本时不安装任何文件
+	installed, err := manager.InstallHookScripts(ctx, hookSettings("/usr/local/bin/lint.sh"))
+	require.NoError(t, err)
+	assert.Empty(t, installed)
+	assert.NoDirExists(t, hooksDir)
+
+	installed, err = manager.InstallHookScripts(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, installed)
+
+	// 已存在的文件不会被覆盖
+	require.NoError(t, os.MkdirAll(hooksDir, 0755))
+	custom := filepath.Join(hooksDir, "common-helpers.sh")
+	require.NoError(t, os.WriteFile(custom, []byte("# mine\n"), 0755))
+
+	installed, err = manager.InstallHookScripts(ctx, hookSettings(filepath.ToSlash(filepath.Join(claudeDir, "hooks", "smart-lint.sh"))))
+	require.NoError(t, err)
+	assert.Contains(t, installed, "hooks/smart-lint.sh")
+	assert.NotContains(t, installed, "hooks/common-helpers.sh")
+	assert.FileExists(t, filepath.Join(hooksDir, "smart-lint.sh"))
+	assert.FileExists(t, filepath.Join(hooksDir, "ntfy-notifier.sh"), "引用的脚本缺失时补齐整个 hooks 组件")
+
+	data, err := os.ReadFile(custom)
+	require.NoError(t, err)
+	assert.Equal(t, "# mine\n", string(data))
+
+	manifest, err := LoadManifest(claudeDir)
+	require.NoError(t, err)
+	assert.True(t, manifest.IsManaged("hooks", "hooks/smart-lint.sh"))
+
+	// 引用的脚本都已存在时不再安装
+	installed, err = manager.InstallHookScripts(ctx, hookSettings(filepath.ToSlash(filepath.Join(claudeDir, "hooks", "smart-lint.sh"))))
+	require.NoError(t, err)
+	assert.Empty(t, installed)
+}
@@ -1,14 +1,23 @@
 package install
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
 	"embed"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
+	"golang.org/x/sync/errgroup"
+
+	"github.com/ooneko/claude-config/internal/config"
+	"github.com/ooneko/claude-config/internal/logging"
 	"github.com/ooneko/claude-config/resources"
 )
 
@@ -16,6 +25,7 @@ import (
 type Manager struct {
 	claudeDir string
 	resources *ResourceManager
+	logger    logging.Logger
 }
 
 // NewManager 创建新的install管理器
@@ -23,117 +33,300 @@ func NewManager(claudeDir string) *Manager {
 	return &Manager{
 		claudeDir: claudeDir,
 		resources: NewResourceManager(),
+		logger:    logging.Default(),
 	}
 }
 
-// Install 安装配置文件
-func (m *Manager) Install(ctx context.Context, options Options) error {
+// SetLogger 设置安装过程提示信息的输出位置，合并settings.json时同样使用
+func (m *Manager) SetLogger(logger logging.Logger) {
+	m.logger = logger
+}
+
+// Install 安装配置文件，返回每个组件新建、覆盖、合并和删除的文件。
+// dry-run 模式下不写入任何内容，结果描述将要进行的更改。出错时返回已完成部分的结果
+func (m *Manager) Install(ctx context.Context, options Options) (*InstallResult, error) {
+	result := &InstallResult{DryRun: options.DryRun}
 	if err := options.Validate(); err != nil {
-		return fmt.Errorf("无效的安装选项: %w", err)
+		return result, fmt.Errorf("无效的安装选项: %w", err)
 	}
 
-	// 确保目标目录存在
-	if err := os.MkdirAll(m.claudeDir, 0755); err != nil {
-		return fmt.Errorf("创建Claude目录失败: %w", err)
+	// 确保目标目录存在，dry-run 模式不写入任何内容
+	if !options.DryRun {
+		if err := os.MkdirAll(m.claudeDir, 0755); err != nil {
+			return result, fmt.Errorf("创建Claude目录失败: %w", err)
+		}
 	}
 
 	components := options.GetSelectedComponents()
 
-	// 第一阶段: 安装组件
-	for _, component := range components {
-		if err := m.installComponent(ctx, component, options.Force); err != nil {
-			return fmt.Errorf("安装组件%s失败: %w", component, err)
+	// 第一阶段: 安装组件，并在安装清单中记录写入的文件。按组件顺序报告，第一个出错的组件决定返回的错误
+	componentResults, errs := m.installComponents(ctx, components, options)
+	for i, component := range components {
+		componentResult := componentResults[i]
+		if componentResult != nil {
+			result.Components = append(result.Components, componentResult)
+		}
+		if errs[i] != nil {
+			return result, errs[i]
+		}
+		if componentResult == nil || options.DryRun || componentResult.Skipped {
+			continue
+		}
+		if err := m.recordComponent(component); err != nil {
+			return result, fmt.Errorf("更新安装清单失败: %w", err)
 		}
 	}
 
 	// 第二阶段: 清理孤立文件(如果启用了删除功能)
 	if options.Delete {
-		for _, component := range components {
-			if err := m.cleanupOrphanedFiles(component, options); err != nil {
-				return fmt.Errorf("清理组件%s的孤立文件失败: %w", component, err)
+		for _, componentResult := range result.Components {
+			if err := m.cleanupOrphanedFiles(componentResult, options); err != nil {
+				return result, fmt.Errorf("清理组件%s的孤立文件失败: %w", componentResult.Component, err)
 			}
 		}
 	}
 
-	return nil
+	return result, nil
 }
 
-// installComponent 安装单个组件
-func (m *Manager) installComponent(ctx context.Context, component string, force bool) error {
+// installComponents 安装各组件，返回与components一一对应的结果和错误。
+// options.Parallel 大于1时最多同时提取这么多个组件，settings.json 是共享的合并状态，
+// 在其他组件完成后单独合并。依次安装时遇到错误即停止，之后的组件结果为nil
+func (m *Manager) installComponents(ctx context.Context, components []string, options Options) ([]*ComponentResult, []error) {
+	results := make([]*ComponentResult, len(components))
+	errs := make([]error, len(components))
+
+	if options.Parallel <= 1 {
+		for i, component := range components {
+			results[i], errs[i] = m.installComponent(ctx, component, options)
+			if errs[i] != nil {
+				break
+			}
+		}
+		return results, errs
+	}
+
+	var group errgroup.Group
+	group.SetLimit(options.Parallel)
+	settingsIndex := -1
+	for i, component := range components {
+		if component == "settings.json" {
+			settingsIndex = i
+			continue
+		}
+		i, component := i, component
+		group.Go(func() error {
+			// 错误按组件保存，不取消其他组件，使报告的错误与完成顺序无关
+			results[i], errs[i] = m.installComponent(ctx, component, options)
+			return nil
+		})
+	}
+	_ = group.Wait()
+
+	if settingsIndex >= 0 {
+		for _, err := range errs[:settingsIndex] {
+			if err != nil {
+				return results, errs
+			}
+		}
+		results[settingsIndex], errs[settingsIndex] = m.installComponent(ctx, "settings.json", options)
+	}
+	return results, errs
+}
+
+// installComponent 安装单个组件，dry-run 模式下只计算将产生的变化
+func (m *Manager) installComponent(ctx context.Context, component string, options Options) (*ComponentResult, error) {
 	select {
 	case <-ctx.Done():
-		return ctx.Err()
+		return nil, ctx.Err()
 	default:
 	}
 
+	result := &ComponentResult{Component: component}
+	if m.shouldSkip(component, options.Force) {
+		result.Skipped = true
+		return result, nil
+	}
+
+	var err error
+	if component == "settings.json" {
+		result.Merged, err = m.settingsChanges(options.MergeStrategy, options.DryRun)
+	} else {
+		err = m.classifyFiles(component, result)
+	}
+	if err != nil || options.DryRun {
+		if err != nil {
+			err = newInstallError(component, embedPath(component), err)
+		}
+		return result, err
+	}
+
 	switch component {
 	case "agents", "commands", "hooks", "output-styles":
-		return m.installDirectory(component, force)
+		_, err = m.resources.ExtractDirectory(ctx, component, filepath.Join(m.claudeDir, component))
+		m.logger.Debug("📁 %s: 写入 %d 个文件", component, result.Written())
 	case "settings.json":
-		return m.installSettingsJSON()
+		err = m.installSettingsJSON(options.MergeStrategy)
 	case "CLAUDE.md.template":
-		return m.installClaudeMd(force)
+		err = m.installClaudeMd()
 	case "statusline.js":
-		return m.installStatuslineJs(force)
+		err = m.installStatuslineJs()
 	default:
-		return fmt.Errorf("未知组件: %s", component)
+		return result, fmt.Errorf("未知组件: %s", component)
 	}
-}
 
-// installDirectory 安装目录 - 根据force参数决定是否覆盖现有目录
-func (m *Manager) installDirectory(dirName string, force bool) error {
-	targetDir := filepath.Join(m.claudeDir, dirName)
-
-	// 如果不强制覆盖，检查目录是否存在
-	if !force {
-		if _, err := os.Stat(targetDir); err == nil {
-			fmt.Printf("⚠️  目录 %s 已存在，跳过安装（使用 --force 强制覆盖）\n", dirName)
-			return nil
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return result, ctxErr
 		}
+		return result, newInstallError(component, embedPath(component), err)
 	}
+	return result, nil
+}
 
-	return m.resources.ExtractDirectory(dirName, targetDir)
+// shouldSkip 检查组件是否因目标已存在且未指定force而跳过安装
+// 目录组件和statusline.js不覆盖已存在的目标，settings.json和CLAUDE.md总是安装
+func (m *Manager) shouldSkip(component string, force bool) bool {
+	if force {
+		return false
+	}
+
+	switch component {
+	case "agents", "commands", "hooks", "output-styles", "statusline.js":
+		_, err := os.Stat(filepath.Join(m.claudeDir, component))
+		return err == nil
+	default:
+		return false
+	}
 }
 
-// installSettingsJSON 安装settings.json - 始终使用智能合并
-func (m *Manager) installSettingsJSON() error {
+// installSettingsJSON 安装settings.json - 始终使用智能合并，按strategy处理环境变量冲突
+func (m *Manager) installSettingsJSON(strategy MergeStrategy) error {
 	targetPath := filepath.Join(m.claudeDir, "settings.json")
 
 	// 创建临时文件来存储源文件内容
 	tempDir := os.TempDir()
 	tempFile := filepath.Join(tempDir, "settings_source.json")
 
-	if err := m.resources.ExtractFile("settings.json", tempFile); err != nil {
+	if _, err := m.resources.ExtractFile("settings.json", tempFile); err != nil {
 		return fmt.Errorf("提取源settings.json失败: %w", err)
 	}
 	defer os.Remove(tempFile) // 清理临时文件
 
 	// 使用智能合并器合并文件
-	merger := NewSettingsJSONMerger()
+	merger, err := m.newSettingsMerger(strategy)
+	if err != nil {
+		return err
+	}
 	return merger.MergeSettings(targetPath, tempFile)
 }
 
+// newSettingsMerger 创建带有固定环境变量和合并策略的settings.json合并器
+func (m *Manager) newSettingsMerger(strategy MergeStrategy) (*SettingsJSONMerger, error) {
+	merger := NewSettingsJSONMerger()
+	merger.SetLogger(m.logger)
+	merger.SetMergeStrategy(strategy)
+	pinned, err := config.LoadPinnedEnv(m.claudeDir)
+	if err != nil {
+		return nil, fmt.Errorf("读取固定环境变量失败: %w", err)
+	}
+	merger.SetPinnedKeys(pinned)
+	return merger, nil
+}
+
+// classifyFiles 按目标文件的现状，把组件将写入的文件记为新建、覆盖或内容相同
+func (m *Manager) classifyFiles(component string, result *ComponentResult) error {
+	sources, err := m.componentSources(component)
+	if err != nil {
+		return err
+	}
+
+	for _, source := range sources {
+		data, err := m.resources.ReadFile(source)
+		if err != nil {
+			return err
+		}
+
+		relPath := filepath.ToSlash(installedPath(source))
+		existing, err := os.ReadFile(filepath.Join(m.claudeDir, filepath.FromSlash(relPath)))
+		switch {
+		case os.IsNotExist(err):
+			result.Created = append(result.Created, relPath)
+		case err != nil:
+			return fmt.Errorf("读取文件失败 %s: %w", relPath, err)
+		case bytes.Equal(existing, data):
+			result.Unchanged = append(result.Unchanged, relPath)
+		default:
+			result.Updated = append(result.Updated, relPath)
+		}
+	}
+
+	return nil
+}
+
+// embeddedSettings 读取并解析内置的settings.json
+func (m *Manager) embeddedSettings() (map[string]interface{}, error) {
+	data, err := m.resources.ReadFile("settings.json")
+	if err != nil {
+		return nil, err
+	}
+
+	var sourceData map[string]interface{}
+	if err := json.Unmarshal(data, &sourceData); err != nil {
+		return nil, fmt.Errorf("解析嵌入的settings.json失败: %w", err)
+	}
+
+	return sourceData, nil
+}
+
+// PreviewSettingsMerge 计算按strategy将内置settings.json合并到已安装配置的键级差异，不写入任何文件
+func (m *Manager) PreviewSettingsMerge(strategy MergeStrategy) (added, changed, preserved []string, err error) {
+	sourceData, err := m.embeddedSettings()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	merger, err := m.newSettingsMerger(strategy)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return merger.previewMergeData(filepath.Join(m.claudeDir, "settings.json"), sourceData)
+}
+
+// settingsChanges 计算按strategy合并settings.json将新增、改变或移除的配置项。
+// 实际安装时合并提示由 MergeSettings 输出，这里只在dry-run时输出
+func (m *Manager) settingsChanges(strategy MergeStrategy, dryRun bool) ([]DiffEntry, error) {
+	sourceData, err := m.embeddedSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	merger, err := m.newSettingsMerger(strategy)
+	if err != nil {
+		return nil, err
+	}
+	if !dryRun {
+		merger.SetLogger(logging.Nop())
+	}
+
+	return merger.PreviewSettings(filepath.Join(m.claudeDir, "settings.json"), sourceData)
+}
+
 // installClaudeMd 安装CLAUDE.md文件 - 总是覆盖现有文件
-func (m *Manager) installClaudeMd(_ bool) error {
+func (m *Manager) installClaudeMd() error {
 	targetPath := filepath.Join(m.claudeDir, "CLAUDE.md")
 	// CLAUDE.md 默认总是覆盖，不受force参数影响
-	return m.resources.ExtractFile("CLAUDE.md.template", targetPath)
+	_, err := m.resources.ExtractFile("CLAUDE.md.template", targetPath)
+	return err
 }
 
-// installStatuslineJs 安装statusline.js文件 - 根据force参数决定是否覆盖现有文件，并设置可执行权限
-func (m *Manager) installStatuslineJs(force bool) error {
+// installStatuslineJs 安装statusline.js文件并设置可执行权限，已存在时是否覆盖由调用方通过shouldSkip决定
+func (m *Manager) installStatuslineJs() error {
 	targetPath := filepath.Join(m.claudeDir, "statusline.js")
 
-	// 如果不强制覆盖，检查文件是否存在
-	if !force {
-		if _, err := os.Stat(targetPath); err == nil {
-			fmt.Printf("⚠️  文件 statusline.js 已存在，跳过安装（使用 --force 强制覆盖）\n")
-			return nil
-		}
-	}
-
 	// 提取文件
-	if err := m.resources.ExtractFile("statusline.js", targetPath); err != nil {
+	if _, err := m.resources.ExtractFile("statusline.js", targetPath); err != nil {
 		return err
 	}
 
@@ -167,8 +360,8 @@ func (rm *ResourceManager) ListEmbeddedFiles() ([]string, error) {
 		}
 
 		// 移除claude-config前缀
-		if strings.HasPrefix(path, "claude-config/") {
-			relativePath := path[len("claude-config/"):]
+		if strings.HasPrefix(path, embedRoot+"/") {
+			relativePath := path[len(embedRoot+"/"):]
 			if d.IsDir() {
 				files = append(files, relativePath+"/")
 			} else {
@@ -182,43 +375,96 @@ func (rm *ResourceManager) ListEmbeddedFiles() ([]string, error) {
 	return files, err
 }
 
-// ExtractFile 提取单个文件
-func (rm *ResourceManager) ExtractFile(srcPath, destPath string) error {
-	fullSrcPath := filepath.Join("claude-config", srcPath)
+// embedRoot 是嵌入文件系统中资源的根目录
+const embedRoot = "claude-config"
+
+// embedPath 返回资源在嵌入文件系统中的完整路径。embed.FS 在所有平台上都只接受 / 分隔的路径，
+// 因此使用 path.Join 而不是 filepath.Join
+func embedPath(name string) string {
+	return path.Join(embedRoot, filepath.ToSlash(name))
+}
+
+// ReadFile 读取单个嵌入文件的内容
+func (rm *ResourceManager) ReadFile(srcPath string) ([]byte, error) {
+	fullSrcPath := embedPath(srcPath)
 
 	data, err := rm.fs.ReadFile(fullSrcPath)
 	if err != nil {
-		return fmt.Errorf("读取嵌入文件失败: %w", err)
+		return nil, fmt.Errorf("读取嵌入文件失败: %w", err)
+	}
+
+	return data, nil
+}
+
+// FileHash 返回嵌入文件内容的sha256，relPath 相对于 claude-config
+func (rm *ResourceManager) FileHash(relPath string) (string, error) {
+	data, err := rm.ReadFile(relPath)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// ExtractStats 提取结果统计
+type ExtractStats struct {
+	Written int // 新建或内容有变化而写入的文件数
+	Skipped int // 内容相同而跳过的文件数
+}
+
+// add 累计一个文件的提取结果
+func (s *ExtractStats) add(written bool) {
+	if written {
+		s.Written++
+	} else {
+		s.Skipped++
+	}
+}
+
+// ExtractFile 提取单个文件，内容与已有文件相同时跳过写入
+func (rm *ResourceManager) ExtractFile(srcPath, destPath string) (ExtractStats, error) {
+	var stats ExtractStats
+
+	data, err := rm.ReadFile(srcPath)
+	if err != nil {
+		return stats, err
 	}
 
 	// 确保目标目录存在
 	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
-		return fmt.Errorf("创建目标目录失败: %w", err)
+		return stats, fmt.Errorf("创建目标目录失败: %w", err)
 	}
 
-	return os.WriteFile(destPath, data, GetFilePermissions(destPath))
+	written, err := writeIfChanged(destPath, data)
+	if err != nil {
+		return stats, err
+	}
+	stats.add(written)
+	return stats, nil
 }
 
-// ExtractDirectory 提取目录
-func (rm *ResourceManager) ExtractDirectory(srcDir, destDir string) error {
-	fullSrcDir := filepath.Join("claude-config", srcDir)
+// ExtractDirectory 提取目录，跳过内容未变化的文件。ctx 取消时在下一个文件前停止并返回 ctx.Err()，
+// 其他失败返回记录了出错嵌入路径的 *InstallError
+func (rm *ResourceManager) ExtractDirectory(ctx context.Context, srcDir, destDir string) (ExtractStats, error) {
+	var stats ExtractStats
+	fullSrcDir := embedPath(srcDir)
 
-	return fs.WalkDir(rm.fs, fullSrcDir, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
+	err := fs.WalkDir(rm.fs, fullSrcDir, func(path string, d fs.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
 		}
-
-		// 计算相对路径
-		relPath, err := filepath.Rel(fullSrcDir, path)
 		if err != nil {
-			return err
+			return &InstallError{Component: srcDir, EmbedPath: path, Err: err}
 		}
 
-		if relPath == "." {
+		if path == fullSrcDir {
 			return nil
 		}
 
-		destPath := filepath.Join(destDir, relPath)
+		// 嵌入路径使用 /，只有目标路径转换为系统分隔符
+		relPath := strings.TrimPrefix(path, fullSrcDir+"/")
+		destPath := filepath.Join(destDir, filepath.FromSlash(relPath))
 
 		if d.IsDir() {
 			return os.MkdirAll(destPath, 0755)
@@ -226,7 +472,7 @@ func (rm *ResourceManager) ExtractDirectory(srcDir, destDir string) error {
 
 		data, err := rm.fs.ReadFile(path)
 		if err != nil {
-			return err
+			return &InstallError{Component: srcDir, EmbedPath: path, Err: err}
 		}
 
 		// 确保目标目录存在
@@ -234,8 +480,28 @@ func (rm *ResourceManager) ExtractDirectory(srcDir, destDir string) error {
 			return err
 		}
 
-		return os.WriteFile(destPath, data, GetFilePermissions(destPath))
+		written, err := writeIfChanged(destPath, data)
+		if err != nil {
+			return err
+		}
+		stats.add(written)
+		return nil
 	})
+
+	return stats, err
+}
+
+// writeIfChanged 在内容哈希与已有文件不同时写入文件，避免无谓地更新修改时间
+// 新建文件的权限由 GetFilePermissions 决定，返回是否实际写入
+func writeIfChanged(destPath string, data []byte) (bool, error) {
+	if existing, err := os.ReadFile(destPath); err == nil && sha256.Sum256(existing) == sha256.Sum256(data) {
+		return false, nil
+	}
+
+	if err := os.WriteFile(destPath, data, GetFilePermissions(destPath)); err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
 // isSpecialFile 检查文件是否为特殊文件(不应被删除的文件)
@@ -258,15 +524,13 @@ func isSpecialFile(filePath string) bool {
 	return false
 }
 
-// listEmbeddedFilesForComponent 获取指定组件的嵌入资源文件列表
+// listEmbeddedFilesForComponent 获取指定组件的嵌入资源文件列表，路径相对于 claude-config 并使用 / 分隔
 func (m *Manager) listEmbeddedFilesForComponent(component string) ([]string, error) {
 	var files []string
 
 	// 对于目录型组件,遍历嵌入资源中的对应目录
 	if component == "agents" || component == "commands" || component == "hooks" || component == "output-styles" {
-		fullSrcDir := filepath.Join("claude-config", component)
-
-		err := fs.WalkDir(m.resources.fs, fullSrcDir, func(path string, d fs.DirEntry, err error) error {
+		err := fs.WalkDir(m.resources.fs, embedPath(component), func(path string, d fs.DirEntry, err error) error {
 			if err != nil {
 				return err
 			}
@@ -276,13 +540,7 @@ func (m *Manager) listEmbeddedFilesForComponent(component string) ([]string, err
 				return nil
 			}
 
-			// 计算相对路径
-			relPath, err := filepath.Rel("claude-config", path)
-			if err != nil {
-				return err
-			}
-
-			files = append(files, relPath)
+			files = append(files, strings.TrimPrefix(path, embedRoot+"/"))
 			return nil
 		})
 
@@ -337,6 +595,7 @@ func (m *Manager) listInstalledFilesInDirectory(component string) ([]string, err
 }
 
 // listOrphanedFiles 获取孤立文件列表(在目标目录中存在但在嵌入资源中不存在的文件)
+// 安装清单中有该组件的记录时，只有曾由install写入的文件才算孤立文件，用户自己创建的文件会被保留
 func (m *Manager) listOrphanedFiles(component string) ([]string, error) {
 	// 获取嵌入资源文件列表
 	embeddedFiles, err := m.listEmbeddedFilesForComponent(component)
@@ -358,6 +617,12 @@ func (m *Manager) listOrphanedFiles(component string) ([]string, error) {
 		embeddedSet[normalizedPath] = true
 	}
 
+	manifest, err := LoadManifest(m.claudeDir)
+	if err != nil {
+		return nil, err
+	}
+	useManifest := manifest.HasComponent(component)
+
 	// 找出孤立文件
 	var orphanedFiles []string
 	for _, installedFile := range installedFiles {
@@ -368,6 +633,11 @@ func (m *Manager) listOrphanedFiles(component string) ([]string, error) {
 			continue
 		}
 
+		// 不是install写入的文件属于用户，不删除
+		if useManifest && !manifest.IsManaged(component, normalizedPath) {
+			continue
+		}
+
 		// 如果不在嵌入资源中,则为孤立文件
 		if !embeddedSet[normalizedPath] {
 			orphanedFiles = append(orphanedFiles, installedFile)
@@ -377,37 +647,29 @@ func (m *Manager) listOrphanedFiles(component string) ([]string, error) {
 	return orphanedFiles, nil
 }
 
-// deleteOrphanedFiles 删除孤立文件(或执行dry-run)
-func (m *Manager) deleteOrphanedFiles(orphanedFiles []string, dryRun bool) (int, error) {
-	count := 0
-
+// deleteOrphanedFiles 删除孤立文件，返回已删除的文件。出错时返回出错前已删除的文件
+func (m *Manager) deleteOrphanedFiles(orphanedFiles []string) ([]string, error) {
+	var deleted []string
 	for _, file := range orphanedFiles {
-		fullPath := filepath.Join(m.claudeDir, file)
-
-		if dryRun {
-			// Dry-run模式: 只显示,不删除
-			fmt.Printf("🗑️  %s\n", file)
-		} else {
-			// 实际删除
-			if err := os.Remove(fullPath); err != nil {
-				return count, fmt.Errorf("删除文件失败 %s: %w", file, err)
-			}
-			fmt.Printf("🗑️  已删除: %s\n", file)
+		if err := os.Remove(filepath.Join(m.claudeDir, file)); err != nil {
+			return deleted, fmt.Errorf("删除文件失败 %s: %w", file, err)
 		}
-		count++
+		deleted = append(deleted, file)
 	}
 
-	return count, nil
+	return deleted, nil
 }
 
-// cleanupOrphanedFiles 清理孤立文件的主入口
-func (m *Manager) cleanupOrphanedFiles(component string, options Options) error {
+// cleanupOrphanedFiles 清理孤立文件的主入口。未指定force或处于dry-run模式时
+// 只把孤立文件记入 result.Orphaned，否则删除并记入 result.Deleted
+func (m *Manager) cleanupOrphanedFiles(result *ComponentResult, options Options) error {
 	// 如果未启用删除功能,直接返回
 	if !options.Delete {
 		return nil
 	}
 
 	// 跳过特殊组件
+	component := result.Component
 	if component == "settings.json" || component == "CLAUDE.md.template" {
 		return nil
 	}
@@ -423,30 +685,151 @@ func (m *Manager) cleanupOrphanedFiles(component string, options Options) error
 		return nil
 	}
 
-	// 确定是dry-run还是实际删除
-	dryRun := !options.Force
+	for i, file := range orphanedFiles {
+		orphanedFiles[i] = filepath.ToSlash(file)
+	}
 
-	// 输出标题
-	if dryRun {
-		fmt.Printf("\n🔍 Dry-run 模式: 以下文件将被删除 (使用 --force 实际执行删除):\n\n")
-	} else {
-		fmt.Printf("\n⚠️  警告: 即将删除以下文件\n\n")
+	// dry-run 时只列出
+	if !options.Force || options.DryRun {
+		result.Orphaned = orphanedFiles
+		return nil
+	}
+
+	deleted, err := m.deleteOrphanedFiles(orphanedFiles)
+	result.Deleted = deleted
+	if len(deleted) > 0 {
+		if forgetErr := m.forgetManifestFiles(component, deleted); forgetErr != nil && err == nil {
+			err = forgetErr
+		}
+	}
+	return err
+}
+
+// forgetManifestFiles 从安装清单中移除已删除的文件
+func (m *Manager) forgetManifestFiles(component string, files []string) error {
+	manifest, err := LoadManifest(m.claudeDir)
+	if err != nil {
+		return err
+	}
+	if !manifest.HasComponent(component) {
+		return nil
 	}
 
-	// 删除或显示文件
-	count, err := m.deleteOrphanedFiles(orphanedFiles, dryRun)
+	manifest.Forget(component, files)
+	return SaveManifest(m.claudeDir, manifest)
+}
+
+// InstallFile 从目录组件中安装单个嵌入文件，例如 agents/code-reviewer.md
+// 目标文件已存在时只有指定force才会覆盖，权限由 GetFilePermissions 决定
+func (m *Manager) InstallFile(ctx context.Context, relPath string, force bool) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	component, cleanPath, err := m.resolveEmbeddedFile(relPath)
 	if err != nil {
 		return err
 	}
 
-	// 输出汇总
-	fmt.Println()
-	if dryRun {
-		fmt.Printf("📊 总计: %d 个文件将被删除\n", count)
-		fmt.Println("\n💡 提示: 使用 --force 参数实际执行删除")
+	targetPath := filepath.Join(m.claudeDir, filepath.FromSlash(cleanPath))
+	if !force {
+		if _, err := os.Stat(targetPath); err == nil {
+			m.logger.Warn("⚠️  文件 %s 已存在，跳过安装（使用 --force 强制覆盖）", cleanPath)
+			return nil
+		}
+	}
+
+	stats, err := m.resources.ExtractFile(cleanPath, targetPath)
+	if err != nil {
+		return newInstallError(component, embedPath(cleanPath), err)
+	}
+	if stats.Skipped > 0 {
+		m.logger.Info("文件 %s 内容无变化，跳过", cleanPath)
 	} else {
-		fmt.Printf("✅ 成功删除 %d 个孤立文件\n", count)
+		m.logger.Info("✅ 已安装 %s", cleanPath)
+	}
+
+	manifest, err := LoadManifest(m.claudeDir)
+	if err != nil {
+		return fmt.Errorf("更新安装清单失败: %w", err)
+	}
+	hash := ""
+	if entry, exists := manifest.Components[component]; exists {
+		hash = entry.Hash
+	}
+	manifest.Record(component, hash, []string{cleanPath})
+	if err := SaveManifest(m.claudeDir, manifest); err != nil {
+		return fmt.Errorf("更新安装清单失败: %w", err)
 	}
 
 	return nil
 }
+
+// resolveEmbeddedFile 校验relPath是目录组件中存在的嵌入文件，返回组件名和规范化后的路径
+func (m *Manager) resolveEmbeddedFile(relPath string) (string, string, error) {
+	cleanPath := path.Clean(filepath.ToSlash(strings.TrimSpace(relPath)))
+	if cleanPath == "." || path.IsAbs(cleanPath) || cleanPath == ".." || strings.HasPrefix(cleanPath, "../") {
+		return "", "", fmt.Errorf("无效的文件路径: %s", relPath)
+	}
+
+	component, _, found := strings.Cut(cleanPath, "/")
+	switch component {
+	case "agents", "commands", "hooks", "output-styles":
+	default:
+		if !found {
+			return "", "", fmt.Errorf("%s 不是目录组件中的文件，请使用对应的安装选项", relPath)
+		}
+		return "", "", fmt.Errorf("未知组件: %s (支持: agents, commands, hooks, output-styles)", component)
+	}
+	if !found {
+		return "", "", fmt.Errorf("%s 是目录组件，请指定其中的文件", relPath)
+	}
+
+	info, err := fs.Stat(m.resources.fs, embedPath(cleanPath))
+	if err != nil {
+		return "", "", fmt.Errorf("嵌入资源中不存在文件: %s", cleanPath)
+	}
+	if info.IsDir() {
+		return "", "", fmt.Errorf("%s 是目录，请指定其中的文件", cleanPath)
+	}
+
+	return component, cleanPath, nil
+}
+
+// Outdated 返回组件中已安装但内容与内置版本不同的文件，路径相对于配置目录
+// 未安装的文件和 settings.json、CLAUDE.md 等特殊文件不在结果中
+func (m *Manager) Outdated(component string) ([]string, error) {
+	sources, err := m.componentSources(component)
+	if err != nil {
+		return nil, fmt.Errorf("获取组件%s的嵌入资源失败: %w", component, err)
+	}
+
+	var outdated []string
+	for _, source := range sources {
+		target := installedPath(source)
+		if isSpecialFile(target) {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(m.claudeDir, target))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("读取文件失败 %s: %w", target, err)
+		}
+
+		embeddedHash, err := m.resources.FileHash(source)
+		if err != nil {
+			return nil, err
+		}
+		installedHash := sha256.Sum256(data)
+		if hex.EncodeToString(installedHash[:]) != embeddedHash {
+			outdated = append(outdated, target)
+		}
+	}
+
+	return outdated, nil
+}
@@ -1,13 +1,22 @@
 package install
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ooneko/claude-config/internal/logging"
 )
 
 func TestNewManager(t *testing.T) {
@@ -43,7 +52,7 @@ func TestManager_Install(t *testing.T) {
 				// 检查是否创建了所有必要的目录和文件
 				assert.DirExists(t, filepath.Join(claudeDir, "agents"))
 				assert.DirExists(t, filepath.Join(claudeDir, "commands"))
-				assert.DirExists(t, filepath.Join(claudeDir, "hooks"))
+				assert.NoDirExists(t, filepath.Join(claudeDir, "hooks"), "hooks 由 check on 管理，--all 不安装")
 				assert.DirExists(t, filepath.Join(claudeDir, "output-styles"))
 				assert.FileExists(t, filepath.Join(claudeDir, "settings.json"))
 				assert.FileExists(t, filepath.Join(claudeDir, "CLAUDE.md"))
@@ -87,7 +96,7 @@ func TestManager_Install(t *testing.T) {
 			os.RemoveAll(claudeDir)
 
 			ctx := context.Background()
-			err := manager.Install(ctx, tt.options)
+			_, err := manager.Install(ctx, tt.options)
 
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -109,16 +118,187 @@ func TestManager_installComponent(t *testing.T) {
 	ctx := context.Background()
 
 	// 测试未知组件
-	err := manager.installComponent(ctx, "unknown-component", false)
+	_, err := manager.installComponent(ctx, "unknown-component", Options{})
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "未知组件")
 
 	// 测试取消上下文
 	cancelCtx, cancel := context.WithCancel(ctx)
 	cancel()
-	err = manager.installComponent(cancelCtx, "agents", false)
+	_, err = manager.installComponent(cancelCtx, "agents", Options{})
 	assert.Error(t, err)
 	assert.Equal(t, context.Canceled, err)
+
+	// 复制目录中途取消: 已写入的文件保留，剩余文件不再写入
+	_, err = manager.installComponent(&cancelAfter{Context: ctx, remaining: 3}, "commands", Options{})
+	assert.Equal(t, context.Canceled, err)
+
+	embedded, err := manager.listEmbeddedFilesForComponent("commands")
+	require.NoError(t, err)
+	require.Greater(t, len(embedded), 2, "需要多个文件才能验证中途停止")
+	var written int
+	for _, file := range embedded {
+		if _, err := os.Stat(filepath.Join(manager.claudeDir, file)); err == nil {
+			written++
+		}
+	}
+	assert.Less(t, written, len(embedded))
+}
+
+// cancelAfter 在 Err 被调用 remaining 次之后报告取消，用于模拟复制过程中途取消
+type cancelAfter struct {
+	context.Context
+	remaining int
+}
+
+func (c *cancelAfter) Err() error {
+	if c.remaining <= 0 {
+		return context.Canceled
+	}
+	c.remaining--
+	return nil
+}
+
+func TestManager_Install_Parallel(t *testing.T) {
+	ctx := context.Background()
+	options := Options{All: true, Hooks: true}
+
+	sequential := NewManager(filepath.Join(t.TempDir(), ".claude"))
+	sequential.SetLogger(logging.Nop())
+	want, err := sequential.Install(ctx, options)
+	require.NoError(t, err)
+
+	options.Parallel = 4
+	parallel := NewManager(filepath.Join(t.TempDir(), ".claude"))
+	parallel.SetLogger(logging.Nop())
+	got, err := parallel.Install(ctx, options)
+	require.NoError(t, err)
+	assert.Equal(t, want, got, "并发安装的结果应与依次安装相同，且按组件顺序排列")
+
+	manifest, err := LoadManifest(parallel.claudeDir)
+	require.NoError(t, err)
+	assert.Len(t, manifest.Components, len(options.GetSelectedComponents()))
+}
+
+func TestManager_Install_ParallelFirstErrorWins(t *testing.T) {
+	claudeDir := filepath.Join(t.TempDir(), ".claude")
+	require.NoError(t, os.MkdirAll(claudeDir, 0755))
+	// 目录组件的目标是普通文件时无法提取
+	for _, component := range []string{"agents", "output-styles"} {
+		require.NoError(t, os.WriteFile(filepath.Join(claudeDir, component), []byte("not a directory"), 0644))
+	}
+
+	manager := NewManager(claudeDir)
+	manager.SetLogger(logging.Nop())
+	for i := 0; i < 5; i++ {
+		result, err := manager.Install(context.Background(), Options{All: true, Force: true, Parallel: 4})
+		var installErr *InstallError
+		require.ErrorAs(t, err, &installErr)
+		assert.Equal(t, "agents", installErr.Component)
+		require.Len(t, result.Components, 1)
+		assert.NoFileExists(t, filepath.Join(claudeDir, "settings.json"), "前面的组件失败时不合并settings.json")
+	}
+}
+
+func TestManager_SetLogger(t *testing.T) {
+	claudeDir := filepath.Join(t.TempDir(), ".claude")
+	ctx := context.Background()
+	require.NoError(t, os.MkdirAll(claudeDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(claudeDir, "settings.json"), []byte(`{"env": {"http_proxy": "http://127.0.0.1:7890"}}`), 0644))
+
+	// 默认输出到stdout，改为no-op后不输出任何提示
+	quiet := NewManager(claudeDir)
+	quiet.SetLogger(logging.Nop())
+	_, err := quiet.Install(ctx, Options{Agents: true})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	manager := NewManager(claudeDir)
+	manager.SetLogger(logging.New(&buf, false))
+	_, err = manager.Install(ctx, Options{Settings: true})
+	require.NoError(t, err)
+
+	// 合并提示只输出一次
+	assert.Equal(t, 1, strings.Count(buf.String(), "保留用户代理设置"), buf.String())
+}
+
+func TestManager_Install_Result(t *testing.T) {
+	claudeDir := filepath.Join(t.TempDir(), ".claude")
+	manager := NewManager(claudeDir)
+	manager.SetLogger(logging.Nop())
+	ctx := context.Background()
+
+	result, err := manager.Install(ctx, Options{Commands: true, Settings: true, Statusline: true})
+	require.NoError(t, err)
+	assert.False(t, result.DryRun)
+	require.Len(t, result.Components, 3)
+
+	commands := result.Component("commands")
+	require.NotNil(t, commands)
+	assert.NotEmpty(t, commands.Created)
+	assert.Empty(t, commands.Updated)
+	for _, file := range commands.Created {
+		assert.FileExists(t, filepath.Join(claudeDir, filepath.FromSlash(file)))
+	}
+	assert.NotEmpty(t, result.Component("settings.json").Merged)
+	assert.Equal(t, []string{"statusline.js"}, result.Component("statusline.js").Created)
+
+	// 再次安装: 目录组件内容相同，statusline.js 已存在而跳过，孤立文件被删除
+	installed := len(commands.Created)
+	changed := commands.Created[0]
+	require.NoError(t, os.WriteFile(filepath.Join(claudeDir, filepath.FromSlash(changed)), []byte("local"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(claudeDir, "commands", "orphaned.md"), []byte("orphaned"), 0644))
+	markManaged(t, claudeDir, "commands", "commands/orphaned.md")
+
+	result, err = manager.Install(ctx, Options{Commands: true, Statusline: true, Delete: true, Force: true})
+	require.NoError(t, err)
+	commands = result.Component("commands")
+	assert.Equal(t, []string{changed}, commands.Updated)
+	assert.Empty(t, commands.Created)
+	assert.Len(t, commands.Unchanged, installed-1)
+	assert.Equal(t, 1, commands.Written())
+	assert.Equal(t, []string{"commands/orphaned.md"}, commands.Deleted)
+	assert.False(t, result.Component("statusline.js").Skipped, "指定 --force 时不跳过")
+
+	result, err = manager.Install(ctx, Options{Statusline: true, DryRun: true})
+	require.NoError(t, err)
+	assert.True(t, result.DryRun)
+	assert.True(t, result.Component("statusline.js").Skipped)
+	assert.Nil(t, result.Component("commands"))
+}
+
+func TestManager_Install_DryRun(t *testing.T) {
+	tempDir := t.TempDir()
+	claudeDir := filepath.Join(tempDir, ".claude")
+	manager := NewManager(claudeDir)
+	ctx := context.Background()
+
+	// 全新安装的预览不创建任何文件
+	_, err := manager.Install(ctx, Options{All: true, DryRun: true})
+	assert.NoError(t, err)
+	assert.NoDirExists(t, claudeDir)
+
+	// 已有安装时预览不修改现有文件
+	_, err = manager.Install(ctx, Options{All: true})
+	assert.NoError(t, err)
+	settingsPath := filepath.Join(claudeDir, "settings.json")
+	custom := []byte(`{"env": {"MY_VAR": "1"}}`)
+	assert.NoError(t, os.WriteFile(settingsPath, custom, 0644))
+	claudeMdPath := filepath.Join(claudeDir, "CLAUDE.md")
+	assert.NoError(t, os.WriteFile(claudeMdPath, []byte("local edits"), 0644))
+	orphanedFile := filepath.Join(claudeDir, "commands", "orphaned.md")
+	assert.NoError(t, os.WriteFile(orphanedFile, []byte("orphaned"), 0644))
+
+	_, err = manager.Install(ctx, Options{All: true, Force: true, Delete: true, DryRun: true})
+	assert.NoError(t, err)
+
+	data, err := os.ReadFile(settingsPath)
+	assert.NoError(t, err)
+	assert.Equal(t, custom, data)
+	data, err = os.ReadFile(claudeMdPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "local edits", string(data))
+	assert.FileExists(t, orphanedFile, "dry-run 即使配合 --force 也不删除文件")
 }
 
 func TestNewResourceManager(t *testing.T) {
@@ -163,7 +343,7 @@ func TestResourceManager_ExtractFile(t *testing.T) {
 	tempDir := t.TempDir()
 	destPath := filepath.Join(tempDir, "settings.json")
 
-	err := manager.ExtractFile("settings.json", destPath)
+	_, err := manager.ExtractFile("settings.json", destPath)
 	assert.NoError(t, err)
 	assert.FileExists(t, destPath)
 
@@ -173,13 +353,64 @@ func TestResourceManager_ExtractFile(t *testing.T) {
 	assert.NotEmpty(t, content)
 }
 
+func TestResourceManager_ExtractFile_SkipsUnchanged(t *testing.T) {
+	manager := NewResourceManager()
+	destPath := filepath.Join(t.TempDir(), "CLAUDE.md")
+
+	stats, err := manager.ExtractFile("CLAUDE.md.template", destPath)
+	assert.NoError(t, err)
+	assert.Equal(t, ExtractStats{Written: 1}, stats)
+
+	// 内容相同时不重写，修改时间保持不变
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	assert.NoError(t, os.Chtimes(destPath, past, past))
+
+	stats, err = manager.ExtractFile("CLAUDE.md.template", destPath)
+	assert.NoError(t, err)
+	assert.Equal(t, ExtractStats{Skipped: 1}, stats)
+	info, err := os.Stat(destPath)
+	assert.NoError(t, err)
+	assert.True(t, info.ModTime().Equal(past), "未变化的文件不应被重写")
+
+	// 内容不同时覆盖
+	assert.NoError(t, os.WriteFile(destPath, []byte("local edits"), 0644))
+	stats, err = manager.ExtractFile("CLAUDE.md.template", destPath)
+	assert.NoError(t, err)
+	assert.Equal(t, ExtractStats{Written: 1}, stats)
+}
+
+func TestResourceManager_ExtractDirectory_SkipsUnchanged(t *testing.T) {
+	manager := NewResourceManager()
+	destDir := filepath.Join(t.TempDir(), "commands")
+
+	first, err := manager.ExtractDirectory(context.Background(), "commands", destDir)
+	assert.NoError(t, err)
+	assert.Positive(t, first.Written)
+	assert.Zero(t, first.Skipped)
+
+	entries, err := os.ReadDir(destDir)
+	assert.NoError(t, err)
+	var changed string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			changed = entry.Name()
+			break
+		}
+	}
+	assert.NoError(t, os.WriteFile(filepath.Join(destDir, changed), []byte("stale"), 0644))
+
+	second, err := manager.ExtractDirectory(context.Background(), "commands", destDir)
+	assert.NoError(t, err)
+	assert.Equal(t, ExtractStats{Written: 1, Skipped: first.Written - 1}, second)
+}
+
 func TestResourceManager_ExtractFile_NotFound(t *testing.T) {
 	manager := NewResourceManager()
 
 	tempDir := t.TempDir()
 	destPath := filepath.Join(tempDir, "nonexistent.json")
 
-	err := manager.ExtractFile("nonexistent.json", destPath)
+	_, err := manager.ExtractFile("nonexistent.json", destPath)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "读取嵌入文件失败")
 }
@@ -231,7 +462,7 @@ func TestResourceManager_ExtractFileWithPermissions(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			destPath := filepath.Join(tempDir, tt.destFileName)
 
-			err := manager.ExtractFile(tt.srcFile, destPath)
+			_, err := manager.ExtractFile(tt.srcFile, destPath)
 
 			// 如果源文件不存在，跳过测试
 			if err != nil && strings.Contains(err.Error(), "file does not exist") {
@@ -259,7 +490,7 @@ func TestResourceManager_ExtractDirectoryWithPermissions(t *testing.T) {
 	tempDir := t.TempDir()
 	destDir := filepath.Join(tempDir, "hooks")
 
-	err := manager.ExtractDirectory("hooks", destDir)
+	_, err := manager.ExtractDirectory(context.Background(), "hooks", destDir)
 	assert.NoError(t, err)
 	assert.DirExists(t, destDir)
 
@@ -330,7 +561,7 @@ func TestManager_InstallPreservesPermissions(t *testing.T) {
 		Hooks: true,
 	}
 
-	err := manager.Install(ctx, options)
+	_, err := manager.Install(ctx, options)
 	assert.NoError(t, err)
 
 	// 验证hooks目录和文件权限
@@ -416,6 +647,18 @@ func TestIsSpecialFile(t *testing.T) {
 	}
 }
 
+// TestEmbedPath 测试嵌入路径在所有平台上都使用 / 分隔，embed.FS 不接受 Windows 分隔符
+func TestEmbedPath(t *testing.T) {
+	assert.Equal(t, "claude-config/agents", embedPath("agents"))
+	assert.Equal(t, "claude-config/hooks/smart-lint.sh", embedPath("hooks/smart-lint.sh"))
+	assert.Equal(t, "claude-config/hooks/smart-lint.sh", embedPath(filepath.Join("hooks", "smart-lint.sh")))
+
+	rm := NewResourceManager()
+	data, err := rm.ReadFile(filepath.Join("hooks", "smart-lint.sh"))
+	require.NoError(t, err)
+	assert.NotEmpty(t, data)
+}
+
 func TestManager_listEmbeddedFilesForComponent(t *testing.T) {
 	manager := NewManager("/tmp/test-claude")
 
@@ -535,16 +778,17 @@ func TestManager_listOrphanedFiles(t *testing.T) {
 
 	// 先安装commands组件以获得嵌入资源
 	ctx := context.Background()
-	err := manager.Install(ctx, Options{Commands: true})
+	_, err := manager.Install(ctx, Options{Commands: true})
 	assert.NoError(t, err)
 
-	// 添加一些孤立文件
+	// 添加一些孤立文件，模拟旧版本安装、现已不再内置的文件
 	commandsDir := filepath.Join(claudeDir, "commands")
 	orphanedFiles := []string{"orphaned1.md", "orphaned2.md"}
 	for _, file := range orphanedFiles {
 		filePath := filepath.Join(commandsDir, file)
 		err := os.WriteFile(filePath, []byte("orphaned"), 0644)
 		assert.NoError(t, err)
+		markManaged(t, claudeDir, "commands", "commands/"+file)
 	}
 
 	// 获取孤立文件列表
@@ -570,7 +814,7 @@ func TestManager_cleanupOrphanedFiles_DryRun(t *testing.T) {
 
 	// 先安装commands组件
 	ctx := context.Background()
-	err := manager.Install(ctx, Options{Commands: true})
+	_, err := manager.Install(ctx, Options{Commands: true})
 	assert.NoError(t, err)
 
 	// 添加孤立文件
@@ -578,6 +822,7 @@ func TestManager_cleanupOrphanedFiles_DryRun(t *testing.T) {
 	orphanedFile := filepath.Join(commandsDir, "orphaned.md")
 	err = os.WriteFile(orphanedFile, []byte("orphaned"), 0644)
 	assert.NoError(t, err)
+	markManaged(t, claudeDir, "commands", "commands/orphaned.md")
 
 	// 执行dry-run删除 (Delete=true, Force=false)
 	options := Options{
@@ -586,8 +831,11 @@ func TestManager_cleanupOrphanedFiles_DryRun(t *testing.T) {
 		Force:    false, // dry-run模式
 	}
 
-	err = manager.cleanupOrphanedFiles("commands", options)
+	result := &ComponentResult{Component: "commands"}
+	err = manager.cleanupOrphanedFiles(result, options)
 	assert.NoError(t, err)
+	assert.Equal(t, []string{"commands/orphaned.md"}, result.Orphaned)
+	assert.Empty(t, result.Deleted)
 
 	// 验证文件仍然存在 (dry-run不删除)
 	assert.FileExists(t, orphanedFile, "Dry-run模式不应删除文件")
@@ -600,7 +848,7 @@ func TestManager_cleanupOrphanedFiles_ActualDelete(t *testing.T) {
 
 	// 先安装commands组件
 	ctx := context.Background()
-	err := manager.Install(ctx, Options{Commands: true})
+	_, err := manager.Install(ctx, Options{Commands: true})
 	assert.NoError(t, err)
 
 	// 添加孤立文件
@@ -608,6 +856,7 @@ func TestManager_cleanupOrphanedFiles_ActualDelete(t *testing.T) {
 	orphanedFile := filepath.Join(commandsDir, "orphaned.md")
 	err = os.WriteFile(orphanedFile, []byte("orphaned"), 0644)
 	assert.NoError(t, err)
+	markManaged(t, claudeDir, "commands", "commands/orphaned.md")
 
 	// 执行实际删除 (Delete=true, Force=true)
 	options := Options{
@@ -616,8 +865,11 @@ func TestManager_cleanupOrphanedFiles_ActualDelete(t *testing.T) {
 		Force:    true, // 实际删除模式
 	}
 
-	err = manager.cleanupOrphanedFiles("commands", options)
+	result := &ComponentResult{Component: "commands"}
+	err = manager.cleanupOrphanedFiles(result, options)
 	assert.NoError(t, err)
+	assert.Equal(t, []string{"commands/orphaned.md"}, result.Deleted)
+	assert.Empty(t, result.Orphaned)
 
 	// 验证文件已被删除
 	assert.NoFileExists(t, orphanedFile, "实际删除模式应该删除文件")
@@ -631,7 +883,7 @@ func TestManager_Install_WithDelete(t *testing.T) {
 	ctx := context.Background()
 
 	// 第一次安装
-	err := manager.Install(ctx, Options{Commands: true})
+	_, err := manager.Install(ctx, Options{Commands: true})
 	assert.NoError(t, err)
 
 	// 添加孤立文件
@@ -639,9 +891,10 @@ func TestManager_Install_WithDelete(t *testing.T) {
 	orphanedFile := filepath.Join(commandsDir, "orphaned.md")
 	err = os.WriteFile(orphanedFile, []byte("orphaned"), 0644)
 	assert.NoError(t, err)
+	markManaged(t, claudeDir, "commands", "commands/orphaned.md")
 
 	// 第二次安装,启用删除功能
-	err = manager.Install(ctx, Options{
+	_, err = manager.Install(ctx, Options{
 		Commands: true,
 		Delete:   true,
 		Force:    true,
@@ -683,10 +936,164 @@ func TestManager_cleanupOrphanedFiles_SkipSpecialFiles(t *testing.T) {
 	}
 
 	// settings.json组件会被跳过
-	err = manager.cleanupOrphanedFiles("settings.json", options)
+	err = manager.cleanupOrphanedFiles(&ComponentResult{Component: "settings.json"}, options)
 	assert.NoError(t, err)
 
 	// 验证特殊文件仍然存在
 	assert.FileExists(t, settingsFile, "settings.json不应被删除")
 	assert.FileExists(t, claudeMdFile, "CLAUDE.md不应被删除")
 }
+
+func TestResourceManager_ExtractDirectory_InstallError(t *testing.T) {
+	manager := NewResourceManager()
+
+	_, err := manager.ExtractDirectory(context.Background(), "nonexistent", t.TempDir())
+	assert.Error(t, err)
+
+	var installErr *InstallError
+	if assert.ErrorAs(t, err, &installErr) {
+		assert.Equal(t, "nonexistent", installErr.Component)
+		assert.Equal(t, filepath.Join("claude-config", "nonexistent"), installErr.EmbedPath)
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+		assert.Contains(t, err.Error(), installErr.EmbedPath)
+	}
+}
+
+func TestManager_installComponent_InstallError(t *testing.T) {
+	tempDir := t.TempDir()
+	claudeDir := filepath.Join(tempDir, ".claude")
+	manager := NewManager(claudeDir)
+
+	// 让目标路径成为目录，使文件写入失败
+	assert.NoError(t, os.MkdirAll(filepath.Join(claudeDir, "statusline.js"), 0755))
+
+	_, err := manager.installComponent(context.Background(), "statusline.js", Options{Force: true})
+	assert.Error(t, err)
+
+	var installErr *InstallError
+	if assert.ErrorAs(t, err, &installErr) {
+		assert.Equal(t, "statusline.js", installErr.Component)
+		assert.Equal(t, filepath.Join("claude-config", "statusline.js"), installErr.EmbedPath)
+	}
+}
+
+func TestManager_InstallFile(t *testing.T) {
+	claudeDir := filepath.Join(t.TempDir(), ".claude")
+	manager := NewManager(claudeDir)
+	ctx := context.Background()
+
+	assert.NoError(t, manager.InstallFile(ctx, "hooks/smart-lint.sh", false))
+
+	// 只安装指定的文件，权限与整体安装一致
+	entries, err := os.ReadDir(filepath.Join(claudeDir, "hooks"))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	info, err := os.Stat(filepath.Join(claudeDir, "hooks", "smart-lint.sh"))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+
+	manifest, err := LoadManifest(claudeDir)
+	assert.NoError(t, err)
+	assert.True(t, manifest.IsManaged("hooks", "hooks/smart-lint.sh"))
+
+	// 已存在的文件需要force才覆盖
+	target := filepath.Join(claudeDir, "hooks", "smart-lint.sh")
+	assert.NoError(t, os.WriteFile(target, []byte("local"), 0755))
+	assert.NoError(t, manager.InstallFile(ctx, "hooks/smart-lint.sh", false))
+	data, err := os.ReadFile(target)
+	assert.NoError(t, err)
+	assert.Equal(t, "local", string(data))
+
+	assert.NoError(t, manager.InstallFile(ctx, "./hooks//smart-lint.sh", true))
+	data, err = os.ReadFile(target)
+	assert.NoError(t, err)
+	assert.NotEqual(t, "local", string(data))
+}
+
+func TestManager_InstallFile_Invalid(t *testing.T) {
+	manager := NewManager(filepath.Join(t.TempDir(), ".claude"))
+
+	tests := []struct {
+		relPath string
+		wantErr string
+	}{
+		{"agents/missing.md", "嵌入资源中不存在文件"},
+		{"../settings.json", "无效的文件路径"},
+		{"/etc/passwd", "无效的文件路径"},
+		{"agents", "是目录组件"},
+		{"settings.json", "不是目录组件中的文件"},
+		{"resources/x.md", "未知组件"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.relPath, func(t *testing.T) {
+			err := manager.InstallFile(context.Background(), tt.relPath, true)
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestResourceManager_FileHash(t *testing.T) {
+	manager := NewResourceManager()
+
+	hash, err := manager.FileHash("settings.json")
+	assert.NoError(t, err)
+	data, err := manager.ReadFile("settings.json")
+	assert.NoError(t, err)
+	sum := sha256.Sum256(data)
+	assert.Equal(t, hex.EncodeToString(sum[:]), hash)
+
+	_, err = manager.FileHash("missing.json")
+	assert.Error(t, err)
+}
+
+func TestManager_Outdated(t *testing.T) {
+	claudeDir := filepath.Join(t.TempDir(), ".claude")
+	manager := NewManager(claudeDir)
+	ctx := context.Background()
+
+	// 未安装的文件不算过期
+	outdated, err := manager.Outdated("hooks")
+	assert.NoError(t, err)
+	assert.Empty(t, outdated)
+
+	_, err = manager.Install(ctx, Options{Hooks: true, Claude: true, Settings: true})
+	assert.NoError(t, err)
+	outdated, err = manager.Outdated("hooks")
+	assert.NoError(t, err)
+	assert.Empty(t, outdated)
+
+	changed := filepath.Join("hooks", "smart-lint.sh")
+	assert.NoError(t, os.WriteFile(filepath.Join(claudeDir, changed), []byte("#!/bin/sh\n"), 0755))
+	outdated, err = manager.Outdated("hooks")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{changed}, outdated)
+
+	// settings.json 和 CLAUDE.md 等特殊文件跳过
+	assert.NoError(t, os.WriteFile(filepath.Join(claudeDir, "CLAUDE.md"), []byte("local edits"), 0644))
+	for _, component := range []string{"settings.json", "CLAUDE.md.template"} {
+		outdated, err = manager.Outdated(component)
+		assert.NoError(t, err)
+		assert.Empty(t, outdated, component)
+	}
+}
+
+func BenchmarkManager_Install(b *testing.B) {
+	for _, parallel := range []int{0, 4} {
+		b.Run(fmt.Sprintf("parallel=%d", parallel), func(b *testing.B) {
+			options := Options{All: true, Hooks: true, Force: true, Parallel: parallel}
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				claudeDir := filepath.Join(b.TempDir(), ".claude")
+				manager := NewManager(claudeDir)
+				manager.SetLogger(logging.Nop())
+				b.StartTimer()
+
+				if _, err := manager.Install(context.Background(), options); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package install
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// manifestFile 记录install写入过的文件，位于配置目录下
+const manifestFile = ".install_manifest.json"
+
+// Manifest 安装清单，记录每个组件由install管理的文件，用于区分用户自己创建的文件
+type Manifest struct {
+	Components map[string]*ManifestEntry `json:"components"`
+}
+
+// ManifestEntry 单个组件的安装记录
+type ManifestEntry struct {
+	Hash  string   `json:"hash"`  // 最近一次安装的嵌入资源内容的sha256
+	Files []string `json:"files"` // 相对于配置目录的文件路径，使用 / 分隔
+}
+
+// LoadManifest 读取配置目录中的安装清单，文件不存在时返回空清单
+func LoadManifest(claudeDir string) (*Manifest, error) {
+	manifest := &Manifest{Components: make(map[string]*ManifestEntry)}
+
+	data, err := os.ReadFile(filepath.Join(claudeDir, manifestFile))
+	if os.IsNotExist(err) {
+		return manifest, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取安装清单失败: %w", err)
+	}
+
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("解析安装清单失败: %w", err)
+	}
+	if manifest.Components == nil {
+		manifest.Components = make(map[string]*ManifestEntry)
+	}
+
+	return manifest, nil
+}
+
+// SaveManifest 将安装清单写入配置目录
+func SaveManifest(claudeDir string, manifest *Manifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化安装清单失败: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(claudeDir, manifestFile), data, 0644); err != nil {
+		return fmt.Errorf("写入安装清单失败: %w", err)
+	}
+
+	return nil
+}
+
+// HasComponent 检查清单中是否有组件的安装记录
+func (mf *Manifest) HasComponent(component string) bool {
+	_, exists := mf.Components[component]
+	return exists
+}
+
+// IsManaged 检查文件是否曾由install为该组件写入
+func (mf *Manifest) IsManaged(component, file string) bool {
+	entry, exists := mf.Components[component]
+	if !exists {
+		return false
+	}
+
+	file = filepath.ToSlash(file)
+	for _, managed := range entry.Files {
+		if managed == file {
+			return true
+		}
+	}
+	return false
+}
+
+// Record 记录组件的一次安装。之前记录的文件会保留，
+// 以便旧版本安装、现已不再内置的文件仍能被识别为孤立文件
+func (mf *Manifest) Record(component, hash string, files []string) {
+	seen := make(map[string]bool)
+	var merged []string
+	add := func(file string) {
+		file = filepath.ToSlash(file)
+		if !seen[file] {
+			seen[file] = true
+			merged = append(merged, file)
+		}
+	}
+
+	if entry, exists := mf.Components[component]; exists {
+		for _, file := range entry.Files {
+			add(file)
+		}
+	}
+	for _, file := range files {
+		add(file)
+	}
+	sort.Strings(merged)
+
+	mf.Components[component] = &ManifestEntry{Hash: hash, Files: merged}
+}
+
+// Forget 从组件的记录中移除已删除的文件
+func (mf *Manifest) Forget(component string, files []string) {
+	entry, exists := mf.Components[component]
+	if !exists {
+		return
+	}
+
+	removed := make(map[string]bool, len(files))
+	for _, file := range files {
+		removed[filepath.ToSlash(file)] = true
+	}
+
+	remaining := entry.Files[:0]
+	for _, file := range entry.Files {
+		if !removed[file] {
+			remaining = append(remaining, file)
+		}
+	}
+	entry.Files = remaining
+}
+
+// componentSources 返回组件对应的嵌入资源文件，路径相对于 claude-config
+func (m *Manager) componentSources(component string) ([]string, error) {
+	switch component {
+	case "settings.json", "CLAUDE.md.template", "statusline.js":
+		return []string{component}, nil
+	default:
+		return m.listEmbeddedFilesForComponent(component)
+	}
+}
+
+// installedPath 返回嵌入资源安装到配置目录后的相对路径
+func installedPath(source string) string {
+	if source == "CLAUDE.md.template" {
+		return "CLAUDE.md"
+	}
+	return source
+}
+
+// recordComponent 将组件写入的文件和嵌入资源哈希记录到安装清单
+func (m *Manager) recordComponent(component string) error {
+	sources, err := m.componentSources(component)
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.New()
+	files := make([]string, 0, len(sources))
+	for _, source := range sources {
+		data, err := m.resources.ReadFile(source)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(hash, "%s\x00%d\x00", filepath.ToSlash(source), len(data))
+		hash.Write(data)
+		files = append(files, installedPath(source))
+	}
+
+	manifest, err := LoadManifest(m.claudeDir)
+	if err != nil {
+		return err
+	}
+	manifest.Record(component, hex.EncodeToString(hash.Sum(nil)), files)
+	return SaveManifest(m.claudeDir, manifest)
+}
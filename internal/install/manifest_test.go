@@ -0,0 +1,122 @@
+package install
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// markManaged 将文件记录为组件曾经安装过的文件，模拟旧版本内置、现已移除的资源
+func markManaged(t *testing.T, claudeDir, component string, files ...string) {
+	t.Helper()
+
+	manifest, err := LoadManifest(claudeDir)
+	require.NoError(t, err)
+	manifest.Record(component, manifest.Components[component].Hash, files)
+	require.NoError(t, SaveManifest(claudeDir, manifest))
+}
+
+func TestLoadSaveManifest(t *testing.T) {
+	claudeDir := t.TempDir()
+
+	// 文件不存在时返回空清单
+	manifest, err := LoadManifest(claudeDir)
+	require.NoError(t, err)
+	assert.Empty(t, manifest.Components)
+	assert.False(t, manifest.HasComponent("hooks"))
+
+	manifest.Record("hooks", "abc", []string{"hooks/b.sh", "hooks/a.sh"})
+	manifest.Record("hooks", "def", []string{"hooks/a.sh", "hooks/c.sh"})
+	require.NoError(t, SaveManifest(claudeDir, manifest))
+
+	loaded, err := LoadManifest(claudeDir)
+	require.NoError(t, err)
+	require.True(t, loaded.HasComponent("hooks"))
+	assert.Equal(t, "def", loaded.Components["hooks"].Hash)
+	assert.Equal(t, []string{"hooks/a.sh", "hooks/b.sh", "hooks/c.sh"}, loaded.Components["hooks"].Files, "之前记录的文件会保留")
+	assert.True(t, loaded.IsManaged("hooks", filepath.Join("hooks", "b.sh")))
+	assert.False(t, loaded.IsManaged("hooks", "hooks/mine.sh"))
+	assert.False(t, loaded.IsManaged("agents", "hooks/a.sh"))
+
+	loaded.Forget("hooks", []string{"hooks/b.sh"})
+	assert.Equal(t, []string{"hooks/a.sh", "hooks/c.sh"}, loaded.Components["hooks"].Files)
+
+	require.NoError(t, os.WriteFile(filepath.Join(claudeDir, manifestFile), []byte("{"), 0644))
+	_, err = LoadManifest(claudeDir)
+	assert.Error(t, err)
+}
+
+func TestManager_Install_RecordsManifest(t *testing.T) {
+	claudeDir := filepath.Join(t.TempDir(), ".claude")
+	manager := NewManager(claudeDir)
+	ctx := context.Background()
+
+	_, err := manager.Install(ctx, Options{Commands: true, Claude: true})
+	require.NoError(t, err)
+
+	manifest, err := LoadManifest(claudeDir)
+	require.NoError(t, err)
+	require.True(t, manifest.HasComponent("commands"))
+	assert.NotEmpty(t, manifest.Components["commands"].Hash)
+	assert.True(t, manifest.IsManaged("CLAUDE.md.template", "CLAUDE.md"))
+
+	embedded, err := manager.listEmbeddedFilesForComponent("commands")
+	require.NoError(t, err)
+	for _, file := range embedded {
+		assert.True(t, manifest.IsManaged("commands", file), file)
+	}
+
+	// dry-run 和跳过的组件不更新清单
+	_, err = manager.Install(ctx, Options{Agents: true, DryRun: true})
+	require.NoError(t, err)
+	_, err = manager.Install(ctx, Options{Commands: true})
+	require.NoError(t, err)
+	manifest, err = LoadManifest(claudeDir)
+	require.NoError(t, err)
+	assert.False(t, manifest.HasComponent("agents"))
+}
+
+func TestManager_Install_WithDelete_KeepsUserFiles(t *testing.T) {
+	claudeDir := filepath.Join(t.TempDir(), ".claude")
+	manager := NewManager(claudeDir)
+	ctx := context.Background()
+
+	_, err := manager.Install(ctx, Options{Commands: true})
+	require.NoError(t, err)
+
+	// 旧版本安装的文件会被清理，用户自己创建的文件保留
+	staleFile := filepath.Join(claudeDir, "commands", "retired.md")
+	userFile := filepath.Join(claudeDir, "commands", "my-command.md")
+	require.NoError(t, os.WriteFile(staleFile, []byte("retired"), 0644))
+	require.NoError(t, os.WriteFile(userFile, []byte("mine"), 0644))
+	markManaged(t, claudeDir, "commands", "commands/retired.md")
+
+	_, err = manager.Install(ctx, Options{Commands: true, Delete: true, Force: true})
+	require.NoError(t, err)
+
+	assert.NoFileExists(t, staleFile)
+	assert.FileExists(t, userFile)
+
+	manifest, err := LoadManifest(claudeDir)
+	require.NoError(t, err)
+	assert.False(t, manifest.IsManaged("commands", "commands/retired.md"), "删除后从清单中移除")
+}
+
+func TestManager_listOrphanedFiles_WithoutManifest(t *testing.T) {
+	claudeDir := filepath.Join(t.TempDir(), ".claude")
+	manager := NewManager(claudeDir)
+
+	_, err := manager.Install(context.Background(), Options{Commands: true})
+	require.NoError(t, err)
+	require.NoError(t, os.Remove(filepath.Join(claudeDir, manifestFile)))
+
+	// 没有安装记录时(旧版本安装)，所有不在嵌入资源中的文件都算孤立文件
+	require.NoError(t, os.WriteFile(filepath.Join(claudeDir, "commands", "extra.md"), []byte("x"), 0644))
+	orphaned, err := manager.listOrphanedFiles("commands")
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join("commands", "extra.md")}, orphaned)
+}
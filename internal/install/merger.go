@@ -5,14 +5,149 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/ooneko/claude-config/internal/config"
+	"github.com/ooneko/claude-config/internal/logging"
+)
+
+// MergeStrategy 合并settings.json时同名环境变量取值不同的处理方式
+type MergeStrategy string
+
+const (
+	// MergeDefault 代理配置和固定环境变量保留目标文件的值，其余以源文件为准
+	MergeDefault MergeStrategy = ""
+	// PreferSource 源文件完全优先，代理配置和固定环境变量也会被覆盖
+	PreferSource MergeStrategy = "source"
+	// PreferTarget 目标文件中已有的环境变量保持不变，只添加新的环境变量
+	PreferTarget MergeStrategy = "target"
+	// FailOnConflict 存在将被覆盖的环境变量时返回错误，不写入文件
+	FailOnConflict MergeStrategy = "fail"
 )
 
+// MergeStrategies 可选的合并策略名称
+var MergeStrategies = []MergeStrategy{PreferSource, PreferTarget, FailOnConflict}
+
+// ParseMergeStrategy 解析合并策略名称，空字符串表示默认策略
+func ParseMergeStrategy(name string) (MergeStrategy, error) {
+	if name == "" {
+		return MergeDefault, nil
+	}
+	for _, strategy := range MergeStrategies {
+		if MergeStrategy(name) == strategy {
+			return strategy, nil
+		}
+	}
+
+	names := make([]string, len(MergeStrategies))
+	for i, strategy := range MergeStrategies {
+		names[i] = string(strategy)
+	}
+	return MergeDefault, fmt.Errorf("未知的合并策略: %s (可选: %s)", name, strings.Join(names, ", "))
+}
+
 // SettingsJSONMerger settings.json智能合并器
-type SettingsJSONMerger struct{}
+type SettingsJSONMerger struct {
+	// pinned 固定的环境变量，目标文件中已有的值始终保留
+	pinned []string
+	// strategy 目标文件已存在时环境变量冲突的处理方式
+	strategy MergeStrategy
+	// logger 接收合并过程的提示信息
+	logger logging.Logger
+}
 
 // NewSettingsJSONMerger 创建新的settings.json合并器
 func NewSettingsJSONMerger() *SettingsJSONMerger {
-	return &SettingsJSONMerger{}
+	return &SettingsJSONMerger{logger: logging.Default()}
+}
+
+// SetLogger 设置合并过程提示信息的输出位置
+func (m *SettingsJSONMerger) SetLogger(logger logging.Logger) {
+	m.logger = logger
+}
+
+// SetPinnedKeys 设置固定的环境变量，合并时与代理配置一样受保护
+func (m *SettingsJSONMerger) SetPinnedKeys(keys []string) {
+	m.pinned = keys
+}
+
+// SetMergeStrategy 设置环境变量冲突的处理方式
+func (m *SettingsJSONMerger) SetMergeStrategy(strategy MergeStrategy) {
+	m.strategy = strategy
+}
+
+// FilterExistingEnvFromSource 从源数据中移除目标文件已设置的环境变量，使已有的值保持不变
+func (m *SettingsJSONMerger) FilterExistingEnvFromSource(sourceData, targetData map[string]interface{}) map[string]interface{} {
+	targetEnv, ok := targetData["env"].(map[string]interface{})
+	if !ok {
+		return sourceData
+	}
+	if _, ok := sourceData["env"].(map[string]interface{}); !ok {
+		return sourceData
+	}
+
+	result := m.deepCopyValue(sourceData).(map[string]interface{})
+	env := result["env"].(map[string]interface{})
+	for key := range targetEnv {
+		delete(env, key)
+	}
+	if len(env) == 0 {
+		delete(result, "env")
+	}
+
+	return result
+}
+
+// EnvConflicts 返回源数据和目标文件中都存在但取值不同的环境变量，按名称排序
+func (m *SettingsJSONMerger) EnvConflicts(sourceData, targetData map[string]interface{}) []string {
+	sourceEnv, _ := sourceData["env"].(map[string]interface{})
+	targetEnv, _ := targetData["env"].(map[string]interface{})
+
+	var conflicts []string
+	for key, value := range sourceEnv {
+		if targetValue, exists := targetEnv[key]; exists && !reflect.DeepEqual(targetValue, value) {
+			conflicts = append(conflicts, key)
+		}
+	}
+	sort.Strings(conflicts)
+
+	return conflicts
+}
+
+// FilterPinnedFromSource 从源数据中移除目标文件已设置的固定环境变量，返回被保留的键
+func (m *SettingsJSONMerger) FilterPinnedFromSource(sourceData, targetData map[string]interface{}) (map[string]interface{}, []string) {
+	targetEnv, ok := targetData["env"].(map[string]interface{})
+	if !ok {
+		return sourceData, nil
+	}
+	sourceEnv, ok := sourceData["env"].(map[string]interface{})
+	if !ok {
+		return sourceData, nil
+	}
+
+	var kept []string
+	for _, key := range m.pinned {
+		value, exists := targetEnv[key]
+		if !exists || value == "" {
+			continue
+		}
+		if _, inSource := sourceEnv[key]; inSource {
+			kept = append(kept, key)
+		}
+	}
+	if len(kept) == 0 {
+		return sourceData, nil
+	}
+
+	result := m.deepCopyValue(sourceData).(map[string]interface{})
+	env := result["env"].(map[string]interface{})
+	for _, key := range kept {
+		delete(env, key)
+	}
+
+	return result, kept
 }
 
 // ShouldPreserveProxyConfig 检查是否应该保留目标文件中的代理配置
@@ -37,8 +172,9 @@ func (m *SettingsJSONMerger) FilterProxyFromSource(sourceData map[string]interfa
 	}
 
 	if env, ok := result["env"].(map[string]interface{}); ok {
-		delete(env, "http_proxy")
-		delete(env, "https_proxy")
+		for _, key := range config.ProxyEnvKeys {
+			delete(env, key)
+		}
 
 		// 如果env为空，删除env字段
 		if len(env) == 0 {
@@ -223,55 +359,149 @@ func (m *SettingsJSONMerger) MergeSettings(targetFile, sourceFile string) error
 		return fmt.Errorf("读取源文件失败: %w", err)
 	}
 
+	targetData, mergedData, preserveProxy, err := m.mergeData(targetFile, sourceData)
+	if err != nil {
+		return err
+	}
+
+	// 目标文件不存在，直接写入过滤后的源配置
+	if targetData == nil {
+		return m.writeJSONFile(targetFile, mergedData)
+	}
+
+	// 检查是否有变化
+	if !m.isEqual(mergedData, targetData) {
+		m.logger.Info("🔄 检测到settings.json配置变化")
+		m.logger.Info("将进行智能合并，保留您的个人配置")
+		if preserveProxy {
+			m.logger.Info("   - 保留现有代理配置")
+		}
+
+		return m.writeJSONFile(targetFile, mergedData)
+	}
+
+	m.logger.Info("settings.json配置无变化，跳过")
+	return nil
+}
+
+// PreviewSettings 计算将sourceData合并到targetFile的结果但不写入，返回相对现有文件的差异
+func (m *SettingsJSONMerger) PreviewSettings(targetFile string, sourceData map[string]interface{}) ([]DiffEntry, error) {
+	targetData, mergedData, _, err := m.mergeData(targetFile, sourceData)
+	if err != nil {
+		return nil, err
+	}
+
+	if targetData == nil {
+		targetData = map[string]interface{}{}
+	}
+	return DiffJSON(targetData, mergedData), nil
+}
+
+// PreviewMerge 计算将sourceFile合并到targetFile的键级差异但不写入。
+// added为新增的配置项，changed为值将改变的配置项，
+// preserved为源文件中也有、但因代理保护或固定环境变量而保留目标文件值的配置项
+func (m *SettingsJSONMerger) PreviewMerge(targetFile, sourceFile string) (added, changed, preserved []string, err error) {
+	sourceData, err := m.readJSONFile(sourceFile)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("读取源文件失败: %w", err)
+	}
+
+	return m.previewMergeData(targetFile, sourceData)
+}
+
+// previewMergeData PreviewMerge 的实现，源配置已解析
+func (m *SettingsJSONMerger) previewMergeData(targetFile string, sourceData map[string]interface{}) (added, changed, preserved []string, err error) {
+	targetData, mergedData, _, err := m.mergeData(targetFile, sourceData)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if targetData == nil {
+		targetData = map[string]interface{}{}
+	}
+
+	for _, entry := range DiffJSON(targetData, mergedData) {
+		switch entry.Kind {
+		case DiffAdded:
+			added = append(added, entry.Path)
+		case DiffChanged:
+			changed = append(changed, entry.Path)
+		}
+	}
+
+	// 源文件中的值与目标不同、合并后仍是目标文件的值，说明该项受到保护
+	sourceEnv, _ := sourceData["env"].(map[string]interface{})
+	targetEnv, _ := targetData["env"].(map[string]interface{})
+	mergedEnv, _ := mergedData["env"].(map[string]interface{})
+	for key, value := range sourceEnv {
+		targetValue, exists := targetEnv[key]
+		if !exists || reflect.DeepEqual(targetValue, value) {
+			continue
+		}
+		if reflect.DeepEqual(mergedEnv[key], targetValue) {
+			preserved = append(preserved, joinDiffPath("env", key))
+		}
+	}
+	sort.Strings(preserved)
+
+	return added, changed, preserved, nil
+}
+
+// mergeData 计算合并后的配置，目标文件不存在时返回的targetData为nil
+func (m *SettingsJSONMerger) mergeData(targetFile string, sourceData map[string]interface{}) (targetData, mergedData map[string]interface{}, preserveProxy bool, err error) {
 	// 检查目标文件是否存在
 	if _, err := os.Stat(targetFile); os.IsNotExist(err) {
 		// 目标文件不存在，检查源文件是否包含代理配置
 		if env, ok := sourceData["env"].(map[string]interface{}); ok {
 			if _, hasHTTP := env["http_proxy"]; hasHTTP {
-				fmt.Println("⚠️  源文件包含代理配置，但将被跳过")
-				fmt.Println("   请使用 claude-config proxy on 来配置代理")
+				m.logger.Warn("⚠️  源文件包含代理配置，但将被跳过")
+				m.logger.Warn("   请使用 claude-config proxy on 来配置代理")
 				sourceData = m.FilterProxyFromSource(sourceData)
 			}
 			if _, hasHTTPS := env["https_proxy"]; hasHTTPS {
-				fmt.Println("⚠️  源文件包含代理配置，但将被跳过")
-				fmt.Println("   请使用 claude-config proxy on 来配置代理")
+				m.logger.Warn("⚠️  源文件包含代理配置，但将被跳过")
+				m.logger.Warn("   请使用 claude-config proxy on 来配置代理")
 				sourceData = m.FilterProxyFromSource(sourceData)
 			}
 		}
 
-		return m.writeJSONFile(targetFile, sourceData)
+		return nil, sourceData, false, nil
 	}
 
 	// 读取目标文件
-	targetData, err := m.readJSONFile(targetFile)
+	targetData, err = m.readJSONFile(targetFile)
 	if err != nil {
-		return fmt.Errorf("读取目标文件失败: %w", err)
+		return nil, nil, false, fmt.Errorf("读取目标文件失败: %w", err)
 	}
 
-	// 检查是否需要保留代理配置
-	preserveProxy := m.ShouldPreserveProxyConfig(targetData)
-
-	if preserveProxy {
-		fmt.Println("📡 检测到现有代理配置，将保留用户代理设置")
-		sourceData = m.FilterProxyFromSource(sourceData)
-	}
-
-	// 深度合并
-	mergedData := m.DeepMergeDict(targetData, sourceData)
+	switch m.strategy {
+	case PreferSource:
+		// 源文件完全优先，不做任何保护
+	case PreferTarget:
+		sourceData = m.FilterExistingEnvFromSource(sourceData, targetData)
+	default:
+		// 检查是否需要保留代理配置
+		preserveProxy = m.ShouldPreserveProxyConfig(targetData)
 
-	// 检查是否有变化
-	if !m.isEqual(mergedData, targetData) {
-		fmt.Println("🔄 检测到settings.json配置变化")
-		fmt.Println("将进行智能合并，保留您的个人配置")
 		if preserveProxy {
-			fmt.Println("   - 保留现有代理配置")
+			m.logger.Info("📡 检测到现有代理配置，将保留用户代理设置")
+			sourceData = m.FilterProxyFromSource(sourceData)
 		}
 
-		return m.writeJSONFile(targetFile, mergedData)
+		var keptPinned []string
+		sourceData, keptPinned = m.FilterPinnedFromSource(sourceData, targetData)
+		if len(keptPinned) > 0 {
+			m.logger.Info("📌 保留固定的环境变量: %s", strings.Join(keptPinned, ", "))
+		}
+
+		if m.strategy == FailOnConflict {
+			if conflicts := m.EnvConflicts(sourceData, targetData); len(conflicts) > 0 {
+				return nil, nil, false, fmt.Errorf("环境变量与现有配置冲突: %s", strings.Join(conflicts, ", "))
+			}
+		}
 	}
 
-	fmt.Println("settings.json配置无变化，跳过")
-	return nil
+	// 深度合并
+	return targetData, m.DeepMergeDict(targetData, sourceData), preserveProxy, nil
 }
 
 // readJSONFile 读取JSON文件
@@ -267,6 +267,43 @@ func TestSettingsJsonMerger_MergeSettings(t *testing.T) {
 	assert.Equal(t, true, mergedData["includeCoAuthoredBy"])
 }
 
+func TestSettingsJsonMerger_MergeSettings_PinnedEnv(t *testing.T) {
+	merger := NewSettingsJSONMerger()
+	merger.SetPinnedKeys([]string{"ANTHROPIC_BASE_URL", "UNSET_PINNED"})
+	tempDir := t.TempDir()
+
+	sourceFile := filepath.Join(tempDir, "source.json")
+	sourceJSON, _ := json.Marshal(map[string]interface{}{
+		"env": map[string]interface{}{
+			"ANTHROPIC_BASE_URL": "https://template.example.com",
+			"UNSET_PINNED":       "from_template",
+			"NEW_VAR":            "new_value",
+		},
+	})
+	require.NoError(t, os.WriteFile(sourceFile, sourceJSON, 0644))
+
+	targetFile := filepath.Join(tempDir, "target.json")
+	targetJSON, _ := json.Marshal(map[string]interface{}{
+		"env": map[string]interface{}{
+			"ANTHROPIC_BASE_URL": "https://my-gateway.example.com",
+		},
+	})
+	require.NoError(t, os.WriteFile(targetFile, targetJSON, 0644))
+
+	require.NoError(t, merger.MergeSettings(targetFile, sourceFile))
+
+	mergedData, err := merger.readJSONFile(targetFile)
+	require.NoError(t, err)
+	env, ok := mergedData["env"].(map[string]interface{})
+	require.True(t, ok)
+
+	// 固定且已设置的变量保留目标值
+	assert.Equal(t, "https://my-gateway.example.com", env["ANTHROPIC_BASE_URL"])
+	// 固定但目标中未设置的变量仍会从模板添加
+	assert.Equal(t, "from_template", env["UNSET_PINNED"])
+	assert.Equal(t, "new_value", env["NEW_VAR"])
+}
+
 func TestSettingsJsonMerger_MergeSettings_NoTargetFile(t *testing.T) {
 	merger := NewSettingsJSONMerger()
 	tempDir := t.TempDir()
@@ -306,6 +343,157 @@ func TestSettingsJsonMerger_MergeSettings_NoTargetFile(t *testing.T) {
 	assert.Equal(t, true, resultData["includeCoAuthoredBy"])
 }
 
+func TestSettingsJsonMerger_PreviewSettings(t *testing.T) {
+	tempDir := t.TempDir()
+	targetFile := filepath.Join(tempDir, "settings.json")
+	merger := NewSettingsJSONMerger()
+
+	sourceData := map[string]interface{}{
+		"includeCoAuthoredBy": false,
+		"env": map[string]interface{}{
+			"NEW_VAR":    "new_value",
+			"http_proxy": "http://template:8080",
+		},
+	}
+
+	// 目标文件不存在时，所有源配置项都是新增，代理配置被过滤
+	entries, err := merger.PreviewSettings(targetFile, sourceData)
+	require.NoError(t, err)
+	assert.Equal(t, []DiffEntry{
+		{Path: "env", Kind: DiffAdded, Embedded: map[string]interface{}{"NEW_VAR": "new_value"}},
+		{Path: "includeCoAuthoredBy", Kind: DiffAdded, Embedded: false},
+	}, entries)
+	assert.NoFileExists(t, targetFile)
+
+	original := `{"includeCoAuthoredBy": true, "env": {"http_proxy": "http://mine:7890", "KEEP": "1"}}`
+	require.NoError(t, os.WriteFile(targetFile, []byte(original), 0644))
+
+	entries, err = merger.PreviewSettings(targetFile, sourceData)
+	require.NoError(t, err)
+	assert.Equal(t, []DiffEntry{
+		{Path: "env.NEW_VAR", Kind: DiffAdded, Embedded: "new_value"},
+		{Path: "includeCoAuthoredBy", Kind: DiffChanged, Installed: true, Embedded: false},
+	}, entries)
+
+	data, err := os.ReadFile(targetFile)
+	require.NoError(t, err)
+	assert.Equal(t, original, string(data), "预览不应修改目标文件")
+}
+
+func TestSettingsJsonMerger_MergeStrategy(t *testing.T) {
+	source := `{"env": {"NEW_VAR": "new", "SHARED": "template", "http_proxy": "http://template:8080", "ANTHROPIC_MODEL": "template"}}`
+	target := `{"env": {"SHARED": "mine", "http_proxy": "http://mine:7890", "ANTHROPIC_MODEL": "mine"}}`
+
+	tests := []struct {
+		name     string
+		strategy MergeStrategy
+		wantEnv  map[string]interface{}
+		wantErr  string
+	}{
+		{
+			name:     "默认 - 保护代理和固定环境变量",
+			strategy: MergeDefault,
+			wantEnv: map[string]interface{}{
+				"NEW_VAR": "new", "SHARED": "template", "http_proxy": "http://mine:7890", "ANTHROPIC_MODEL": "mine",
+			},
+		},
+		{
+			name:     "源文件优先",
+			strategy: PreferSource,
+			wantEnv: map[string]interface{}{
+				"NEW_VAR": "new", "SHARED": "template", "http_proxy": "http://template:8080", "ANTHROPIC_MODEL": "template",
+			},
+		},
+		{
+			name:     "目标文件优先",
+			strategy: PreferTarget,
+			wantEnv: map[string]interface{}{
+				"NEW_VAR": "new", "SHARED": "mine", "http_proxy": "http://mine:7890", "ANTHROPIC_MODEL": "mine",
+			},
+		},
+		{
+			name:     "冲突时报错 - 只列出未受保护的冲突项",
+			strategy: FailOnConflict,
+			wantErr:  "SHARED",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			targetFile := filepath.Join(tempDir, "settings.json")
+			sourceFile := filepath.Join(tempDir, "source.json")
+			require.NoError(t, os.WriteFile(sourceFile, []byte(source), 0644))
+			require.NoError(t, os.WriteFile(targetFile, []byte(target), 0644))
+
+			merger := NewSettingsJSONMerger()
+			merger.SetPinnedKeys([]string{"ANTHROPIC_MODEL"})
+			merger.SetMergeStrategy(tt.strategy)
+
+			err := merger.MergeSettings(targetFile, sourceFile)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				assert.NotContains(t, err.Error(), "http_proxy")
+
+				data, readErr := os.ReadFile(targetFile)
+				require.NoError(t, readErr)
+				assert.Equal(t, target, string(data), "冲突时不应写入目标文件")
+				return
+			}
+			require.NoError(t, err)
+
+			resultData, err := merger.readJSONFile(targetFile)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantEnv, resultData["env"])
+		})
+	}
+}
+
+func TestParseMergeStrategy(t *testing.T) {
+	strategy, err := ParseMergeStrategy("")
+	assert.NoError(t, err)
+	assert.Equal(t, MergeDefault, strategy)
+
+	strategy, err = ParseMergeStrategy("target")
+	assert.NoError(t, err)
+	assert.Equal(t, PreferTarget, strategy)
+
+	_, err = ParseMergeStrategy("merge")
+	assert.Error(t, err)
+}
+
+func TestSettingsJsonMerger_PreviewMerge(t *testing.T) {
+	tempDir := t.TempDir()
+	targetFile := filepath.Join(tempDir, "settings.json")
+	sourceFile := filepath.Join(tempDir, "source.json")
+	merger := NewSettingsJSONMerger()
+	merger.SetPinnedKeys([]string{"ANTHROPIC_MODEL"})
+
+	source := `{
+		"includeCoAuthoredBy": false,
+		"env": {"NEW_VAR": "new", "http_proxy": "http://template:8080", "ANTHROPIC_MODEL": "template", "SAME": "1"},
+		"hooks": {"Stop": [{"matcher": "", "hooks": [{"type": "command", "command": "notify.sh"}]}]}
+	}`
+	require.NoError(t, os.WriteFile(sourceFile, []byte(source), 0644))
+
+	original := `{"includeCoAuthoredBy": true, "env": {"http_proxy": "http://mine:7890", "ANTHROPIC_MODEL": "mine", "SAME": "1"}}`
+	require.NoError(t, os.WriteFile(targetFile, []byte(original), 0644))
+
+	added, changed, preserved, err := merger.PreviewMerge(targetFile, sourceFile)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"env.NEW_VAR", "hooks"}, added)
+	assert.Equal(t, []string{"includeCoAuthoredBy"}, changed)
+	assert.Equal(t, []string{"env.ANTHROPIC_MODEL", "env.http_proxy"}, preserved)
+
+	data, err := os.ReadFile(targetFile)
+	require.NoError(t, err)
+	assert.Equal(t, original, string(data), "预览不应修改目标文件")
+
+	_, _, _, err = merger.PreviewMerge(targetFile, filepath.Join(tempDir, "missing.json"))
+	assert.Error(t, err)
+}
+
 func TestSettingsJsonMerger_MergeHooks(t *testing.T) {
 	merger := NewSettingsJSONMerger()
 
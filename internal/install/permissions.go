@@ -1,9 +1,17 @@
 package install
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+
+	"github.com/ooneko/claude-config/internal/claude"
 )
 
 // IsExecutableFile 检查文件是否应该设置为可执行
@@ -30,11 +38,177 @@ func IsExecutableFile(filePath string) bool {
 	return executableExts[ext]
 }
 
+// IsKeyFile 检查文件是否为 API 密钥文件 (.<provider>_api_key)，与 claude.IsKeyFile 一致
+func IsKeyFile(filePath string) bool {
+	return claude.IsKeyFile(filePath)
+}
+
 // GetFilePermissions 根据文件路径返回适当的权限
-// 可执行文件返回 0755，普通文件返回 0644
+// 密钥文件返回 0600，可执行文件返回 0755，普通文件返回 0644
 func GetFilePermissions(filePath string) os.FileMode {
+	if IsKeyFile(filePath) {
+		return 0600 // 仅所有者可读写：rw-------
+	}
 	if IsExecutableFile(filePath) {
 		return 0755 // 可执行权限：rwxr-xr-x
 	}
 	return 0644 // 默认文件权限：rw-r--r--
 }
+
+// PermissionChange 记录一次权限修复
+type PermissionChange struct {
+	Path    string      // 相对于配置目录的路径
+	OldMode os.FileMode // 修复前的权限
+	NewMode os.FileMode // 修复后的权限
+}
+
+// RepairPermissions 修正 install 管理的文件的权限，规则见 repairedMode:
+//   - 配置目录顶层的 API 密钥文件改为 0600
+//   - 安装清单中记录的文件：脚本（按扩展名或 #! 开头识别）为 0755，其他文件不超过 0644
+//   - 清单中目录型组件 (如 hooks/) 下用户自己的文件只补上脚本缺失的执行位
+//
+// 其他文件（如 .credentials.json、local/ 下的程序）不会被访问，
+// 没有安装清单时只修正 API 密钥文件。
+func (m *Manager) RepairPermissions() ([]PermissionChange, error) {
+	manifest, err := LoadManifest(m.claudeDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []PermissionChange
+	repair := func(path string, info os.FileInfo, managed bool) error {
+		current := info.Mode().Perm()
+		want := repairedMode(path, current, managed)
+		if current == want {
+			return nil
+		}
+
+		if err := os.Chmod(path, want); err != nil {
+			return fmt.Errorf("修改权限失败 %s: %w", path, err)
+		}
+
+		relPath, err := filepath.Rel(m.claudeDir, path)
+		if err != nil {
+			return err
+		}
+		changes = append(changes, PermissionChange{Path: relPath, OldMode: current, NewMode: want})
+		return nil
+	}
+
+	// 顶层的 API 密钥文件
+	entries, err := os.ReadDir(m.claudeDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("读取配置目录失败: %w", err)
+	}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !IsKeyFile(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		if err := repair(filepath.Join(m.claudeDir, entry.Name()), info, true); err != nil {
+			return changes, err
+		}
+	}
+
+	// 安装清单记录的文件及其目录型组件
+	managed := make(map[string]bool)
+	var roots []string
+	components := make([]string, 0, len(manifest.Components))
+	for component := range manifest.Components {
+		components = append(components, component)
+	}
+	sort.Strings(components)
+	for _, component := range components {
+		var files []string
+		for _, file := range manifest.Components[component].Files {
+			path := filepath.Join(m.claudeDir, filepath.FromSlash(file))
+			managed[path] = true
+			files = append(files, path)
+		}
+
+		componentDir := filepath.Join(m.claudeDir, component)
+		if info, err := os.Lstat(componentDir); err == nil && info.IsDir() {
+			roots = append(roots, componentDir)
+		} else {
+			roots = append(roots, files...)
+		}
+	}
+
+	for _, root := range roots {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+
+			// 只处理普通文件，跳过目录和符号链接
+			if !d.Type().IsRegular() {
+				return nil
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			return repair(path, info, managed[path])
+		})
+		if err != nil {
+			return changes, err
+		}
+	}
+
+	return changes, nil
+}
+
+// repairedMode 返回文件修复后的权限。managed 表示文件由 install 写入：
+// 其中的脚本与安装时一样设为 0755，其他文件只去掉超出 0644 的权限，
+// 用户收紧过的权限（如 0600 的 settings.json）保持不变。
+// 非 install 写入的脚本只补上与读权限对应的执行位，其他文件不变
+func repairedMode(path string, current os.FileMode, managed bool) os.FileMode {
+	if IsKeyFile(path) {
+		return 0600
+	}
+
+	script := IsExecutableFile(path) || hasShebang(path)
+	switch {
+	case managed && script:
+		return 0755
+	case managed:
+		return current & 0644
+	case script:
+		return current | (current&0444)>>2
+	default:
+		return current
+	}
+}
+
+// FixPermissions 修正 install 管理的文件的权限，返回修正的文件数
+// 规则与 RepairPermissions 相同，需要逐个文件的变化时使用 RepairPermissions
+func (m *Manager) FixPermissions(ctx context.Context) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	changes, err := m.RepairPermissions()
+	return len(changes), err
+}
+
+// hasShebang 检查文件是否以 #! 开头
+func hasShebang(filePath string) bool {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(file, header); err != nil {
+		return false
+	}
+	return bytes.Equal(header, []byte("#!"))
+}
@@ -1,7 +1,9 @@
 package install
 
 import (
+	"context"
 	"os"
+	"path/filepath"
 	"runtime"
 	"testing"
 	"testing/quick"
@@ -63,6 +65,7 @@ func TestGetFilePermissions(t *testing.T) {
 		{"YAML配置文件获取只读权限", "config.yaml", 0644},
 		{"文本文件获取只读权限", "notes.txt", 0644},
 		{"隐藏配置文件获取只读权限", ".env", 0644},
+		{"API密钥文件获取私有权限", ".deepseek_api_key", 0600},
 		{"没有扩展名的文件获取只读权限", "Makefile", 0644},
 		{"空路径获取只读权限", "", 0644},
 		{"大写扩展名脚本获取可执行权限", "hooks/TEST.SH", 0755},
@@ -217,3 +220,172 @@ func BenchmarkGetFilePermissions(b *testing.B) {
 		}
 	}
 }
+
+// writeModeFiles creates files under claudeDir with the given contents and modes
+func writeModeFiles(t *testing.T, claudeDir string, files map[string]os.FileMode, contents map[string]string) {
+	t.Helper()
+	for name, mode := range files {
+		path := filepath.Join(claudeDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(contents[name]), mode); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chmod(path, mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// recordManifest records files as installed by install
+func recordManifest(t *testing.T, claudeDir string, components map[string][]string) {
+	t.Helper()
+	manifest := &Manifest{Components: make(map[string]*ManifestEntry)}
+	for component, files := range components {
+		manifest.Record(component, "hash", files)
+	}
+	if err := SaveManifest(claudeDir, manifest); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestManager_RepairPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix permission bits are not supported on Windows")
+	}
+
+	claudeDir := t.TempDir()
+	files := map[string]os.FileMode{
+		filepath.Join("hooks", "smart-lint.sh"): 0644,
+		filepath.Join("hooks", "common.md"):     0755,
+		filepath.Join("hooks", "my-guard"):      0600,
+		filepath.Join("hooks", "notes.txt"):     0666,
+		filepath.Join("bin", "run-checks"):      0644,
+		filepath.Join("local", "claude"):        0755,
+		".kimi_api_key":                         0644,
+		".credentials.json":                     0600,
+		"settings.json":                         0600,
+	}
+	contents := map[string]string{
+		filepath.Join("hooks", "my-guard"):  "#!/bin/sh\nexit 0\n",
+		filepath.Join("bin", "run-checks"):  "#!/bin/sh\necho ok\n",
+		filepath.Join("local", "claude"):    "\x7fELF",
+		filepath.Join("hooks", "notes.txt"): "notes",
+	}
+	writeModeFiles(t, claudeDir, files, contents)
+	recordManifest(t, claudeDir, map[string][]string{
+		"hooks":         {"hooks/smart-lint.sh", "hooks/common.md"},
+		"settings.json": {"settings.json"},
+	})
+
+	changes, err := NewManager(claudeDir).RepairPermissions()
+	if err != nil {
+		t.Fatalf("RepairPermissions() error = %v", err)
+	}
+	if len(changes) != 4 {
+		t.Errorf("RepairPermissions() changed %d files, want 4: %+v", len(changes), changes)
+	}
+
+	want := map[string]os.FileMode{
+		filepath.Join("hooks", "smart-lint.sh"): 0755,
+		filepath.Join("hooks", "common.md"):     0644,
+		// The user's own files in hooks/ only gain execute bits for their read bits
+		filepath.Join("hooks", "my-guard"):  0700,
+		filepath.Join("hooks", "notes.txt"): 0666,
+		// Files outside what install manages are never touched
+		filepath.Join("bin", "run-checks"): 0644,
+		filepath.Join("local", "claude"):   0755,
+		".credentials.json":                0600,
+		".kimi_api_key":                    0600,
+		// A managed file the user tightened stays tight
+		"settings.json": 0600,
+	}
+	for name, mode := range want {
+		info, err := os.Stat(filepath.Join(claudeDir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != mode {
+			t.Errorf("%s mode = %#o, want %#o", name, info.Mode().Perm(), mode)
+		}
+	}
+
+	// 再次运行不应有任何变化
+	changes, err = NewManager(claudeDir).RepairPermissions()
+	if err != nil {
+		t.Fatalf("RepairPermissions() error = %v", err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("second RepairPermissions() changed %d files, want 0", len(changes))
+	}
+}
+
+func TestManager_RepairPermissions_NoManifest(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix permission bits are not supported on Windows")
+	}
+
+	claudeDir := t.TempDir()
+	writeModeFiles(t, claudeDir, map[string]os.FileMode{
+		".deepseek_api_key":                     0644,
+		".credentials.json":                     0600,
+		filepath.Join("hooks", "smart-lint.sh"): 0644,
+	}, nil)
+
+	changes, err := NewManager(claudeDir).RepairPermissions()
+	if err != nil {
+		t.Fatalf("RepairPermissions() error = %v", err)
+	}
+	if len(changes) != 1 || changes[0].Path != ".deepseek_api_key" {
+		t.Errorf("RepairPermissions() = %+v, want only the key file", changes)
+	}
+	if info, _ := os.Stat(filepath.Join(claudeDir, ".credentials.json")); info.Mode().Perm() != 0600 {
+		t.Errorf(".credentials.json mode = %#o, want 0600", info.Mode().Perm())
+	}
+}
+
+func TestManager_FixPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix permission bits are not supported on Windows")
+	}
+
+	claudeDir := t.TempDir()
+	keyPath := filepath.Join(claudeDir, ".deepseek.work_api_key")
+	scriptPath := filepath.Join(claudeDir, "hooks", "ntfy-notifier.sh")
+	if err := os.MkdirAll(filepath.Dir(scriptPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for path, mode := range map[string]os.FileMode{keyPath: 0644, scriptPath: 0600} {
+		if err := os.WriteFile(path, []byte("x"), mode); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chmod(path, mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	recordManifest(t, claudeDir, map[string][]string{"hooks": {"hooks/ntfy-notifier.sh"}})
+
+	manager := NewManager(claudeDir)
+	fixed, err := manager.FixPermissions(context.Background())
+	if err != nil {
+		t.Fatalf("FixPermissions() error = %v", err)
+	}
+	if fixed != 2 {
+		t.Errorf("FixPermissions() = %d, want 2", fixed)
+	}
+
+	if info, _ := os.Stat(keyPath); info.Mode().Perm() != 0600 {
+		t.Errorf("key file mode = %#o, want 0600", info.Mode().Perm())
+	}
+	if info, _ := os.Stat(scriptPath); info.Mode().Perm() != 0755 {
+		t.Errorf("script mode = %#o, want 0755", info.Mode().Perm())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := manager.FixPermissions(ctx); err == nil {
+		t.Error("FixPermissions() with canceled context should fail")
+	}
+}
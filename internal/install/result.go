@@ -0,0 +1,34 @@
+package install
+
+// ComponentResult 单个组件的安装结果，文件路径相对于配置目录并使用 / 分隔
+type ComponentResult struct {
+	Component string      `json:"component"`
+	Skipped   bool        `json:"skipped,omitempty"`   // 目标已存在且未指定force，未安装
+	Created   []string    `json:"created,omitempty"`   // 新建的文件
+	Updated   []string    `json:"updated,omitempty"`   // 内容有变化而覆盖的文件
+	Unchanged []string    `json:"unchanged,omitempty"` // 内容相同而未写入的文件
+	Merged    []DiffEntry `json:"merged,omitempty"`    // settings.json 合并时新增、改变或移除的配置项
+	Orphaned  []string    `json:"orphaned,omitempty"`  // 未删除的孤立文件，未指定force或dry-run时只列出
+	Deleted   []string    `json:"deleted,omitempty"`   // 已删除的孤立文件
+}
+
+// Written 返回新建和覆盖的文件数
+func (r *ComponentResult) Written() int {
+	return len(r.Created) + len(r.Updated)
+}
+
+// InstallResult 一次安装的结果，dry-run 时描述将要进行的更改
+type InstallResult struct {
+	DryRun     bool               `json:"dry_run"`
+	Components []*ComponentResult `json:"components"`
+}
+
+// Component 返回组件的安装结果，组件未安装时返回nil
+func (r *InstallResult) Component(component string) *ComponentResult {
+	for _, result := range r.Components {
+		if result.Component == component {
+			return result
+		}
+	}
+	return nil
+}
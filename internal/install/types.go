@@ -1,19 +1,52 @@
 package install
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
+
+// InstallError 安装失败时的结构化错误，记录失败的组件和尝试读取的嵌入路径
+type InstallError struct {
+	Component string // 组件名称
+	EmbedPath string // 尝试读取的嵌入资源路径
+	Err       error  // 底层错误
+}
+
+// Error 实现 error 接口
+func (e *InstallError) Error() string {
+	return fmt.Sprintf("安装组件%s失败 (嵌入路径: %s): %v", e.Component, e.EmbedPath, e.Err)
+}
+
+// Unwrap 返回底层错误
+func (e *InstallError) Unwrap() error {
+	return e.Err
+}
+
+// newInstallError 包装组件安装错误，已是 InstallError 的保留其更精确的嵌入路径
+func newInstallError(component, embedPath string, err error) error {
+	var installErr *InstallError
+	if errors.As(err, &installErr) {
+		return installErr
+	}
+	return &InstallError{Component: component, EmbedPath: embedPath, Err: err}
+}
 
 // Options 安装选项配置
 type Options struct {
-	All          bool // 安装所有配置文件
+	All          bool // 安装除hooks外的所有配置文件，check on 和 notify on 会安装它们引用的hook脚本
 	Agents       bool // 仅安装agents
 	Commands     bool // 仅安装commands
-	Hooks        bool // 仅安装hooks
+	Hooks        bool // 安装hooks，可与All同时使用
 	OutputStyles bool // 仅安装output-styles
 	Settings     bool // 仅安装settings.json
 	Claude       bool // 仅安装CLAUDE.md
 	Statusline   bool // 仅安装statusline.js
 	Force        bool // 强制覆盖已存在的文件
 	Delete       bool // 删除目标目录中不在源资源中的文件（需要与Force配合使用）
+	DryRun       bool // 只打印将新建、覆盖或合并的内容，不写入任何文件
+	Parallel     int  // 同时提取的组件数，0或1时依次安装；settings.json 始终单独合并
+
+	MergeStrategy MergeStrategy // settings.json 中环境变量冲突的处理方式
 }
 
 // Validate 验证安装选项
@@ -22,15 +55,25 @@ func (opts Options) Validate() error {
 		!opts.OutputStyles && !opts.Settings && !opts.Claude && !opts.Statusline {
 		return fmt.Errorf("必须至少选择一个安装选项")
 	}
+	if opts.Parallel < 0 {
+		return fmt.Errorf("并发数不能为负数: %d", opts.Parallel)
+	}
+	if _, err := ParseMergeStrategy(string(opts.MergeStrategy)); err != nil {
+		return err
+	}
 	return nil
 }
 
-// GetSelectedComponents 获取选中的组件列表
+// GetSelectedComponents 获取选中的组件列表。All 不包含 hooks，
+// 需要同时设置 Hooks 才会安装hook脚本
 func (opts Options) GetSelectedComponents() []string {
 	var components []string
 
 	if opts.All {
-		return []string{"agents", "commands", "hooks", "output-styles", "settings.json", "CLAUDE.md.template", "statusline.js"}
+		if opts.Hooks {
+			return []string{"agents", "commands", "hooks", "output-styles", "settings.json", "CLAUDE.md.template", "statusline.js"}
+		}
+		return []string{"agents", "commands", "output-styles", "settings.json", "CLAUDE.md.template", "statusline.js"}
 	}
 
 	if opts.Agents {
@@ -27,6 +27,16 @@ func TestOptions_Validate(t *testing.T) {
 			options: Options{Settings: true},
 			wantErr: false,
 		},
+		{
+			name:    "有效选项 - 合并策略",
+			options: Options{Settings: true, MergeStrategy: FailOnConflict},
+			wantErr: false,
+		},
+		{
+			name:    "无效选项 - 未知合并策略",
+			options: Options{Settings: true, MergeStrategy: "merge"},
+			wantErr: true,
+		},
 		{
 			name:    "无效选项 - 全部为false",
 			options: Options{},
@@ -53,8 +63,16 @@ func TestOptions_GetSelectedComponents(t *testing.T) {
 		expected []string
 	}{
 		{
-			name:    "All选项",
+			name:    "All选项不包含hooks",
 			options: Options{All: true},
+			expected: []string{
+				"agents", "commands", "output-styles",
+				"settings.json", "CLAUDE.md.template", "statusline.js",
+			},
+		},
+		{
+			name:    "All和Hooks",
+			options: Options{All: true, Hooks: true},
 			expected: []string{
 				"agents", "commands", "hooks", "output-styles",
 				"settings.json", "CLAUDE.md.template", "statusline.js",
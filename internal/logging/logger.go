@@ -0,0 +1,62 @@
+// Package logging provides the progress logger used by the managers, so
+// commands can silence or redirect status messages.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// Logger receives progress messages. Messages are formatted like fmt.Printf
+// and end with a newline added by the logger.
+type Logger interface {
+	// Info reports normal progress
+	Info(format string, args ...interface{})
+	// Warn reports something the user may want to act on
+	Warn(format string, args ...interface{})
+	// Debug reports details only shown in verbose mode
+	Debug(format string, args ...interface{})
+}
+
+// writerLogger writes messages to an io.Writer
+type writerLogger struct {
+	w       io.Writer
+	verbose bool
+}
+
+// New returns a Logger writing to w. Debug messages are written only when verbose is set.
+func New(w io.Writer, verbose bool) Logger {
+	return &writerLogger{w: w, verbose: verbose}
+}
+
+// Default returns the logger managers use unless told otherwise: stdout, without debug messages
+func Default() Logger {
+	return New(os.Stdout, false)
+}
+
+func (l *writerLogger) Info(format string, args ...interface{}) {
+	fmt.Fprintf(l.w, format+"\n", args...)
+}
+
+func (l *writerLogger) Warn(format string, args ...interface{}) {
+	fmt.Fprintf(l.w, format+"\n", args...)
+}
+
+func (l *writerLogger) Debug(format string, args ...interface{}) {
+	if l.verbose {
+		fmt.Fprintf(l.w, format+"\n", args...)
+	}
+}
+
+// nopLogger discards every message
+type nopLogger struct{}
+
+// Nop returns a Logger that discards every message
+func Nop() Logger {
+	return nopLogger{}
+}
+
+func (nopLogger) Info(string, ...interface{})  {}
+func (nopLogger) Warn(string, ...interface{})  {}
+func (nopLogger) Debug(string, ...interface{}) {}
@@ -0,0 +1,36 @@
+package logging
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, false)
+
+	logger.Info("📁 %s: 写入 %d 个文件", "agents", 3)
+	logger.Warn("⚠️  警告")
+	logger.Debug("hidden")
+
+	assert.Equal(t, "📁 agents: 写入 3 个文件\n⚠️  警告\n", buf.String())
+}
+
+func TestNew_Verbose(t *testing.T) {
+	var buf bytes.Buffer
+	New(&buf, true).Debug("detail %d", 1)
+
+	assert.Equal(t, "detail 1\n", buf.String())
+}
+
+func TestNop(t *testing.T) {
+	logger := Nop()
+
+	assert.NotPanics(t, func() {
+		logger.Info("a")
+		logger.Warn("b")
+		logger.Debug("c")
+	})
+}
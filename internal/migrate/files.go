@@ -0,0 +1,184 @@
+package migrate
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ooneko/claude-config/internal/claude"
+)
+
+// utf8BOM is the byte order mark some Windows editors prepend to files
+var utf8BOM = []byte("\ufeff")
+
+// configFiles are the structured config files next to settings.json
+var configFiles = []string{".check_config.json", ".proxy_config", ".pinned_env"}
+
+// valueFileSuffixes and valueFileNames identify top-level dotfiles that hold a single value
+var (
+	valueFileSuffixes = []string{claude.APIKeyFileSuffix, "_endpoint", "_base_url"}
+	valueFileNames    = map[string]bool{
+		".active_provider":      true,
+		".default_provider":     true,
+		".last_active_provider": true,
+	}
+)
+
+// cleanConfigFiles removes a UTF-8 BOM from the config files and, in
+// single-value dotfiles, converts CRLF to LF and trims whitespace.
+// settings.json loses its BOM when the migrated settings are written.
+func cleanConfigFiles(claudeDir string, _ map[string]interface{}) ([]string, error) {
+	var changes []string
+	for _, name := range configFiles {
+		changed, err := rewriteFile(claudeDir, name, func(data []byte) []byte {
+			return bytes.TrimPrefix(data, utf8BOM)
+		})
+		if err != nil {
+			return changes, err
+		}
+		if changed {
+			changes = append(changes, name)
+		}
+	}
+
+	names, err := valueFiles(claudeDir)
+	if err != nil {
+		return changes, err
+	}
+	for _, name := range names {
+		changed, err := rewriteFile(claudeDir, name, func(data []byte) []byte {
+			data = bytes.TrimPrefix(data, utf8BOM)
+			return bytes.TrimSpace(bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n")))
+		})
+		if err != nil {
+			return changes, err
+		}
+		if changed {
+			changes = append(changes, name)
+		}
+	}
+
+	return changes, nil
+}
+
+// restrictKeyFiles restricts API key files to their owner
+func restrictKeyFiles(claudeDir string, _ map[string]interface{}) ([]string, error) {
+	entries, err := os.ReadDir(claudeDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read claude directory: %w", err)
+	}
+
+	var changes []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || !claude.IsKeyFile(name) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return changes, fmt.Errorf("failed to stat %s: %w", name, err)
+		}
+		if info.Mode().Perm() == 0600 {
+			continue
+		}
+
+		if err := os.Chmod(filepath.Join(claudeDir, name), 0600); err != nil {
+			return changes, fmt.Errorf("failed to chmod %s: %w", name, err)
+		}
+		changes = append(changes, fmt.Sprintf("%s: %#o → 0600", name, info.Mode().Perm()))
+	}
+
+	return changes, nil
+}
+
+// adoptDeepSeek records DeepSeek as the active provider for settings written
+// before .active_provider existed, when the base URL points at DeepSeek
+func adoptDeepSeek(claudeDir string, settings map[string]interface{}) ([]string, error) {
+	activePath := filepath.Join(claudeDir, ".active_provider")
+	if _, err := os.Stat(activePath); err == nil {
+		return nil, nil
+	}
+
+	env, _ := settings["env"].(map[string]interface{})
+	baseURL, _ := env["ANTHROPIC_BASE_URL"].(string)
+	if !strings.Contains(baseURL, "deepseek") {
+		return nil, nil
+	}
+
+	changes := []string{".active_provider: deepseek"}
+
+	// Old versions only kept the key in settings.json; store it where ai on looks for it
+	keyPath := filepath.Join(claudeDir, ".deepseek_api_key")
+	if token, _ := env["ANTHROPIC_AUTH_TOKEN"].(string); token != "" {
+		if _, err := os.Stat(keyPath); os.IsNotExist(err) {
+			if err := os.WriteFile(keyPath, []byte(token), 0600); err != nil {
+				return nil, fmt.Errorf("failed to write API key file: %w", err)
+			}
+			changes = append(changes, ".deepseek_api_key")
+		}
+	}
+
+	if err := os.WriteFile(activePath, []byte("deepseek"), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write active provider file: %w", err)
+	}
+	return changes, nil
+}
+
+// valueFiles lists the single-value dotfiles at the top of the claude directory
+func valueFiles(claudeDir string) ([]string, error) {
+	entries, err := os.ReadDir(claudeDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read claude directory: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || !strings.HasPrefix(name, ".") {
+			continue
+		}
+		if valueFileNames[name] {
+			names = append(names, name)
+			continue
+		}
+		for _, suffix := range valueFileSuffixes {
+			if strings.HasSuffix(name, suffix) {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+
+	return names, nil
+}
+
+// rewriteFile applies fix to a file in the claude directory, keeping its mode.
+// Missing files are skipped. It reports whether the content changed.
+func rewriteFile(claudeDir, name string, fix func([]byte) []byte) (bool, error) {
+	path := filepath.Join(claudeDir, name)
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to stat %s: %w", name, err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+
+	fixed := fix(data)
+	if bytes.Equal(data, fixed) {
+		return false, nil
+	}
+
+	if err := os.WriteFile(path, fixed, info.Mode().Perm()); err != nil {
+		return false, fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return true, nil
+}
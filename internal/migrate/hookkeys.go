@@ -0,0 +1,41 @@
+package migrate
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ooneko/claude-config/internal/claude"
+)
+
+// NormalizeHookKeys renames hook events such as "postToolUse" or
+// "post_tool_use" in raw settings to their canonical names, merging rules
+// when both exist. It returns one change per renamed key.
+func NormalizeHookKeys(settings map[string]interface{}) []string {
+	hooks, ok := settings["hooks"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	// Visit keys in order so merged rules are deterministic
+	keys := make([]string, 0, len(hooks))
+	for key := range hooks {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var changes []string
+	for _, key := range keys {
+		canonical := claude.CanonicalHookEvent(key)
+		if canonical == "" || canonical == key {
+			continue
+		}
+
+		rules, _ := hooks[key].([]interface{})
+		existing, _ := hooks[canonical].([]interface{})
+		hooks[canonical] = append(existing, rules...)
+		delete(hooks, key)
+		changes = append(changes, fmt.Sprintf("hooks.%s → hooks.%s", key, canonical))
+	}
+
+	return changes
+}
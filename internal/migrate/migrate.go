@@ -0,0 +1,237 @@
+// Package migrate upgrades settings.json between schema versions. The
+// version is stored in the top-level "schemaVersion" key; files without it
+// are version 0. Each migration runs once, in order, after settings.json has
+// been backed up. Migrations may also fix the other files in the claude
+// directory that older versions wrote.
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ooneko/claude-config/internal/fsutil"
+)
+
+// SchemaVersionKey is the settings.json key holding the schema version
+const SchemaVersionKey = "schemaVersion"
+
+// Migration upgrades raw settings from Version-1 to Version
+type Migration struct {
+	Version     int
+	Description string
+	// Apply edits settings in place, and other files in claudeDir directly,
+	// and returns a description of each change
+	Apply func(claudeDir string, settings map[string]interface{}) ([]string, error)
+}
+
+// Applied is a migration that ran and the changes it made
+type Applied struct {
+	Migration
+	Changes []string
+}
+
+// Result describes one Run
+type Result struct {
+	FromVersion int
+	ToVersion   int
+	Applied     []Applied
+	BackupPath  string // empty when nothing was migrated
+}
+
+// migrations lists every schema migration in version order
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "normalize hook event key casing",
+		Apply: func(_ string, settings map[string]interface{}) ([]string, error) {
+			return NormalizeHookKeys(settings), nil
+		},
+	},
+	{
+		Version:     2,
+		Description: "strip UTF-8 BOMs and stray whitespace from config files",
+		Apply:       cleanConfigFiles,
+	},
+	{
+		Version:     3,
+		Description: "restrict API key file permissions",
+		Apply:       restrictKeyFiles,
+	},
+	{
+		Version:     4,
+		Description: "adopt legacy DeepSeek settings",
+		Apply:       adoptDeepSeek,
+	},
+}
+
+// LatestVersion returns the schema version written by this build
+func LatestVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// Manager applies schema migrations to settings.json in a claude directory
+type Manager struct {
+	claudeDir  string
+	migrations []Migration
+}
+
+// NewManager creates a new migration manager
+func NewManager(claudeDir string) *Manager {
+	return &Manager{
+		claudeDir:  claudeDir,
+		migrations: migrations,
+	}
+}
+
+// Pending returns the current schema version and the migrations not yet
+// applied. A missing settings.json has nothing pending.
+func (m *Manager) Pending(_ context.Context) (int, []Migration, error) {
+	settings, err := m.loadSettings()
+	if settings == nil || err != nil {
+		return 0, nil, err
+	}
+
+	version, err := schemaVersion(settings)
+	if err != nil {
+		return 0, nil, err
+	}
+	pending, err := m.pendingFrom(version)
+	return version, pending, err
+}
+
+// Run backs up settings.json and applies the pending migrations in order.
+// Settings are only written after every migration succeeded, with
+// schemaVersion set to the last applied version.
+func (m *Manager) Run(_ context.Context) (*Result, error) {
+	settings, err := m.loadSettings()
+	if settings == nil || err != nil {
+		return &Result{}, err
+	}
+
+	version, err := schemaVersion(settings)
+	if err != nil {
+		return nil, err
+	}
+	pending, err := m.pendingFrom(version)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{FromVersion: version, ToVersion: version}
+	if len(pending) == 0 {
+		return result, nil
+	}
+
+	for _, migration := range pending {
+		changes, err := migration.Apply(m.claudeDir, settings)
+		if err != nil {
+			return nil, fmt.Errorf("migration to version %d failed: %w", migration.Version, err)
+		}
+		result.Applied = append(result.Applied, Applied{Migration: migration, Changes: changes})
+		result.ToVersion = migration.Version
+	}
+	settings[SchemaVersionKey] = result.ToVersion
+
+	if result.BackupPath, err = m.backupSettings(version); err != nil {
+		return nil, err
+	}
+	if err := m.saveSettings(settings); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// pendingFrom returns the migrations newer than version
+func (m *Manager) pendingFrom(version int) ([]Migration, error) {
+	latest := 0
+	if len(m.migrations) > 0 {
+		latest = m.migrations[len(m.migrations)-1].Version
+	}
+	if version > latest {
+		return nil, fmt.Errorf("settings.json schema version %d is newer than the supported version %d, please upgrade claude-config", version, latest)
+	}
+
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if migration.Version > version {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// schemaVersion reads the schema version from raw settings, 0 when unset
+func schemaVersion(settings map[string]interface{}) (int, error) {
+	value, exists := settings[SchemaVersionKey]
+	if !exists {
+		return 0, nil
+	}
+
+	number, ok := value.(float64)
+	if !ok || number < 0 || number != float64(int(number)) {
+		return 0, fmt.Errorf("invalid %s in settings.json: %v", SchemaVersionKey, value)
+	}
+	return int(number), nil
+}
+
+// settingsPath returns the settings.json path
+func (m *Manager) settingsPath() string {
+	return filepath.Join(m.claudeDir, "settings.json")
+}
+
+// loadSettings reads settings.json keeping every key, ignoring a UTF-8 BOM
+// that Windows editors may have added. It returns nil when the file doesn't exist.
+func (m *Manager) loadSettings() (map[string]interface{}, error) {
+	data, err := os.ReadFile(m.settingsPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read settings file: %w", err)
+	}
+
+	var settings map[string]interface{}
+	if err := json.Unmarshal(bytes.TrimPrefix(data, utf8BOM), &settings); err != nil {
+		return nil, fmt.Errorf("failed to parse settings file: %w", err)
+	}
+	if settings == nil {
+		settings = make(map[string]interface{})
+	}
+
+	return settings, nil
+}
+
+// saveSettings writes raw settings to settings.json
+func (m *Manager) saveSettings(settings map[string]interface{}) error {
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal settings: %w", err)
+	}
+
+	if err := fsutil.AtomicWriteFile(m.settingsPath(), data, 0644); err != nil {
+		return fmt.Errorf("failed to write settings file: %w", err)
+	}
+
+	return nil
+}
+
+// backupSettings copies settings.json next to itself before migrating from
+// version, returning the backup path
+func (m *Manager) backupSettings(version int) (string, error) {
+	data, err := os.ReadFile(m.settingsPath())
+	if err != nil {
+		return "", fmt.Errorf("failed to read settings file: %w", err)
+	}
+
+	backupPath := fmt.Sprintf("%s.schema_v%d_backup", m.settingsPath(), version)
+	if err := fsutil.AtomicWriteFile(backupPath, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to back up settings file: %w", err)
+	}
+
+	return backupPath, nil
+}
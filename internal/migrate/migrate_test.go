@@ -0,0 +1,153 @@
+package migrate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readSettings reads settings.json in claudeDir as raw JSON
+func readSettings(t *testing.T, claudeDir string) map[string]interface{} {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join(claudeDir, "settings.json"))
+	require.NoError(t, err)
+	var settings map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &settings))
+	return settings
+}
+
+func TestManager_Run(t *testing.T) {
+	claudeDir := t.TempDir()
+	original := `{
+		"hooks": {
+			"postToolUse": [{"matcher": "Write", "hooks": [{"type": "command", "command": "lint.sh"}]}],
+			"PostToolUse": [{"matcher": "Edit", "hooks": [{"type": "command", "command": "fmt.sh"}]}]
+		},
+		"permissions": {"allow": ["Bash(ls)"]}
+	}`
+	require.NoError(t, os.WriteFile(filepath.Join(claudeDir, "settings.json"), []byte(original), 0644))
+
+	manager := NewManager(claudeDir)
+	ctx := context.Background()
+
+	version, pending, err := manager.Pending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, version)
+	require.Len(t, pending, LatestVersion())
+
+	result, err := manager.Run(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.FromVersion)
+	assert.Equal(t, LatestVersion(), result.ToVersion)
+	require.Len(t, result.Applied, LatestVersion())
+	assert.Equal(t, []string{"hooks.postToolUse → hooks.PostToolUse"}, result.Applied[0].Changes)
+
+	// The backup holds the settings from before the migration
+	backup, err := os.ReadFile(result.BackupPath)
+	require.NoError(t, err)
+	assert.Equal(t, original, string(backup))
+
+	settings := readSettings(t, claudeDir)
+	assert.Equal(t, float64(LatestVersion()), settings[SchemaVersionKey])
+	assert.Contains(t, settings, "permissions")
+	hooks := settings["hooks"].(map[string]interface{})
+	assert.NotContains(t, hooks, "postToolUse")
+	assert.Len(t, hooks["PostToolUse"], 2)
+
+	// Nothing is pending afterwards
+	result, err = manager.Run(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, result.Applied)
+	assert.Empty(t, result.BackupPath)
+}
+
+func TestManager_Run_LegacyFiles(t *testing.T) {
+	claudeDir := t.TempDir()
+	writeFile := func(name, content string, mode os.FileMode) {
+		require.NoError(t, os.WriteFile(filepath.Join(claudeDir, name), []byte(content), mode))
+	}
+
+	// A config left behind by an old version and edited on Windows
+	writeFile("settings.json", "\ufeff"+`{"env":{"ANTHROPIC_BASE_URL":"https://api.deepseek.com/anthropic","ANTHROPIC_AUTH_TOKEN":"sk-legacy"},
+"hooks":{"session_start":[{"matcher":"","hooks":[{"type":"command","command":"hello.sh"}]}]},
+"permissions":{"allow":["Bash(ls)"]}}`, 0644)
+	writeFile(".kimi_api_key", "\ufeffsk-kimi\r\n", 0644)
+	writeFile(".default_provider", "  kimi\n", 0644)
+	writeFile(".check_config.json", "\ufeff{}", 0644)
+
+	result, err := NewManager(claudeDir).Run(context.Background())
+	require.NoError(t, err)
+
+	changes := make(map[int][]string, len(result.Applied))
+	for _, applied := range result.Applied {
+		changes[applied.Version] = applied.Changes
+	}
+	assert.Equal(t, []string{"hooks.session_start → hooks.SessionStart"}, changes[1])
+	assert.ElementsMatch(t, []string{".check_config.json", ".kimi_api_key", ".default_provider"}, changes[2])
+	assert.Equal(t, []string{".kimi_api_key: 0644 → 0600"}, changes[3])
+	assert.Equal(t, []string{".active_provider: deepseek", ".deepseek_api_key"}, changes[4])
+
+	data, err := os.ReadFile(filepath.Join(claudeDir, ".kimi_api_key"))
+	require.NoError(t, err)
+	assert.Equal(t, "sk-kimi", string(data))
+	info, err := os.Stat(filepath.Join(claudeDir, ".kimi_api_key"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	data, err = os.ReadFile(filepath.Join(claudeDir, ".deepseek_api_key"))
+	require.NoError(t, err)
+	assert.Equal(t, "sk-legacy", string(data))
+
+	// settings.json is rewritten without the BOM, keeping unknown keys
+	raw, err := os.ReadFile(filepath.Join(claudeDir, "settings.json"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(raw), "\ufeff")
+	settings := readSettings(t, claudeDir)
+	assert.Contains(t, settings, "permissions")
+	assert.Contains(t, settings["hooks"], "SessionStart")
+}
+
+func TestManager_Run_MissingSettings(t *testing.T) {
+	result, err := NewManager(t.TempDir()).Run(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, result.Applied)
+}
+
+func TestManager_Run_NewerSchema(t *testing.T) {
+	claudeDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(claudeDir, "settings.json"), []byte(`{"schemaVersion": 999}`), 0644))
+
+	_, err := NewManager(claudeDir).Run(context.Background())
+	assert.ErrorContains(t, err, "upgrade claude-config")
+}
+
+func TestManager_Run_FailureKeepsSettings(t *testing.T) {
+	claudeDir := t.TempDir()
+	original := `{"env": {"A": "1"}}`
+	require.NoError(t, os.WriteFile(filepath.Join(claudeDir, "settings.json"), []byte(original), 0644))
+
+	manager := NewManager(claudeDir)
+	manager.migrations = []Migration{
+		{Version: 1, Apply: func(_ string, settings map[string]interface{}) ([]string, error) {
+			settings["env"] = nil
+			return []string{"env"}, nil
+		}},
+		{Version: 2, Apply: func(string, map[string]interface{}) ([]string, error) {
+			return nil, errors.New("boom")
+		}},
+	}
+
+	_, err := manager.Run(context.Background())
+	assert.ErrorContains(t, err, "version 2")
+
+	data, err := os.ReadFile(filepath.Join(claudeDir, "settings.json"))
+	require.NoError(t, err)
+	assert.Equal(t, original, string(data))
+}
@@ -45,13 +45,25 @@ func (m *EnvMapper) addDefaultModelEnvVars(envVars map[string]string, provider c
 		sonnetModel = config.Model
 		opusModel = config.Model
 	case claude.ProviderGLM:
-		haikuModel = "glm-4.7"
-		sonnetModel = "glm-4.7"
-		opusModel = "glm-4.7"
+		haikuModel = config.Model
+		sonnetModel = config.Model
+		opusModel = config.Model
 	case claude.ProviderDoubao:
 		haikuModel = config.Model
 		sonnetModel = config.Model
 		opusModel = config.Model
+	case claude.ProviderAnthropic:
+		haikuModel = config.SmallFastModel
+		sonnetModel = config.Model
+		opusModel = config.Model
+	default:
+		// providers.json 中声明的自定义 provider
+		haikuModel = config.SmallFastModel
+		if haikuModel == "" {
+			haikuModel = config.Model
+		}
+		sonnetModel = config.Model
+		opusModel = config.Model
 	}
 
 	envVars["ANTHROPIC_DEFAULT_HAIKU_MODEL"] = haikuModel
@@ -77,11 +89,9 @@ func (m *EnvMapper) ValidateProviderConfig(provider claude.ProviderType, config
 		return fmt.Errorf("model is required")
 	}
 
-	// 验证 provider 是否支持
-	switch provider {
-	case claude.ProviderDeepSeek, claude.ProviderKimi, claude.ProviderGLM, claude.ProviderDoubao:
-		return nil
-	default:
-		return fmt.Errorf("unsupported provider: %s", provider)
+	// providers.json 中的自定义 provider 由调用方解析，这里只要求 provider 非空
+	if provider == claude.ProviderNone {
+		return fmt.Errorf("provider is required")
 	}
+	return nil
 }
@@ -0,0 +1,77 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/ooneko/claude-config/internal/claude"
+)
+
+// DefaultCheckTimeout bounds TestProxy when the context has no deadline
+const DefaultCheckTimeout = 3 * time.Second
+
+// defaultProxyPorts are used when a proxy URL has no explicit port
+var defaultProxyPorts = map[string]string{
+	"http":    "80",
+	"https":   "443",
+	"socks5":  "1080",
+	"socks5h": "1080",
+}
+
+// TestProxy checks that every configured proxy accepts TCP connections.
+// It only dials the proxy itself and never sends traffic through it.
+func (m *Manager) TestProxy(ctx context.Context, config *claude.ProxyConfig) error {
+	if err := ValidateConfig(config); err != nil {
+		return err
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultCheckTimeout)
+		defer cancel()
+	}
+
+	checked := make(map[string]bool)
+	for _, field := range []struct {
+		name  string
+		value string
+	}{
+		{"http_proxy", config.HTTPProxy},
+		{"https_proxy", config.HTTPSProxy},
+		{"all_proxy", config.AllProxy},
+	} {
+		if field.value == "" || checked[field.value] {
+			continue
+		}
+		checked[field.value] = true
+
+		if err := dialProxy(ctx, field.value); err != nil {
+			return fmt.Errorf("%s %s is unreachable: %w", field.name, field.value, err)
+		}
+	}
+
+	return nil
+}
+
+// dialProxy opens and closes a TCP connection to the proxy in proxyURL
+func dialProxy(ctx context.Context, proxyURL string) error {
+	parsed, err := url.Parse(proxyURL)
+	if err != nil {
+		return err
+	}
+
+	port := parsed.Port()
+	if port == "" {
+		port = defaultProxyPorts[parsed.Scheme]
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(parsed.Hostname(), port))
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
@@ -0,0 +1,58 @@
+package proxy
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ooneko/claude-config/internal/claude"
+)
+
+// closedAddress returns a local address with nothing listening on it
+func closedAddress(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	require.NoError(t, listener.Close())
+	return address
+}
+
+func TestProxyManager_TestProxy(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	up := "http://" + listener.Addr().String()
+	down := "http://" + closedAddress(t)
+	manager := NewManager(t.TempDir())
+	ctx := context.Background()
+
+	require.NoError(t, manager.TestProxy(ctx, &claude.ProxyConfig{HTTPProxy: up, HTTPSProxy: up}))
+
+	err = manager.TestProxy(ctx, &claude.ProxyConfig{HTTPProxy: up, HTTPSProxy: down})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "https_proxy "+down+" is unreachable")
+
+	err = manager.TestProxy(ctx, &claude.ProxyConfig{HTTPProxy: down, HTTPSProxy: up})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "http_proxy "+down+" is unreachable")
+
+	// Invalid URLs are reported before any dial
+	err = manager.TestProxy(ctx, &claude.ProxyConfig{HTTPProxy: "http:/127.0.0.1:7890"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "missing host")
+}
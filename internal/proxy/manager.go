@@ -1,13 +1,17 @@
 package proxy
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 
 	"github.com/ooneko/claude-config/internal/claude"
+	"github.com/ooneko/claude-config/internal/settingsstore"
 )
 
 // Manager implements the ProxyManager interface
@@ -24,6 +28,10 @@ func NewManager(claudeDir string) *Manager {
 
 // Enable enables proxy with the given configuration
 func (m *Manager) Enable(_ context.Context, config *claude.ProxyConfig) error {
+	if err := ValidateConfig(config); err != nil {
+		return err
+	}
+
 	settings, err := m.loadSettings()
 	if err != nil {
 		return fmt.Errorf("failed to load settings: %w", err)
@@ -35,8 +43,10 @@ func (m *Manager) Enable(_ context.Context, config *claude.ProxyConfig) error {
 	}
 
 	// Set proxy configuration
-	settings.Env["http_proxy"] = config.HTTPProxy
-	settings.Env["https_proxy"] = config.HTTPSProxy
+	setOrDelete(settings.Env, "http_proxy", config.HTTPProxy)
+	setOrDelete(settings.Env, "https_proxy", config.HTTPSProxy)
+	setOrDelete(settings.Env, "no_proxy", config.NoProxy)
+	setOrDelete(settings.Env, "all_proxy", config.AllProxy)
 
 	// Save proxy configuration to .proxy_config file for future use
 	if err := m.saveProxyConfig(config); err != nil {
@@ -61,6 +71,8 @@ func (m *Manager) Disable(_ context.Context) error {
 	if settings.Env != nil {
 		delete(settings.Env, "http_proxy")
 		delete(settings.Env, "https_proxy")
+		delete(settings.Env, "no_proxy")
+		delete(settings.Env, "all_proxy")
 
 		// If env map is empty, set it to nil
 		if len(settings.Env) == 0 {
@@ -92,11 +104,16 @@ func (m *Manager) Toggle(ctx context.Context) error {
 	if err != nil {
 		// Use default proxy configuration if no saved config
 		config = &claude.ProxyConfig{
-			HTTPProxy:  "http://127.0.0.1:7890",
-			HTTPSProxy: "http://127.0.0.1:7890",
+			HTTPProxy:  defaultProxyURL,
+			HTTPSProxy: defaultProxyURL,
 		}
 	}
 
+	// A hand-edited .proxy_config may hold a typo; report it before touching settings
+	if err := ValidateConfig(config); err != nil {
+		return fmt.Errorf("saved proxy config is invalid: %w", err)
+	}
+
 	return m.Enable(ctx, config)
 }
 
@@ -107,14 +124,14 @@ func (m *Manager) IsEnabled(_ context.Context) (bool, error) {
 		return false, fmt.Errorf("failed to load settings: %w", err)
 	}
 
-	if settings.Env == nil {
-		return false, nil
-	}
-
-	httpProxy := settings.Env["http_proxy"]
-	httpsProxy := settings.Env["https_proxy"]
+	return EnabledInEnv(settings.Env), nil
+}
 
-	return httpProxy != "" && httpsProxy != "", nil
+// EnabledInEnv reports whether a settings env map enables the proxy: both
+// http_proxy and https_proxy, or all_proxy, are set. Other packages use it
+// so every status view agrees with IsEnabled.
+func EnabledInEnv(env map[string]string) bool {
+	return (env["http_proxy"] != "" && env["https_proxy"] != "") || env["all_proxy"] != ""
 }
 
 // GetConfig returns current proxy configuration
@@ -130,60 +147,93 @@ func (m *Manager) GetConfig(_ context.Context) (*claude.ProxyConfig, error) {
 
 	httpProxy := settings.Env["http_proxy"]
 	httpsProxy := settings.Env["https_proxy"]
+	allProxy := settings.Env["all_proxy"]
 
-	if httpProxy == "" && httpsProxy == "" {
+	if httpProxy == "" && httpsProxy == "" && allProxy == "" {
 		return nil, nil
 	}
 
 	return &claude.ProxyConfig{
 		HTTPProxy:  httpProxy,
 		HTTPSProxy: httpsProxy,
+		NoProxy:    settings.Env["no_proxy"],
+		AllProxy:   allProxy,
 	}, nil
 }
 
-// loadSettings loads settings from settings.json
-func (m *Manager) loadSettings() (*claude.Settings, error) {
-	settingsPath := filepath.Join(m.claudeDir, "settings.json")
+// defaultProxyURL is used by Toggle when no proxy config has been saved
+const defaultProxyURL = "http://127.0.0.1:7890"
 
-	// If file doesn't exist, return default settings
-	if _, err := os.Stat(settingsPath); os.IsNotExist(err) {
-		return &claude.Settings{
-			IncludeCoAuthoredBy: false,
-		}, nil
+// supportedProxySchemes lists the proxy URL schemes accepted by ValidateConfig
+var supportedProxySchemes = map[string]bool{
+	"http":    true,
+	"https":   true,
+	"socks5":  true,
+	"socks5h": true,
+}
+
+// ValidateConfig checks that the configuration has at least one proxy and
+// that every proxy is a URL with a supported scheme and a host
+func ValidateConfig(config *claude.ProxyConfig) error {
+	if config == nil {
+		return fmt.Errorf("proxy config is required")
 	}
 
-	data, err := os.ReadFile(settingsPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read settings file: %w", err)
+	if config.HTTPProxy == "" && config.HTTPSProxy == "" && config.AllProxy == "" {
+		return fmt.Errorf("at least one of http_proxy, https_proxy or all_proxy is required")
 	}
 
-	var settings claude.Settings
-	if err := json.Unmarshal(data, &settings); err != nil {
-		return nil, fmt.Errorf("failed to parse settings file: %w", err)
+	for _, field := range []struct {
+		name  string
+		value string
+	}{
+		{"http_proxy", config.HTTPProxy},
+		{"https_proxy", config.HTTPSProxy},
+		{"all_proxy", config.AllProxy},
+	} {
+		if field.value == "" {
+			continue
+		}
+		parsed, err := url.Parse(field.value)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q: %w", field.name, field.value, err)
+		}
+		if !supportedProxySchemes[parsed.Scheme] {
+			return fmt.Errorf("invalid %s %q: scheme must be one of http, https, socks5, socks5h", field.name, field.value)
+		}
+		if parsed.Hostname() == "" {
+			return fmt.Errorf("invalid %s %q: missing host", field.name, field.value)
+		}
+		if port := parsed.Port(); port != "" {
+			if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+				return fmt.Errorf("invalid %s %q: port must be between 1 and 65535", field.name, field.value)
+			}
+		}
+		if parsed.Path != "" && parsed.Path != "/" {
+			return fmt.Errorf("invalid %s %q: unexpected path %q", field.name, field.value, parsed.Path)
+		}
 	}
 
-	return &settings, nil
+	return nil
 }
 
-// saveSettings saves settings to settings.json
-func (m *Manager) saveSettings(settings *claude.Settings) error {
-	settingsPath := filepath.Join(m.claudeDir, "settings.json")
-
-	// Ensure directory exists
-	if err := os.MkdirAll(m.claudeDir, 0755); err != nil {
-		return fmt.Errorf("failed to create claude directory: %w", err)
-	}
-
-	data, err := json.MarshalIndent(settings, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal settings: %w", err)
+// setOrDelete sets env[key] to value, removing the key when value is empty
+func setOrDelete(env map[string]string, key, value string) {
+	if value == "" {
+		delete(env, key)
+		return
 	}
+	env[key] = value
+}
 
-	if err := os.WriteFile(settingsPath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write settings file: %w", err)
-	}
+// loadSettings loads settings from settings.json
+func (m *Manager) loadSettings() (*claude.Settings, error) {
+	return settingsstore.Load(m.claudeDir)
+}
 
-	return nil
+// saveSettings saves settings to settings.json
+func (m *Manager) saveSettings(settings *claude.Settings) error {
+	return settingsstore.Save(m.claudeDir, settings)
 }
 
 // saveProxyConfig saves proxy configuration to .proxy_config file
@@ -215,6 +265,9 @@ func (m *Manager) loadProxyConfig() (*claude.ProxyConfig, error) {
 		return nil, fmt.Errorf("failed to read proxy config file: %w", err)
 	}
 
+	// Windows editors may prepend a UTF-8 BOM, which the JSON parser rejects
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
 	var config claude.ProxyConfig
 	if err := json.Unmarshal(data, &config); err != nil {
 		return nil, fmt.Errorf("failed to parse proxy config file: %w", err)
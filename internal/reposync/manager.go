@@ -0,0 +1,133 @@
+package reposync
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/ooneko/claude-config/internal/claude"
+	"github.com/ooneko/claude-config/internal/file"
+)
+
+// templateSubDir is the optional directory inside a template repo holding the resources
+const templateSubDir = "claude-config"
+
+// Result describes the outcome of a sync operation
+type Result struct {
+	RepoURL   string `json:"repo_url"`
+	CacheDir  string `json:"cache_dir"`
+	SourceDir string `json:"source_dir"`
+	Cloned    bool   `json:"cloned"`
+}
+
+// Manager syncs an organization template repository into the claude directory
+type Manager struct {
+	claudeDir string
+	cacheDir  string
+}
+
+// NewManager creates a new sync manager, cacheDir holds the cloned template repos
+func NewManager(claudeDir, cacheDir string) *Manager {
+	return &Manager{
+		claudeDir: claudeDir,
+		cacheDir:  cacheDir,
+	}
+}
+
+// DefaultCacheDir returns the default cache directory for template repos
+func DefaultCacheDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user cache directory: %w", err)
+	}
+	return filepath.Join(cacheDir, "claude-config", "templates"), nil
+}
+
+// Sync clones or pulls repoURL into the cache and installs its resources into claudeDir.
+// Authentication relies on the user's existing git configuration.
+func (m *Manager) Sync(ctx context.Context, repoURL string) (*Result, error) {
+	if strings.TrimSpace(repoURL) == "" {
+		return nil, fmt.Errorf("repository URL cannot be empty")
+	}
+
+	repoDir := m.repoCacheDir(repoURL)
+	result := &Result{
+		RepoURL:  repoURL,
+		CacheDir: repoDir,
+	}
+
+	if _, err := os.Stat(filepath.Join(repoDir, ".git")); err == nil {
+		if err := runGit(ctx, "-C", repoDir, "pull", "--ff-only"); err != nil {
+			return nil, fmt.Errorf("failed to pull template repository: %w", err)
+		}
+	} else {
+		if err := os.MkdirAll(filepath.Dir(repoDir), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create cache directory: %w", err)
+		}
+		// "--" keeps a URL starting with "-" from being read as a git option
+		if err := runGit(ctx, "clone", "--depth", "1", "--", repoURL, repoDir); err != nil {
+			return nil, fmt.Errorf("failed to clone template repository: %w", err)
+		}
+		result.Cloned = true
+	}
+
+	result.SourceDir = templateSourceDir(repoDir)
+
+	if err := m.InstallFromDir(ctx, result.SourceDir); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// InstallFromDir installs resources from a local template directory into claudeDir
+func (m *Manager) InstallFromDir(ctx context.Context, sourceDir string) error {
+	info, err := os.Stat(sourceDir)
+	if err != nil {
+		return fmt.Errorf("failed to stat template directory: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("template path is not a directory: %s", sourceDir)
+	}
+
+	ops := file.NewOperations(sourceDir, m.claudeDir)
+	if err := ops.Copy(ctx, &claude.CopyOptions{All: true}); err != nil {
+		return fmt.Errorf("failed to install template: %w", err)
+	}
+
+	return nil
+}
+
+// repoCacheDir returns a stable cache directory for the repository URL
+func (m *Manager) repoCacheDir(repoURL string) string {
+	sum := sha256.Sum256([]byte(repoURL))
+	name := strings.TrimSuffix(filepath.Base(strings.TrimRight(repoURL, "/")), ".git")
+	if name == "" || name == "." || name == string(filepath.Separator) {
+		name = "template"
+	}
+	return filepath.Join(m.cacheDir, fmt.Sprintf("%s-%s", name, hex.EncodeToString(sum[:])[:12]))
+}
+
+// templateSourceDir prefers a claude-config subdirectory when the repo has one
+func templateSourceDir(repoDir string) string {
+	subDir := filepath.Join(repoDir, templateSubDir)
+	if info, err := os.Stat(subDir); err == nil && info.IsDir() {
+		return subDir
+	}
+	return repoDir
+}
+
+// runGit runs a git command, including its output in the returned error
+func runGit(ctx context.Context, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
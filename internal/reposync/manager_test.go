@@ -0,0 +1,106 @@
+package reposync
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ooneko/claude-config/internal/claude"
+)
+
+// createTemplateRepo creates a local git repository with template resources
+func createTemplateRepo(t *testing.T, dir string) {
+	t.Helper()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "agents"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "agents", "org-agent.md"), []byte("# Org Agent"), 0644))
+
+	settings := &claude.Settings{
+		Env: map[string]string{
+			"ORG_VAR":              "org",
+			"http_proxy":           "http://org-proxy:8080",
+			"ANTHROPIC_AUTH_TOKEN": "sk-should-not-leak",
+		},
+	}
+	data, err := json.Marshal(settings)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "settings.json"), data, 0644))
+
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(output))
+	}
+	git("init", "-q")
+	git("add", "-A")
+	git("commit", "-q", "-m", "template")
+}
+
+func TestManager_Sync_LocalRepo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	tempDir := t.TempDir()
+	repoDir := filepath.Join(tempDir, "org-template")
+	claudeDir := filepath.Join(tempDir, ".claude")
+	cacheDir := filepath.Join(tempDir, "cache")
+	createTemplateRepo(t, repoDir)
+
+	// Existing user settings with a proxy that must be preserved
+	require.NoError(t, os.MkdirAll(claudeDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(claudeDir, "settings.json"),
+		[]byte(`{"env": {"http_proxy": "http://127.0.0.1:7890"}}`), 0644))
+
+	manager := NewManager(claudeDir, cacheDir)
+	ctx := context.Background()
+
+	result, err := manager.Sync(ctx, repoDir)
+	require.NoError(t, err)
+	assert.True(t, result.Cloned)
+	assert.DirExists(t, filepath.Join(result.CacheDir, ".git"))
+
+	assert.FileExists(t, filepath.Join(claudeDir, "agents", "org-agent.md"))
+
+	data, err := os.ReadFile(filepath.Join(claudeDir, "settings.json"))
+	require.NoError(t, err)
+	var settings claude.Settings
+	require.NoError(t, json.Unmarshal(data, &settings))
+	assert.Equal(t, "org", settings.Env["ORG_VAR"])
+	assert.Equal(t, "http://127.0.0.1:7890", settings.Env["http_proxy"])
+	assert.Empty(t, settings.Env["ANTHROPIC_AUTH_TOKEN"])
+
+	// Second sync pulls the cached clone instead of cloning again
+	result, err = manager.Sync(ctx, repoDir)
+	require.NoError(t, err)
+	assert.False(t, result.Cloned)
+}
+
+func TestManager_Sync_OptionLikeURL(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	tempDir := t.TempDir()
+	marker := filepath.Join(tempDir, "pwned")
+	manager := NewManager(filepath.Join(tempDir, ".claude"), filepath.Join(tempDir, "cache"))
+
+	// The URL is passed after "--", so git treats it as a repository, not an option
+	_, err := manager.Sync(context.Background(), "--upload-pack=touch "+marker)
+	require.Error(t, err)
+	assert.NoFileExists(t, marker)
+}
+
+func TestManager_Sync_EmptyURL(t *testing.T) {
+	manager := NewManager(t.TempDir(), t.TempDir())
+
+	_, err := manager.Sync(context.Background(), " ")
+	assert.Error(t, err)
+}
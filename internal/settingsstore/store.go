@@ -0,0 +1,59 @@
+// Package settingsstore reads and writes settings.json in a claude directory,
+// so every manager shares one implementation.
+package settingsstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ooneko/claude-config/internal/claude"
+	"github.com/ooneko/claude-config/internal/fsutil"
+)
+
+// FileName is the name of the settings file inside the claude directory
+const FileName = "settings.json"
+
+// Path returns the settings.json path inside dir
+func Path(dir string) string {
+	return filepath.Join(dir, FileName)
+}
+
+// Load reads settings.json from dir. A missing file yields empty settings.
+// Keys unknown to claude.Settings are kept in Settings.Extra.
+func Load(dir string) (*claude.Settings, error) {
+	data, err := os.ReadFile(Path(dir))
+	if os.IsNotExist(err) {
+		return &claude.Settings{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read settings file: %w", err)
+	}
+
+	var settings claude.Settings
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("failed to parse settings file: %w", err)
+	}
+
+	return &settings, nil
+}
+
+// Save writes settings to settings.json in dir, creating dir when needed.
+// The file is replaced atomically so a crash never leaves it half-written.
+func Save(dir string, settings *claude.Settings) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create claude directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal settings: %w", err)
+	}
+
+	if err := fsutil.AtomicWriteFile(Path(dir), data, 0644); err != nil {
+		return fmt.Errorf("failed to write settings file: %w", err)
+	}
+
+	return nil
+}
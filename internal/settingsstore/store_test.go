@@ -0,0 +1,69 @@
+package settingsstore
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ooneko/claude-config/internal/claude"
+)
+
+func TestLoad_MissingFile(t *testing.T) {
+	settings, err := Load(t.TempDir())
+	require.NoError(t, err)
+	assert.Equal(t, &claude.Settings{}, settings)
+}
+
+func TestLoad_InvalidJSON(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(Path(dir), []byte(`{"env": {`), 0644))
+
+	_, err := Load(dir)
+	assert.ErrorContains(t, err, "failed to parse settings file")
+}
+
+func TestSaveLoad_RoundTrip(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), ".claude")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	require.NoError(t, os.WriteFile(Path(dir), []byte(`{"model": "opus", "customKey": {"nested": true}}`), 0644))
+
+	settings, err := Load(dir)
+	require.NoError(t, err)
+	settings.Env = map[string]string{"A": "1"}
+	require.NoError(t, Save(dir, settings))
+
+	data, err := os.ReadFile(Path(dir))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"model": "opus", "customKey": {"nested": true}, "env": {"A": "1"}, "includeCoAuthoredBy": false}`, string(data))
+}
+
+func TestSave_CreatesDirectory(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "nested", ".claude")
+
+	require.NoError(t, Save(dir, &claude.Settings{Env: map[string]string{"A": "1"}}))
+
+	settings, err := Load(dir)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"A": "1"}, settings.Env)
+}
+
+func TestSave_KeepsFileMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix permission bits are not supported on Windows")
+	}
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(Path(dir), []byte(`{}`), 0600))
+	require.NoError(t, os.Chmod(Path(dir), 0600))
+
+	settings := &claude.Settings{Env: map[string]string{"ANTHROPIC_AUTH_TOKEN": "sk-secret"}}
+	require.NoError(t, Save(dir, settings))
+
+	info, err := os.Stat(Path(dir))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
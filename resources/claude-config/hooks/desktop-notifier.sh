@@ -0,0 +1,45 @@
+#!/usr/bin/env bash
+# desktop-notifier.sh - Show Linux desktop notifications for Claude Code events
+#
+# SYNOPSIS
+#   desktop-notifier.sh < hook-payload.json
+#
+# DESCRIPTION
+#   Reads the Notification payload from stdin and shows its message with
+#   notify-send, so permission prompts are visible outside the terminal.
+#
+# REQUIREMENTS
+#   notify-send (libnotify-bin on Debian/Ubuntu, libnotify on Fedora/Arch).
+#   jq is optional; without it a generic message is shown.
+#
+# ENVIRONMENT
+#   CLAUDE_HOOKS_DESKTOP_NOTIFY_ENABLED   Set to "false" to disable notifications
+
+set +e
+
+if [[ "${CLAUDE_HOOKS_DESKTOP_NOTIFY_ENABLED:-true}" != "true" ]]; then
+    exit 0
+fi
+
+# Notifications must never block Claude Code
+if ! command -v notify-send >/dev/null 2>&1; then
+    exit 0
+fi
+
+payload=$(cat)
+
+message=""
+if command -v jq >/dev/null 2>&1; then
+    message=$(printf '%s' "$payload" | jq -r '.message // empty' 2>/dev/null)
+fi
+if [[ -z "$message" ]]; then
+    message="Claude Code 需要你的确认"
+fi
+
+title="Claude Code"
+if [[ -n "${PWD:-}" ]]; then
+    title="Claude Code - $(basename "$PWD")"
+fi
+
+notify-send --app-name="Claude Code" --urgency=normal "$title" "$message"
+exit 0
@@ -6,11 +6,12 @@
 #
 # DESCRIPTION
 #   Sends push notifications via ntfy service when Claude Code events occur.
-#   Supports notification and stop events. Automatically detects terminal
+#   Supports notification, stop, subagent_stop and pre_compact events.
+#   Automatically detects terminal
 #   context and includes it in the notification for better identification.
 #
 # ARGUMENTS
-#   event_type    Either "notification" or "stop"
+#   event_type    One of "notification", "stop", "subagent_stop" or "pre_compact"
 #
 # CONFIGURATION
 #   Configuration is read from ~/.claude/settings.json:
@@ -249,9 +250,23 @@ case "$EVENT_TYPE" in
         say "Claude Code 任务已完成" >/dev/null 2>&1 &
         ;;
 
+    "subagent_stop")
+        TITLE="$CONTEXT"
+        MESSAGE="Claude 子代理已完成任务"
+        TAGS="claude-code,subagent,checkmark"
+        PRIORITY="low"
+        ;;
+
+    "pre_compact")
+        TITLE="$CONTEXT"
+        MESSAGE="Claude 即将压缩上下文"
+        TAGS="claude-code,compact"
+        PRIORITY="low"
+        ;;
+
     *)
         echo "Error: Unknown event type: $EVENT_TYPE" >&2
-        echo "Usage: $0 {notification|stop} [subtype]" >&2
+        echo "Usage: $0 {notification|stop|subagent_stop|pre_compact} [subtype]" >&2
         exit 1
         ;;
 esac
@@ -0,0 +1,52 @@
+#!/usr/bin/env bash
+# protect-files.sh - PreToolUse guard that blocks edits to protected files
+#
+# SYNOPSIS
+#   protect-files.sh < hook-payload.json
+#
+# DESCRIPTION
+#   Reads the PreToolUse payload from stdin and blocks Write/Edit/MultiEdit
+#   calls whose file_path matches a protected pattern.
+#
+# EXIT CODES
+#   0 - Edit allowed
+#   2 - Edit blocked (reason on stderr, shown to Claude)
+#
+# CONFIGURATION
+#   CLAUDE_HOOKS_PROTECTED_FILES - comma-separated glob patterns matched
+#   against the file path and its basename.
+#   Default: .env,.env.*,*.pem,*.key,.git/*
+
+set +e
+
+DEFAULT_PATTERNS=".env,.env.*,*.pem,*.key,.git/*"
+PATTERNS="${CLAUDE_HOOKS_PROTECTED_FILES:-$DEFAULT_PATTERNS}"
+
+# Without jq the payload can't be parsed; never block in that case
+if ! command -v jq >/dev/null 2>&1; then
+    exit 0
+fi
+
+payload=$(cat)
+file_path=$(echo "$payload" | jq -r '.tool_input.file_path // empty' 2>/dev/null)
+if [[ -z "$file_path" ]]; then
+    exit 0
+fi
+
+base_name=$(basename "$file_path")
+
+IFS=',' read -ra patterns <<< "$PATTERNS"
+for pattern in "${patterns[@]}"; do
+    pattern="${pattern#"${pattern%%[![:space:]]*}"}"
+    pattern="${pattern%"${pattern##*[![:space:]]}"}"
+    [[ -z "$pattern" ]] && continue
+
+    # shellcheck disable=SC2053
+    if [[ "$base_name" == $pattern ]] || [[ "$file_path" == $pattern ]] || [[ "$file_path" == */$pattern ]]; then
+        echo "⛔ $file_path is protected (pattern: $pattern)." >&2
+        echo "   Edit CLAUDE_HOOKS_PROTECTED_FILES to change protected files." >&2
+        exit 2
+    fi
+done
+
+exit 0
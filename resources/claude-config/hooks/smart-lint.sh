@@ -11,6 +11,7 @@
 # OPTIONS
 #   --debug       Enable debug output
 #   --fast        Skip slow checks (import cycles, security scans)
+#   --staged      Only check files staged in git (git diff --cached)
 #
 # EXIT CODES
 #   0 - Success (all checks passed - everything is ✅ GREEN)
@@ -619,6 +620,7 @@ lint_nix() {
 
 # Parse command line options
 FAST_MODE=false
+STAGED_MODE=false
 while [[ $# -gt 0 ]]; do
     case $1 in
         --debug)
@@ -629,6 +631,10 @@ while [[ $# -gt 0 ]]; do
             FAST_MODE=true
             shift
             ;;
+        --staged)
+            STAGED_MODE=true
+            shift
+            ;;
         *)
             echo "Unknown option: $1" >&2
             exit 2
@@ -636,6 +642,17 @@ while [[ $# -gt 0 ]]; do
     esac
 done
 
+# Restrict the checks to files staged in git
+if [[ "$STAGED_MODE" == "true" ]]; then
+    staged_files=$(git diff --cached --name-only --diff-filter=ACMR 2>/dev/null)
+    if [[ -z "$staged_files" ]]; then
+        echo "🔍 Style Check - No staged files, skipping" >&2
+        exit 0
+    fi
+    CLAUDE_FILE_PATHS=$(printf '%s\n' "$staged_files" | jq -R . | jq -s -c .)
+    export CLAUDE_FILE_PATHS
+fi
+
 # Print header
 echo "" >&2
 echo "🔍 Style Check - Validating code formatting..." >&2
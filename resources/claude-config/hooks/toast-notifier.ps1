@@ -0,0 +1,43 @@
+# toast-notifier.ps1 - Show Windows toast notifications for Claude Code events
+#
+# SYNOPSIS
+#   powershell.exe -NoProfile -ExecutionPolicy Bypass -File toast-notifier.ps1 < hook-payload.json
+#
+# DESCRIPTION
+#   Reads the Notification payload from stdin and shows its message as a
+#   toast, so permission prompts are visible outside the terminal. Uses the
+#   Windows Runtime notification API built into Windows 10 and later; no
+#   extra modules are required.
+#
+# ENVIRONMENT
+#   CLAUDE_HOOKS_DESKTOP_NOTIFY_ENABLED   Set to "false" to disable notifications
+
+$ErrorActionPreference = 'SilentlyContinue'
+
+if ($env:CLAUDE_HOOKS_DESKTOP_NOTIFY_ENABLED -eq 'false') {
+    exit 0
+}
+
+$message = ''
+$payload = [Console]::In.ReadToEnd()
+if ($payload) {
+    $message = ($payload | ConvertFrom-Json).message
+}
+if (-not $message) {
+    $message = 'Claude Code 需要你的确认'
+}
+
+$title = 'Claude Code - ' + (Split-Path -Leaf (Get-Location))
+
+[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] | Out-Null
+$template = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$texts = $template.GetElementsByTagName('text')
+$texts.Item(0).AppendChild($template.CreateTextNode($title)) | Out-Null
+$texts.Item(1).AppendChild($template.CreateTextNode($message)) | Out-Null
+
+# Toasts need a registered AppUserModelID; PowerShell's own is always present
+$appID = '{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}\WindowsPowerShell\v1.0\powershell.exe'
+$toast = [Windows.UI.Notifications.ToastNotification]::new($template)
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier($appID).Show($toast)
+
+exit 0